@@ -0,0 +1,70 @@
+// The errexplain program checks targets once, as the wait program
+// would, and for each one that is not ready describes how the error
+// is made up, layer by layer: the type and message of each error it
+// wraps, and the Timeout, Temporary, Retryable, RetryAfter, and Is
+// methods they implement. It is a debugging aid for errors that the
+// wait program retries when it shouldn't, or the other way around.
+//
+// Usage:
+//
+//	errexplain [-timeout d] target ...
+//
+// It exits with 1 if any target is not ready.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/errexplain"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Second, "limit the time each check may take")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: errexplain [-timeout d] target ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, target := range flag.Args() {
+		err := check(target, *timeout)
+		name := wait.RedactURL(target)
+		if err == nil {
+			fmt.Printf("%s: ready\n", name)
+			continue
+		}
+		status = 1
+		fmt.Printf("%s: not ready (%s)\n", name, classify(err))
+		errexplain.Fprint(os.Stdout, err)
+	}
+	os.Exit(status)
+}
+
+// check checks target once.
+func check(target string, timeout time.Duration) error {
+	c, err := wait.NewChecker(target)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.Check(ctx)
+}
+
+func classify(err error) string {
+	if errclass.Retryable(err) {
+		return "retryable"
+	}
+	return "permanent"
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/forbidden"
+)
+
+// classCodes are the error codes of the paths that could not be
+// checked, by class, which key the templates of -messages.
+var classCodes = [...]*errcode.Entry{
+	forbidden.Permission: errcode.Register(errcode.Entry{Name: "forbidden.permission_denied", Category: errcode.Denied, Severity: errcode.Warning}),
+	forbidden.NotExist:   errcode.Register(errcode.Entry{Name: "forbidden.not_exist", Category: errcode.NotFound, Severity: errcode.Error}),
+	forbidden.IsDir:      errcode.Register(errcode.Entry{Name: "forbidden.is_directory", Category: errcode.Invalid, Severity: errcode.Error}),
+	forbidden.Loop:       errcode.Register(errcode.Entry{Name: "forbidden.too_many_symlinks", Category: errcode.Invalid, Severity: errcode.Error}),
+	forbidden.Broken:     errcode.Register(errcode.Entry{Name: "forbidden.broken_symlink", Category: errcode.NotFound, Severity: errcode.Error}),
+	forbidden.Other:      errcode.Register(errcode.Entry{Name: "forbidden.other", Severity: errcode.Error}),
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+
+	"github.com/go-monk/error-handling/pkg/clidoc"
+)
+
+// source is that of this file's neighbor main.go, whose doc comment
+// is the description of the manual page.
+//
+//go:embed main.go
+var source []byte
+
+// command describes forbidden for its completions and manual page,
+// once its flags are defined.
+func command() *clidoc.Command {
+	return &clidoc.Command{
+		Name:    "forbidden",
+		Summary: "find the files that lack permissions",
+		Usage: []string{
+			"[-r] [-include pattern] [-exclude pattern] [-access] [-mode r,w,x] [-follow-symlinks | -no-follow] [-v] [-suggest] [-output format] [-concurrency n] [-watch interval] [path ...]",
+			"-policy file [-access]",
+			"completion bash|zsh|fish",
+			"docs",
+		},
+		Doc:   clidoc.PackageDoc(source),
+		Flags: flag.CommandLine,
+		Values: map[string][]string{
+			"mode":   {"r", "w", "x", "r,w", "r,x", "w,x", "r,w,x"},
+			"output": {"text", "json", "ndjson", "csv"},
+		},
+	}
+}
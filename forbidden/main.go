@@ -1,34 +1,426 @@
+// The forbidden program prints those of the paths it is given that it
+// is not permitted to open, one per line, so that it can audit the
+// permissions of lists of files. It then logs the other errors it
+// got, by kind: paths that don't exist, directories, which can't be
+// read as files, symbolic links that loop or go too deep, broken
+// symbolic links, and other errors.
+//
+// Usage:
+//
+//	forbidden [-r] [-include pattern] [-exclude pattern] [-access]
+//		[-mode r,w,x] [-follow-symlinks | -no-follow] [-v] [-suggest]
+//		[-output format] [-concurrency n] [-watch interval] [path ...]
+//
+// With no arguments, or for an argument of "-", it reads the paths
+// from the standard input, one per line, so that it can filter the
+// output of other programs:
+//
+//	find /etc -type f | forbidden
+//
+// With -r (or -recursive), it walks the directory trees of the paths,
+// and prints every file or directory it is not permitted to open or
+// list. -include limits the files it checks to those whose name or
+// path matches one of the glob patterns, as by path.Match, and
+// -exclude skips the files and directories that match, and all that
+// is in them:
+//
+//	forbidden -r -include '*.conf' -exclude .git /etc
+//
+// It checks the files that symbolic links link to, and logs the links
+// to files that don't exist as broken apart from the paths not found,
+// but doesn't walk the directories they link to, unless given
+// -follow-symlinks, which stops at links that loop back to where the
+// walk came from. With -no-follow, it skips symbolic links.
+//
+// With -access, it checks whether the files may be read with access(2)
+// instead of opening them, so that their access times are left alone
+// and audit logs stay quiet. On Windows, where there is no access(2),
+// the access control lists of the files are checked instead, as
+// opening them would, and files that may be written must not be
+// read-only.
+//
+// With -mode, it checks for the given permissions, to read (r), write
+// (w), or execute (x), rather than for that to read, and prints the
+// permissions it lacks after each path and a tab:
+//
+//	forbidden -mode r,x /usr/local/bin/deploy
+//
+// The permissions to write and execute are always checked with
+// access(2), so that no file is opened for writing or run.
+//
+// With -v, it follows each path with a line describing its file, as
+// ls -l does, with the IDs of its owner and group:
+//
+//	/etc/shadow
+//		-rw-r----- root:shadow (0:42) 2026-10-14 09:30:00
+//
+// With -suggest, it follows each path with a line suggesting how to
+// fix its permissions, from the owner and mode of the file:
+//
+//	/etc/shadow
+//		owned by root:shadow mode 0640; try: sudo chmod o+r /etc/shadow
+//
+// Where the mode grants the permissions denied, it describes what else
+// may deny them, as far as it can tell: POSIX ACLs, the immutable and
+// append-only attributes or flags, SELinux labels, and AppArmor
+// profiles on Linux, and the immutable and append-only flags on macOS.
+//
+// With -output json or -output csv, it writes a record of every path
+// it printed or logged an error for instead, with its category, such
+// as permission-denied or not-exist, the permissions denied, the
+// errno and message of the error, and the owner, group, their IDs, the
+// mode, and the modification time of the file if it can be stat'ed,
+// what else denies the permissions, and the suggested fix, with
+// -suggest, for compliance tools and dashboards. JSON records are
+// written one per line, so -output ndjson is the same.
+//
+// With -concurrency, it checks several paths at once, which speeds up
+// large scans, but still prints them, and logs the errors, in the
+// order it came across the paths.
+//
+// With -watch, it scans the paths again at an interval, until it is
+// interrupted, and prints the time and the paths whose permissions, or
+// errors, changed since the scan before, such as
+//
+//	2026-10-14 09:30:00 /etc/app.conf: ok -> permission-denied r
+//
+// for catching the regressions of deployments and configuration
+// management runs. It then exits with the status of the last scan.
+//
+// With -policy, it checks the permissions declared in a policy file,
+// which lists the permissions that paths must grant the user, and
+// those they must deny, and prints those that differ, as drift:
+//
+//	rules:
+//	  - path: /etc/hosts
+//	    grant: r
+//	  - path: /etc/shadow
+//	    deny: r,w
+//
+// The file is read as JSON if its name ends in .json.
+//
+// With -messages, the errors of the paths that could not be checked
+// are written as rendered by the templates of a YAML or JSON file,
+// keyed by their codes, such as forbidden.not_exist, one line per
+// path, and given the path, its class, and the original message as
+// Error, so that they can be translated; see package errcatalog:
+//
+//	forbidden.not_exist: '{{.path}} existiert nicht'
+//
+// "forbidden completion bash", zsh, or fish writes a script completing
+// the flags of forbidden and their values for the shell, and
+// "forbidden docs" writes its manual page; to check files named
+// completion or docs, give them as ./completion or ./docs.
+//
+// It exits with 0 if it found nothing forbidden, 1 if it found paths
+// lacking permissions, or drift, and 2 if it got any other error, such
+// as of a path that does not exist, as it could then not check
+// everything.
 package main
 
 import (
-	"errors"
+	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
-	"log"
+	"iter"
 	"os"
+	"os/signal"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcatalog"
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"github.com/go-monk/error-handling/pkg/errreport"
+	"github.com/go-monk/error-handling/pkg/forbidden"
+	"github.com/go-monk/error-handling/pkg/multierr"
 )
 
 func main() {
-	paths := []string{
-		"/does/not/exist",
-		"/etc/sudoers",
-		"/etc/hosts",
-		"/etc/master.passwd",
+	s := forbidden.Scanner{FS: forbidden.OS}
+	var r reporter
+	flag.BoolVar(&s.Recursive, "r", false, "walk the directory trees of the paths")
+	flag.BoolVar(&s.Recursive, "recursive", false, "same as -r")
+	flag.Func("include", "with -r, check only the files whose name or path matches the glob `pattern` (repeatable)", addPattern(&s.Include))
+	flag.Func("exclude", "with -r, skip the files and directories whose name or path matches the glob `pattern` (repeatable)", addPattern(&s.Exclude))
+	flag.BoolVar(&s.Access, "access", false, "check whether files may be read without opening them")
+	flag.Func("mode", "check for the permissions in the `list` of r (read), w (write), and x (execute), and print those denied", func(v string) (err error) {
+		s.Modes, err = forbidden.ParseModes(v)
+		r.modes = true
+		return err
+	})
+	flag.BoolFunc("follow-symlinks", "with -r, walk the directories that symbolic links link to too", func(string) error {
+		s.Links = forbidden.FollowLinks
+		return nil
+	})
+	flag.BoolFunc("no-follow", "skip symbolic links, rather than check the files they link to", func(string) error {
+		s.Links = forbidden.SkipLinks
+		return nil
+	})
+	flag.BoolVar(&r.verbose, "v", false, "follow each path with the mode, owner, group, and modification time of its file")
+	flag.BoolVar(&r.suggest, "suggest", false, "suggest how to fix the permissions of each path")
+	flag.IntVar(&s.Concurrency, "concurrency", 1, "check up to `n` paths at once")
+	interval := flag.Duration("watch", 0, "scan again every `interval`, and print the paths whose permissions changed, until interrupted")
+	policy := flag.String("policy", "", "check the permissions declared in the policy `file` instead of paths")
+	messages := flag.String("messages", "", "write the errors of the paths that could not be checked as rendered by the templates of their codes in the YAML or JSON `file`, such as to translate them")
+	format := flag.String("output", "text", "write the paths as text, or records in the `format` json (or ndjson) or csv")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: forbidden [-r] [-include pattern] [-exclude pattern] [-access] [-mode r,w,x] [-follow-symlinks | -no-follow] [-v] [-suggest] [-output format] [-concurrency n] [-watch interval] [path ...]\n")
+		fmt.Fprintf(os.Stderr, "       forbidden -policy file [-access]\n")
+		fmt.Fprintf(os.Stderr, "       forbidden completion bash|zsh|fish\n")
+		fmt.Fprintf(os.Stderr, "       forbidden docs\n")
+		flag.PrintDefaults()
+	}
+	if ok, err := command().Run(os.Args[1:], os.Stdout); ok {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "forbidden: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+	flag.Parse()
+	var f errreport.Format
+	f.UnmarshalText([]byte(*format)) // unless csv, or unknown, as newOutput reports
+	rep := errreport.New("forbidden", f, os.Stdout, os.Stderr)
+	if *messages != "" {
+		var err error
+		if r.catalog, err = errcatalog.Load(*messages); err != nil {
+			rep.Error(err)
+			os.Exit(exitError)
+		}
+		rep.SetCatalog(r.catalog)
+	}
+	if *policy != "" {
+		if flag.NArg() > 0 || *format != "text" || *interval != 0 {
+			rep.Printf("-policy takes no paths, and writes text only, once")
+			flag.Usage()
+			os.Exit(exitError)
+		}
+		os.Exit(checkPolicy(rep, *policy, &s))
+	}
+	if *format != "text" && *interval != 0 {
+		rep.Printf("-watch writes text only")
+		flag.Usage()
+		os.Exit(exitError)
+	}
+	if *format != "text" {
+		var err error
+		if r.out, err = newOutput(*format, rep, os.Stdout); err != nil {
+			rep.Error(err)
+			flag.Usage()
+			os.Exit(exitError)
+		}
+	}
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"-"}
 	}
 
-	var forbidden []string
+	var readErr error // of reading the standard input
+	paths := eachPath(args, os.Stdin, &readErr)
+	if *interval != 0 {
+		// The paths of the standard input are read once.
+		list := slices.Collect(paths)
+		if readErr != nil {
+			rep.Error(readErr)
+			os.Exit(exitError)
+		}
+		paths = slices.Values(list)
+	}
+	first := make(states)
+	// The errors of the scan are those of the findings, and the
+	// patterns are well-formed.
+	err := s.Scan(paths, func(f *forbidden.Finding) {
+		first.add(f)
+		r.report(f)
+	})
 
-	for _, path := range paths {
-		f, err := os.Open(path)
-		if err != nil {
-			if errors.Is(err, fs.ErrPermission) {
-				forbidden = append(forbidden, path)
+	for c, errs := range r.errs {
+		switch err := errs.Err(); {
+		case err == nil:
+		case r.catalog.Has(classCodes[c].Name):
+			rep.Error(err) // one line per path, without the heading
+		default:
+			rep.Printf("%s: %v", headings[c], err)
+		}
+	}
+	if readErr != nil {
+		rep.Error(readErr)
+		err = multierr.Append(err, readErr)
+	}
+	if r.out != nil {
+		if flushErr := r.out.flush(); flushErr != nil {
+			rep.Error(flushErr)
+			err = multierr.Append(err, flushErr)
+		}
+	}
+	if *interval != 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		last := watch(ctx, &s, slices.Collect(paths), first, *interval)
+		os.Exit(lastStatus(last))
+	}
+	switch {
+	case err != nil:
+		os.Exit(errreport.ExitCode(err, exitError))
+	case r.denied:
+		os.Exit(exitForbidden)
+	}
+}
+
+// lastStatus returns the exit status for the states of the last scan
+// of -watch.
+func lastStatus(last states) int {
+	status := 0
+	for _, s := range last {
+		if !strings.HasPrefix(s, forbidden.Permission.String()) {
+			return exitError
+		}
+		status = exitForbidden
+	}
+	return status
+}
+
+// Exit statuses.
+const (
+	exitForbidden = 1 // some paths lack permissions, or differ from the policy
+	exitError     = 2 // some paths could not be checked, or the usage is wrong
+)
+
+// checkPolicy prints how the permissions differ from those declared
+// in the policy file name, checked with s, and returns the exit status,
+// reporting any error loading the file to rep.
+func checkPolicy(rep *errreport.Reporter, name string, s *forbidden.Scanner) int {
+	p, err := forbidden.LoadPolicy(name)
+	if err != nil {
+		rep.Error(err)
+		return exitError
+	}
+	drifts, err := p.Check(s)
+	for _, d := range drifts {
+		fmt.Println(d)
+	}
+	switch {
+	case err != nil:
+		return exitError
+	case drifts != nil:
+		return exitForbidden
+	}
+	return 0
+}
+
+// A reporter reports the findings of a scan.
+type reporter struct {
+	modes   bool                // whether to print the permissions denied
+	suggest bool                // whether to suggest fixes
+	verbose bool                // whether to describe the files
+	out     output              // of -output, or nil for text
+	catalog *errcatalog.Catalog // of -messages, or nil
+	denied  bool                // whether any path lacks permissions
+
+	// The errors found, by class, but for permission errors, and
+	// all errors if out is set.
+	errs [len(headings)]multierr.Collector
+}
+
+// Headings of the errors logged, by class.
+var headings = [...]string{
+	forbidden.Permission: "permission denied",
+	forbidden.NotExist:   "not found",
+	forbidden.IsDir:      "directories",
+	forbidden.Loop:       "too many symbolic links",
+	forbidden.Broken:     "broken symbolic links",
+	forbidden.Other:      "other errors",
+}
+
+// report prints the path of f if it lacks permissions, followed by
+// them if -mode is set, and records the error of f by its class
+// otherwise. With -output, it writes the record of f instead.
+func (r *reporter) report(f *forbidden.Finding) {
+	if f.Class == forbidden.Permission {
+		r.denied = true
+	}
+	switch {
+	case r.out != nil:
+		r.out.write(newRecord(f, r.suggest))
+	case f.Class != forbidden.Permission:
+		err := errfields.With(f.Err, "path", f.Path, "class", f.Class.String())
+		r.errs[f.Class].Add(errcode.With(err, classCodes[f.Class]))
+	case !r.modes:
+		fmt.Println(f.Path)
+	default:
+		names := make([]string, len(f.Denied))
+		for i, m := range f.Denied {
+			names[i] = m.String()
+		}
+		fmt.Printf("%s\t%s\n", f.Path, strings.Join(names, ","))
+	}
+	if (!r.suggest && !r.verbose) || r.out != nil || f.Class != forbidden.Permission {
+		return
+	}
+	fi, err := os.Stat(f.Path)
+	if err != nil {
+		return
+	}
+	if r.verbose {
+		fmt.Printf("\t%s\n", describe(fi))
+	}
+	if s := suggest(f, fi); r.suggest && s != "" {
+		fmt.Printf("\t%s\n", s)
+	}
+}
+
+// describe describes the file of fi like ls -l, with the IDs of its
+// owner and group, if any, as in
+// "-rw-r----- root:shadow (0:42) 2026-10-14 09:30:00".
+func describe(fi fs.FileInfo) string {
+	s := fi.Mode().String()
+	if uid, gid, ok := ids(fi); ok {
+		usr, group := owner(fi)
+		s += fmt.Sprintf(" %s:%s (%d:%d)", usr, group, uid, gid)
+	}
+	return s + " " + fi.ModTime().Format(time.DateTime)
+}
+
+// addPattern returns a function adding a glob pattern to patterns, for
+// -include or -exclude.
+func addPattern(patterns *[]string) func(string) error {
+	return func(pattern string) error {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+		*patterns = append(*patterns, pattern)
+		return nil
+	}
+}
+
+// eachPath returns the paths of args, with the lines of stdin in place
+// of an argument of "-", and sets *err if reading stdin fails.
+func eachPath(args []string, stdin io.Reader, err *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, arg := range args {
+			if arg != "-" {
+				if !yield(arg) {
+					return
+				}
 				continue
 			}
-			log.Print(err)
+			s := bufio.NewScanner(stdin)
+			for s.Scan() {
+				if line := s.Text(); line != "" && !yield(line) {
+					return
+				}
+			}
+			if e := s.Err(); e != nil {
+				*err = fmt.Errorf("reading standard input: %w", e)
+				return
+			}
 		}
-		f.Close()
 	}
-
-	fmt.Println(forbidden)
 }
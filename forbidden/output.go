@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errreport"
+	"github.com/go-monk/error-handling/pkg/forbidden"
+)
+
+// A record describes a path that lacks permissions, or could not be
+// checked, for -output json and csv.
+type record struct {
+	Path     string   `json:"path"`
+	Category string   `json:"category"`         // such as "permission-denied"
+	Denied   []string `json:"denied,omitempty"` // the permissions denied, such as "r"
+	Errno    int      `json:"errno,omitempty"`  // of the error, if it has one
+	Error    string   `json:"error"`
+
+	// Of the file, if it can be stat'ed.
+	Owner string `json:"owner,omitempty"`
+	Group string `json:"group,omitempty"`
+	UID   *int   `json:"uid,omitempty"`
+	GID   *int   `json:"gid,omitempty"`
+	Mode  string `json:"mode,omitempty"`  // such as "-rw-r-----"
+	MTime string `json:"mtime,omitempty"` // the modification time, in RFC 3339 format
+
+	// What else may deny the permissions, if the mode grants them,
+	// such as "a POSIX ACL".
+	Restrictions []string `json:"restrictions,omitempty"`
+
+	Suggestion string `json:"suggestion,omitempty"` // a fix, with -suggest
+}
+
+// newRecord returns the record of f, with a suggested fix if
+// withSuggestion.
+func newRecord(f *forbidden.Finding, withSuggestion bool) record {
+	r := record{Path: f.Path, Category: f.Class.String(), Error: f.Err.Error()}
+	for _, m := range f.Denied {
+		r.Denied = append(r.Denied, m.String())
+	}
+	var errno syscall.Errno
+	if errors.As(f.Err, &errno) {
+		r.Errno = int(errno)
+	}
+	if fi, err := os.Stat(f.Path); err == nil {
+		r.Owner, r.Group = owner(fi)
+		if uid, gid, ok := ids(fi); ok {
+			r.UID, r.GID = &uid, &gid
+		}
+		r.Mode = fi.Mode().String()
+		r.MTime = fi.ModTime().Format(time.RFC3339)
+		if modeAllows(f, fi) {
+			r.Restrictions = restrictions(f.Path)
+		}
+		if withSuggestion {
+			r.Suggestion = suggest(f, fi)
+		}
+	}
+	return r
+}
+
+// An output writes records.
+type output interface {
+	write(r record)
+	flush() error
+}
+
+// newOutput returns an output of format, which is json or ndjson, as
+// the results of rep, or csv, to w.
+func newOutput(format string, rep *errreport.Reporter, w io.Writer) (output, error) {
+	switch format {
+	case "json", "ndjson":
+		return &jsonOutput{rep}, nil
+	case "csv":
+		o := &csvOutput{w: csv.NewWriter(w)}
+		o.w.Write([]string{"path", "category", "denied", "errno", "error", "owner", "group", "uid", "gid", "mode", "mtime", "restrictions", "suggestion"})
+		return o, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// A jsonOutput writes records as the results of rep, JSON objects one
+// per line.
+type jsonOutput struct {
+	rep *errreport.Reporter
+}
+
+func (o *jsonOutput) write(r record) { o.rep.Result(r) }
+
+func (o *jsonOutput) flush() error { return o.rep.Err() }
+
+// A csvOutput writes records as CSV, after a header.
+type csvOutput struct {
+	w *csv.Writer
+}
+
+func (o *csvOutput) write(r record) {
+	errno := ""
+	if r.Errno != 0 {
+		errno = strconv.Itoa(r.Errno)
+	}
+	o.w.Write([]string{r.Path, r.Category, strings.Join(r.Denied, ","), errno, r.Error, r.Owner, r.Group,
+		optional(r.UID), optional(r.GID), r.Mode, r.MTime, strings.Join(r.Restrictions, "; "), r.Suggestion})
+}
+
+// optional formats n, or returns "" if it is nil.
+func optional(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func (o *csvOutput) flush() error {
+	o.w.Flush()
+	return o.w.Error()
+}
@@ -0,0 +1,21 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// restrictions describes what, other than its mode, may keep the user
+// from the file of path: its immutable and append-only flags. The ACLs
+// of macOS can't be read without cgo, so they are not described.
+func restrictions(path string) []string {
+	var st unix.Stat_t
+	if unix.Stat(path, &st) != nil {
+		return nil
+	}
+	var rs []string
+	if st.Flags&(unix.UF_IMMUTABLE|unix.SF_IMMUTABLE) != 0 {
+		rs = append(rs, "the immutable flag")
+	}
+	if st.Flags&(unix.UF_APPEND|unix.SF_APPEND) != 0 {
+		rs = append(rs, "the append-only flag")
+	}
+	return rs
+}
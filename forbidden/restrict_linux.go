@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// restrictions describes what, other than its mode, may keep the user
+// from the file of path: a POSIX ACL, the immutable or append-only
+// attribute, an SELinux label enforced by the system, and an AppArmor
+// profile confining the program.
+func restrictions(path string) []string {
+	var rs []string
+	if _, err := unix.Getxattr(path, "system.posix_acl_access", nil); err == nil {
+		rs = append(rs, "a POSIX ACL")
+	}
+	var stx unix.Statx_t
+	if unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BASIC_STATS, &stx) == nil {
+		attrs := stx.Attributes & stx.Attributes_mask
+		if attrs&unix.STATX_ATTR_IMMUTABLE != 0 {
+			rs = append(rs, "the immutable attribute")
+		}
+		if attrs&unix.STATX_ATTR_APPEND != 0 {
+			rs = append(rs, "the append-only attribute")
+		}
+	}
+	if enforce, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil && strings.TrimSpace(string(enforce)) == "1" {
+		if label := xattr(path, "security.selinux"); label != "" {
+			rs = append(rs, fmt.Sprintf("the SELinux label %s", label))
+		}
+	}
+	if profile := apparmor(); profile != "" {
+		rs = append(rs, fmt.Sprintf("the AppArmor profile %s", profile))
+	}
+	return rs
+}
+
+// xattr returns the extended attribute name of path, or "" if it has
+// none.
+func xattr(path, name string) string {
+	buf := make([]byte, 256)
+	n, err := unix.Getxattr(path, name, buf)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(buf[:n]), "\x00")
+}
+
+// apparmor returns the AppArmor profile confining the program, such as
+// "forbidden (enforce)", or "" if it is unconfined.
+func apparmor() string {
+	b, err := os.ReadFile("/proc/self/attr/apparmor/current")
+	if err != nil {
+		return ""
+	}
+	profile := strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	if profile == "unconfined" {
+		return ""
+	}
+	return profile
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// restrictions returns nothing, as the restrictions of files other
+// than their modes are not known here.
+func restrictions(path string) []string {
+	return nil
+}
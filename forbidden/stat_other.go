@@ -0,0 +1,17 @@
+//go:build !unix
+
+package main
+
+import "io/fs"
+
+// ids returns nothing, as files have no owning user and group IDs
+// here.
+func ids(fi fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// owner returns nothing, as files have no owning user and group IDs
+// here.
+func owner(fi fs.FileInfo) (usr, group string) {
+	return "", ""
+}
@@ -0,0 +1,65 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+var (
+	namesMu sync.Mutex
+	names   = make(map[string]string) // of users and groups, by "u" or "g" and ID
+)
+
+// ids returns the IDs of the user and group owning the file of fi.
+func ids(fi fs.FileInfo) (uid, gid int, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(st.Uid), int(st.Gid), true
+}
+
+// owner returns the names of the user and group owning the file of
+// fi, or their IDs if they have no names.
+func owner(fi fs.FileInfo) (usr, group string) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	usr = lookup("u", strconv.FormatUint(uint64(st.Uid), 10), func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+	group = lookup("g", strconv.FormatUint(uint64(st.Gid), 10), func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+	return usr, group
+}
+
+// lookup returns the name of id, of the kind "u" or "g", as found by
+// find once, or id if there is none.
+func lookup(kind, id string, find func(id string) (string, error)) string {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	name, ok := names[kind+id]
+	if !ok {
+		var err error
+		if name, err = find(id); err != nil {
+			name = id
+		}
+		names[kind+id] = name
+	}
+	return name
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-monk/error-handling/pkg/forbidden"
+)
+
+// suggest returns a fix for f, whose file is that of fi, such as
+// "owned by root:shadow mode 0640; try: sudo chmod o+r /etc/shadow",
+// or "" if it has none.
+func suggest(f *forbidden.Finding, fi fs.FileInfo) string {
+	who, shift, ok := userBits(fi)
+	if !ok || f.Class != forbidden.Permission {
+		return ""
+	}
+	var perms string
+	for _, m := range f.Denied {
+		perms += m.String()
+	}
+	usr, group := owner(fi)
+	mode := fi.Mode().Perm()
+	s := fmt.Sprintf("owned by %s:%s mode %04o", usr, group, mode)
+	if modeAllows(f, fi) {
+		if rs := restrictions(f.Path); rs != nil {
+			return s + "; the mode allows it, so look into " + strings.Join(rs, ", ")
+		}
+		return s + "; the mode allows it, so check the directories above it, ACLs, and security modules"
+	}
+	s += fmt.Sprintf("; try: sudo chmod %s+%s %s", who, perms, shellQuote(f.Path))
+	if ownerBits := deniedBits(f, 6); shift != 6 && mode&ownerBits == ownerBits {
+		s += fmt.Sprintf(", or run as its owner with sudo -u %s", shellQuote(usr))
+	}
+	return s
+}
+
+// userBits returns the class of the permission bits of the file of fi
+// that apply to the user, as for chmod, u, g, or o, and their shift.
+func userBits(fi fs.FileInfo) (who string, shift int, ok bool) {
+	uid, gid, ok := ids(fi)
+	switch {
+	case !ok:
+		return "", 0, false
+	case os.Getuid() == uid:
+		return "u", 6, true
+	case inGroup(gid):
+		return "g", 3, true
+	}
+	return "o", 0, true
+}
+
+// deniedBits returns the permission bits of the modes denied to f,
+// shifted by shift.
+func deniedBits(f *forbidden.Finding, shift int) fs.FileMode {
+	var bits fs.FileMode
+	for _, m := range f.Denied {
+		bits |= fs.FileMode(m) << shift
+	}
+	return bits
+}
+
+// modeAllows reports whether the mode of the file of fi grants the
+// user the permissions denied to f, so that something else denied
+// them.
+func modeAllows(f *forbidden.Finding, fi fs.FileInfo) bool {
+	_, shift, ok := userBits(fi)
+	bits := deniedBits(f, shift)
+	return ok && f.Class == forbidden.Permission && fi.Mode().Perm()&bits == bits
+}
+
+// inGroup reports whether the user is in the group of gid.
+func inGroup(gid int) bool {
+	if os.Getgid() == gid {
+		return true
+	}
+	groups, err := os.Getgroups()
+	return err == nil && slices.Contains(groups, gid)
+}
+
+// shellQuote quotes s for a POSIX shell, unless it needs no quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=.,:/@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
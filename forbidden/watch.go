@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/forbidden"
+)
+
+// states are the states of the paths found, by path. Those of the
+// paths not found are "ok".
+type states map[string]string
+
+// add adds the state of f to st.
+func (st states) add(f *forbidden.Finding) {
+	s := f.Class.String()
+	if f.Denied != nil {
+		names := make([]string, len(f.Denied))
+		for i, m := range f.Denied {
+			names[i] = m.String()
+		}
+		s += " " + strings.Join(names, ",")
+	}
+	st[f.Path] = s
+}
+
+func (st states) get(path string) string {
+	if s, ok := st[path]; ok {
+		return s
+	}
+	return "ok"
+}
+
+// watch scans paths with s every interval until ctx is done, and
+// prints the paths whose state changed since the last scan, which was
+// found in last. It returns the states of the last scan.
+func watch(ctx context.Context, s *forbidden.Scanner, paths []string, last states, interval time.Duration) states {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return last
+		case <-t.C:
+		}
+		now := make(states)
+		s.Scan(slices.Values(paths), now.add) // the errors are in now
+		changed := make(map[string]bool)
+		for path := range now {
+			changed[path] = now[path] != last.get(path)
+		}
+		for path := range last {
+			changed[path] = changed[path] || now.get(path) != last[path]
+		}
+		when := time.Now().Format(time.DateTime)
+		for _, path := range slices.Sorted(func(yield func(string) bool) {
+			for path, c := range changed {
+				if c && !yield(path) {
+					return
+				}
+			}
+		}) {
+			fmt.Printf("%s %s: %s -> %s\n", when, path, last.get(path), now.get(path))
+		}
+		last = now
+	}
+}
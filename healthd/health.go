@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"github.com/go-monk/error-handling/pkg/multierr"
+	"github.com/go-monk/error-handling/pkg/problem"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// health is the state of the targets, as last reported by their
+// checks.
+type health struct {
+	token     string        // that reports must be posted with, if not empty
+	reportTTL time.Duration // after which reported states are stale, if positive
+
+	mu      sync.Mutex
+	targets []*targetHealth // in the order of the configuration, then of the reports
+	byName  map[string]*targetHealth
+}
+
+type targetHealth struct {
+	Name  string    `json:"name"`
+	URL   string    `json:"url"`
+	State string    `json:"state"` // "unknown", "up", "down", or "stale"
+	Since time.Time `json:"since,omitzero"`
+	Error string    `json:"error,omitempty"`
+
+	// Of the targets reported by other wait instances.
+	Source   string    `json:"source,omitempty"`
+	Reported time.Time `json:"reported,omitzero"` // last
+}
+
+// newHealth returns the health of the targets of conf, as kept in
+// state, if not nil, or else unknown.
+func newHealth(conf *wait.Config, state *wait.StateFile) (*health, error) {
+	h := &health{byName: make(map[string]*targetHealth)}
+	for _, t := range conf.Targets {
+		th := &targetHealth{Name: t.Name, URL: wait.RedactURL(t.URL), State: "unknown"}
+		if state != nil {
+			s, err := state.Get(t.Name)
+			if err != nil {
+				return nil, err
+			}
+			if s.Up != nil {
+				th.State, th.Since, th.Error = "down", s.Since, s.Error
+				if *s.Up {
+					th.State = "up"
+				}
+			}
+		}
+		h.targets = append(h.targets, th)
+		h.byName[t.Name] = th
+	}
+	return h, nil
+}
+
+// update records a change in the state of a target.
+func (h *health) update(ch wait.Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	th := h.byName[ch.Target]
+	state := "down"
+	if ch.Up {
+		state = "up"
+	}
+	if th.State != state {
+		th.State, th.Since = state, ch.Time
+	}
+	th.Error = ""
+	if !ch.Up {
+		th.Error = ch.Err.Error()
+	}
+}
+
+// errNotReady is the code of the error of /readyz.
+var errNotReady = errcode.Register(errcode.Entry{
+	Name:       "healthd.not_ready",
+	Category:   errcode.Unavailable,
+	Severity:   errcode.Error,
+	HTTPStatus: http.StatusServiceUnavailable,
+})
+
+// serveReady responds with 200 OK if all targets are up, and with
+// 503 Service Unavailable listing those that are not otherwise, as
+// problem details if the request accepts them.
+func (h *health) serveReady(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	var notUp []string
+	var errs multierr.Collector
+	now := time.Now()
+	for _, th := range h.targets {
+		if state := h.stateOf(th, now); state != "up" {
+			notUp = append(notUp, fmt.Sprintf("%s: %s", th.Name, state))
+			errs.Add(errfields.With(errors.New(notUp[len(notUp)-1]), "target", th.Name, "state", state))
+		}
+	}
+	h.mu.Unlock()
+	if len(notUp) > 0 {
+		if strings.Contains(req.Header.Get("Accept"), problem.ContentType) {
+			problem.Write(w, errcode.With(errs.Err(), errNotReady))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		for _, s := range notUp {
+			fmt.Fprintln(w, s)
+		}
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// serveStatus responds with the state of all targets as JSON.
+func (h *health) serveStatus(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	targets := make([]targetHealth, len(h.targets))
+	now := time.Now()
+	for i, th := range h.targets {
+		targets[i] = *th
+		targets[i].State = h.stateOf(th, now)
+	}
+	h.mu.Unlock()
+	b, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(b, '\n'))
+}
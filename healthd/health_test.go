@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/problem"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+func TestHealth(t *testing.T) {
+	state := &wait.StateFile{Path: filepath.Join(t.TempDir(), "state.json")}
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	up, down := true, false
+	for name, s := range map[string]wait.TargetState{
+		"db":    {Up: &up, Since: since},
+		"cache": {Up: &down, Since: since, Error: "connection refused"},
+	} {
+		if err := state.Put(name, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, err := newHealth(&wait.Config{Targets: []wait.Target{
+		{Name: "db", URL: "postgres://app:secret@db:5432"},
+		{Name: "cache", URL: "redis://cache"},
+		{Name: "api", URL: "http://api"},
+	}}, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := states(t, h)
+	for name, want := range map[string]targetHealth{
+		"db":    {URL: "postgres://xxxxx@db:5432", State: "up", Since: since},
+		"cache": {URL: "redis://cache", State: "down", Since: since, Error: "connection refused"},
+		"api":   {URL: "http://api", State: "unknown"},
+	} {
+		if th := got[name]; th.URL != want.URL || th.State != want.State || !th.Since.Equal(want.Since) || th.Error != want.Error {
+			t.Errorf("%s: %+v, want %+v", name, th, want)
+		}
+	}
+
+	later := since.Add(time.Hour)
+	h.update(wait.Change{Target: "cache", Up: true, Time: later})
+	h.update(wait.Change{Target: "db", Up: false, Time: later, Err: errors.New("timeout")})
+	h.update(wait.Change{Target: "db", Up: false, Time: later.Add(time.Minute), Err: errors.New("refused")})
+	got = states(t, h)
+	if th := got["cache"]; th.State != "up" || !th.Since.Equal(later) || th.Error != "" {
+		t.Errorf("cache: %+v, want it up since the change", th)
+	}
+	if th := got["db"]; th.State != "down" || !th.Since.Equal(later) || th.Error != "refused" {
+		t.Errorf("db: %+v, want it down since the first change, with the last error", th)
+	}
+
+	w := httptest.NewRecorder()
+	h.serveReady(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if want := "db: down\napi: unknown\n"; w.Code != http.StatusServiceUnavailable || w.Body.String() != want {
+		t.Errorf("/readyz: status %d, %q, want %q", w.Code, w.Body, want)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", problem.ContentType)
+	w = httptest.NewRecorder()
+	h.serveReady(w, req)
+	d, ok, err := problem.FromResponse(w.Result())
+	if !ok || err != nil || d.Status != http.StatusServiceUnavailable || len(d.Errors) != 2 {
+		t.Errorf("/readyz as problem details: %+v, %v, %v", d, ok, err)
+	} else if d.Errors[0].Extensions["target"] != "db" || d.Errors[1].Extensions["state"] != "unknown" {
+		t.Errorf("/readyz as problem details: errors %+v", d.Errors)
+	}
+
+	h.update(wait.Change{Target: "db", Up: true, Time: later})
+	h.update(wait.Change{Target: "api", Up: true, Time: later})
+	w = httptest.NewRecorder()
+	h.serveReady(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Errorf("/readyz: status %d, %q, want ok", w.Code, w.Body)
+	}
+}
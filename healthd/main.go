@@ -0,0 +1,126 @@
+// The healthd program checks the targets described by a wait
+// configuration file continuously, and serves a summary of their
+// health over HTTP, for use as a sidecar:
+//
+//	/healthz      200 OK while healthd runs
+//	/readyz       200 OK if all targets are up, 503 otherwise, with
+//	              application/problem+json details if accepted
+//	/status.json  the state of every target
+//	/report       with -accept-reports, where other wait instances
+//	              post their results, which count as targets too
+//
+// Usage:
+//
+//	healthd [flags] -config file
+//
+// The configuration file is the one of wait -config; see package wait.
+// With -state, the state of the targets, such as since when they have
+// been up and whether they are flapping, is kept in a file, so that a
+// restarted healthd serves it at once and carries on detecting flapping
+// rather than starting over.
+//
+// With -accept-reports, healthd also serves the states of targets that
+// other wait instances report, for a view of the readiness of a fleet.
+// They post the output of wait -output json to /report, optionally
+// with ?source=name to name the reported targets source/target, as in
+//
+//	wait -output json tcp://db:5432 | curl --data-binary @- http://healthd:8080/report?source=web-1
+//
+// and, with -watch, as the targets go up and down. Reported targets
+// not reported on for -report-ttl are stale, and so not up.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+func main() {
+	configFile := flag.String("config", "", "check the targets described by the YAML or JSON `file`")
+	addr := flag.String("addr", ":8080", "serve the health summary on `host:port`")
+	interval := flag.Duration("interval", 10*time.Second, "check each target this often")
+	attemptTimeout := flag.Duration("attempt-timeout", 5*time.Second, "limit the time each check may take")
+	stateFile := flag.String("state", "", "keep the state of the targets in the JSON `file`, and start from it")
+	acceptReports := flag.Bool("accept-reports", false, "accept the results of other wait instances posted to /report")
+	reportToken := flag.String("report-token", "", "with -accept-reports, require reports to carry `token` as a bearer token")
+	reportTTL := flag.Duration("report-ttl", 5*time.Minute, "consider reported targets stale when not reported on for this long (0 never does)")
+	var logLevel slog.Level
+	flag.TextVar(&logLevel, "log-level", slog.LevelInfo, "log `level`: debug, info, warn, or error")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: healthd [flags] -config file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *configFile == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	conf, err := wait.LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthd: %v\n", err)
+		os.Exit(2)
+	}
+	opts := []wait.Option{wait.WithAttemptTimeout(*attemptTimeout), wait.WithLogger(logger)}
+	var state *wait.StateFile
+	if *stateFile != "" {
+		state = &wait.StateFile{Path: *stateFile}
+		opts = append(opts, wait.WithState(state))
+	}
+	h, err := newHealth(conf, state)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthd: %v\n", err)
+		os.Exit(2)
+	}
+	h.token, h.reportTTL = *reportToken, *reportTTL
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", h.serveReady)
+	mux.HandleFunc("GET /status.json", h.serveStatus)
+	if *acceptReports {
+		mux.HandleFunc("POST /report", h.serveReport)
+	}
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	go func() {
+		err := conf.Watch(ctx, *interval, func(ch wait.Change) {
+			h.update(ch)
+			if ch.Up {
+				logger.Info("target up", "target", ch.Target)
+			} else {
+				logger.Warn("target down", "target", ch.Target, "err", ch.Err)
+			}
+		}, opts...)
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "healthd: %v\n", err)
+			os.Exit(2)
+		}
+	}()
+
+	logger.Info("serving", "addr", *addr, "targets", len(conf.Targets))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		fmt.Fprintf(os.Stderr, "healthd: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxReport limits the size of the reports posted to /report.
+const maxReport = 1 << 20
+
+// A report is what wait -output json writes and healthd is posted:
+// the "done" object with the results of a wait, or an "up" or "down"
+// event of -watch. Other events, such as attempts, are ignored.
+type report struct {
+	Event   string         `json:"event"`
+	Target  string         `json:"target"`
+	Time    time.Time      `json:"time"`
+	Error   string         `json:"error"`
+	Results []reportResult `json:"results"`
+}
+
+type reportResult struct {
+	Target    string `json:"target"`
+	State     string `json:"state"` // "ready", "not ready", "down", "still up", or "not tried"
+	LastError string `json:"last_error"`
+}
+
+// errBadReport is the error of reports that cannot be read.
+var errBadReport = errors.New("bad report")
+
+// serveReport merges the reports posted by wait instances, one or
+// more JSON objects as written by wait -output json, as the states of
+// targets named after the source given by the query, if any, such as
+// /report?source=web-1, and the targets of the reports.
+func (h *health) serveReport(w http.ResponseWriter, req *http.Request) {
+	if h.token != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+		return
+	}
+	source := req.URL.Query().Get("source")
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxReport))
+	now := time.Now()
+	n := 0
+	for {
+		var r report
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			http.Error(w, fmt.Sprintf("%v: %v", errBadReport, err), http.StatusBadRequest)
+			return
+		}
+		switch r.Event {
+		case "done":
+			for _, res := range r.Results {
+				// The targets of -down waits that stopped
+				// responding are down too.
+				up := res.State == "ready" || res.State == "still up"
+				h.report(source, res.Target, up, now, now, res.LastError)
+				n++
+			}
+		case "up", "down":
+			t := r.Time
+			if t.IsZero() {
+				t = now
+			}
+			h.report(source, r.Target, r.Event == "up", t, now, r.Error)
+			n++
+		}
+	}
+	if n == 0 {
+		http.Error(w, errBadReport.Error()+": no results or changes", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// report records that the target of source was reported up or not,
+// as of since, at time now, with errMsg saying why it is not.
+func (h *health) report(source, target string, up bool, since, now time.Time, errMsg string) {
+	name := target
+	if source != "" {
+		name = source + "/" + target
+	}
+	state := "down"
+	if up {
+		state = "up"
+		errMsg = ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	th := h.byName[name]
+	if th == nil {
+		th = &targetHealth{Name: name, URL: target, Source: source}
+		h.targets = append(h.targets, th)
+		h.byName[name] = th
+	}
+	if th.State != state {
+		th.State, th.Since = state, since
+	}
+	th.Error, th.Reported = errMsg, now
+}
+
+// stateOf returns the state of th, which is "stale" for reported
+// targets that have not been reported on for longer than h.reportTTL.
+func (h *health) stateOf(th *targetHealth, now time.Time) string {
+	if !th.Reported.IsZero() && h.reportTTL > 0 && now.Sub(th.Reported) > h.reportTTL {
+		return "stale"
+	}
+	return th.State
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// post posts body to the /report of h with the query and the
+// authorization given, and returns the status of the response.
+func post(t *testing.T, h *health, query, auth, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/report"+query, strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	h.serveReport(w, req)
+	return w.Code
+}
+
+// states returns the states of the targets of h by name, as served
+// at /status.json.
+func states(t *testing.T, h *health) map[string]targetHealth {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.serveStatus(w, httptest.NewRequest(http.MethodGet, "/status.json", nil))
+	var targets []targetHealth
+	if err := json.Unmarshal(w.Body.Bytes(), &targets); err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]targetHealth)
+	for _, th := range targets {
+		m[th.Name] = th
+	}
+	return m
+}
+
+func TestReportToken(t *testing.T) {
+	h, err := newHealth(&wait.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.token = "secret"
+	const body = `{"event": "up", "target": "tcp://db:5432"}`
+	for _, test := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer other", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Basic c2VjcmV0", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusNoContent},
+	} {
+		if got := post(t, h, "", test.auth, body); got != test.want {
+			t.Errorf("authorized by %q: status %d, want %d", test.auth, got, test.want)
+		}
+	}
+	if got := states(t, h); len(got) != 1 || got["tcp://db:5432"].State != "up" {
+		t.Errorf("got the states %+v, want db up by the one report accepted", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	h, err := newHealth(&wait.Config{Targets: []wait.Target{{Name: "api", URL: "http://api"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := `{"event": "attempt", "target": "tcp://db:5432", "attempt": 1}
+{"event": "done", "ready": false, "results": [
+	{"target": "tcp://db:5432", "state": "ready"},
+	{"target": "tcp://cache:6379", "state": "not ready", "last_error": "connection refused"},
+	{"target": "tcp://old:1", "state": "still up", "last_error": "still responding"},
+	{"target": "tcp://gone:1", "state": "down"},
+	{"target": "tcp://queue:5672", "state": "not tried"}
+]}
+{"event": "down", "target": "tcp://mq:5672", "time": "` + since.Format(time.RFC3339) + `", "error": "timeout"}
+`
+	if got := post(t, h, "?source=web-1", "", body); got != http.StatusNoContent {
+		t.Fatalf("status %d", got)
+	}
+	got := states(t, h)
+	for name, want := range map[string]targetHealth{
+		"api":                    {State: "unknown"},
+		"web-1/tcp://db:5432":    {State: "up"},
+		"web-1/tcp://cache:6379": {State: "down", Error: "connection refused"},
+		"web-1/tcp://old:1":      {State: "up"}, // -down waits for it were not over
+		"web-1/tcp://gone:1":     {State: "down"},
+		"web-1/tcp://queue:5672": {State: "down"},
+		"web-1/tcp://mq:5672":    {State: "down", Error: "timeout", Since: since},
+	} {
+		th, ok := got[name]
+		if !ok {
+			t.Errorf("%s not reported", name)
+			continue
+		}
+		if th.State != want.State || th.Error != want.Error || !want.Since.IsZero() && !th.Since.Equal(want.Since) {
+			t.Errorf("%s: %s since %v, %q, want %s since %v, %q", name, th.State, th.Since, th.Error, want.State, want.Since, want.Error)
+		}
+		if name != "api" && (th.Source != "web-1" || th.URL != strings.TrimPrefix(name, "web-1/") || th.Reported.IsZero()) {
+			t.Errorf("%s: reported %+v", name, th)
+		}
+	}
+	if len(got) != 7 {
+		t.Errorf("got %d targets, want 7: %+v", len(got), got)
+	}
+
+	// The state changes, and its time with it, only as reported.
+	up := `{"event": "up", "target": "tcp://mq:5672", "time": "` + since.Add(time.Hour).Format(time.RFC3339) + `"}`
+	for range 2 {
+		if got := post(t, h, "?source=web-1", "", up); got != http.StatusNoContent {
+			t.Fatalf("status %d", got)
+		}
+	}
+	if th := states(t, h)["web-1/tcp://mq:5672"]; th.State != "up" || !th.Since.Equal(since.Add(time.Hour)) || th.Error != "" {
+		t.Errorf("mq: %+v, want it up since an hour later", th)
+	}
+
+	for _, body := range []string{"", `{"event": "attempt"}`, `{"event": "done", "results": []}`, `{"event":`, `[]`} {
+		if got := post(t, h, "", "", body); got != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want %d", body, got, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestReportStale(t *testing.T) {
+	h, err := newHealth(&wait.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.reportTTL = time.Minute
+	if got := post(t, h, "", "", `{"event": "up", "target": "tcp://db:5432"}`); got != http.StatusNoContent {
+		t.Fatalf("status %d", got)
+	}
+	w := httptest.NewRecorder()
+	h.serveReady(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/readyz: status %d after a fresh report", w.Code)
+	}
+
+	h.byName["tcp://db:5432"].Reported = time.Now().Add(-2 * time.Minute)
+	if th := states(t, h)["tcp://db:5432"]; th.State != "stale" {
+		t.Errorf("got the state %s, want stale", th.State)
+	}
+	w = httptest.NewRecorder()
+	h.serveReady(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "tcp://db:5432: stale\n" {
+		t.Errorf("/readyz: status %d, %q, want the target stale", w.Code, w.Body)
+	}
+}
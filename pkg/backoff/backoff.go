@@ -0,0 +1,102 @@
+// Package backoff computes the delays between attempts of a retried
+// operation. Package backofftest checks Backoffs written elsewhere
+// against the properties its own keep to.
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// A Backoff computes how long to wait before retrying an operation.
+type Backoff interface {
+	// NextDelay returns the delay after the given failed attempt.
+	// Attempts are numbered from 0.
+	NextDelay(attempt int) time.Duration
+}
+
+// Func adapts an ordinary function to the Backoff interface.
+type Func func(attempt int) time.Duration
+
+// NextDelay returns f(attempt).
+func (f Func) NextDelay(attempt int) time.Duration { return f(attempt) }
+
+// maxDuration is the longest representable time.Duration.
+const maxDuration = time.Duration(math.MaxInt64)
+
+// limit returns d clamped to max, where a max of zero or less means
+// no limit other than the largest representable duration.
+func limit(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+// Constant returns a Backoff that always waits d.
+func Constant(d time.Duration) Backoff {
+	return Func(func(int) time.Duration { return d })
+}
+
+// Linear returns a Backoff that waits initial after the first
+// attempt and step longer after each following one, up to max.
+// A max of zero means no limit.
+func Linear(initial, step, max time.Duration) Backoff {
+	return Func(func(attempt int) time.Duration {
+		attempt = max0(attempt)
+		if step > 0 && time.Duration(attempt) > (maxDuration-initial)/step {
+			return limit(maxDuration, max)
+		}
+		return limit(initial+time.Duration(attempt)*step, max)
+	})
+}
+
+// Exponential returns a Backoff that waits initial after the first
+// attempt and twice as long after each following one, up to max.
+// A max of zero means no limit. The delays never overflow no matter
+// how many attempts are made.
+func Exponential(initial, max time.Duration) Backoff {
+	return Func(func(attempt int) time.Duration {
+		return exponential(initial, attempt, max)
+	})
+}
+
+// exponential returns initial doubled tries times, limited to max.
+func exponential(initial time.Duration, tries int, max time.Duration) time.Duration {
+	if max <= 0 {
+		max = maxDuration
+	}
+	if initial <= 0 || tries < 0 {
+		return min(initial, max)
+	}
+	// Shifting by tries must not carry bits of initial past the
+	// sign bit; if it would, the result is bigger than max anyway.
+	if tries >= 63 || initial > maxDuration>>uint(tries) {
+		return max
+	}
+	return min(initial<<uint(tries), max)
+}
+
+// Fibonacci returns a Backoff whose delays follow the Fibonacci
+// sequence scaled by initial (1, 1, 2, 3, 5, ... times initial),
+// up to max. A max of zero means no limit.
+func Fibonacci(initial, max time.Duration) Backoff {
+	return Func(func(attempt int) time.Duration {
+		if initial <= 0 {
+			return limit(initial, max)
+		}
+		a, b := initial, initial
+		for range max0(attempt) {
+			if b > maxDuration-a {
+				return limit(maxDuration, max)
+			}
+			a, b = b, a+b
+			if max > 0 && a >= max {
+				return max
+			}
+		}
+		return limit(a, max)
+	})
+}
+
+func max0(n int) int { return max(n, 0) }
@@ -0,0 +1,61 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		initial time.Duration
+		tries   int
+		max     time.Duration
+		want    time.Duration
+	}{
+		{time.Second, 0, 0, time.Second},
+		{time.Second, 1, 0, 2 * time.Second},
+		{time.Second, 5, 0, 32 * time.Second},
+		{time.Second, 5, 10 * time.Second, 10 * time.Second},
+		{time.Second, 30, time.Minute, time.Minute},
+		{time.Second, 34, 0, maxDuration},
+		{time.Second, 62, 0, maxDuration},
+		{time.Second, 63, 0, maxDuration},
+		{time.Second, 64, 0, maxDuration},
+		{time.Second, 1000, time.Minute, time.Minute},
+		{1, 62, 0, 1 << 62},
+		{1, 63, 0, maxDuration},
+		{0, 100, time.Minute, 0},
+	}
+	for _, test := range tests {
+		got := Exponential(test.initial, test.max).NextDelay(test.tries)
+		if got != test.want {
+			t.Errorf("Exponential(%v, %v).NextDelay(%d) = %v, want %v",
+				test.initial, test.max, test.tries, got, test.want)
+		}
+	}
+}
+
+func TestNeverNegative(t *testing.T) {
+	backoffs := map[string]Backoff{
+		"Exponential": Exponential(time.Second, 0),
+		"Linear":      Linear(time.Second, time.Hour, 0),
+		"Fibonacci":   Fibonacci(time.Second, 0),
+	}
+	for name, b := range backoffs {
+		for attempt := 0; attempt < 200; attempt++ {
+			if d := b.NextDelay(attempt); d <= 0 {
+				t.Fatalf("%s.NextDelay(%d) = %v, want positive", name, attempt, d)
+			}
+		}
+	}
+}
+
+func TestFibonacci(t *testing.T) {
+	b := Fibonacci(time.Second, 10*time.Second)
+	want := []time.Duration{1, 1, 2, 3, 5, 8, 10, 10}
+	for attempt, w := range want {
+		if got := b.NextDelay(attempt); got != w*time.Second {
+			t.Errorf("NextDelay(%d) = %v, want %v", attempt, got, w*time.Second)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package backofftest checks that Backoffs keep the promises retry
+// loops rely on, so that strategies written outside package backoff
+// can be tested against the properties its own strategies are.
+package backofftest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+// Bounds are what a Backoff promises about its delays. Every Backoff
+// is checked never to return a negative delay, and those given a
+// Max never to return a longer one.
+type Bounds struct {
+	// Min and Max bound every delay; a Max of zero means no limit.
+	Min, Max time.Duration
+
+	// Monotonic says delays never get shorter as attempts go on,
+	// which is not so once they are jittered.
+	Monotonic bool
+
+	// Base, if not nil, is the Backoff the tested one randomizes,
+	// as backoff.WithJitter does: every delay must be between Low
+	// and High times that of Base for the same attempt, such as
+	// between 0 and 1 for backoff.FullJitter.
+	Base      backoff.Backoff
+	Low, High float64
+
+	// Attempts is how many attempts are checked, from 0, 200 if
+	// zero; attempts far beyond it are checked too, for overflows.
+	// Samples is how many times the delay of each is asked for,
+	// to catch what randomized Backoffs do only now and then, 10
+	// if zero.
+	Attempts, Samples int
+}
+
+// hugeAttempts are attempts checked beyond Bounds.Attempts, where
+// computing delays may overflow.
+var hugeAttempts = []int{1000, 1 << 20, math.MaxInt32, math.MaxInt - 1, math.MaxInt}
+
+// Test checks that b keeps to bounds, and returns an error listing
+// the delays that do not, or nil if all do. It calls b.NextDelay in
+// order of attempts, from 0, as a retry loop does, starting over
+// for every sample, so that Backoffs remembering earlier delays,
+// such as backoff.Decorrelated, are checked as they are used.
+func Test(b backoff.Backoff, bounds Bounds) error {
+	attempts, samples := bounds.Attempts, bounds.Samples
+	if attempts <= 0 {
+		attempts = 200
+	}
+	if samples <= 0 {
+		samples = 10
+	}
+	var errs []error
+	fail := func(format string, args ...any) {
+		// A broken Backoff typically breaks for most attempts;
+		// the first few say enough.
+		if len(errs) < 10 {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	for range samples {
+		prev := time.Duration(-1)
+		check := func(attempt int) {
+			d := b.NextDelay(attempt)
+			switch {
+			case d < 0:
+				fail("delay %v after attempt %d is negative", d, attempt)
+			case d < bounds.Min:
+				fail("delay %v after attempt %d is shorter than %v", d, attempt, bounds.Min)
+			case bounds.Max > 0 && d > bounds.Max:
+				fail("delay %v after attempt %d is longer than %v", d, attempt, bounds.Max)
+			}
+			if bounds.Monotonic && d < prev {
+				fail("delay %v after attempt %d is shorter than the %v before", d, attempt, prev)
+			}
+			prev = d
+			if bounds.Base != nil {
+				base := bounds.Base.NextDelay(attempt)
+				if !within(d, base, bounds.Low, bounds.High) {
+					fail("delay %v after attempt %d is not between %v and %v", d, attempt, scale(base, bounds.Low), scale(base, bounds.High))
+				}
+			}
+		}
+		for attempt := range attempts {
+			check(attempt)
+		}
+		for _, attempt := range hugeAttempts {
+			check(attempt)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// within reports whether d is between low and high times base, give
+// or take what converting long durations to float64 rounds off.
+func within(d, base time.Duration, low, high float64) bool {
+	const tolerance = 1e-9
+	fd, fb := float64(d), float64(base)
+	return fd >= fb*low*(1-tolerance)-1 && fd <= fb*high*(1+tolerance)+1
+}
+
+// scale returns d times f, saturating at the longest duration.
+func scale(d time.Duration, f float64) time.Duration {
+	if v := float64(d) * f; v < math.MaxInt64 {
+		return time.Duration(v)
+	}
+	return math.MaxInt64
+}
@@ -0,0 +1,94 @@
+package backofftest_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/backoff/backofftest"
+)
+
+// checkStrategies checks the strategies of package backoff made with
+// initial, step, and max, unjittered and with every kind of jitter.
+func checkStrategies(t *testing.T, initial, step, max time.Duration) {
+	t.Helper()
+	lowest := initial
+	if max > 0 {
+		lowest = min(initial, max)
+	}
+	for name, b := range map[string]backoff.Backoff{
+		"Exponential": backoff.Exponential(initial, max),
+		"Linear":      backoff.Linear(initial, step, max),
+		"Fibonacci":   backoff.Fibonacci(initial, max),
+		"Constant":    backoff.Constant(lowest),
+	} {
+		for name, test := range map[string]struct {
+			b      backoff.Backoff
+			bounds backofftest.Bounds
+		}{
+			name: {b, backofftest.Bounds{Min: lowest, Max: max, Monotonic: true}},
+			name + " with full jitter": {backoff.WithJitter(b, backoff.FullJitter),
+				backofftest.Bounds{Max: max, Base: b, Low: 0, High: 1}},
+			name + " with equal jitter": {backoff.WithJitter(b, backoff.EqualJitter),
+				backofftest.Bounds{Max: max, Base: b, Low: 0.5, High: 1}},
+			name + " with decorrelated jitter": {backoff.WithJitter(b, backoff.DecorrelatedJitter),
+				backofftest.Bounds{Min: b.NextDelay(0), Max: b.NextDelay(math.MaxInt)}},
+		} {
+			if err := backofftest.Test(test.b, test.bounds); err != nil {
+				t.Errorf("%s(%v, %v, %v):\n%v", name, initial, step, max, err)
+			}
+		}
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	checkStrategies(t, time.Second, time.Second, 0)
+	checkStrategies(t, 100*time.Millisecond, time.Second, 30*time.Second)
+	checkStrategies(t, time.Minute, 0, time.Second) // max below initial
+	checkStrategies(t, 0, 0, 0)
+	checkStrategies(t, 1, math.MaxInt64, math.MaxInt64)
+}
+
+func FuzzStrategies(f *testing.F) {
+	f.Add(int64(time.Second), int64(time.Second), int64(0))
+	f.Add(int64(time.Millisecond), int64(time.Hour), int64(time.Minute))
+	f.Add(int64(1), int64(1), int64(math.MaxInt64))
+	f.Add(int64(math.MaxInt64), int64(math.MaxInt64), int64(0))
+	f.Fuzz(func(t *testing.T, initial, step, max int64) {
+		if initial < 0 || step < 0 || max < 0 {
+			t.Skip("negative delays are out of range")
+		}
+		checkStrategies(t, time.Duration(initial), time.Duration(step), time.Duration(max))
+	})
+}
+
+func TestCatchesBroken(t *testing.T) {
+	for name, test := range map[string]struct {
+		b      backoff.Backoff
+		bounds backofftest.Bounds
+	}{
+		"overflowing": {backoff.Func(func(attempt int) time.Duration { return time.Second << attempt }), backofftest.Bounds{}},
+		"beyond max":  {backoff.Constant(time.Hour), backofftest.Bounds{Max: time.Minute}},
+		"shrinking": {backoff.Func(func(attempt int) time.Duration { return time.Hour / time.Duration(attempt%100+1) }),
+			backofftest.Bounds{Monotonic: true}},
+		"badly jittered": {backoff.Constant(2 * time.Second),
+			backofftest.Bounds{Base: backoff.Constant(time.Second), Low: 0, High: 1}},
+	} {
+		if err := backofftest.Test(test.b, test.bounds); err == nil {
+			t.Errorf("%s Backoff passed", name)
+		}
+	}
+}
+
+func ExampleTest() {
+	// A strategy of one's own, doubling the delay every second
+	// attempt, checked against what it promises.
+	b := backoff.Func(func(attempt int) time.Duration {
+		return backoff.Exponential(time.Second, time.Minute).NextDelay(attempt / 2)
+	})
+	err := backofftest.Test(b, backofftest.Bounds{Min: time.Second, Max: time.Minute, Monotonic: true})
+	fmt.Println(err)
+	// Output: <nil>
+}
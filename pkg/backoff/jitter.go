@@ -0,0 +1,125 @@
+package backoff
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// A Jitter strategy randomizes the delays of a Backoff so that many
+// clients started at once don't retry in lockstep.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type Jitter int
+
+const (
+	// NoJitter uses the delays unchanged.
+	NoJitter Jitter = iota
+	// FullJitter picks a delay between zero and the original delay.
+	FullJitter
+	// EqualJitter keeps half of the original delay and randomizes
+	// the other half.
+	EqualJitter
+	// DecorrelatedJitter picks a delay between the first delay and
+	// three times the previous delay, independently of the attempt
+	// number.
+	DecorrelatedJitter
+)
+
+var jitterNames = [...]string{
+	NoJitter:           "none",
+	FullJitter:         "full",
+	EqualJitter:        "equal",
+	DecorrelatedJitter: "decorrelated",
+}
+
+func (j Jitter) String() string {
+	if j < 0 || int(j) >= len(jitterNames) {
+		return fmt.Sprintf("Jitter(%d)", int(j))
+	}
+	return jitterNames[j]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (j Jitter) MarshalText() ([]byte, error) {
+	return []byte(j.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the
+// names none, full, equal, and decorrelated.
+func (j *Jitter) UnmarshalText(text []byte) error {
+	for i, name := range jitterNames {
+		if string(text) == name {
+			*j = Jitter(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown jitter %q", text)
+}
+
+// WithJitter returns a Backoff that randomizes the delays of b
+// using strategy j.
+//
+// With DecorrelatedJitter the delays are drawn between the first
+// delay of b and three times the previous delay, and limited by
+// the longest delay b ever returns; see Decorrelated.
+func WithJitter(b Backoff, j Jitter) Backoff {
+	switch j {
+	case FullJitter:
+		return Func(func(attempt int) time.Duration {
+			return between(0, b.NextDelay(attempt))
+		})
+	case EqualJitter:
+		return Func(func(attempt int) time.Duration {
+			d := b.NextDelay(attempt)
+			return d/2 + between(0, d-d/2)
+		})
+	case DecorrelatedJitter:
+		return Decorrelated(b.NextDelay(0), b.NextDelay(math.MaxInt))
+	}
+	return b
+}
+
+// Decorrelated returns a Backoff implementing AWS-style
+// decorrelated jitter: each delay is drawn between base and three
+// times the previous delay, up to max. A max of zero means no limit.
+//
+// Unlike the other backoffs it remembers the previous delay, which
+// is forgotten whenever attempt 0 is requested. It is safe for
+// concurrent use, but sharing it between retry loops mixes their
+// histories.
+func Decorrelated(base, max time.Duration) Backoff {
+	return &decorrelated{base: base, max: max}
+}
+
+type decorrelated struct {
+	base, max time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func (b *decorrelated) NextDelay(attempt int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev := b.prev
+	if attempt <= 0 || prev < b.base {
+		prev = b.base
+	}
+	upper := maxDuration
+	if prev <= maxDuration/3 {
+		upper = prev * 3
+	}
+	b.prev = limit(between(b.base, upper), b.max)
+	return b.prev
+}
+
+// between returns a random duration in [lo, hi), or lo if the
+// interval is empty.
+func between(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + rand.N(hi-lo)
+}
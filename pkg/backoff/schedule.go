@@ -0,0 +1,276 @@
+package backoff
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule tells the wall-clock times at which to retry, for
+// operations that can only succeed at known times, such as after the
+// maintenance windows of a server.
+type Schedule interface {
+	// Next returns the first time of the schedule after t, or the
+	// zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+// OnSchedule returns a Backoff that waits until the next time of s,
+// whatever the attempt, as told by now, such as time.Now or the Now
+// method of a clock.Clock. If s has no next time, it waits forever,
+// which retry.Do cuts short at the deadline of its context.
+func OnSchedule(s Schedule, now func() time.Time) Backoff {
+	return Func(func(int) time.Duration {
+		t := now()
+		next := s.Next(t)
+		if next.IsZero() {
+			return maxDuration
+		}
+		return next.Sub(t)
+	})
+}
+
+// A cron is a Schedule given by a cron expression, as bit sets of the
+// minutes, hours, days of the month, months, and days of the week it
+// matches.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool // the fields were "*"
+	loc                           *time.Location
+}
+
+// cronFields describe the fields of cron expressions.
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string // of the values from min, if any
+}{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronDescriptors are the shorthands of common expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression of five fields, the minutes,
+// hours, days of the month, months, and days of the week at which to
+// retry, such as "0,30 * * * *" for every hour on the hour and at half
+// past, or "*/15 2-4 * * mon-fri" for every quarter of an hour from
+// 2:00 to 4:45 on weekdays. Fields are lists of values, ranges such as
+// 2-4, and *, each optionally followed by a step, such as /15; months
+// and days of the week may be named by their first three letters, and
+// Sunday is 0 or 7. As in cron, a time matches if it matches both the
+// day of the month and the day of the week, unless neither is *, in
+// which case matching either is enough. The descriptors @hourly,
+// @daily, @midnight, @weekly, @monthly, @yearly, and @annually stand
+// for their expressions.
+//
+// The times are those of the location of the times given to Next,
+// unless the expression starts with CRON_TZ= and the name of a
+// location, as in "CRON_TZ=Europe/Berlin 0 3 * * *".
+func ParseCron(expr string) (Schedule, error) {
+	c := &cron{}
+	fields := strings.Fields(expr)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "CRON_TZ=") {
+		loc, err := time.LoadLocation(strings.TrimPrefix(fields[0], "CRON_TZ="))
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		c.loc, fields = loc, fields[1:]
+	}
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		d, ok := cronDescriptors[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("cron expression %q: unknown descriptor %s", expr, fields[0])
+		}
+		fields = strings.Fields(d)
+	}
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q: want %d fields, not %d", expr, len(cronFields), len(fields))
+	}
+	sets := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		set, err := parseCronField(field, i)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %s: %w", expr, cronFields[i].name, err)
+		}
+		*sets[i] = set
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // Sunday
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField returns the set of values of field, the ith of its
+// expression.
+func parseCronField(field string, i int) (uint64, error) {
+	f := cronFields[i]
+	var set uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(loText, i); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = cronValue(hiText, i); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // as in 5/15, from 5 on
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// cronValue returns the value of text in the ith field of cron
+// expressions.
+func cronValue(text string, i int) (int, error) {
+	f := cronFields[i]
+	for j, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + j, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q, want %d-%d", text, f.min, f.max)
+	}
+	return v, nil
+}
+
+// cronYears is how far ahead Next looks for a matching time, which
+// covers the leap days of February 29.
+const cronYears = 8
+
+func (c *cron) Next(t time.Time) time.Time {
+	loc := c.loc
+	if loc == nil {
+		loc = t.Location()
+	}
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+	end := t.Year() + cronYears
+	for t.Year() <= end {
+		y, m, d := t.Date()
+		switch {
+		case c.month&(1<<uint(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			// The next minute of the set in this hour, if any.
+			if rest := c.minute >> uint(t.Minute()); rest != 0 {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			} else {
+				t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches c.
+func (c *cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// A clockTimes is a Schedule of times of day, or of minutes of every
+// hour.
+type clockTimes struct {
+	minutes []int // of the day, or of the hour if hourly
+	hourly  bool
+}
+
+// At returns a Schedule of the times of day given as 15:04, such as
+// At("02:00", "14:30"), or of the minutes of every hour given as :04,
+// such as At(":00", ":30"), in the location of the times given to
+// Next. The two cannot be mixed.
+func At(times ...string) (Schedule, error) {
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no times")
+	}
+	var s clockTimes
+	for i, text := range times {
+		hourly := strings.HasPrefix(text, ":")
+		if i > 0 && hourly != s.hourly {
+			return nil, fmt.Errorf("times of day and minutes of the hour mixed in %q", strings.Join(times, ","))
+		}
+		s.hourly = hourly
+		layout := "15:04"
+		if hourly {
+			layout = ":04"
+		}
+		t, err := time.Parse(layout, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q, want %s", text, layout)
+		}
+		s.minutes = append(s.minutes, t.Hour()*60+t.Minute())
+	}
+	return s, nil
+}
+
+func (s clockTimes) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	y, m, d := t.Date()
+	var next time.Time
+	for _, minute := range s.minutes {
+		var c time.Time
+		if s.hourly {
+			c = time.Date(y, m, d, t.Hour(), minute, 0, 0, t.Location())
+			if !c.After(t) {
+				c = c.Add(time.Hour)
+			}
+		} else {
+			c = time.Date(y, m, d, 0, minute, 0, 0, t.Location())
+			if !c.After(t) {
+				c = time.Date(y, m, d+1, 0, minute, 0, 0, t.Location())
+			}
+		}
+		if next.IsZero() || c.Before(next) {
+			next = c
+		}
+	}
+	return next
+}
@@ -0,0 +1,134 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+// at parses a time in UTC as 2006-01-02 15:04.
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr, from, want string
+	}{
+		{"0,30 * * * *", "2024-03-01 10:05", "2024-03-01 10:30"},
+		{"0,30 * * * *", "2024-03-01 10:30", "2024-03-01 11:00"},
+		{"0,30 * * * *", "2024-12-31 23:45", "2025-01-01 00:00"},
+		{"*/15 2-4 * * mon-fri", "2024-03-01 04:50", "2024-03-04 02:00"}, // Friday to Monday
+		{"5/20 * * * *", "2024-03-01 10:06", "2024-03-01 10:25"},
+		{"0 3 * * *", "2024-03-01 03:00", "2024-03-02 03:00"},
+		{"0 0 29 feb *", "2025-01-01 00:00", "2028-02-29 00:00"},
+		{"0 0 13 * fri", "2024-03-01 00:00", "2024-03-08 00:00"}, // either day matches
+		{"0 0 * * 7", "2024-03-01 00:00", "2024-03-03 00:00"},    // Sunday
+		{"0 12 1 JAN,jul *", "2024-03-01 00:00", "2024-07-01 12:00"},
+		{"@hourly", "2024-03-01 10:59", "2024-03-01 11:00"},
+		{"@weekly", "2024-03-01 10:00", "2024-03-03 00:00"},
+	}
+	for _, test := range tests {
+		s, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", test.expr, err)
+			continue
+		}
+		if got, want := s.Next(at(t, test.from)), at(t, test.want); !got.Equal(want) {
+			t.Errorf("ParseCron(%q).Next(%s) = %v, want %v", test.expr, test.from, got, want)
+		}
+	}
+}
+
+func TestParseCronNever(t *testing.T) {
+	s, err := ParseCron("0 0 30 feb *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(at(t, "2024-01-01 00:00")); !got.IsZero() {
+		t.Errorf("Next = %v, want the zero time", got)
+	}
+}
+
+func TestParseCronZone(t *testing.T) {
+	s, err := ParseCron("CRON_TZ=America/New_York 0 3 * * *")
+	if err != nil {
+		t.Skip(err) // no time zone database
+	}
+	// 3:00 in New York is 8:00 UTC in winter, 7:00 in summer.
+	if got, want := s.Next(at(t, "2024-01-10 09:00")), at(t, "2024-01-11 08:00"); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+	if got, want := s.Next(at(t, "2024-07-10 09:00")), at(t, "2024-07-11 07:00"); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@sometimes",
+		"CRON_TZ=Nowhere/Else 0 * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		times      []string
+		from, want string
+	}{
+		{[]string{":00", ":30"}, "2024-03-01 10:05", "2024-03-01 10:30"},
+		{[]string{":00", ":30"}, "2024-03-01 10:30", "2024-03-01 11:00"},
+		{[]string{"14:30", "02:00"}, "2024-03-01 10:05", "2024-03-01 14:30"},
+		{[]string{"14:30", "02:00"}, "2024-03-01 14:30", "2024-03-02 02:00"},
+	}
+	for _, test := range tests {
+		s, err := At(test.times...)
+		if err != nil {
+			t.Errorf("At(%q): %v", test.times, err)
+			continue
+		}
+		if got, want := s.Next(at(t, test.from)), at(t, test.want); !got.Equal(want) {
+			t.Errorf("At(%q).Next(%s) = %v, want %v", test.times, test.from, got, want)
+		}
+	}
+	for _, times := range [][]string{nil, {"25:00"}, {"10"}, {":00", "10:00"}} {
+		if _, err := At(times...); err == nil {
+			t.Errorf("At(%q) succeeded", times)
+		}
+	}
+}
+
+func TestOnSchedule(t *testing.T) {
+	now := at(t, "2024-03-01 10:05").Add(20 * time.Second)
+	s, _ := At(":00", ":30")
+	b := OnSchedule(s, func() time.Time { return now })
+	for _, attempt := range []int{0, 1, 100} {
+		if got, want := b.NextDelay(attempt), 24*time.Minute+40*time.Second; got != want {
+			t.Errorf("NextDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	never, _ := ParseCron("0 0 30 feb *")
+	if got := OnSchedule(never, func() time.Time { return now }).NextDelay(0); got != maxDuration {
+		t.Errorf("NextDelay of a schedule with no next time = %v, want %v", got, maxDuration)
+	}
+}
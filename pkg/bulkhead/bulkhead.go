@@ -0,0 +1,112 @@
+// Package bulkhead limits how many operations run at the same time,
+// so that a slow dependency ties up only the calls made to it rather
+// than every goroutine of a program.
+//
+// Calls that find a Bulkhead full wait in a queue for at most its
+// queue timeout, and then fail with ErrFull. Like the errors of an
+// open circuitbreaker.Breaker, ErrFull is worth retrying later, so a
+// Bulkhead composes with retry.Do and a Breaker as in
+//
+//	retry.Do(ctx, p, func(ctx context.Context) (T, error) {
+//		return circuitbreaker.Do(ctx, breaker, func(ctx context.Context) (T, error) {
+//			return bulkhead.Do(ctx, b, op)
+//		})
+//	})
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrFull is the error of calls refused by a full Bulkhead.
+var ErrFull = errors.New("bulkhead full")
+
+// A Bulkhead is a semaphore bounding the number of operations running
+// at the same time. Its methods may be called concurrently.
+type Bulkhead struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// New returns a Bulkhead letting at most limit operations run at the
+// same time, where calls wait in a queue for up to queueTimeout for
+// one of the running operations to end. With a queueTimeout of zero,
+// calls fail at once when the Bulkhead is full.
+func New(limit int, queueTimeout time.Duration) *Bulkhead {
+	return &Bulkhead{slots: make(chan struct{}, limit), queueTimeout: queueTimeout}
+}
+
+// Acquire takes a slot of b, waiting for one as configured. It
+// returns an error wrapping ErrFull if it could not, or the error of
+// ctx if ctx was done first. Each successful Acquire must be followed
+// by a Release.
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.queueTimeout <= 0 {
+		return b.fullError()
+	}
+	t := time.NewTimer(b.queueTimeout)
+	defer t.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-t.C:
+		return b.fullError()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release gives back a slot taken by Acquire.
+func (b *Bulkhead) Release() { <-b.slots }
+
+// Running returns the number of operations running in b.
+func (b *Bulkhead) Running() int { return len(b.slots) }
+
+func (b *Bulkhead) fullError() error {
+	if b.queueTimeout > 0 {
+		return fmt.Errorf("%w: %d operations running for over %s", ErrFull, cap(b.slots), b.queueTimeout)
+	}
+	return fmt.Errorf("%w: %d operations running", ErrFull, cap(b.slots))
+}
+
+// Do calls op once it gets a slot of b, which it gives back when op
+// returns. If it gets none, it returns the error of Acquire.
+func Do[T any](ctx context.Context, b *Bulkhead, op func(context.Context) (T, error)) (T, error) {
+	if err := b.Acquire(ctx); err != nil {
+		return *new(T), err
+	}
+	defer b.Release()
+	return op(ctx)
+}
+
+// A Checker checks once whether something is ready, like a
+// wait.Checker.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Checker returns a Checker whose checks of c are limited by b.
+func (b *Bulkhead) Checker(c Checker) Checker {
+	return bulkheadChecker{b, c}
+}
+
+type bulkheadChecker struct {
+	b *Bulkhead
+	c Checker
+}
+
+func (bc bulkheadChecker) Check(ctx context.Context) error {
+	if err := bc.b.Acquire(ctx); err != nil {
+		return err
+	}
+	defer bc.b.Release()
+	return bc.c.Check(ctx)
+}
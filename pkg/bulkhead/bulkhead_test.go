@@ -0,0 +1,48 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkhead(t *testing.T) {
+	b := New(2, 0)
+	ctx := context.Background()
+	for i := range 2 {
+		if err := b.Acquire(ctx); err != nil {
+			t.Fatalf("Acquire %d: %v", i+1, err)
+		}
+	}
+	_, err := Do(ctx, b, func(context.Context) (int, error) { return 0, nil })
+	if !errors.Is(err, ErrFull) {
+		t.Fatalf("Do on a full bulkhead returned %v, want ErrFull", err)
+	}
+	b.Release()
+	if v, err := Do(ctx, b, func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatalf("Do with a free slot returned %d, %v; want 1, nil", v, err)
+	}
+	if n := b.Running(); n != 1 {
+		t.Errorf("%d operations running after Do, want 1", n)
+	}
+}
+
+func TestBulkheadQueue(t *testing.T) {
+	b := New(1, time.Minute)
+	ctx := context.Background()
+	b.Acquire(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Release()
+	}()
+	if err := b.Acquire(ctx); err != nil {
+		t.Errorf("queued Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.Acquire(ctx); err != context.Canceled {
+		t.Errorf("queued Acquire with a canceled context returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,290 @@
+// Package circuitbreaker stops calling a dependency that keeps
+// failing, so that long-running programs don't hammer it while it is
+// down, and tries it again once in a while to notice when it is back.
+//
+// A Breaker is closed while calls succeed, and opens once too many
+// fail in a row. While it is open, calls fail at once with ErrOpen.
+// After ResetTimeout it is half-open: a single trial call is let
+// through, which closes the Breaker if it succeeds and opens it again
+// if it fails.
+//
+// The errors of an open Breaker ask retry.Do to wait until the
+// Breaker lets a call through again, so that operations retried
+// through a Breaker are paced by it rather than by their backoff.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/events"
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// ErrOpen is the error of calls refused by an open Breaker.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// A State is the state of a Breaker.
+type State int
+
+const (
+	Closed   State = iota // calls go through
+	Open                  // calls are refused
+	HalfOpen              // a trial call goes through
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// MarshalText returns the name of s, as String does.
+func (s State) MarshalText() ([]byte, error) {
+	if s < Closed || s > HalfOpen {
+		return nil, fmt.Errorf("unknown state %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText sets s to the state named by text.
+func (s *State) UnmarshalText(text []byte) error {
+	for t := Closed; t <= HalfOpen; t++ {
+		if t.String() == string(text) {
+			*s = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown state %q", text)
+}
+
+// A StateChange is the event of a Breaker changing state, such as
+// opening, as published on its Events.
+type StateChange struct {
+	Breaker  *Breaker
+	From, To State
+	Time     time.Time // by the Clock of the Breaker
+}
+
+// Defaults for the zero fields of a Breaker.
+const (
+	DefaultFailureThreshold = 5
+	DefaultResetTimeout     = 30 * time.Second
+)
+
+// A Breaker guards calls of a dependency. Its fields must not be
+// changed once it is in use; its methods may be called concurrently.
+// The zero Breaker is closed and uses the defaults.
+type Breaker struct {
+	// FailureThreshold is the number of consecutive failed calls
+	// that open the Breaker, or DefaultFailureThreshold if zero.
+	FailureThreshold int
+
+	// ResetTimeout is how long the Breaker stays open before it
+	// lets a trial call through, or DefaultResetTimeout if zero.
+	ResetTimeout time.Duration
+
+	// OnStateChange, if non-nil, is called whenever the Breaker
+	// changes state. It must not call the methods of the Breaker.
+	OnStateChange func(from, to State)
+
+	// Events, if non-nil, is where a StateChange is published
+	// whenever the Breaker changes state, on the same terms.
+	Events *events.Bus
+
+	// Clock, if non-nil, times ResetTimeout instead of clock.Real.
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	state    State
+	failures int       // consecutive, while closed
+	openedAt time.Time // while open
+	trial    bool      // a trial call is going on, while half-open
+}
+
+// State returns the state of b.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+	return b.state
+}
+
+// A Snapshot is the state of a Breaker, which Restore gives another
+// Breaker, such as that of the next run of a program that saved it.
+type Snapshot struct {
+	State    State     `json:"state"`
+	Failures int       `json:"failures,omitempty"` // consecutive, while closed
+	OpenedAt time.Time `json:"opened_at,omitzero"` // while open
+}
+
+// Snapshot returns the state of b.
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+	s := Snapshot{State: b.state, Failures: b.failures}
+	if b.state == Open {
+		s.OpenedAt = b.openedAt
+	}
+	return s
+}
+
+// Restore puts b in the state s, as if the calls that led to it had
+// been made through b. A half-open Breaker is restored open, as of
+// ResetTimeout ago, which lets the next call through as a trial.
+// Restore calls OnStateChange if the state changes.
+func (b *Breaker) Restore(s Snapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.trial = 0, false
+	switch s.State {
+	case Open:
+		b.openedAt = s.OpenedAt
+	case HalfOpen:
+		b.openedAt = b.now().Add(-b.resetTimeout())
+	default:
+		b.failures = s.Failures
+		b.setState(Closed)
+		return
+	}
+	b.setState(Open)
+	b.update()
+}
+
+// Allow reports whether a call may be made now, with nil, or why not,
+// with an error wrapping ErrOpen. If the call is allowed, its result
+// must be passed to Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+	switch {
+	case b.state == Closed:
+		return nil
+	case b.state == HalfOpen && !b.trial:
+		b.trial = true
+		return nil
+	case b.state == HalfOpen:
+		return retry.After(ErrOpen, b.resetTimeout()) // try after the trial
+	}
+	return retry.After(ErrOpen, b.openedAt.Add(b.resetTimeout()).Sub(b.now()))
+}
+
+// Record records the result of a call allowed by Allow. The call
+// failed if err is not nil, unless the call was canceled.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+	failed := err != nil && !errors.Is(err, context.Canceled)
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.failureThreshold() {
+			b.open()
+		}
+	case HalfOpen:
+		b.trial = false
+		if failed {
+			b.open()
+		} else if err == nil {
+			b.failures = 0
+			b.setState(Closed)
+		}
+	}
+}
+
+// Do calls op through b: it returns an error wrapping ErrOpen if b
+// refuses the call, and otherwise the result of op, which b records.
+func Do[T any](ctx context.Context, b *Breaker, op func(context.Context) (T, error)) (T, error) {
+	if err := b.Allow(); err != nil {
+		return *new(T), err
+	}
+	v, err := op(ctx)
+	b.Record(err)
+	return v, err
+}
+
+// A Checker checks once whether something is ready, like a
+// wait.Checker.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Checker returns a Checker whose checks go through b to c.
+func (b *Breaker) Checker(c Checker) Checker {
+	return breakerChecker{b, c}
+}
+
+type breakerChecker struct {
+	b *Breaker
+	c Checker
+}
+
+func (bc breakerChecker) Check(ctx context.Context) error {
+	_, err := Do(ctx, bc.b, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, bc.c.Check(ctx)
+	})
+	return err
+}
+
+// update makes an open Breaker half-open once ResetTimeout has passed.
+func (b *Breaker) update() {
+	if b.state == Open && !b.now().Before(b.openedAt.Add(b.resetTimeout())) {
+		b.trial = false
+		b.setState(HalfOpen)
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(s State) {
+	from := b.state
+	b.state = s
+	if from == s {
+		return
+	}
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, s)
+	}
+	b.Events.Publish(context.Background(), StateChange{b, from, s, b.now()})
+}
+
+func (b *Breaker) failureThreshold() int {
+	if b.FailureThreshold > 0 {
+		return b.FailureThreshold
+	}
+	return DefaultFailureThreshold
+}
+
+func (b *Breaker) resetTimeout() time.Duration {
+	if b.ResetTimeout > 0 {
+		return b.ResetTimeout
+	}
+	return DefaultResetTimeout
+}
+
+func (b *Breaker) now() time.Time {
+	if b.Clock != nil {
+		return b.Clock.Now()
+	}
+	return time.Now()
+}
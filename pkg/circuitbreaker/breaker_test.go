@@ -0,0 +1,152 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/events"
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+func TestBreaker(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	var changes []string
+	b := &Breaker{
+		FailureThreshold: 2,
+		ResetTimeout:     time.Minute,
+		Clock:            clk,
+		OnStateChange:    func(from, to State) { changes = append(changes, fmt.Sprint(from, "->", to)) },
+	}
+	down := errors.New("down")
+	call := func(err error) error {
+		_, e := Do(context.Background(), b, func(context.Context) (int, error) { return 0, err })
+		return e
+	}
+
+	call(down)
+	if b.State() != Closed {
+		t.Fatalf("state after 1 failure = %v, want closed", b.State())
+	}
+	call(down)
+	if b.State() != Open {
+		t.Fatalf("state after 2 failures = %v, want open", b.State())
+	}
+	err := call(nil)
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("call while open returned %v, want ErrOpen", err)
+	}
+	var ra interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &ra) || ra.RetryAfter() != time.Minute {
+		t.Errorf("call while open did not ask to retry after a minute: %v", err)
+	}
+
+	clk.Advance(time.Minute)
+	if b.State() != HalfOpen {
+		t.Fatalf("state after reset timeout = %v, want half-open", b.State())
+	}
+	call(down)
+	if b.State() != Open {
+		t.Fatalf("state after failed trial = %v, want open", b.State())
+	}
+	clk.Advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("trial not allowed: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("second call during trial returned %v, want ErrOpen", err)
+	}
+	b.Record(nil)
+	if b.State() != Closed {
+		t.Fatalf("state after successful trial = %v, want closed", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !slices.Equal(changes, want) {
+		t.Errorf("state changes %v, want %v", changes, want)
+	}
+}
+
+func TestBreakerEvents(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	var bus events.Bus
+	b := &Breaker{FailureThreshold: 1, ResetTimeout: time.Minute, Clock: clk, Events: &bus}
+	var changes []StateChange
+	events.Subscribe(&bus, func(_ context.Context, c StateChange) { changes = append(changes, c) })
+	b.Record(errors.New("down"))
+	clk.Advance(time.Minute)
+	b.State()
+	want := []StateChange{
+		{b, Closed, Open, time.Unix(0, 0)},
+		{b, Open, HalfOpen, time.Unix(60, 0)},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("published %v, want %v", changes, want)
+	}
+}
+
+func TestBreakerPacesRetries(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	b := &Breaker{FailureThreshold: 1, ResetTimeout: 10 * time.Second, Clock: clk}
+	c := b.Checker(checkFunc(func(context.Context) error { return errors.New("down") }))
+	p := retry.Policy{MaxAttempts: 3, Clock: clk}
+	retry.Do(context.Background(), p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.Check(ctx)
+	})
+	want := []time.Duration{time.Second, 9 * time.Second} // backoff, then until half-open
+	if got := clk.Timers(); !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+}
+
+func TestBreakerSnapshot(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	b := &Breaker{FailureThreshold: 3, ResetTimeout: time.Minute, Clock: clk}
+	b.Record(errors.New("down"))
+	b.Record(errors.New("down"))
+	text, err := json.Marshal(b.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(text, &s); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Snapshot{State: Closed, Failures: 2}); s != want {
+		t.Fatalf("snapshot of closed breaker = %+v, want %+v", s, want)
+	}
+
+	restored := &Breaker{FailureThreshold: 3, ResetTimeout: time.Minute, Clock: clk}
+	restored.Restore(s)
+	restored.Record(errors.New("down"))
+	if restored.State() != Open {
+		t.Fatalf("state after the 3rd failure = %v, want open", restored.State())
+	}
+	s = restored.Snapshot()
+	if s.State != Open || !s.OpenedAt.Equal(clk.Now()) {
+		t.Fatalf("snapshot of open breaker = %+v", s)
+	}
+
+	clk.Advance(30 * time.Second)
+	again := &Breaker{ResetTimeout: time.Minute, Clock: clk}
+	again.Restore(s)
+	if err := again.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Allow of restored open breaker = %v, want ErrOpen", err)
+	}
+	clk.Advance(30 * time.Second)
+	if again.State() != HalfOpen {
+		t.Errorf("state after ResetTimeout = %v, want half-open", again.State())
+	}
+	if err := json.Unmarshal([]byte(`{"state":"ajar"}`), &s); err == nil {
+		t.Error("unmarshaling unknown state succeeded")
+	}
+}
+
+type checkFunc func(context.Context) error
+
+func (f checkFunc) Check(ctx context.Context) error { return f(ctx) }
@@ -0,0 +1,156 @@
+// Package clidoc writes the shell completion scripts and manual pages
+// of programs from the definitions of their flags, as made with
+// package flag, and the doc comments of their packages, so that they
+// keep up with the flags that programs grow. Programs run them as
+// subcommands, with Command.Run:
+//
+//	wait completion bash > /etc/bash_completion.d/wait
+//	wait docs > /usr/local/share/man/man1/wait.1
+package clidoc
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A Command describes a program for its completions and manual page.
+type Command struct {
+	// Name is the name of the program.
+	Name string
+
+	// Summary tells what the program does, in a line, such as "wait
+	// for servers to be ready".
+	Summary string
+
+	// Usage are the ways to run the program, without its name, such
+	// as "[flags] url ...".
+	Usage []string
+
+	// Doc describes the program, as the text of a Go doc comment,
+	// such as that given by PackageDoc.
+	Doc string
+
+	// Flags are the flags of the program, such as flag.CommandLine,
+	// once they are all defined.
+	Flags *flag.FlagSet
+
+	// Values are the words that the values of flags are completed
+	// with, by name of flag, such as the names of presets. The
+	// values of other flags are completed with the names of files
+	// if the name of their value, as by flag.UnquoteUsage, is file,
+	// path, or dir, and not at all otherwise.
+	Values map[string][]string
+
+	// Args are the words that the arguments of the program are
+	// completed with, not followed by a space, such as the prefixes
+	// of URLs; the arguments are completed with the names of files
+	// if there are none.
+	Args []string
+}
+
+// Shells are the shells that Command.Run writes completion scripts for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Run runs the subcommand of c given by args, the arguments of the
+// program without its name, if they name one, and reports whether
+// they did: "completion" and the name of a shell writes a completion
+// script for the shell to w, and "docs" writes the manual page of the
+// program, in roff. It returns an error if the subcommand is given
+// wrong arguments.
+func (c *Command) Run(args []string, w io.Writer) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch {
+	case args[0] == "completion" && len(args) == 2:
+		switch args[1] {
+		case "bash":
+			return true, c.Bash(w)
+		case "zsh":
+			return true, c.Zsh(w)
+		case "fish":
+			return true, c.Fish(w)
+		}
+		return true, fmt.Errorf("no completion for shell %q, only for %s", args[1], strings.Join(Shells, ", "))
+	case args[0] == "completion":
+		return true, fmt.Errorf("usage: %s completion %s", c.Name, strings.Join(Shells, "|"))
+	case args[0] == "docs" && len(args) == 1:
+		return true, c.Man(w)
+	case args[0] == "docs":
+		return true, fmt.Errorf("usage: %s docs", c.Name)
+	}
+	return false, nil
+}
+
+// PackageDoc returns the text of the doc comment of the package of the
+// Go source src, such as the main.go of a program embedded in it, or
+// the empty string if it has none.
+func PackageDoc(src []byte) string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil || f.Doc == nil {
+		return ""
+	}
+	return f.Doc.Text()
+}
+
+// A flagInfo describes a flag for completions and manual pages.
+type flagInfo struct {
+	*flag.Flag
+	arg    string   // name of the value, or "" if the flag takes none
+	usage  string   // without the name of the value quoted
+	values []string // to complete the value with, if any
+	files  bool     // whether the value is completed with file names
+}
+
+// fileArgs are the names of the values of flags that are files.
+var fileArgs = map[string]bool{"file": true, "path": true, "dir": true}
+
+// flags returns the flags of c, sorted by name.
+func (c *Command) flags() []flagInfo {
+	var flags []flagInfo
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		arg, usage := flag.UnquoteUsage(f)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			arg = ""
+		} else if arg == "" {
+			arg = "value"
+		}
+		flags = append(flags, flagInfo{
+			Flag:   f,
+			arg:    arg,
+			usage:  usage,
+			values: c.Values[f.Name],
+			files:  fileArgs[arg] && c.Values[f.Name] == nil,
+		})
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// hasDefault reports whether f has a default worth telling, as
+// flag.PrintDefaults does: one other than the zero value of its type.
+func hasDefault(f *flag.Flag) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false // the zero value cannot tell itself
+		}
+	}()
+	typ := reflect.TypeOf(f.Value)
+	var z reflect.Value
+	if typ.Kind() == reflect.Pointer {
+		z = reflect.New(typ.Elem())
+	} else {
+		z = reflect.Zero(typ)
+	}
+	return f.DefValue != z.Interface().(flag.Value).String()
+}
+
+// funcName matches what is not allowed in the names of shell functions.
+var funcName = regexp.MustCompile(`[^A-Za-z0-9_]`)
@@ -0,0 +1,148 @@
+package clidoc
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCommand returns a Command with flags of every kind.
+func testCommand() *Command {
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	fs.Bool("v", false, "log every attempt")
+	fs.Duration("timeout", time.Minute, "give up after this long")
+	fs.String("config", "", "read the targets from the YAML `file`")
+	fs.String("output", "text", "output `format`: text or json")
+	fs.String("user", "", "authenticate as `name:password` [sic]")
+	return &Command{
+		Name:    "probe",
+		Summary: "probe servers",
+		Usage:   []string{"[flags] url ..."},
+		Doc:     "Probe probes servers.\n\n.Dots and back\\slashes are escaped:\n\n\tprobe -v http://localhost\n\nIt probes:\n  - first\n  - second\n",
+		Flags:   fs,
+		Values:  map[string][]string{"output": {"text", "json"}},
+		Args:    []string{"http://", "tcp://"},
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := testCommand()
+	for _, test := range []struct {
+		args    []string
+		handled bool
+		want    string // in the output
+		err     bool
+	}{
+		{nil, false, "", false},
+		{[]string{"-v", "http://localhost"}, false, "", false},
+		{[]string{"docs"}, true, ".TH PROBE 1", false},
+		{[]string{"docs", "man"}, true, "", true},
+		{[]string{"completion", "bash"}, true, "complete -F _probe_completion probe", false},
+		{[]string{"completion", "zsh"}, true, "#compdef probe", false},
+		{[]string{"completion", "fish"}, true, "complete -c probe", false},
+		{[]string{"completion", "tcsh"}, true, "", true},
+		{[]string{"completion"}, true, "", true},
+	} {
+		var b bytes.Buffer
+		handled, err := c.Run(test.args, &b)
+		if handled != test.handled || (err != nil) != test.err || !strings.Contains(b.String(), test.want) {
+			t.Errorf("Run(%q) = %v, %v, writing %q; want %v, error %v, writing %q", test.args, handled, err, b.String(), test.handled, test.err, test.want)
+		}
+	}
+}
+
+// output returns what f writes.
+func output(t *testing.T, f func(w io.Writer) error) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := f(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCompletions(t *testing.T) {
+	c := testCommand()
+	for name, test := range map[string]struct {
+		f    func(io.Writer) error
+		want []string
+	}{
+		"bash": {c.Bash, []string{
+			"-output|--output)\n\t\tCOMPREPLY=($(compgen -W 'text json' -- \"$cur\"))",
+			"-config|--config)\n\t\tcompopt -o filenames",
+			"-timeout|--timeout|-user|--user)\n\t\tCOMPREPLY=()",
+			"compgen -W '-config -output -timeout -user -v'",
+			"compgen -W 'http:// tcp://'",
+		}},
+		"zsh": {c.Zsh, []string{
+			`'*-v[log every attempt]' \`,
+			`'*-config[read the targets from the YAML file]:file:_files' \`,
+			`'*-output[output format\: text or json]:format:(text json)' \`,
+			`'*-user[authenticate as name\:password \[sic\]]:name\:password: ' \`,
+			`'*:argument:{compadd -S '\'''\'' -- http:// tcp://}'`,
+		}},
+		"fish": {c.Fish, []string{
+			"complete -c probe -f -a 'http:// tcp://'",
+			"complete -c probe -o v -d 'log every attempt'",
+			"complete -c probe -o config -r -F -d",
+			"complete -c probe -o output -x -a 'text json' -d",
+			"complete -c probe -o timeout -x -d",
+		}},
+	} {
+		got := output(t, test.f)
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s completion lacks %q:\n%s", name, want, got)
+			}
+		}
+	}
+}
+
+// TestBashSyntax checks the bash completion script with bash, if any.
+func TestBashSyntax(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip(err)
+	}
+	script := filepath.Join(t.TempDir(), "probe.bash")
+	if err := os.WriteFile(script, []byte(output(t, testCommand().Bash)), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(bash, "-n", script).CombinedOutput(); err != nil {
+		t.Errorf("bash -n: %v\n%s", err, out)
+	}
+}
+
+func TestMan(t *testing.T) {
+	got := output(t, testCommand().Man)
+	for _, want := range []string{
+		".TH PROBE 1\n.SH NAME\nprobe \\- probe servers\n",
+		".SH SYNOPSIS\n.B probe\n[flags] url ...\n",
+		".SH DESCRIPTION\n.PP\nProbe probes servers.\n.PP\n\\&.Dots and back\\eslashes are escaped:\n",
+		".nf\nprobe \\-v http://localhost\n.fi\n",
+		".IP \\(bu 4\nfirst\n.IP \\(bu 4\nsecond\n",
+		".TP\n.B \\-timeout \\fIduration\\fR\ngive up after this long (default 1m0s)\n",
+		".TP\n.B \\-v\nlog every attempt\n",
+		".TP\n.B \\-config \\fIfile\\fR\nread the targets from the YAML file\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("manual page lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPackageDoc(t *testing.T) {
+	src := "// The probe program probes.\n//\n// It takes URLs.\npackage main\n\nfunc main() {}\n"
+	if got, want := PackageDoc([]byte(src)), "The probe program probes.\n\nIt takes URLs.\n"; got != want {
+		t.Errorf("PackageDoc = %q, want %q", got, want)
+	}
+	if got := PackageDoc([]byte("package main")); got != "" {
+		t.Errorf("PackageDoc of a package without doc = %q, want none", got)
+	}
+}
@@ -0,0 +1,141 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Bash writes a completion script for bash to w, to be sourced, as
+// from /etc/bash_completion.d. It completes URLs and the like whole
+// when the bash-completion package is installed, and up to their
+// colons otherwise.
+func (c *Command) Bash(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fn := "_" + funcName.ReplaceAllString(c.Name, "_") + "_completion"
+	flags := c.flags()
+	fmt.Fprintf(b, "# bash completion for %s, as written by %q.\n", c.Name, c.Name+" completion bash")
+	fmt.Fprintf(b, "%s() {\n", fn)
+	fmt.Fprintf(b, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	fmt.Fprintf(b, "\tif declare -F _get_comp_words_by_ref >/dev/null; then\n")
+	fmt.Fprintf(b, "\t\t_get_comp_words_by_ref -n =: cur prev\n")
+	fmt.Fprintf(b, "\tfi\n")
+	fmt.Fprintf(b, "\tcase $prev in\n")
+	var files, others []string
+	for _, f := range flags {
+		switch {
+		case f.arg == "":
+		case f.values != nil:
+			fmt.Fprintf(b, "\t%s)\n\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n\t\treturn ;;\n", bashFlag(f.Name), shQuote(strings.Join(f.values, " ")))
+		case f.files:
+			files = append(files, bashFlag(f.Name))
+		default:
+			others = append(others, bashFlag(f.Name))
+		}
+	}
+	if files != nil {
+		fmt.Fprintf(b, "\t%s)\n\t\tcompopt -o filenames\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn ;;\n", strings.Join(files, "|"))
+	}
+	if others != nil {
+		fmt.Fprintf(b, "\t%s)\n\t\tCOMPREPLY=()\n\t\treturn ;;\n", strings.Join(others, "|"))
+	}
+	fmt.Fprintf(b, "\tesac\n")
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "-" + f.Name
+	}
+	fmt.Fprintf(b, "\tif [[ $cur == -* ]]; then\n")
+	fmt.Fprintf(b, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shQuote(strings.Join(names, " ")))
+	if c.Args != nil {
+		fmt.Fprintf(b, "\telse\n")
+		fmt.Fprintf(b, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shQuote(strings.Join(c.Args, " ")))
+		fmt.Fprintf(b, "\t\tcompopt -o nospace\n")
+	} else {
+		fmt.Fprintf(b, "\telse\n")
+		fmt.Fprintf(b, "\t\tcompopt -o filenames\n")
+		fmt.Fprintf(b, "\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	}
+	fmt.Fprintf(b, "\tfi\n")
+	fmt.Fprintf(b, "\tif declare -F __ltrim_colon_completions >/dev/null; then\n")
+	fmt.Fprintf(b, "\t\t__ltrim_colon_completions \"$cur\"\n")
+	fmt.Fprintf(b, "\tfi\n")
+	fmt.Fprintf(b, "}\n")
+	fmt.Fprintf(b, "complete -F %s %s\n", fn, c.Name)
+	return b.Flush()
+}
+
+// bashFlag returns the patterns matching flag name in a case of bash.
+func bashFlag(name string) string {
+	return "-" + name + "|--" + name
+}
+
+// Zsh writes a completion script for zsh to w, to be installed as the
+// file _name, for name the name of the program, in a directory of
+// $fpath.
+func (c *Command) Zsh(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "#compdef %s\n", c.Name)
+	fmt.Fprintf(b, "# zsh completion for %s, as written by %q.\n", c.Name, c.Name+" completion zsh")
+	fmt.Fprintf(b, "_arguments -S \\\n")
+	for _, f := range c.flags() {
+		spec := "*-" + f.Name + "[" + zshEscape(f.usage) + "]"
+		switch {
+		case f.arg == "":
+		case f.values != nil:
+			spec += ":" + zshEscape(f.arg) + ":(" + strings.Join(f.values, " ") + ")"
+		case f.files:
+			spec += ":" + zshEscape(f.arg) + ":_files"
+		default:
+			spec += ":" + zshEscape(f.arg) + ": "
+		}
+		fmt.Fprintf(b, "\t%s \\\n", shQuote(spec))
+	}
+	if c.Args != nil {
+		fmt.Fprintf(b, "\t%s\n", shQuote("*:argument:{compadd -S '' -- "+strings.Join(c.Args, " ")+"}"))
+	} else {
+		fmt.Fprintf(b, "\t%s\n", shQuote("*:file:_files"))
+	}
+	return b.Flush()
+}
+
+// zshEscape escapes s for the specifications of _arguments.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+// Fish writes a completion script for fish to w, to be installed as
+// the file name.fish, for name the name of the program, in
+// ~/.config/fish/completions or another directory of
+// $fish_complete_path.
+func (c *Command) Fish(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# fish completion for %s, as written by %q.\n", c.Name, c.Name+" completion fish")
+	if c.Args != nil {
+		fmt.Fprintf(b, "complete -c %s -f -a %s\n", c.Name, fishQuote(strings.Join(c.Args, " ")))
+	}
+	for _, f := range c.flags() {
+		var opts string
+		switch {
+		case f.arg == "":
+		case f.values != nil:
+			opts = " -x -a " + fishQuote(strings.Join(f.values, " "))
+		case f.files:
+			opts = " -r -F"
+		default:
+			opts = " -x"
+		}
+		fmt.Fprintf(b, "complete -c %s -o %s%s -d %s\n", c.Name, f.Name, opts, fishQuote(f.usage))
+	}
+	return b.Flush()
+}
+
+// shQuote quotes s for POSIX shells.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,110 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"strings"
+)
+
+// Man writes the manual page of c to w, in roff for man(1), of section
+// 1: its name and summary, usage, description, and flags.
+func (c *Command) Man(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, ".TH %s 1\n", roffText(strings.ToUpper(c.Name)))
+	fmt.Fprintf(b, ".SH NAME\n%s \\- %s\n", roffText(c.Name), roffText(c.Summary))
+	if len(c.Usage) > 0 {
+		fmt.Fprintf(b, ".SH SYNOPSIS\n")
+		for i, u := range c.Usage {
+			if i > 0 {
+				fmt.Fprintf(b, ".br\n")
+			}
+			fmt.Fprintf(b, ".B %s\n%s\n", roffText(c.Name), roffLines(u))
+		}
+	}
+	if c.Doc != "" {
+		fmt.Fprintf(b, ".SH DESCRIPTION\n")
+		var p comment.Parser
+		manBlocks(b, p.Parse(c.Doc).Content)
+	}
+	fmt.Fprintf(b, ".SH OPTIONS\n")
+	for _, f := range c.flags() {
+		fmt.Fprintf(b, ".TP\n.B \\-%s", roffText(f.Name))
+		if f.arg != "" {
+			fmt.Fprintf(b, " \\fI%s\\fR", roffText(f.arg))
+		}
+		usage := f.usage
+		if hasDefault(f.Flag) {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(b, "\n%s\n", roffLines(usage))
+	}
+	return b.Flush()
+}
+
+// manBlocks writes the blocks of a doc comment in roff.
+func manBlocks(b *bufio.Writer, blocks []comment.Block) {
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *comment.Heading:
+			fmt.Fprintf(b, ".SS %s\n", roffText(plainText(block.Text)))
+		case *comment.Paragraph:
+			fmt.Fprintf(b, ".PP\n%s\n", roffLines(plainText(block.Text)))
+		case *comment.Code:
+			fmt.Fprintf(b, ".PP\n.RS\n.nf\n%s.fi\n.RE\n", roffLines(block.Text))
+		case *comment.List:
+			for _, item := range block.Items {
+				mark := "\\(bu"
+				if item.Number != "" {
+					mark = item.Number + "."
+				}
+				fmt.Fprintf(b, ".IP %s 4\n", mark)
+				for j, content := range item.Content {
+					if p, ok := content.(*comment.Paragraph); ok {
+						if j > 0 {
+							fmt.Fprintf(b, ".IP \"\" 4\n")
+						}
+						fmt.Fprintf(b, "%s\n", roffLines(plainText(p.Text)))
+					}
+				}
+			}
+		}
+	}
+}
+
+// plainText returns the text of a doc comment without its markup,
+// links as their text.
+func plainText(text []comment.Text) string {
+	var s strings.Builder
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			s.WriteString(string(t))
+		case comment.Italic:
+			s.WriteString(string(t))
+		case *comment.Link:
+			s.WriteString(plainText(t.Text))
+		case *comment.DocLink:
+			s.WriteString(plainText(t.Text))
+		}
+	}
+	return s.String()
+}
+
+// roffText escapes s for roff, within a line.
+func roffText(s string) string {
+	return strings.NewReplacer(`\`, `\e`, `-`, `\-`).Replace(s)
+}
+
+// roffLines escapes s for roff, so that none of its lines is taken to
+// be a request.
+func roffLines(s string) string {
+	lines := strings.Split(roffText(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,41 @@
+// Package clock abstracts the passing of time, so that code that
+// sleeps, such as retry loops, can be tested without waiting.
+package clock
+
+import "time"
+
+// A Clock tells the time and makes timers.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// A Timer delivers the time on its channel once it expires, like a
+// time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, and reports whether that
+	// happened, as for time.Timer.
+	Stop() bool
+}
+
+// Real is the Clock of package time.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+
+func (t realTimer) Stop() bool { return t.t.Stop() }
+
+// Reset makes the timer expire after d, as for time.Timer, so that
+// code sleeping over and over can reuse one timer; code given a Clock
+// can find out whether its timers have it with a type assertion.
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
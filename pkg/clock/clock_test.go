@@ -0,0 +1,25 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealReset(t *testing.T) {
+	timer := Real.NewTimer(time.Hour)
+	r, ok := timer.(interface{ Reset(time.Duration) bool })
+	if !ok {
+		t.Fatal("real timers cannot be reset")
+	}
+	if !r.Reset(time.Millisecond) {
+		t.Error("Reset of a pending timer reported false")
+	}
+	select {
+	case <-timer.C():
+	case <-time.After(10 * time.Second):
+		t.Error("the timer reset did not fire")
+	}
+	if timer.Stop() {
+		t.Error("Stop of a fired timer reported true")
+	}
+}
@@ -0,0 +1,100 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// A Fake is a Clock whose time only passes when told to, for tests.
+// Its methods may be called concurrently.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	instant bool
+	timers  []*fakeTimer // not yet expired, in no particular order
+	slept   []time.Duration
+}
+
+// NewFake returns a Fake set to now, whose time passes only when
+// Advance is called.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// NewInstant returns a Fake set to now that advances to the expiry of
+// each timer as soon as it is made, so that code sleeping with it runs
+// without delay while seeing the time pass.
+func NewInstant(now time.Time) *Fake {
+	return &Fake{now: now, instant: true}
+}
+
+// Now returns the time of f.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer returns a Timer that expires once the time of f has
+// advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.slept = append(f.slept, d)
+	t := &fakeTimer{f: f, when: f.now.Add(d), c: make(chan time.Time, 1)}
+	if f.instant && t.when.After(f.now) {
+		f.now = t.when
+	}
+	if t.when.After(f.now) {
+		f.timers = append(f.timers, t)
+	} else {
+		t.c <- f.now
+	}
+	return t
+}
+
+// Advance moves the time of f forward by d, firing the timers that
+// expire in that time.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if t.when.After(f.now) {
+			pending = append(pending, t)
+		} else {
+			t.c <- f.now
+		}
+	}
+	clear(f.timers[len(pending):])
+	f.timers = pending
+}
+
+// Timers returns the durations of all timers made with f, in order,
+// which for code that sleeps with timers is how long it slept.
+func (f *Fake) Timers() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.slept...)
+}
+
+type fakeTimer struct {
+	f    *Fake
+	when time.Time
+	c    chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	for i, u := range t.f.timers {
+		if u == t {
+			t.f.timers = append(t.f.timers[:i], t.f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
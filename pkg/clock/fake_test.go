@@ -0,0 +1,120 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Unix(0, 0)
+
+// fired reports whether t has fired, and at what time.
+func fired(t Timer) (time.Time, bool) {
+	select {
+	case now := <-t.C():
+		return now, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAdvance(t *testing.T) {
+	f := NewFake(epoch)
+	second, minute := f.NewTimer(time.Second), f.NewTimer(time.Minute)
+	if !f.Now().Equal(epoch) {
+		t.Errorf("made timers, and the time is %v, want %v", f.Now(), epoch)
+	}
+
+	f.Advance(999 * time.Millisecond)
+	if _, ok := fired(second); ok {
+		t.Error("the timer of a second fired before its expiry")
+	}
+	f.Advance(time.Millisecond)
+	if now, ok := fired(second); !ok || !now.Equal(epoch.Add(time.Second)) {
+		t.Errorf("the timer of a second fired %v at %v, want at its expiry", ok, now)
+	}
+	if _, ok := fired(minute); ok {
+		t.Error("the timer of a minute fired after a second")
+	}
+
+	// Advancing past the expiry fires at the time advanced to.
+	f.Advance(time.Hour)
+	if now, ok := fired(minute); !ok || !now.Equal(epoch.Add(time.Hour+time.Second)) {
+		t.Errorf("the timer of a minute fired %v at %v, want after an hour", ok, now)
+	}
+	if _, ok := fired(second); ok {
+		t.Error("the timer of a second fired twice")
+	}
+	if got := f.Timers(); !slices.Equal(got, []time.Duration{time.Second, time.Minute}) {
+		t.Errorf("Timers() = %v", got)
+	}
+}
+
+func TestFakeExpired(t *testing.T) {
+	f := NewFake(epoch)
+	for _, d := range []time.Duration{0, -time.Second} {
+		if now, ok := fired(f.NewTimer(d)); !ok || !now.Equal(epoch) {
+			t.Errorf("the timer of %v fired %v at %v, want at once", d, ok, now)
+		}
+	}
+}
+
+func TestFakeStop(t *testing.T) {
+	f := NewFake(epoch)
+	stopped, kept := f.NewTimer(time.Second), f.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer reported false")
+	}
+	if stopped.Stop() {
+		t.Error("Stop of a stopped timer reported true")
+	}
+	f.Advance(time.Second)
+	if _, ok := fired(stopped); ok {
+		t.Error("a stopped timer fired")
+	}
+	if _, ok := fired(kept); !ok {
+		t.Error("the timer kept did not fire")
+	}
+	if kept.Stop() {
+		t.Error("Stop of a fired timer reported true")
+	}
+}
+
+func TestInstant(t *testing.T) {
+	f := NewInstant(epoch)
+	for i, d := range []time.Duration{time.Second, 2 * time.Second, 0, -time.Second} {
+		timer := f.NewTimer(d)
+		want := epoch.Add(3 * time.Second)
+		if i == 0 {
+			want = epoch.Add(time.Second)
+		}
+		if now, ok := fired(timer); !ok || !now.Equal(want) || !f.Now().Equal(want) {
+			t.Errorf("the timer of %v fired %v at %v, with the time %v, want %v", d, ok, now, f.Now(), want)
+		}
+	}
+	if timer := f.NewTimer(time.Minute); timer.Stop() {
+		t.Error("Stop of an instant timer reported true")
+	}
+	f.Advance(time.Hour)
+	if want := epoch.Add(time.Hour + time.Minute + 3*time.Second); !f.Now().Equal(want) {
+		t.Errorf("advanced to %v, want %v", f.Now(), want)
+	}
+}
+
+func TestFakeConcurrent(t *testing.T) {
+	f := NewFake(epoch)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-f.NewTimer(time.Second).C()
+		}()
+	}
+	for len(f.Timers()) < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	f.Advance(time.Second)
+	wg.Wait()
+}
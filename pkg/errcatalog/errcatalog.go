@@ -0,0 +1,134 @@
+// Package errcatalog renders the messages of errors from templates
+// keyed by their codes, as given by errcode, so that the messages that
+// programs show people can be translated or reworded, as for another
+// brand, without changing the errors that programs match with
+// errors.Is, errors.As, and errcode.Is, or the messages they log.
+//
+// A catalog maps the names of codes to text/template templates, which
+// are given the fields of the errors, as attached by errfields:
+//
+//	wait.timeout: '{{.target}} war nach {{round .elapsed "1s"}} noch nicht bereit'
+//	wait.not_ready: "{{.target}} ist nicht bereit: {{.Error}}"
+//
+// The function round rounds a duration to a multiple of another, given
+// as by time.ParseDuration.
+package errcatalog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"gopkg.in/yaml.v3"
+)
+
+// A Catalog holds the message templates of error codes. The nil
+// Catalog has none. Its methods may be called concurrently.
+type Catalog struct {
+	templates map[string]*template.Template // by code name
+}
+
+// Parse parses a catalog written in YAML or JSON, as a mapping of the
+// names of registered codes to templates. Besides the fields of the
+// errors, by key, templates are given the original message of the
+// error as Error, and the name of its code as Code.
+func Parse(data []byte) (*Catalog, error) {
+	var texts map[string]string
+	if err := yaml.Unmarshal(data, &texts); err != nil {
+		return nil, err
+	}
+	c := &Catalog{templates: make(map[string]*template.Template, len(texts))}
+	for name, text := range texts {
+		if errcode.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown error code %q", name)
+		}
+		t, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		c.templates[name] = t
+	}
+	return c, nil
+}
+
+// funcs are the functions of templates.
+var funcs = template.FuncMap{
+	"round": func(d time.Duration, to string) (time.Duration, error) {
+		m, err := time.ParseDuration(to)
+		return d.Round(m), err
+	},
+}
+
+// Load parses the catalog in the file name, as by Parse.
+func Load(name string) (*Catalog, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
+
+// Has reports whether c has a template for the code named name.
+func (c *Catalog) Has(name string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.templates[name]
+	return ok
+}
+
+// Message returns the message of err rendered by the template of its
+// code: the outermost code attached in its chain above any errors it
+// joins, as by errors.Join, whose messages are rendered each on its
+// own line instead. Errors that have no template, or whose template
+// fails, such as for lack of a field, keep their own messages.
+func (c *Catalog) Message(err error) string {
+	if c == nil || len(c.templates) == 0 {
+		return err.Error()
+	}
+	code, joined := codeOf(err)
+	t := c.templates[code]
+	switch {
+	case t != nil:
+		data := map[string]any{"Error": err.Error(), "Code": code}
+		attrs := errfields.Attrs(err)
+		for i := len(attrs) - 1; i >= 0; i-- { // outermost last, to win
+			data[attrs[i].Key] = attrs[i].Value.Any()
+		}
+		var b strings.Builder
+		if t.Execute(&b, data) == nil {
+			return b.String()
+		}
+	case joined != nil:
+		lines := make([]string, len(joined))
+		for i, err := range joined {
+			lines[i] = c.Message(err)
+		}
+		return strings.Join(lines, "\n")
+	}
+	return err.Error()
+}
+
+// codeOf returns the name of the outermost code in the chain of err,
+// or else the errors that err joins, if any.
+func codeOf(err error) (code string, joined []error) {
+	for err != nil {
+		if c, ok := err.(interface{ ErrorCode() *errcode.Entry }); ok && c.ErrorCode() != nil {
+			return c.ErrorCode().Name, nil
+		}
+		if j, ok := err.(interface{ Unwrap() []error }); ok {
+			return "", j.Unwrap()
+		}
+		err = errors.Unwrap(err)
+	}
+	return "", nil
+}
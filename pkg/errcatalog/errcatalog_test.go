@@ -0,0 +1,50 @@
+package errcatalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+)
+
+var (
+	codeTimeout  = errcode.Register(errcode.Entry{Name: "errcatalog_test.timeout"})
+	codeNotFound = errcode.Register(errcode.Entry{Name: "errcatalog_test.not_found"})
+)
+
+func TestMessage(t *testing.T) {
+	c, err := Parse([]byte(`
+errcatalog_test.timeout: '{{.target}} war nach {{round .elapsed "1s"}} nicht bereit'
+errcatalog_test.not_found: "{{.path}} fehlt ({{.Code}}: {{.Error}})"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout := errcode.With(errfields.With(errors.New("db timed out"), "target", "db", "elapsed", 3200*time.Millisecond), codeTimeout)
+	notFound := errcode.With(errfields.With(errors.New("no such file"), "path", "/etc/app"), codeNotFound)
+	for _, test := range []struct {
+		err  error
+		want string
+	}{
+		{timeout, "db war nach 3s nicht bereit"},
+		{errors.Join(timeout, errors.New("other"), notFound),
+			"db war nach 3s nicht bereit\nother\n/etc/app fehlt (errcatalog_test.not_found: no such file)"},
+		{errcode.With(errors.New("no fields"), codeTimeout), "no fields"}, // the template fails
+		{errors.New("uncoded"), "uncoded"},
+	} {
+		if got := c.Message(test.err); got != test.want {
+			t.Errorf("Message(%q) = %q, want %q", test.err, got, test.want)
+		}
+	}
+	if got := (*Catalog)(nil).Message(timeout); got != timeout.Error() {
+		t.Errorf("nil Catalog Message = %q", got)
+	}
+
+	for _, bad := range []string{"nope.unknown: x", "errcatalog_test.timeout: '{{.target'", "[1, 2]"} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package errclass classifies errors as retryable, when the failed
+// operation may succeed if tried again, or permanent, when it won't.
+//
+// Errors can be marked either way with MarkRetryable and
+// MarkPermanent. Unmarked errors are classified by what they are:
+// timeouts, refused or reset connections, and unreachable networks
+// are retryable; cancellations and unknown host names are permanent.
+// Errors with a Retryable() bool or Temporary() bool method classify
+// themselves. Any other error is taken to be retryable, as most
+// errors are worth retrying while waiting for something to start.
+package errclass
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// MarkRetryable returns an error wrapping err that Retryable reports
+// as retryable.
+func MarkRetryable(err error) error {
+	return &markedError{err, true}
+}
+
+// MarkPermanent returns an error wrapping err that Retryable reports
+// as permanent.
+func MarkPermanent(err error) error {
+	return &markedError{err, false}
+}
+
+type markedError struct {
+	err       error
+	retryable bool
+}
+
+func (e *markedError) Error() string { return e.err.Error() }
+func (e *markedError) Unwrap() error { return e.err }
+
+// Marked reports how err was marked by MarkRetryable or
+// MarkPermanent, if it was, going by the outermost mark in its chain.
+func Marked(err error) (retryable, ok bool) {
+	if me, ok := as[*markedError](err); ok {
+		return me.retryable, true
+	}
+	return false, false
+}
+
+// as finds the first error in the chain of err that is an E, as
+// errors.As does, but without allocating unless an error of the chain
+// has an As method, since the Retryable of every failed attempt of a
+// retried operation calls it several times.
+func as[E any](err error) (E, bool) {
+	for err != nil {
+		if e, ok := err.(E); ok {
+			return e, true
+		}
+		if x, ok := err.(interface{ As(any) bool }); ok {
+			var e E
+			if x.As(&e) {
+				return e, true
+			}
+		}
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range x.Unwrap() {
+				if e, ok := as[E](err); ok {
+					return e, true
+				}
+			}
+			return *new(E), false
+		default:
+			return *new(E), false
+		}
+	}
+	return *new(E), false
+}
+
+// retryableErrnos are the system call errors of transient network
+// failures.
+var retryableErrnos = []syscall.Errno{
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.ETIMEDOUT,
+	syscall.EHOSTUNREACH,
+	syscall.ENETUNREACH,
+	syscall.ENETDOWN,
+	syscall.EPIPE,
+	syscall.EAGAIN,
+}
+
+// Retryable reports whether the operation that failed with err may
+// succeed if tried again. It reports false for a nil err.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if retryable, ok := Marked(err); ok {
+		return retryable
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	for _, errno := range retryableErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	if dnsErr, ok := as[*net.DNSError](err); ok {
+		return !dnsErr.IsNotFound
+	}
+	if netErr, ok := as[net.Error](err); ok && netErr.Timeout() {
+		return true
+	}
+	if r, ok := as[interface{ Retryable() bool }](err); ok {
+		return r.Retryable()
+	}
+	if t, ok := as[interface{ Temporary() bool }](err); ok {
+		return t.Temporary()
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("unknown"), true},
+		{context.Canceled, false},
+		{fmt.Errorf("attempt: %w", context.DeadlineExceeded), true},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{syscall.ETIMEDOUT, true},
+		{&net.DNSError{Err: "no such host", Name: "db", IsNotFound: true}, false},
+		{&net.DNSError{Err: "timeout", Name: "db", IsTimeout: true}, true},
+		{MarkPermanent(syscall.ECONNREFUSED), false},
+		{MarkRetryable(context.Canceled), true},
+		{fmt.Errorf("outer: %w", MarkPermanent(MarkRetryable(errors.New("inner")))), false},
+		{errors.Join(errors.New("first"), &net.DNSError{Err: "no such host", Name: "db", IsNotFound: true}), false},
+	} {
+		if got := Retryable(test.err); got != test.want {
+			t.Errorf("Retryable(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestRetryableAllocs(t *testing.T) {
+	err := fmt.Errorf("attempt: %w", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+	if n := testing.AllocsPerRun(100, func() { Retryable(err) }); n != 0 {
+		t.Errorf("Retryable allocates %v times, want 0", n)
+	}
+}
@@ -0,0 +1,249 @@
+// Package errcode gives errors stable codes, so that programs can map
+// them to exit statuses, HTTP statuses, and alerting rules in one
+// place rather than by matching error messages.
+//
+// Codes are registered once, typically in package variables:
+//
+//	var ErrNotReady = errcode.Register(errcode.Entry{
+//		Name: "wait.not_ready", Number: 1001,
+//		Category: errcode.Unavailable, Severity: errcode.Error,
+//		HTTPStatus: 503, ExitCode: 1,
+//	})
+//
+// and attached to errors with With. Code returns the code of an
+// error, wherever it was attached in its chain.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A Category groups codes by the kind of failure they describe.
+type Category string
+
+// Categories for common kinds of failures.
+const (
+	Invalid     Category = "invalid"     // bad input or configuration
+	NotFound    Category = "not_found"   // something does not exist
+	Denied      Category = "denied"      // not allowed
+	Unavailable Category = "unavailable" // a dependency is down
+	Timeout     Category = "timeout"     // something took too long
+	Canceled    Category = "canceled"    // the operation was given up
+	Internal    Category = "internal"    // a bug
+)
+
+// A Severity says how much attention an error deserves. Severities
+// compare in order, from Debug, for errors only worth looking at when
+// debugging, to Critical, for those worth waking someone up for.
+type Severity int
+
+const (
+	Debug Severity = iota - 1
+	Info
+	Warning
+	Error
+	Critical
+)
+
+var severityNames = []string{"debug", "info", "warning", "error", "critical"}
+
+func (s Severity) String() string {
+	if s < Debug || int(s-Debug) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s-Debug]
+}
+
+// MarshalText returns the name of s: debug, info, warning, error, or
+// critical.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText sets s to the Severity named by text.
+func (s *Severity) UnmarshalText(text []byte) error {
+	i := slices.Index(severityNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown severity %q", text)
+	}
+	*s = Debug + Severity(i)
+	return nil
+}
+
+// An Entry describes an error code.
+type Entry struct {
+	Name     string // stable and unique, such as "wait.timeout"
+	Number   int    // stable and unique, if not zero
+	Category Category
+	Severity Severity
+
+	// HTTPStatus and ExitCode are the status of an HTTP response
+	// and the exit status of a program that fail with the error,
+	// if not zero.
+	HTTPStatus int
+	ExitCode   int
+}
+
+func (e *Entry) String() string { return e.Name }
+
+var registry struct {
+	sync.Mutex
+	byName   map[string]*Entry
+	byNumber map[int]*Entry
+}
+
+// Register adds the code described by e to the registry, and returns
+// it. It panics if the name is empty or the name or number are taken,
+// like expvar.Publish.
+func Register(e Entry) *Entry {
+	registry.Lock()
+	defer registry.Unlock()
+	if e.Name == "" {
+		panic("errcode: Register with empty name")
+	}
+	if registry.byName == nil {
+		registry.byName = make(map[string]*Entry)
+		registry.byNumber = make(map[int]*Entry)
+	}
+	if _, ok := registry.byName[e.Name]; ok {
+		panic("errcode: Register of duplicate name " + e.Name)
+	}
+	if _, ok := registry.byNumber[e.Number]; ok && e.Number != 0 {
+		panic(fmt.Sprintf("errcode: Register of duplicate number %d", e.Number))
+	}
+	p := &e
+	registry.byName[e.Name] = p
+	if e.Number != 0 {
+		registry.byNumber[e.Number] = p
+	}
+	return p
+}
+
+// Lookup returns the registered code named name, or nil.
+func Lookup(name string) *Entry {
+	registry.Lock()
+	defer registry.Unlock()
+	return registry.byName[name]
+}
+
+// LookupNumber returns the registered code numbered n, or nil.
+func LookupNumber(n int) *Entry {
+	registry.Lock()
+	defer registry.Unlock()
+	return registry.byNumber[n]
+}
+
+// Codes returns the registered codes, ordered by name.
+func Codes() []*Entry {
+	registry.Lock()
+	defer registry.Unlock()
+	codes := make([]*Entry, 0, len(registry.byName))
+	for _, e := range registry.byName {
+		codes = append(codes, e)
+	}
+	slices.SortFunc(codes, func(a, b *Entry) int { return strings.Compare(a.Name, b.Name) })
+	return codes
+}
+
+// With returns an error wrapping err, with the same message, and the
+// code c. It returns nil if err is nil.
+func With(err error, c *Entry) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{err, c}
+}
+
+type codedError struct {
+	err  error
+	code *Entry
+}
+
+func (e *codedError) Error() string     { return e.err.Error() }
+func (e *codedError) Unwrap() error     { return e.err }
+func (e *codedError) ErrorCode() *Entry { return e.code }
+
+// Code returns the code of err: that attached by With, or returned by
+// an ErrorCode() *Entry method, of the outermost error in its chain
+// that has one. It returns nil if there is none.
+func Code(err error) *Entry {
+	var c interface{ ErrorCode() *Entry }
+	if errors.As(err, &c) {
+		return c.ErrorCode()
+	}
+	return nil
+}
+
+// Is reports whether the code of err is c.
+func Is(err error, c *Entry) bool {
+	return Code(err) == c
+}
+
+// WithSeverity returns an error wrapping err, with the same message,
+// and the severity s, which overrides that of its code. It returns nil
+// if err is nil.
+func WithSeverity(err error, s Severity) error {
+	if err == nil {
+		return nil
+	}
+	return &severityError{err, s}
+}
+
+type severityError struct {
+	err      error
+	severity Severity
+}
+
+func (e *severityError) Error() string           { return e.err.Error() }
+func (e *severityError) Unwrap() error           { return e.err }
+func (e *severityError) ErrorSeverity() Severity { return e.severity }
+
+// SeverityOf returns the severity of err: that attached by
+// WithSeverity, or returned by an ErrorSeverity() Severity method, of
+// the outermost error in its chain that has one, or else that of its
+// code, or else Error. It returns Info if err is nil.
+func SeverityOf(err error) Severity {
+	if err == nil {
+		return Info
+	}
+	var s interface{ ErrorSeverity() Severity }
+	if errors.As(err, &s) {
+		return s.ErrorSeverity()
+	}
+	if c := Code(err); c != nil {
+		return c.Severity
+	}
+	return Error
+}
+
+// CategoryOf returns the category of err: that of its code, or else,
+// for the errors of the standard library that have one, such as
+// context.DeadlineExceeded or fs.ErrNotExist, that which they fall
+// in. It returns "" if err has none.
+func CategoryOf(err error) Category {
+	if c := Code(err); c != nil {
+		return c.Category
+	}
+	var timeout interface{ Timeout() bool }
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return Timeout
+	case errors.Is(err, fs.ErrNotExist):
+		return NotFound
+	case errors.Is(err, fs.ErrPermission):
+		return Denied
+	case errors.Is(err, fs.ErrInvalid):
+		return Invalid
+	}
+	return ""
+}
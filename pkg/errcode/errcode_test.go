@@ -0,0 +1,56 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+var testNotReady = Register(Entry{
+	Name: "test.not_ready", Number: 9001,
+	Category: Unavailable, Severity: Error,
+	HTTPStatus: 503, ExitCode: 1,
+})
+
+func TestCode(t *testing.T) {
+	err := fmt.Errorf("db not ready: %w", With(errors.New("connection refused"), testNotReady))
+	if c := Code(err); c != testNotReady {
+		t.Fatalf("Code(%v) = %v, want %v", err, c, testNotReady)
+	}
+	if !Is(err, testNotReady) || Code(errors.New("plain")) != nil {
+		t.Error("Is or Code misclassified an error")
+	}
+	if Lookup("test.not_ready") != testNotReady || LookupNumber(9001) != testNotReady {
+		t.Error("Lookup did not find the registered code")
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register of a duplicate number did not panic")
+		}
+	}()
+	Register(Entry{Name: "test.other", Number: 9001})
+}
+
+func TestSeverity(t *testing.T) {
+	err := fmt.Errorf("db: %w", With(errors.New("connection refused"), testNotReady))
+	if s := SeverityOf(err); s != Error {
+		t.Errorf("SeverityOf coded error = %v, want %v", s, Error)
+	}
+	if s := SeverityOf(WithSeverity(err, Critical)); s != Critical {
+		t.Errorf("SeverityOf(WithSeverity(err, Critical)) = %v", s)
+	}
+	if c := CategoryOf(fmt.Errorf("open: %w", fs.ErrPermission)); c != Denied {
+		t.Errorf("CategoryOf(fs.ErrPermission) = %q, want %q", c, Denied)
+	}
+	var s Severity
+	if err := s.UnmarshalText([]byte("debug")); err != nil || s != Debug || s.String() != "debug" {
+		t.Errorf("UnmarshalText(debug) = %v, %v", s, err)
+	}
+	if Debug >= Info || Error >= Critical {
+		t.Error("severities are out of order")
+	}
+}
@@ -0,0 +1,130 @@
+// Package errexplain describes how an error is made up, layer by
+// layer, as a debugging aid for errors wrapped many times over: the
+// type and message of each error in its tree, and what the error
+// tells errors.Is, errors.As, and retry logic about itself.
+package errexplain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sentinels are the well-known errors checked against the Is methods
+// of errors.
+var sentinels = []struct {
+	name string
+	err  error
+}{
+	{"context.Canceled", context.Canceled},
+	{"context.DeadlineExceeded", context.DeadlineExceeded},
+	{"os.ErrDeadlineExceeded", os.ErrDeadlineExceeded},
+	{"fs.ErrNotExist", fs.ErrNotExist},
+	{"fs.ErrExist", fs.ErrExist},
+	{"fs.ErrPermission", fs.ErrPermission},
+	{"io.EOF", io.EOF},
+	{"io.ErrUnexpectedEOF", io.ErrUnexpectedEOF},
+	{"net.ErrClosed", net.ErrClosed},
+}
+
+// Explain returns the description of err written by Fprint.
+func Explain(err error) string {
+	var b strings.Builder
+	Fprint(&b, err)
+	return b.String()
+}
+
+// Fprint writes a description of each error in the tree of err to w,
+// one per line, indented by depth, as in
+//
+//	*fmt.wrapError: db not ready: dial tcp 127.0.0.1:1: connect: connection refused
+//	  *net.OpError: dial tcp 127.0.0.1:1: connect: connection refused [Timeout()=false Temporary()=false]
+//	    *os.SyscallError: connect: connection refused [Timeout()=false]
+//	      syscall.Errno(111): connection refused [Timeout()=false Temporary()=false Is(...)]
+//
+// where Is(...) lists the well-known errors, such as
+// context.DeadlineExceeded, that the Is method of the error matches.
+func Fprint(w io.Writer, err error) {
+	explain(w, err, 0)
+}
+
+func explain(w io.Writer, err error, depth int) {
+	if err == nil {
+		fmt.Fprintf(w, "%s<nil>\n", indent(depth))
+		return
+	}
+	name := fmt.Sprintf("%T", err)
+	if errno, ok := err.(syscall.Errno); ok {
+		name = fmt.Sprintf("syscall.Errno(%d)", uintptr(errno))
+	}
+	fmt.Fprintf(w, "%s%s: %s", indent(depth), name, firstLine(err.Error()))
+	if traits := traits(err); len(traits) > 0 {
+		fmt.Fprintf(w, " [%s]", strings.Join(traits, " "))
+	}
+	fmt.Fprintln(w)
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, err := range u.Unwrap() {
+			explain(w, err, depth+1)
+		}
+	case interface{ Unwrap() error }:
+		if inner := u.Unwrap(); inner != nil {
+			explain(w, inner, depth+1)
+		}
+	}
+}
+
+// traits describes the methods of err besides Error and Unwrap.
+func traits(err error) []string {
+	var ts []string
+	if e, ok := err.(interface{ Timeout() bool }); ok {
+		ts = append(ts, fmt.Sprintf("Timeout()=%v", e.Timeout()))
+	}
+	if e, ok := err.(interface{ Temporary() bool }); ok {
+		ts = append(ts, fmt.Sprintf("Temporary()=%v", e.Temporary()))
+	}
+	if e, ok := err.(interface{ Retryable() bool }); ok {
+		ts = append(ts, fmt.Sprintf("Retryable()=%v", e.Retryable()))
+	}
+	if e, ok := err.(interface{ RetryAfter() time.Duration }); ok {
+		ts = append(ts, fmt.Sprintf("RetryAfter()=%v", e.RetryAfter()))
+	}
+	if e, ok := err.(interface{ Is(error) bool }); ok {
+		var is []string
+		for _, s := range sentinels {
+			if s.err != err && e.Is(s.err) {
+				is = append(is, s.name)
+			}
+		}
+		if len(is) == 0 {
+			ts = append(ts, "Is(...)")
+		} else {
+			ts = append(ts, "Is("+strings.Join(is, ", ")+")")
+		}
+	}
+	if _, ok := err.(interface{ As(any) bool }); ok {
+		ts = append(ts, "As(...)")
+	}
+	for _, s := range sentinels {
+		if err == s.err {
+			ts = append(ts, "= "+s.name)
+		}
+	}
+	return ts
+}
+
+func indent(depth int) string { return strings.Repeat("  ", depth) }
+
+// firstLine returns the first line of msg, marking that there are more.
+func firstLine(msg string) string {
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		return msg[:i] + " ..."
+	}
+	return msg
+}
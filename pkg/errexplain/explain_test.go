@@ -0,0 +1,19 @@
+package errexplain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	err := fmt.Errorf("db not ready: %w", errors.Join(context.DeadlineExceeded, errors.New("refused")))
+	want := "*fmt.wrapError: db not ready: context deadline exceeded ...\n" +
+		"  *errors.joinError: context deadline exceeded ...\n" +
+		"    context.deadlineExceededError: context deadline exceeded [Timeout()=true Temporary()=true = context.DeadlineExceeded]\n" +
+		"    *errors.errorString: refused\n"
+	if got := Explain(err); got != want {
+		t.Errorf("Explain(%v) =\n%s\nwant\n%s", err, got, want)
+	}
+}
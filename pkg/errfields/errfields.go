@@ -0,0 +1,84 @@
+// Package errfields attaches key/value fields to errors, such as the
+// URL and the attempt that failed, so that they can be logged and
+// inspected as data rather than picked out of error messages.
+//
+// An error made by With logs with log/slog as a group of its message
+// and the fields of its whole chain:
+//
+//	err := errfields.With(err, "url", u, "attempt", n)
+//	slog.Error("giving up", "err", err)
+//	// level=ERROR msg="giving up" err.msg="..." err.url=... err.attempt=3
+package errfields
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// With returns an error wrapping err, with the same message, and the
+// fields given by args, which are key/value pairs or slog.Attrs as
+// for slog.Logger.Info. It returns nil if err is nil.
+func With(err error, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	e := &fieldsError{err: err}
+	r.Attrs(func(a slog.Attr) bool {
+		e.attrs = append(e.attrs, a)
+		return true
+	})
+	return e
+}
+
+type fieldsError struct {
+	err   error
+	attrs []slog.Attr
+}
+
+func (e *fieldsError) Error() string { return e.err.Error() }
+
+func (e *fieldsError) Unwrap() error { return e.err }
+
+// LogValue returns a group of the message of e, as msg, and of the
+// fields in its chain.
+func (e *fieldsError) LogValue() slog.Value {
+	return slog.GroupValue(append([]slog.Attr{slog.String("msg", e.Error())}, Attrs(e)...)...)
+}
+
+// Attrs returns the fields attached by With anywhere in the chain of
+// err, including the errors joined by errors.Join, outermost first.
+// A key attached more than once appears more than once.
+func Attrs(err error) []slog.Attr {
+	var attrs []slog.Attr
+	var walk func(err error)
+	walk = func(err error) {
+		for err != nil {
+			if e, ok := err.(*fieldsError); ok {
+				attrs = append(attrs, e.attrs...)
+			}
+			if u, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, err := range u.Unwrap() {
+					walk(err)
+				}
+				return
+			}
+			err = errors.Unwrap(err)
+		}
+	}
+	walk(err)
+	return attrs
+}
+
+// Get returns the value of the outermost field with key in the chain
+// of err, if there is one.
+func Get(err error, key string) (any, bool) {
+	for _, a := range Attrs(err) {
+		if a.Key == key {
+			return a.Value.Any(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,38 @@
+package errfields
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	inner := With(fs.ErrNotExist, "path", "/etc/wait.yaml")
+	err := With(fmt.Errorf("loading config: %w", inner), "attempt", 3)
+	if got, want := err.Error(), "loading config: file does not exist"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is does not find fs.ErrNotExist")
+	}
+	if v, ok := Get(err, "path"); !ok || v != "/etc/wait.yaml" {
+		t.Errorf("Get(path) = %v, %v; want /etc/wait.yaml, true", v, ok)
+	}
+	if v, ok := Get(errors.Join(errors.New("other"), err), "attempt"); !ok || v != int64(3) {
+		t.Errorf("Get(attempt) of joined errors = %v, %v; want 3, true", v, ok)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("giving up", "err", err)
+	want := `err.msg="loading config: file does not exist" err.attempt=3 err.path=/etc/wait.yaml`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("logged %q, want it to contain %q", buf.String(), want)
+	}
+	if With(nil, "k", "v") != nil {
+		t.Error("With(nil) did not return nil")
+	}
+}
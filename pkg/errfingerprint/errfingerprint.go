@@ -0,0 +1,187 @@
+// Package errfingerprint tells errors that fail the same way apart
+// from those that fail differently, by fingerprints that ignore the
+// numbers and IDs of their messages, so that the failures of many
+// attempts or files can be counted and alerted on as a few groups:
+//
+//	var g errfingerprint.Grouper
+//	events.Subscribe(bus, func(_ context.Context, e wait.Retrying) { g.Add(e.Err) })
+//	...
+//	g.Report(os.Stderr)
+//
+// The fingerprint of "dial tcp 10.0.0.7:5432: connect: connection
+// refused" is that of "dial tcp 10.0.0.9:5433: connect: connection
+// refused", but not that of "dial tcp 10.0.0.7:5432: i/o timeout".
+package errfingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// Fingerprint returns a fingerprint of err, in 16 hexadecimal digits,
+// that is the same for errors of the same types, in the same tree of
+// wrapped errors, whose messages are the same once normalized by
+// Normalize. It returns the empty string if err is nil.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := sha256.New()
+	io.WriteString(h, Normalize(err.Error()))
+	var walk func(err error, depth int)
+	walk = func(err error, depth int) {
+		fmt.Fprintf(h, "\x00%d %s", depth, reflect.TypeOf(err))
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if err := u.Unwrap(); err != nil {
+				walk(err, depth+1)
+			}
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				if err != nil {
+					walk(err, depth+1)
+				}
+			}
+		}
+	}
+	walk(err, 0)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// The patterns of what Normalize replaces, in order.
+var (
+	uuidPattern   = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	hexPattern    = regexp.MustCompile(`\b(0x[0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`)
+	numberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// Normalize returns msg with its UUIDs and hexadecimal IDs, of 8
+// digits or more or prefixed by 0x, replaced by "<id>", and its other
+// numbers, such as those of addresses, ports, attempts, and
+// durations, by "<n>", as in "dial tcp <n>.<n>.<n>.<n>:<n>: i/o
+// timeout".
+func Normalize(msg string) string {
+	msg = uuidPattern.ReplaceAllString(msg, "<id>")
+	msg = hexPattern.ReplaceAllStringFunc(msg, func(s string) string {
+		if !strings.HasPrefix(s, "0x") && !strings.ContainsAny(s, "0123456789") {
+			return s // a word, such as "deadbeef"
+		}
+		return "<id>"
+	})
+	return numberPattern.ReplaceAllString(msg, "<n>")
+}
+
+// A Group is of the errors of the same fingerprint given to a
+// Grouper.
+type Group struct {
+	Fingerprint string
+	Message     string    // the normalized message of the errors
+	Err         error     // the first of the errors
+	Last        error     // the last of them
+	Count       int       // how many there were
+	First, Seen time.Time // when the first and the last were added
+}
+
+// A Grouper groups the errors added to it by their fingerprints, and
+// reports how many there were of each group. The zero Grouper is
+// ready to use, and its methods may be called concurrently.
+type Grouper struct {
+	// Threshold, if positive, is the number of errors of a group at
+	// which OnThreshold is called with the group, once, as to alert
+	// on a failure that keeps happening.
+	Threshold   int
+	OnThreshold func(Group)
+
+	// Clock, if non-nil, tells the time instead of clock.Real.
+	Clock clock.Clock
+
+	mu     sync.Mutex
+	groups map[string]*Group
+}
+
+// Add adds err to the group of its fingerprint, and returns the
+// fingerprint. It does nothing with a nil err.
+func (g *Grouper) Add(err error) string {
+	if err == nil {
+		return ""
+	}
+	fp := Fingerprint(err)
+	clk := g.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	now := clk.Now()
+	g.mu.Lock()
+	if g.groups == nil {
+		g.groups = make(map[string]*Group)
+	}
+	gr := g.groups[fp]
+	if gr == nil {
+		gr = &Group{Fingerprint: fp, Message: Normalize(err.Error()), Err: err, First: now}
+		g.groups[fp] = gr
+	}
+	gr.Last, gr.Seen = err, now
+	gr.Count++
+	var alert *Group
+	if gr.Count == g.Threshold && g.OnThreshold != nil {
+		gr := *gr
+		alert = &gr
+	}
+	g.mu.Unlock()
+	if alert != nil {
+		g.OnThreshold(*alert)
+	}
+	return fp
+}
+
+// Groups returns the groups of the errors added to g, the largest
+// first, and those of the same size in the order they began.
+func (g *Grouper) Groups() []Group {
+	g.mu.Lock()
+	groups := make([]Group, 0, len(g.groups))
+	for _, gr := range g.groups {
+		groups = append(groups, *gr)
+	}
+	g.mu.Unlock()
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if !groups[i].First.Equal(groups[j].First) {
+			return groups[i].First.Before(groups[j].First)
+		}
+		return groups[i].Fingerprint < groups[j].Fingerprint
+	})
+	return groups
+}
+
+// Reset forgets the errors added to g.
+func (g *Grouper) Reset() {
+	g.mu.Lock()
+	g.groups = nil
+	g.mu.Unlock()
+}
+
+// Report writes a table of the groups of g to w, in the order of
+// Groups, with a row for each giving how many errors it has, its
+// fingerprint, and the first line of its last error.
+func (g *Grouper) Report(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tFINGERPRINT\tERROR")
+	for _, gr := range g.Groups() {
+		msg, _, _ := strings.Cut(gr.Last.Error(), "\n")
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", gr.Count, gr.Fingerprint, msg)
+	}
+	return tw.Flush()
+}
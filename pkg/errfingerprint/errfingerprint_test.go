@@ -0,0 +1,93 @@
+package errfingerprint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestNormalize(t *testing.T) {
+	for msg, want := range map[string]string{
+		"dial tcp 10.0.0.7:5432: connect: connection refused": "dial tcp <n>.<n>.<n>.<n>:<n>: connect: connection refused",
+		"request 3f2a9c1b-0d4e-4c5f-9a8b-7e6d5c4b3a21 failed": "request <id> failed",
+		"object 9f86d081884c7d65 not found at 0x1f":           "object <id> not found at <id>",
+		"giving up after 3 attempts in 1.5s":                  "giving up after <n> attempts in <n>.<n>s",
+		"bad deadbeef":                                        "bad deadbeef",
+	} {
+		if got := Normalize(msg); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	refused := func(addr string) error {
+		return fmt.Errorf("attempt 2: %w", &fs.PathError{Op: "dial", Path: addr, Err: errors.New("connection refused")})
+	}
+	same := [][2]error{
+		{refused("10.0.0.7:5432"), refused("10.0.0.9:5433")},
+		{errors.Join(refused("a1"), errors.New("b")), errors.Join(refused("a2"), errors.New("b"))},
+	}
+	for _, errs := range same {
+		if a, b := Fingerprint(errs[0]), Fingerprint(errs[1]); a != b || len(a) != 16 {
+			t.Errorf("fingerprints %q of %v and %q of %v, want the same", a, errs[0], b, errs[1])
+		}
+	}
+	different := [][2]error{
+		{refused("10.0.0.7:5432"), fmt.Errorf("attempt 2: %w", &fs.PathError{Op: "dial", Path: "10.0.0.7:5432", Err: errors.New("i/o timeout")})},
+		// The same message, of different types.
+		{refused("10.0.0.7:5432"), errors.New("attempt 2: dial 10.0.0.7:5432: connection refused")},
+	}
+	for _, errs := range different {
+		if a, b := Fingerprint(errs[0]), Fingerprint(errs[1]); a == b {
+			t.Errorf("fingerprint %q of both %v and %v, want different ones", a, errs[0], errs[1])
+		}
+	}
+	if fp := Fingerprint(nil); fp != "" {
+		t.Errorf("Fingerprint(nil) = %q, want none", fp)
+	}
+}
+
+func TestGrouper(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	var alerts []Group
+	g := Grouper{Threshold: 3, OnThreshold: func(gr Group) { alerts = append(alerts, gr) }, Clock: clk}
+	for i := range 4 {
+		g.Add(fmt.Errorf("attempt %d: connection refused", i+1))
+		clk.Advance(time.Second)
+	}
+	g.Add(errors.New("no such host"))
+	g.Add(nil)
+
+	groups := g.Groups()
+	if len(groups) != 2 || groups[0].Count != 4 || groups[1].Count != 1 {
+		t.Fatalf("groups %+v, want one of 4 errors and one of 1", groups)
+	}
+	if gr := groups[0]; gr.Message != "attempt <n>: connection refused" || gr.Err.Error() != "attempt 1: connection refused" ||
+		gr.Last.Error() != "attempt 4: connection refused" || !gr.First.Equal(time.Unix(0, 0)) || !gr.Seen.Equal(time.Unix(3, 0)) {
+		t.Errorf("group %+v, want of attempts 1 to 4, from 0s to 3s", gr)
+	}
+	if len(alerts) != 1 || alerts[0].Count != 3 {
+		t.Errorf("alerted on %+v, want once, at 3 errors", alerts)
+	}
+
+	var b strings.Builder
+	if err := g.Report(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("COUNT  FINGERPRINT       ERROR\n4      %s  attempt 4: connection refused\n1      %s  no such host\n",
+		groups[0].Fingerprint, groups[1].Fingerprint)
+	if b.String() != want {
+		t.Errorf("report:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	g.Reset()
+	if groups := g.Groups(); len(groups) != 0 {
+		t.Errorf("groups %+v after Reset, want none", groups)
+	}
+}
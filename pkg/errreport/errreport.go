@@ -0,0 +1,198 @@
+// Package errreport renders the errors, summaries, and outcomes of
+// command-line programs, and maps their errors to exit statuses, so
+// that the programs of this module all report in the same way:
+//
+//   - errors, and other messages, go to standard error as lines
+//     prefixed with the name of the program, such as
+//     "wait: db not ready: ...", in every format;
+//   - summaries meant for people go to standard error too, in the text
+//     format only;
+//   - results, describing outcomes, go to standard output as JSON
+//     objects, one per line, in the json and ndjson formats;
+//   - events, describing progress as it is made, go to standard
+//     output as JSON objects, one per line, in the ndjson format only.
+package errreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-monk/error-handling/pkg/errcatalog"
+	"github.com/go-monk/error-handling/pkg/errcode"
+)
+
+// A Format is how a Reporter writes its output.
+type Format int
+
+const (
+	// Text writes errors and summaries for people, and no results
+	// or events.
+	Text Format = iota
+
+	// JSON writes results as JSON objects, one per line.
+	JSON
+
+	// NDJSON writes events as JSON objects, one per line, as they
+	// happen, and results as JSON does.
+	NDJSON
+)
+
+var formatNames = []string{"text", "json", "ndjson"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// MarshalText returns the name of f: text, json, or ndjson.
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText sets f to the Format named by text.
+func (f *Format) UnmarshalText(text []byte) error {
+	i := slices.Index(formatNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown output format %q", text)
+	}
+	*f = Format(i)
+	return nil
+}
+
+// A Reporter writes the output of a program in a Format. Its methods
+// may be called concurrently.
+type Reporter struct {
+	program string
+	format  Format
+	catalog *errcatalog.Catalog
+
+	mu     sync.Mutex
+	stdout *json.Encoder
+	stderr io.Writer
+	err    error // the first error writing to stdout
+}
+
+// New returns a Reporter for the program named program, writing
+// results and events to stdout, and errors and summaries to stderr.
+func New(program string, format Format, stdout, stderr io.Writer) *Reporter {
+	return &Reporter{program: program, format: format, stdout: json.NewEncoder(stdout), stderr: stderr}
+}
+
+// Format returns the format of r.
+func (r *Reporter) Format() Format { return r.format }
+
+// SetCatalog makes Error write the messages of errors rendered by the
+// templates of c, as by c.Message, such as to translate them. It is
+// to be called before the other methods of r.
+func (r *Reporter) SetCatalog(c *errcatalog.Catalog) { r.catalog = c }
+
+// Printf writes a message, formatted as by fmt.Sprintf, to standard
+// error, after the name of the program.
+func (r *Reporter) Printf(format string, args ...any) {
+	r.println(fmt.Sprintf(format, args...))
+}
+
+// Error writes err to standard error, after the name of the program,
+// or with the catalog set by SetCatalog, its message from the catalog,
+// each line after the name of the program.
+func (r *Reporter) Error(err error) {
+	if r.catalog == nil {
+		r.println(err.Error())
+		return
+	}
+	for line := range strings.SplitSeq(strings.TrimSuffix(r.catalog.Message(err), "\n"), "\n") {
+		r.println(line)
+	}
+}
+
+// Summaryf writes a summary, formatted as by fmt.Sprintf, to standard
+// error, after the name of the program, in the Text format only.
+func (r *Reporter) Summaryf(format string, args ...any) {
+	if r.format == Text {
+		r.println(fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *Reporter) println(msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprintf(r.stderr, "%s: %s\n", r.program, strings.TrimSuffix(msg, "\n"))
+}
+
+// Result writes v, describing an outcome, to standard output as a
+// JSON object, in the JSON and NDJSON formats.
+func (r *Reporter) Result(v any) {
+	if r.format != Text {
+		r.encode(v)
+	}
+}
+
+// Event writes v, describing progress, to standard output as a JSON
+// object, in the NDJSON format only.
+func (r *Reporter) Event(v any) {
+	if r.format == NDJSON {
+		r.encode(v)
+	}
+}
+
+func (r *Reporter) encode(v any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.stdout.Encode(v); err != nil && r.err == nil {
+		r.err = fmt.Errorf("writing output: %w", err)
+	}
+}
+
+// Err returns the first error writing results or events, if any.
+func (r *Reporter) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// A Rule maps the errors it matches to an exit status.
+type Rule struct {
+	Match func(error) bool
+	Code  int
+}
+
+// Is returns a Rule mapping the errors that match target, as by
+// errors.Is, to code.
+func Is(target error, code int) Rule {
+	return Rule{func(err error) bool { return errors.Is(err, target) }, code}
+}
+
+// As returns a Rule mapping the errors that have an error of type E
+// in their tree, as found by errors.As, to code.
+func As[E error](code int) Rule {
+	return Rule{func(err error) bool {
+		var e E
+		return errors.As(err, &e)
+	}, code}
+}
+
+// ExitCode returns the status for a program to exit with after failing
+// with err: 0 if err is nil, or else the code of the first of rules
+// that matches err, or else the ExitCode of the errcode.Code of err,
+// if it is not zero, or else fallback.
+func ExitCode(err error, fallback int, rules ...Rule) int {
+	if err == nil {
+		return 0
+	}
+	for _, rule := range rules {
+		if rule.Match(err) {
+			return rule.Code
+		}
+	}
+	if c := errcode.Code(err); c != nil && c.ExitCode != 0 {
+		return c.ExitCode
+	}
+	return fallback
+}
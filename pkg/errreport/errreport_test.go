@@ -0,0 +1,86 @@
+package errreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/go-monk/error-handling/pkg/errcatalog"
+	"github.com/go-monk/error-handling/pkg/errcode"
+)
+
+func TestReporter(t *testing.T) {
+	for _, test := range []struct {
+		format         Format
+		stdout, stderr string
+	}{
+		{Text, "", "prog: boom\nprog: done in 1s\n"},
+		{JSON, `{"ok":true}` + "\n", "prog: boom\n"},
+		{NDJSON, `{"attempt":1}` + "\n" + `{"ok":true}` + "\n", "prog: boom\n"},
+	} {
+		var stdout, stderr strings.Builder
+		r := New("prog", test.format, &stdout, &stderr)
+		r.Event(map[string]int{"attempt": 1})
+		r.Error(errors.New("boom"))
+		r.Result(map[string]bool{"ok": true})
+		r.Summaryf("done in %s", "1s")
+		if stdout.String() != test.stdout || stderr.String() != test.stderr {
+			t.Errorf("%v: stdout %q, stderr %q; want %q, %q", test.format, &stdout, &stderr, test.stdout, test.stderr)
+		}
+		if err := r.Err(); err != nil {
+			t.Errorf("%v: Err() = %v", test.format, err)
+		}
+	}
+}
+
+func TestReporterCatalog(t *testing.T) {
+	c, err := errcatalog.Parse([]byte(`errreport.test_denied: "verweigert"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stderr strings.Builder
+	r := New("prog", Text, io.Discard, &stderr)
+	r.SetCatalog(c)
+	denied := errcode.With(errors.New("denied"), testDenied)
+	r.Error(errors.Join(denied, errors.New("boom"), denied))
+	if want := "prog: verweigert\nprog: boom\nprog: verweigert\n"; stderr.String() != want {
+		t.Errorf("stderr %q, want %q", &stderr, want)
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	var f Format
+	if err := f.UnmarshalText([]byte("ndjson")); err != nil || f != NDJSON {
+		t.Errorf("UnmarshalText(ndjson) = %v, %v", f, err)
+	}
+	if err := f.UnmarshalText([]byte("csv")); err == nil {
+		t.Error("UnmarshalText(csv) succeeded")
+	}
+}
+
+var testDenied = errcode.Register(errcode.Entry{Name: "errreport.test_denied", ExitCode: 7})
+
+func TestExitCode(t *testing.T) {
+	rules := []Rule{
+		Is(context.DeadlineExceeded, 3),
+		As[*fs.PathError](5),
+	}
+	for _, test := range []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{fmt.Errorf("waiting: %w", context.DeadlineExceeded), 3},
+		{&fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, 5},
+		{errcode.With(errors.New("denied"), testDenied), 7},
+		{errors.New("other"), 1},
+	} {
+		if got := ExitCode(test.err, 1, rules...); got != test.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package errroute sends errors to sinks, such as a log, metrics, and
+// webhooks, chosen by their severity and category, as given by package
+// errcode, so that where each kind of failure ends up is decided in
+// one place rather than at each call site:
+//
+//	router := &errroute.Router{Routes: []errroute.Route{
+//		{MinSeverity: errcode.Debug, Sink: errroute.Log(logger)},
+//		{MinSeverity: errcode.Warning, Sink: metrics},
+//		{MinSeverity: errcode.Critical, Sink: &errroute.Webhook{URL: pagerURL}},
+//		{Categories: []errcode.Category{errcode.Denied}, Sink: &errroute.Webhook{URL: securityURL}},
+//	}}
+//	...
+//	router.Report(ctx, err)
+//
+// A severity is attached to an error with errcode.WithSeverity, or by
+// its code, and errors with neither are of severity errcode.Error.
+package errroute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+)
+
+// An Alert is an error as sent to sinks.
+type Alert struct {
+	Err      error
+	Severity errcode.Severity
+	Category errcode.Category // or ""
+	Code     *errcode.Entry   // or nil
+	Time     time.Time        // when it was reported
+}
+
+// NewAlert returns the Alert of err, reported at t.
+func NewAlert(err error, t time.Time) Alert {
+	return Alert{
+		Err:      err,
+		Severity: errcode.SeverityOf(err),
+		Category: errcode.CategoryOf(err),
+		Code:     errcode.Code(err),
+		Time:     t,
+	}
+}
+
+// A Sink receives alerts. Its Send method may be called concurrently.
+type Sink interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// A SinkFunc is a function used as a Sink.
+type SinkFunc func(ctx context.Context, a Alert) error
+
+// Send calls f.
+func (f SinkFunc) Send(ctx context.Context, a Alert) error { return f(ctx, a) }
+
+// A Route sends the alerts it matches to its Sink.
+type Route struct {
+	// MinSeverity is the least severity of the alerts matched. The
+	// zero value is errcode.Info.
+	MinSeverity errcode.Severity
+
+	// Categories, if not empty, limits the alerts matched to those
+	// of one of these categories.
+	Categories []errcode.Category
+
+	Sink Sink
+
+	// Final keeps the alerts matched from the routes that follow.
+	Final bool
+}
+
+// Match reports whether r matches a.
+func (r *Route) Match(a Alert) bool {
+	return a.Severity >= r.MinSeverity && (len(r.Categories) == 0 || slices.Contains(r.Categories, a.Category))
+}
+
+// A Router sends alerts to the Sink of each of its Routes that
+// matches them, in order, until a Final one, and those that none
+// matches to its Fallback. A Router is itself a Sink, so that routes
+// may lead to Routers of their own. Its fields must not be changed
+// while it is in use.
+type Router struct {
+	Routes   []Route
+	Fallback Sink // if non-nil
+
+	// Now, if non-nil, tells the time of the alerts of Report
+	// instead of time.Now.
+	Now func() time.Time
+}
+
+// Report sends the Alert of err, unless err is nil.
+func (r *Router) Report(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	return r.Send(ctx, NewAlert(err, now()))
+}
+
+// Send sends a to the sinks of the routes matching it, and returns the
+// errors of those that failed to take it.
+func (r *Router) Send(ctx context.Context, a Alert) error {
+	var errs []error
+	matched := false
+	for i := range r.Routes {
+		route := &r.Routes[i]
+		if !route.Match(a) {
+			continue
+		}
+		matched = true
+		if err := route.Sink.Send(ctx, a); err != nil {
+			errs = append(errs, fmt.Errorf("route %d: %w", i, err))
+		}
+		if route.Final {
+			break
+		}
+	}
+	if !matched && r.Fallback != nil {
+		if err := r.Fallback.Send(ctx, a); err != nil {
+			errs = append(errs, fmt.Errorf("fallback route: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Log returns a Sink logging alerts to logger, at the level of their
+// severity: errcode.Debug and errcode.Info at those of slog, Warning
+// at slog.LevelWarn, Error at slog.LevelError, and Critical above it.
+func Log(logger *slog.Logger) Sink {
+	return SinkFunc(func(ctx context.Context, a Alert) error {
+		attrs := []slog.Attr{slog.String("severity", a.Severity.String())}
+		if a.Category != "" {
+			attrs = append(attrs, slog.String("category", string(a.Category)))
+		}
+		if a.Code != nil {
+			attrs = append(attrs, slog.String("code", a.Code.Name))
+		}
+		attrs = append(attrs, slog.Any("err", a.Err))
+		logger.LogAttrs(ctx, Level(a.Severity), a.Err.Error(), attrs...)
+		return nil
+	})
+}
+
+// Level returns the log level of errors of severity s.
+func Level(s errcode.Severity) slog.Level {
+	switch {
+	case s <= errcode.Debug:
+		return slog.LevelDebug
+	case s == errcode.Info:
+		return slog.LevelInfo
+	case s == errcode.Warning:
+		return slog.LevelWarn
+	case s == errcode.Error:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
@@ -0,0 +1,65 @@
+package errroute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+)
+
+func TestRouter(t *testing.T) {
+	var got []string
+	sink := func(name string) Sink {
+		return SinkFunc(func(_ context.Context, a Alert) error {
+			got = append(got, fmt.Sprintf("%s %v %s", name, a.Severity, a.Category))
+			return nil
+		})
+	}
+	r := &Router{
+		Routes: []Route{
+			{Categories: []errcode.Category{errcode.Denied}, Sink: sink("security"), Final: true},
+			{MinSeverity: errcode.Warning, Sink: sink("log")},
+			{MinSeverity: errcode.Critical, Sink: sink("pager")},
+		},
+		Fallback: sink("debug"),
+	}
+	ctx := context.Background()
+	r.Report(ctx, fmt.Errorf("open: %w", fs.ErrPermission))
+	r.Report(ctx, errcode.WithSeverity(errors.New("disk full"), errcode.Critical))
+	r.Report(ctx, errcode.WithSeverity(errors.New("cache miss"), errcode.Debug))
+	r.Report(ctx, nil)
+	want := []string{"security error denied", "log critical ", "pager critical ", "debug debug "}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	err := errfields.With(errcode.WithSeverity(errors.New("db down"), errcode.Critical), "attempts", 3)
+	w := &Webhook{URL: srv.URL}
+	if err := w.Send(context.Background(), NewAlert(err, time.Unix(0, 0))); err != nil {
+		t.Fatal(err)
+	}
+	if body["severity"] != "critical" || body["error"] != "db down" || body["fields"].(map[string]any)["attempts"] != 3.0 {
+		t.Errorf("posted %v", body)
+	}
+	srv.Config.Handler = http.NotFoundHandler()
+	if err := w.Send(context.Background(), NewAlert(err, time.Unix(0, 0))); err == nil {
+		t.Error("Send to a webhook answering 404 succeeded")
+	}
+}
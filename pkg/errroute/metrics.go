@@ -0,0 +1,42 @@
+package errroute
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a Sink counting alerts, as the Prometheus metric
+//
+//	errors_total{severity,category,code}   alerts sent
+//
+// where category and code are empty for alerts without them.
+type Metrics struct {
+	errors *prometheus.CounterVec
+}
+
+// NewMetrics returns Metrics registered with reg, unless reg is nil.
+// It panics if the registration fails, as when the metrics are
+// registered twice.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors reported, by severity, category, and code.",
+		}, []string{"severity", "category", "code"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.errors)
+	}
+	return m
+}
+
+// Send counts a.
+func (m *Metrics) Send(_ context.Context, a Alert) error {
+	code := ""
+	if a.Code != nil {
+		code = a.Code.Name
+	}
+	m.errors.WithLabelValues(a.Severity.String(), string(a.Category), code).Inc()
+	return nil
+}
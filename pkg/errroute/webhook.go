@@ -0,0 +1,87 @@
+package errroute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errfields"
+)
+
+// A Webhook is a Sink posting alerts to URL as JSON objects such as
+//
+//	{"time": "2026-10-14T09:30:00Z", "severity": "critical", "category": "unavailable",
+//	 "code": "wait.not_ready", "error": "db not ready: ...", "fields": {"attempts": 3}}
+//
+// where the fields are those attached to the error with package
+// errfields. Alerts are not retried: Client may retry them, with a
+// retry.Transport, if the receiver dedupes them.
+type Webhook struct {
+	URL    string
+	Client *http.Client // or nil for http.DefaultClient
+}
+
+type webhookAlert struct {
+	Time     time.Time      `json:"time"`
+	Severity string         `json:"severity"`
+	Category string         `json:"category,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Error    string         `json:"error"`
+	Fields   map[string]any `json:"fields,omitempty"`
+}
+
+// Send posts a to w.URL, and returns an error if the response has a
+// status other than 2xx. The error does not give w.URL, which may hold
+// a secret, as those of chat services do.
+func (w *Webhook) Send(ctx context.Context, a Alert) error {
+	body := webhookAlert{
+		Time:     a.Time,
+		Severity: a.Severity.String(),
+		Category: string(a.Category),
+		Error:    a.Err.Error(),
+	}
+	if a.Code != nil {
+		body.Code = a.Code.Name
+	}
+	for _, attr := range errfields.Attrs(a.Err) {
+		if body.Fields == nil {
+			body.Fields = make(map[string]any)
+		}
+		if _, ok := body.Fields[attr.Key]; !ok { // the outermost
+			body.Fields[attr.Key] = attr.Value.Resolve().Any()
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
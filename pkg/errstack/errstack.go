@@ -0,0 +1,113 @@
+// Package errstack wraps errors with the place they were wrapped at,
+// so that an error that went up through many functions can be traced
+// back to where it came from.
+//
+// The errors of this package print as usual with %v and %s, and with
+// %+v they print the locations recorded in their chain as well:
+//
+//	loading config: open wait.yaml: no such file or directory
+//		main.loadConfig
+//			/src/wait/config.go:42
+//		main.main
+//			/src/wait/main.go:17
+//
+// They work with errors.Is, errors.As, and errors.Unwrap like the
+// errors of fmt.Errorf with %w.
+package errstack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// maxDepth limits the stacks recorded by WithStack.
+const maxDepth = 32
+
+// Wrap returns an error wrapping err with the message msg, as
+// "msg: err", and the location of the call of Wrap. It returns nil if
+// err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{msg: msg, err: err, pcs: callers(1)}
+}
+
+// Wrapf is like Wrap with a message formatted as by fmt.Sprintf.
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{msg: fmt.Sprintf(format, args...), err: err, pcs: callers(1)}
+}
+
+// WithStack returns an error wrapping err, with the same message, and
+// the whole stack of the call of WithStack. It returns nil if err is
+// nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, pcs: callers(maxDepth)}
+}
+
+// callers returns the program counters of the caller of the caller of
+// callers and up to depth-1 of its own callers.
+func callers(depth int) []uintptr {
+	pcs := make([]uintptr, depth)
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+type stackError struct {
+	msg string // or "" to keep the message of err
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// Format prints the message of e, followed with the %+v verb by the
+// locations recorded in its chain, outermost first.
+func (e *stackError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		io.WriteString(s, e.Error())
+		for _, f := range Frames(e) {
+			fmt.Fprintf(s, "\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
+		}
+	case verb == 'v' || verb == 's':
+		io.WriteString(s, e.Error())
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// Frames returns the locations recorded by Wrap, Wrapf, and
+// WithStack in the chain of err, outermost first.
+func Frames(err error) []runtime.Frame {
+	var frames []runtime.Frame
+	for ; err != nil; err = errors.Unwrap(err) {
+		e, ok := err.(*stackError)
+		if !ok {
+			continue
+		}
+		fs := runtime.CallersFrames(e.pcs)
+		for {
+			f, more := fs.Next()
+			frames = append(frames, f)
+			if !more {
+				break
+			}
+		}
+	}
+	return frames
+}
@@ -0,0 +1,52 @@
+package errstack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func open(name string) error {
+	return Wrapf(&fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}, "loading %s", name)
+}
+
+func TestWrap(t *testing.T) {
+	err := Wrap(open("wait.yaml"), "starting")
+	if got, want := err.Error(), "starting: loading wait.yaml: open wait.yaml: file does not exist"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is does not find fs.ErrNotExist")
+	}
+
+	frames := Frames(err)
+	if len(frames) != 2 {
+		t.Fatalf("%d frames recorded, want 2: %v", len(frames), frames)
+	}
+	if !strings.HasSuffix(frames[0].Function, ".TestWrap") || !strings.HasSuffix(frames[1].Function, ".open") {
+		t.Errorf("frames in %s and %s, want TestWrap and open", frames[0].Function, frames[1].Function)
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.HasPrefix(verbose, err.Error()+"\n") || !strings.Contains(verbose, "errstack_test.go:") {
+		t.Errorf("%%+v printed %q, want the message and file:line locations", verbose)
+	}
+	if s := fmt.Sprintf("%v", err); s != err.Error() {
+		t.Errorf("%%v printed %q, want %q", s, err.Error())
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	if Wrap(nil, "x") != nil || WithStack(nil) != nil {
+		t.Error("wrapping nil did not return nil")
+	}
+	err := WithStack(fs.ErrPermission)
+	if err.Error() != fs.ErrPermission.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), fs.ErrPermission.Error())
+	}
+	if n := len(Frames(err)); n < 2 {
+		t.Errorf("%d frames recorded, want the whole stack", n)
+	}
+}
@@ -0,0 +1,99 @@
+// Package events passes the typed events of the subsystems of a
+// program, such as the attempts of waits, the changes of state of
+// circuit breakers, and the files checked by scans, to the subscribers
+// interested in them, so that metrics, logs, notifications, and
+// outputs each subscribe to the events they report on, instead of
+// being called by hooks of their own.
+//
+// Events are values of any type, which the packages publishing them
+// define, such as circuitbreaker.StateChange. Subscribers are called
+// with those of the type they subscribe to:
+//
+//	var bus events.Bus
+//	events.Subscribe(&bus, func(ctx context.Context, c circuitbreaker.StateChange) {
+//		slog.InfoContext(ctx, "circuit breaker changed state", "from", c.From, "to", c.To)
+//	})
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// A Bus passes the events published on it to its subscribers. The zero
+// Bus has none, and publishing on the nil Bus does nothing. A Bus may
+// be used concurrently.
+type Bus struct {
+	mu   sync.Mutex // held to change subs
+	subs atomic.Pointer[[]*subscriber]
+}
+
+// A subscriber is a function subscribed to a Bus.
+type subscriber struct {
+	f func(context.Context, any)
+}
+
+// Publish calls the subscribers of b to the type of e with ctx and e,
+// in the order they subscribed, before it returns, so that they see
+// the events of each goroutine in the order they happen. Subscribers
+// must not take long, nor be slowed down by each other: those that do,
+// such as to send events over the network, should hand them to a
+// goroutine of their own.
+func (b *Bus) Publish(ctx context.Context, e any) {
+	if b == nil {
+		return
+	}
+	subs := b.subs.Load()
+	if subs == nil {
+		return
+	}
+	for _, s := range *subs {
+		s.f(ctx, e)
+	}
+}
+
+// SubscribeAll makes b call f with every event published on it, such
+// as to write them all out or pass them on to another Bus, and returns
+// a function that cancels the subscription. f may be called
+// concurrently.
+func (b *Bus) SubscribeAll(f func(ctx context.Context, e any)) (unsubscribe func()) {
+	s := &subscriber{f}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var subs []*subscriber
+	if p := b.subs.Load(); p != nil {
+		subs = *p
+	}
+	subs = append(subs[:len(subs):len(subs)], s)
+	b.subs.Store(&subs)
+	return func() { b.unsubscribe(s) }
+}
+
+// Subscribe makes b call f with the events of type E published on it,
+// and returns a function that cancels the subscription. f may be
+// called concurrently.
+func Subscribe[E any](b *Bus, f func(ctx context.Context, e E)) (unsubscribe func()) {
+	return b.SubscribeAll(func(ctx context.Context, e any) {
+		if e, ok := e.(E); ok {
+			f(ctx, e)
+		}
+	})
+}
+
+// unsubscribe removes s from the subscribers of b.
+func (b *Bus) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.subs.Load()
+	if p == nil {
+		return
+	}
+	subs := make([]*subscriber, 0, len(*p))
+	for _, sub := range *p {
+		if sub != s {
+			subs = append(subs, sub)
+		}
+	}
+	b.subs.Store(&subs)
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type started struct{ name string }
+type finished struct {
+	name string
+	err  error
+}
+
+func TestSubscribe(t *testing.T) {
+	var b Bus
+	var got []string
+	Subscribe(&b, func(_ context.Context, e started) { got = append(got, "started "+e.name) })
+	Subscribe(&b, func(_ context.Context, e finished) { got = append(got, fmt.Sprintf("finished %s: %v", e.name, e.err)) })
+	unsubscribe := b.SubscribeAll(func(_ context.Context, e any) { got = append(got, fmt.Sprintf("any %T", e)) })
+	ctx := context.Background()
+	b.Publish(ctx, started{"a"})
+	b.Publish(ctx, finished{"a", nil})
+	unsubscribe()
+	b.Publish(ctx, started{"b"})
+	b.Publish(ctx, 42)
+	want := []string{
+		"started a", "any events.started",
+		"finished a: <nil>", "any events.finished",
+		"started b",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPublishNil(t *testing.T) {
+	var b *Bus
+	b.Publish(context.Background(), started{"a"})
+	new(Bus).Publish(context.Background(), started{"a"})
+}
+
+func TestContext(t *testing.T) {
+	type key struct{}
+	var b Bus
+	var got any
+	Subscribe(&b, func(ctx context.Context, _ started) { got = ctx.Value(key{}) })
+	b.Publish(context.WithValue(context.Background(), key{}, "v"), started{"a"})
+	if got != "v" {
+		t.Errorf("subscriber got context value %v, want v", got)
+	}
+}
+
+// TestSubscribeWhilePublishing checks that subscribers may subscribe
+// and unsubscribe while events are published, which takes effect from
+// the next event on.
+func TestSubscribeWhilePublishing(t *testing.T) {
+	var b Bus
+	n := 0
+	var unsubscribe func()
+	unsubscribe = Subscribe(&b, func(context.Context, started) {
+		unsubscribe()
+		Subscribe(&b, func(context.Context, started) { n++ })
+	})
+	b.Publish(context.Background(), started{"a"})
+	b.Publish(context.Background(), started{"b"})
+	if n != 1 {
+		t.Errorf("new subscriber called %d times, want 1", n)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	var b Bus
+	var mu sync.Mutex
+	n := 0
+	Subscribe(&b, func(context.Context, started) {
+		mu.Lock()
+		n++
+		mu.Unlock()
+	})
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				b.Publish(context.Background(), started{"a"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			unsubscribe := Subscribe(&b, func(context.Context, finished) {})
+			unsubscribe()
+		}()
+	}
+	wg.Wait()
+	if n != 1000 {
+		t.Errorf("subscriber called %d times, want 1000", n)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package forbidden
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// access checks whether the effective user has permission m to path,
+// without opening it.
+func access(path string, m Mode) error {
+	if err := unix.Faccessat(unix.AT_FDCWD, path, uint32(m), unix.AT_EACCESS); err != nil {
+		return &fs.PathError{Op: "access", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package forbidden
+
+import (
+	"errors"
+	"io/fs"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// procAccessCheck is AccessCheck of advapi32.dll, which package
+// windows does not wrap.
+var procAccessCheck = windows.NewLazySystemDLL("advapi32.dll").NewProc("AccessCheck")
+
+// fileRights are the rights to files that the modes check, which are
+// the same for directories: listing, adding files, and traversing.
+var fileRights = map[Mode]uint32{
+	Read:  windows.FILE_READ_DATA,
+	Write: windows.FILE_WRITE_DATA,
+	Exec:  windows.FILE_EXECUTE,
+}
+
+// fileMapping maps the generic rights of access control entries to
+// those of files.
+var fileMapping = genericMapping{
+	read:    windows.FILE_GENERIC_READ,
+	write:   windows.FILE_GENERIC_WRITE,
+	execute: windows.FILE_GENERIC_EXECUTE,
+	all:     windows.STANDARD_RIGHTS_REQUIRED | windows.SYNCHRONIZE | 0x1ff, // FILE_ALL_ACCESS
+}
+
+// genericMapping is a GENERIC_MAPPING.
+type genericMapping struct {
+	read, write, execute, all uint32
+}
+
+// access checks whether the user has permission m to path, without
+// opening it. Windows has no access(2): the access control list of
+// the file is checked against the token of the process, as AccessCheck
+// does when the file is opened, and the file must not be read-only to
+// be written. Files whose access control list the user may not read
+// are only checked for being read-only.
+func access(path string, m Mode) error {
+	err := accessACL(path, m)
+	if err == nil || errors.Is(err, errCannotCheck) {
+		err = accessAttributes(path, m)
+	}
+	if err != nil {
+		return &fs.PathError{Op: "access", Path: path, Err: err}
+	}
+	return nil
+}
+
+// errCannotCheck is the error of accessACL when the access control
+// list of the file cannot be read.
+var errCannotCheck = errors.New("cannot read access control list")
+
+// accessACL checks the access control list of path for permission m,
+// returning ERROR_ACCESS_DENIED if it denies it.
+func accessACL(path string, m Mode) error {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.GROUP_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	switch {
+	case errors.Is(err, windows.ERROR_ACCESS_DENIED):
+		return errCannotCheck // READ_CONTROL is denied
+	case err != nil:
+		return err
+	}
+	// AccessCheck takes an impersonation token, not the primary
+	// token of the process.
+	var process, token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_QUERY|windows.TOKEN_DUPLICATE, &process); err != nil {
+		return err
+	}
+	defer process.Close()
+	if err := windows.DuplicateTokenEx(process, windows.TOKEN_QUERY, nil,
+		windows.SecurityImpersonation, windows.TokenImpersonation, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+
+	var (
+		privileges       [64]uint32 // a PRIVILEGE_SET, which is not used
+		privilegesLength = uint32(unsafe.Sizeof(privileges))
+		granted          uint32
+		status           int32
+	)
+	ok, _, err := procAccessCheck.Call(
+		uintptr(unsafe.Pointer(sd)),
+		uintptr(token),
+		uintptr(fileRights[m]),
+		uintptr(unsafe.Pointer(&fileMapping)),
+		uintptr(unsafe.Pointer(&privileges[0])),
+		uintptr(unsafe.Pointer(&privilegesLength)),
+		uintptr(unsafe.Pointer(&granted)),
+		uintptr(unsafe.Pointer(&status)))
+	switch {
+	case ok == 0:
+		return err
+	case status == 0:
+		return windows.ERROR_ACCESS_DENIED
+	}
+	return nil
+}
+
+// accessAttributes checks the attributes of path, which only tell
+// whether it can be found and whether it is read-only.
+func accessAttributes(path string, m Mode) error {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrs, err := windows.GetFileAttributes(p)
+	if err != nil {
+		return err
+	}
+	const readOnly = windows.FILE_ATTRIBUTE_READONLY
+	if m == Write && attrs&readOnly != 0 && attrs&windows.FILE_ATTRIBUTE_DIRECTORY == 0 {
+		return fs.ErrPermission
+	}
+	return nil
+}
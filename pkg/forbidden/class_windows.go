@@ -0,0 +1,25 @@
+package forbidden
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// classifyOS returns the Class of the errors of Windows that package
+// syscall does not tell are of permissions or of missing files.
+func classifyOS(err error) (Class, bool) {
+	var errno windows.Errno
+	if !errors.As(err, &errno) {
+		return 0, false
+	}
+	switch errno {
+	case windows.ERROR_PRIVILEGE_NOT_HELD, windows.ERROR_NETWORK_ACCESS_DENIED, windows.ERROR_ACCOUNT_RESTRICTION:
+		return Permission, true
+	case windows.ERROR_INVALID_NAME, windows.ERROR_BAD_NET_NAME:
+		return NotExist, true
+	case windows.ERROR_CANT_RESOLVE_FILENAME:
+		return Loop, true
+	}
+	return 0, false
+}
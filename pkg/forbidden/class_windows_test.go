@@ -0,0 +1,41 @@
+package forbidden
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestClassifyWindows(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want Class
+	}{
+		{&fs.PathError{Op: "open", Path: `C:\Windows\System32\config\SAM`, Err: windows.ERROR_ACCESS_DENIED}, Permission},
+		{&fs.PathError{Op: "open", Path: `C:\pagefile.sys`, Err: windows.ERROR_PRIVILEGE_NOT_HELD}, Permission},
+		{&fs.PathError{Op: "open", Path: `\\server\share\f`, Err: windows.ERROR_NETWORK_ACCESS_DENIED}, Permission},
+		{&fs.PathError{Op: "open", Path: `C:\a<b`, Err: windows.ERROR_INVALID_NAME}, NotExist},
+		{&fs.PathError{Op: "open", Path: `C:\loop`, Err: windows.ERROR_CANT_RESOLVE_FILENAME}, Loop},
+		{&fs.PathError{Op: "open", Path: `C:\f`, Err: windows.ERROR_SHARING_VIOLATION}, Other},
+	} {
+		if got := Classify(test.err); got != test.want {
+			t.Errorf("Classify(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestAccessWindows(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(name, nil, 0o444); err != nil { // read-only
+		t.Fatal(err)
+	}
+	if err := access(name, Read); err != nil {
+		t.Errorf("access(%s, r) = %v", name, err)
+	}
+	if err := access(name, Write); Classify(err) != Permission {
+		t.Errorf("access(%s, w) = %v, want a permission error", name, err)
+	}
+}
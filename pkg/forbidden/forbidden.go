@@ -0,0 +1,381 @@
+// Package forbidden finds the files that lack permissions, such as
+// those the user may not read, in any fs.FS, and tells the other
+// errors of checking them apart, by their Class.
+//
+// Permissions other than that to read can only be checked without
+// opening the files, on file systems that implement AccessFS, such as
+// OS.
+package forbidden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/go-monk/error-handling/pkg/events"
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+// A Class is a kind of error of a check.
+type Class int
+
+const (
+	Permission Class = iota // a permission was denied
+	NotExist                // the file does not exist
+	IsDir                   // the file is a directory, which can't be read as a file
+	Loop                    // symbolic links loop or go too deep
+	Broken                  // the file is a symbolic link to a file that does not exist
+	Other                   // any other error
+)
+
+var classNames = []string{"permission-denied", "not-exist", "is-directory", "too-many-symlinks", "broken-symlink", "other"}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return fmt.Sprintf("Class(%d)", int(c))
+	}
+	return classNames[c]
+}
+
+// Classify returns the Class of err, which is never Broken, as only
+// the file tells whether it is a symbolic link. On Windows, the errors
+// of privileges that are not held and of network shares that deny
+// access are of Class Permission too.
+func Classify(err error) Class {
+	if c, ok := classifyOS(err); ok {
+		return c
+	}
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return Permission
+	case errors.Is(err, fs.ErrNotExist):
+		return NotExist
+	case errors.Is(err, syscall.EISDIR):
+		return IsDir
+	case errors.Is(err, syscall.ELOOP):
+		return Loop
+	}
+	return Other
+}
+
+// A Finding is a file that lacks permissions, or whose check failed.
+type Finding struct {
+	Path   string
+	Class  Class
+	Denied []Mode // the permissions denied, if Class is Permission
+	Err    error
+}
+
+// newFinding returns the Finding of name, which failed with err, as
+// its permissions of denied were denied if err is a permission error.
+func newFinding(name string, denied []Mode, err error) *Finding {
+	f := &Finding{Path: name, Class: Classify(err), Err: err}
+	if f.Class == Permission {
+		f.Denied = denied
+	}
+	return f
+}
+
+// An LstatFS is a file system with symbolic links, which tells them
+// apart from the files they link to.
+type LstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// Links says what a Scanner does with symbolic links.
+type Links int
+
+const (
+	// CheckLinks checks the files that symbolic links link to, but
+	// doesn't walk the directories they link to.
+	CheckLinks Links = iota
+
+	// FollowLinks walks the directories that symbolic links link to
+	// as well, stopping at the links that loop back to a directory
+	// the walk is in, whose findings are of Class Loop. Loops are
+	// only found in OS.
+	FollowLinks
+
+	// SkipLinks neither checks nor walks symbolic links.
+	SkipLinks
+)
+
+// An AccessFS is a file system that checks the permissions of its
+// files without opening them, as access(2) does.
+type AccessFS interface {
+	fs.FS
+	Access(name string, m Mode) error
+}
+
+// A Scanner checks the permissions of files.
+type Scanner struct {
+	// FS holds the files, such as OS.
+	FS fs.FS
+
+	// Modes are the permissions to check, Read if none. Those to
+	// write and execute are always checked with Access, so that no
+	// file is opened for writing or run.
+	Modes []Mode
+
+	// Access makes the permission to read be checked with Access too,
+	// rather than by opening and reading the files, so that their
+	// access times are left alone and audit logs stay quiet.
+	Access bool
+
+	// Recursive makes Scan walk the directory trees of the paths, and
+	// check every file in them, and every directory it may not list.
+	Recursive bool
+
+	// With Recursive, Include limits the files checked to those whose
+	// name or path matches one of the patterns, as by path.Match, and
+	// Exclude skips the files and directories that match, and all
+	// that is in them.
+	Include, Exclude []string
+
+	// Links says what to do with symbolic links, which are only
+	// told apart from other files in an LstatFS, such as OS. The
+	// findings of links to files that do not exist are of Class
+	// Broken there.
+	Links Links
+
+	// Concurrency is how many files Scan checks at once, 1 if zero.
+	Concurrency int
+
+	// Events, if non-nil, is where Scan publishes a Scanned event
+	// for every file it checks or fails to walk, in the order they
+	// came up, such as to show its progress.
+	Events *events.Bus
+}
+
+// A Scanned is the event of a file checked by Scan, or of a directory
+// it failed to walk, as published on the Events of its Scanner.
+type Scanned struct {
+	Path    string
+	Finding *Finding // nil if every permission was granted
+}
+
+// A job is a path to check, or a finding of the walk of a directory
+// tree, numbered in the order they came up.
+type job struct {
+	n     int
+	name  string
+	check bool     // whether name is to be checked
+	found *Finding // of the check, if any
+}
+
+// Scan checks the files of paths, or with s.Recursive the trees under
+// them, and calls report with each Finding in the order the files came
+// up, whatever s.Concurrency. It returns the errors of the findings
+// other than permission errors, which kept it from checking files,
+// joined as by multierr.Join, or an error, before checking any file,
+// if a pattern of s.Include or s.Exclude is malformed.
+func (s *Scanner) Scan(paths iter.Seq[string], report func(*Finding)) error {
+	for _, pattern := range append(s.Include[:len(s.Include):len(s.Include)], s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: %w", pattern, err)
+		}
+	}
+	jobs := make(chan job)
+	done := make(chan job)
+	var wg sync.WaitGroup
+	for range max(s.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if j.check {
+					j.found = s.check(j.name)
+				}
+				done <- j
+			}
+		}()
+	}
+	go func() {
+		n := 0
+		for name := range paths {
+			s.walk(name, func(j job) {
+				j.n, n = n, n+1
+				jobs <- j
+			})
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	var errs multierr.Collector
+	pending := make(map[int]job) // done before the next
+	next := 0
+	for j := range done {
+		pending[j.n] = j
+		for {
+			j, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			f := j.found
+			s.Events.Publish(context.Background(), Scanned{j.name, f})
+			if f == nil {
+				continue
+			}
+			if f.Class != Permission {
+				errs.Add(f.Err)
+			}
+			report(f)
+		}
+	}
+	return errs.Err()
+}
+
+// walk sends the job of checking name, or, if s.Recursive, those of
+// checking the files under it and of its errors.
+func (s *Scanner) walk(name string, send func(job)) {
+	switch {
+	case !s.Recursive && s.Links == SkipLinks && s.isLink(name):
+	case !s.Recursive:
+		send(job{name: name, check: true})
+	default:
+		s.walkTree(name, nil, send)
+	}
+}
+
+// walkTree walks the tree of root, which the walk reached by following
+// links to the directories of ancestors.
+func (s *Scanner) walkTree(root string, ancestors []fs.FileInfo, send func(job)) {
+	if fi, err := fs.Stat(s.FS, root); err == nil {
+		ancestors = append(ancestors[:len(ancestors):len(ancestors)], fi)
+	}
+	fs.WalkDir(s.FS, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The file could not be stat'ed, or the directory
+			// could not be listed, and is skipped.
+			send(job{name: name, found: newFinding(name, []Mode{Read}, err)})
+			return nil
+		}
+		if match(s.Exclude, name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			s.link(name, ancestors, send)
+			return nil
+		}
+		if !d.IsDir() && (len(s.Include) == 0 || match(s.Include, name)) {
+			send(job{name: name, check: true})
+		}
+		return nil
+	})
+}
+
+// link sends the jobs of the symbolic link name, which the walk found
+// in the trees of ancestors.
+func (s *Scanner) link(name string, ancestors []fs.FileInfo, send func(job)) {
+	if s.Links == SkipLinks {
+		return
+	}
+	fi, err := fs.Stat(s.FS, name)
+	if err != nil || !fi.IsDir() {
+		// The check tells whether the link is broken, or loops.
+		if len(s.Include) == 0 || match(s.Include, name) {
+			send(job{name: name, check: true})
+		}
+		return
+	}
+	if s.Links != FollowLinks {
+		return
+	}
+	if slices.ContainsFunc(ancestors, func(a fs.FileInfo) bool { return os.SameFile(a, fi) }) {
+		err := &fs.PathError{Op: "walk", Path: name, Err: syscall.ELOOP}
+		send(job{name: name, found: newFinding(name, nil, err)})
+		return
+	}
+	s.walkTree(name, ancestors, send)
+}
+
+// isLink reports whether name is a symbolic link, as far as s.FS can
+// tell.
+func (s *Scanner) isLink(name string) bool {
+	fsys, ok := s.FS.(LstatFS)
+	if !ok {
+		return false
+	}
+	fi, err := fsys.Lstat(name)
+	return err == nil && fi.Mode()&fs.ModeSymlink != 0
+}
+
+// match reports whether the base or the whole of name matches one of
+// patterns, which are well-formed.
+func match(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks whether name lacks any of the permissions of s.Modes,
+// and returns the Finding, if any.
+func (s *Scanner) check(name string) *Finding {
+	modes := s.Modes
+	if modes == nil {
+		modes = []Mode{Read}
+	}
+	var denied []Mode
+	var last error // of the denied permissions
+	for _, m := range modes {
+		err := s.check1(name, m)
+		if err == nil {
+			continue
+		}
+		if Classify(err) != Permission {
+			f := newFinding(name, nil, err) // once, whatever the mode
+			if f.Class == NotExist && s.isLink(name) {
+				f.Class = Broken
+			}
+			return f
+		}
+		denied, last = append(denied, m), err
+	}
+	if denied == nil {
+		return nil
+	}
+	return newFinding(name, denied, last)
+}
+
+// check1 checks whether name has permission m; that to read by opening
+// and reading it, unless s.Access is set.
+func (s *Scanner) check1(name string, m Mode) error {
+	if m != Read || s.Access {
+		fsys, ok := s.FS.(AccessFS)
+		if !ok {
+			return &fs.PathError{Op: "access", Path: name, Err: errors.ErrUnsupported}
+		}
+		return fsys.Access(name, m)
+	}
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Read(make([]byte, 1)); err != nil && err != io.EOF {
+		return err // such as that of a directory
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package forbidden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-monk/error-handling/pkg/events"
+)
+
+// denyFS denies the permission to read the files of names.
+type denyFS struct {
+	fstest.MapFS
+	names []string
+}
+
+func (d denyFS) Open(name string) (fs.File, error) {
+	if slices.Contains(d.names, name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.MapFS.Open(name)
+}
+
+func TestScan(t *testing.T) {
+	fsys := denyFS{
+		MapFS: fstest.MapFS{
+			"etc/hosts":           {Data: []byte("127.0.0.1 localhost\n")},
+			"etc/shadow":          {Data: []byte("root:*:::::::\n")},
+			"etc/ssl/private/key": {Data: []byte("secret")},
+			"etc/ssl/cert.pem":    {Data: []byte("cert")},
+			"etc/sudoers":         {Data: []byte("root ALL=(ALL) ALL\n")},
+		},
+		names: []string{"etc/shadow", "etc/ssl/private/key", "etc/sudoers"},
+	}
+	for _, concurrency := range []int{1, 8} {
+		s := Scanner{FS: fsys, Recursive: true, Exclude: []string{"private"}, Concurrency: concurrency}
+		var got []string
+		err := s.Scan(slices.Values([]string{"etc", "nope"}), func(f *Finding) {
+			got = append(got, f.Class.String()+" "+f.Path)
+		})
+		if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Scan returned %v, want the error of nope only", err)
+		}
+		want := []string{"permission-denied etc/shadow", "permission-denied etc/sudoers", "not-exist nope"}
+		if !slices.Equal(got, want) {
+			t.Errorf("with concurrency %d, found %q, want %q", concurrency, got, want)
+		}
+	}
+}
+
+func TestScanEvents(t *testing.T) {
+	fsys := denyFS{
+		MapFS: fstest.MapFS{"a": {}, "b": {}, "c": {}},
+		names: []string{"b"},
+	}
+	var bus events.Bus
+	var got []string
+	events.Subscribe(&bus, func(_ context.Context, e Scanned) {
+		got = append(got, fmt.Sprint(e.Path, " ", e.Finding != nil))
+	})
+	s := Scanner{FS: fsys, Recursive: true, Concurrency: 4, Events: &bus}
+	s.Scan(slices.Values([]string{"."}), func(*Finding) {})
+	want := []string{"a false", "b true", "c false"}
+	if !slices.Equal(got, want) {
+		t.Errorf("published %q, want %q", got, want)
+	}
+}
+
+func TestScanAccess(t *testing.T) {
+	s := Scanner{FS: fstest.MapFS{"f": {}}, Modes: []Mode{Read, Exec}}
+	var found []*Finding
+	s.Scan(slices.Values([]string{"f"}), func(f *Finding) { found = append(found, f) })
+	// A MapFS can't check the permission to execute.
+	if len(found) != 1 || found[0].Class != Other {
+		t.Errorf("found %v, want one error of class other", found)
+	}
+}
+
+func TestScanLinks(t *testing.T) {
+	dir := t.TempDir()
+	for _, link := range [][2]string{
+		{"nowhere", "broken"},
+		{"..", "sub/up"}, // loops back to dir
+	} {
+		os.MkdirAll(filepath.Join(dir, "sub"), 0o755)
+		if err := os.Symlink(link[0], filepath.Join(dir, link[1])); err != nil {
+			t.Skip(err)
+		}
+	}
+	for _, tt := range []struct {
+		links Links
+		want  []string
+	}{
+		{CheckLinks, []string{"broken-symlink broken"}},
+		{FollowLinks, []string{"broken-symlink broken", "too-many-symlinks sub/up"}},
+		{SkipLinks, nil},
+	} {
+		s := Scanner{FS: OS, Recursive: true, Links: tt.links}
+		var got []string
+		s.Scan(slices.Values([]string{dir}), func(f *Finding) {
+			rel, _ := filepath.Rel(dir, f.Path)
+			got = append(got, f.Class.String()+" "+rel)
+		})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("with links %d, found %q, want %q", tt.links, got, tt.want)
+		}
+	}
+}
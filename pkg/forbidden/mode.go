@@ -0,0 +1,46 @@
+package forbidden
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Mode is a permission to check, with the value of access(2).
+type Mode uint32
+
+const (
+	Exec  Mode = 1 // X_OK
+	Write Mode = 2 // W_OK
+	Read  Mode = 4 // R_OK
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Read:
+		return "r"
+	case Write:
+		return "w"
+	case Exec:
+		return "x"
+	}
+	return fmt.Sprintf("Mode(%d)", uint32(m))
+}
+
+// ParseModes parses a comma-separated list of the modes r, w, and x,
+// such as "r,x".
+func ParseModes(s string) ([]Mode, error) {
+	var modes []Mode
+	for _, name := range strings.Split(s, ",") {
+		switch name {
+		case "r":
+			modes = append(modes, Read)
+		case "w":
+			modes = append(modes, Write)
+		case "x":
+			modes = append(modes, Exec)
+		default:
+			return nil, fmt.Errorf("unknown mode %q", name)
+		}
+	}
+	return modes, nil
+}
@@ -0,0 +1,27 @@
+package forbidden
+
+import (
+	"io/fs"
+	"os"
+)
+
+// OS is the file system of the operating system. Unlike those of
+// other fs.FS, its names are the paths of the operating system, such
+// as "/etc/hosts" or "../config.yaml".
+// It implements LstatFS too.
+var OS AccessFS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err // not a nil *os.File
+	}
+	return f, nil
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) Access(name string, m Mode) error           { return access(name, m) }
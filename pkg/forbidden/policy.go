@@ -0,0 +1,162 @@
+package forbidden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-monk/error-handling/pkg/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+// A Policy declares the permissions that the user must have to files,
+// and those the user must not have, as read from a policy file by
+// LoadPolicy, such as
+//
+//	rules:
+//	  - path: /etc/hosts
+//	    grant: r
+//	  - path: /etc/shadow
+//	    deny: r,w
+type Policy struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// A Rule declares the permissions of the file of Path.
+type Rule struct {
+	Path  string `json:"path" yaml:"path"`
+	Grant Modes  `json:"grant" yaml:"grant"` // the permissions the user must have
+	Deny  Modes  `json:"deny" yaml:"deny"`   // the permissions the user must not have
+}
+
+// Modes are permissions, written as a list such as "r,x" in policy
+// files.
+type Modes []Mode
+
+// MarshalText formats m as a list, such as "r,x".
+func (m Modes) MarshalText() ([]byte, error) {
+	names := make([]string, len(m))
+	for i, mode := range m {
+		names[i] = mode.String()
+	}
+	return []byte(strings.Join(names, ",")), nil
+}
+
+// UnmarshalText parses a list of modes, as by ParseModes.
+func (m *Modes) UnmarshalText(text []byte) error {
+	modes, err := ParseModes(string(text))
+	if err != nil {
+		return err
+	}
+	*m = modes
+	return nil
+}
+
+// LoadPolicy reads a Policy from the named file, as JSON if its name
+// ends in .json and as YAML otherwise.
+func LoadPolicy(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var p *Policy
+	if filepath.Ext(name) == ".json" {
+		p, err = ParsePolicyJSON(data)
+	} else {
+		p, err = ParsePolicyYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p, nil
+}
+
+// ParsePolicyJSON parses and validates a Policy written in JSON.
+func ParsePolicyJSON(data []byte) (*Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, p.validate()
+}
+
+// ParsePolicyYAML parses and validates a Policy written in YAML.
+func ParsePolicyYAML(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, p.validate()
+}
+
+func (p *Policy) validate() error {
+	for i, r := range p.Rules {
+		if r.Path == "" {
+			return fmt.Errorf("rule %d: missing path", i+1)
+		}
+		for _, m := range r.Grant {
+			if slices.Contains(r.Deny, m) {
+				return fmt.Errorf("rule %d: %s: %s both granted and denied", i+1, r.Path, m)
+			}
+		}
+	}
+	return nil
+}
+
+// A Drift is a permission that differs from a Policy, or that could
+// not be checked.
+type Drift struct {
+	Path  string
+	Mode  Mode
+	Grant bool  // whether the Policy grants it, rather than denies it
+	Err   error // of the check, if it denied the permission or failed
+}
+
+func (d Drift) String() string {
+	switch {
+	case d.Err != nil && Classify(d.Err) != Permission:
+		return fmt.Sprintf("%s: %s could not be checked: %v", d.Path, d.Mode, d.Err)
+	case d.Grant:
+		return fmt.Sprintf("%s: %s is denied, but must be granted", d.Path, d.Mode)
+	}
+	return fmt.Sprintf("%s: %s is granted, but must be denied", d.Path, d.Mode)
+}
+
+// Check checks the permissions of the rules of p over the files of
+// s.FS, with s.Access, and returns those that differ, or could not be
+// checked, in the order of the rules. It returns the errors of the
+// checks other than permission errors too, joined as by multierr.Join,
+// so that a file
+// that does not exist is not taken to be readable, nor unreadable.
+func (p *Policy) Check(s *Scanner) ([]Drift, error) {
+	var drifts []Drift
+	var errs []error
+	check := func(r Rule, m Mode, grant bool) {
+		err := s.check1(r.Path, m)
+		if err != nil && Classify(err) != Permission {
+			errs = append(errs, err)
+			drifts = append(drifts, Drift{Path: r.Path, Mode: m, Grant: grant, Err: err})
+			return
+		}
+		if granted := err == nil; granted != grant {
+			drifts = append(drifts, Drift{Path: r.Path, Mode: m, Grant: grant, Err: err})
+		}
+	}
+	for _, r := range p.Rules {
+		for _, m := range r.Grant {
+			check(r, m, true)
+		}
+		for _, m := range r.Deny {
+			check(r, m, false)
+		}
+	}
+	return drifts, multierr.Join(errs...)
+}
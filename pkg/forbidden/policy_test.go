@@ -0,0 +1,63 @@
+package forbidden
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p, err := ParsePolicyYAML([]byte(`
+rules:
+  - path: etc/hosts
+    grant: r
+  - path: etc/shadow
+    deny: r
+  - path: etc/sudoers
+    grant: r
+  - path: etc/gone
+    deny: r
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := denyFS{
+		MapFS: fstest.MapFS{
+			"etc/hosts":   {},
+			"etc/shadow":  {},
+			"etc/sudoers": {},
+		},
+		names: []string{"etc/sudoers"},
+	}
+	drifts, err := p.Check(&Scanner{FS: fsys})
+	var got []string
+	for _, d := range drifts {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"etc/shadow: r is granted, but must be denied",
+		"etc/sudoers: r is denied, but must be granted",
+		"etc/gone: r could not be checked: open etc/gone: file does not exist",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("drifts are %q, want %q", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Check returned %v, want the error of etc/gone", err)
+	}
+}
+
+func TestParsePolicyInvalid(t *testing.T) {
+	for _, data := range []string{
+		`rules: [{grant: r}]`,
+		`rules: [{path: /etc/hosts, grant: r, deny: "r,w"}]`,
+		`rules: [{path: /etc/hosts, grant: q}]`,
+		`rules: [{path: /etc/hosts, read: true}]`,
+	} {
+		if _, err := ParsePolicyYAML([]byte(data)); err == nil {
+			t.Errorf("ParsePolicyYAML(%q) succeeded", data)
+		}
+	}
+}
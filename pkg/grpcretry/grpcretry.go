@@ -0,0 +1,120 @@
+// Package grpcretry retries the calls of gRPC clients as directed by a
+// retry.Policy, with interceptors for grpc.WithUnaryInterceptor and
+// grpc.WithStreamInterceptor:
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithUnaryInterceptor(grpcretry.UnaryClientInterceptor(p)),
+//		grpc.WithStreamInterceptor(grpcretry.StreamClientInterceptor(p)))
+//
+// The errors of failed calls are classified by their status codes, as
+// Retryable reports, and marked as retryable or permanent with
+// package errclass, so that the Policy retries them unless its
+// RetryIf decides otherwise. A RetryInfo in the details of a status
+// sets the delay before the next attempt, as a Retry-After header
+// does for HTTP.
+package grpcretry
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/retry"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Retryable reports whether a call that failed with the status code c
+// may succeed if made again: if the server is unavailable, out of
+// some resource, such as a quota, or aborted the call, as for a
+// concurrency conflict, or if the call took too long. Calls that were
+// invalid, not found, denied, or failed otherwise are not retried.
+func Retryable(c codes.Code) bool {
+	switch c {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// Classify returns err marked as retryable or permanent by the code of
+// its status, as Retryable reports, and, if its status asks for a
+// delay with a RetryInfo, to be retried after that delay. Errors
+// without a status are returned as they are, for errclass to decide.
+// Classify returns nil if err is nil.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if !Retryable(s.Code()) {
+		return errclass.MarkPermanent(err)
+	}
+	err = errclass.MarkRetryable(err)
+	for _, d := range s.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return retry.After(err, info.GetRetryDelay().AsDuration())
+		}
+	}
+	return err
+}
+
+// UnaryClientInterceptor returns an interceptor retrying unary calls
+// as directed by p. The error of a call that never succeeds is a
+// status error with the message of the *retry.Error, which lists the
+// attempts, and the code of the last attempt, or DeadlineExceeded or
+// Canceled if its context was done first.
+func UnaryClientInterceptor(p retry.Policy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, err := retry.Do(ctx, p, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, Classify(invoker(ctx, method, req, reply, cc, opts...))
+		})
+		return statusError(err)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor retrying the opening
+// of streams as directed by p, with errors as UnaryClientInterceptor
+// returns them. The messages sent and received over a stream once it
+// is open are not retried, as the server may have acted on them.
+func StreamClientInterceptor(p retry.Policy) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		// The stream must outlive the contexts of the attempts,
+		// which are done once retry.Do returns.
+		stream, err := retry.Do(ctx, p, func(actx context.Context) (grpc.ClientStream, error) {
+			sctx, cancel := context.WithCancel(ctx)
+			stop := context.AfterFunc(actx, cancel)
+			stream, err := streamer(sctx, desc, cc, method, opts...)
+			if stop() && err == nil {
+				return stream, nil
+			}
+			cancel()
+			if err == nil { // the attempt timed out as the stream opened
+				err = actx.Err()
+			}
+			return nil, Classify(err)
+		})
+		return stream, statusError(err)
+	}
+}
+
+// statusError returns err, of retry.Do, as a status error with the
+// same message, and the code of the last attempt, or that of the
+// context if retrying stopped because it was done. It returns nil if
+// err is nil.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *retry.Error
+	if errors.As(err, &e) && e.ContextDone() {
+		return status.Error(status.FromContextError(e.Err).Code(), err.Error())
+	}
+	s, _ := status.FromError(err)
+	return s.Err()
+}
@@ -0,0 +1,97 @@
+package grpcretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/retry"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var policy = retry.Policy{Backoff: backoff.Constant(time.Millisecond), MaxAttempts: 3}
+
+// invoker returns an invoker failing with the codes in turn, and then
+// succeeding, and counting its calls in *calls.
+func invoker(calls *int, fails ...codes.Code) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls <= len(fails) {
+			return status.Error(fails[*calls-1], "failed")
+		}
+		return nil
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	intercept := UnaryClientInterceptor(policy)
+	for _, test := range []struct {
+		fails     []codes.Code
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{[]codes.Code{codes.Unavailable, codes.ResourceExhausted}, codes.OK, 3},
+		{[]codes.Code{codes.InvalidArgument}, codes.InvalidArgument, 1},
+		{[]codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, codes.Unavailable, 3},
+	} {
+		calls := 0
+		err := intercept(context.Background(), "/test.Service/Method", nil, nil, nil, invoker(&calls, test.fails...))
+		if got := status.Code(err); got != test.wantCode || calls != test.wantCalls {
+			t.Errorf("%v: got %v after %d calls, want %v after %d", test.fails, err, calls, test.wantCode, test.wantCalls)
+		}
+	}
+}
+
+func TestUnaryClientInterceptorContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p := retry.Policy{Backoff: backoff.Constant(time.Second)}
+	calls := 0
+	err := UnaryClientInterceptor(p)(ctx, "/test.Service/Method", nil, nil, nil, invoker(&calls, codes.Unavailable, codes.Unavailable))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	if !errclass.Retryable(Classify(status.Error(codes.Unavailable, "down"))) ||
+		errclass.Retryable(Classify(status.Error(codes.PermissionDenied, "no"))) {
+		t.Error("Classify marked the codes wrongly")
+	}
+	s, _ := status.New(codes.ResourceExhausted, "quota").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)})
+	var ra interface{ RetryAfter() time.Duration }
+	if err := Classify(s.Err()); !errors.As(err, &ra) || ra.RetryAfter() != 2*time.Second {
+		t.Errorf("Classify(%v) asks for no delay of 2s", err)
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	calls := 0
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if calls++; calls == 1 {
+			return nil, status.Error(codes.Unavailable, "starting")
+		}
+		return fakeStream{ctx: ctx}, nil
+	}
+	stream, err := StreamClientInterceptor(policy)(context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Stream", streamer)
+	if err != nil || calls != 2 {
+		t.Fatalf("got %v after %d calls, want a stream after 2", err, calls)
+	}
+	if err := stream.Context().Err(); err != nil {
+		t.Errorf("the context of the stream is done: %v", err)
+	}
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s fakeStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,120 @@
+// Package idempotency guards operations that must not take effect
+// twice, such as charging a card or sending an email, when they are
+// retried: each operation gets a key, generated once by NewKey before
+// the first attempt, and Do runs the operation of a key at most once
+// to completion, returning the result it recorded in a Store to the
+// attempts that follow.
+//
+//	key := idempotency.NewKey()
+//	receipt, err := retry.Do(ctx, p, func(ctx context.Context) (Receipt, error) {
+//		return idempotency.Do(ctx, store, key, charge)
+//	})
+//
+// Do only dedupes the attempts that see the same Store. An operation
+// that fails after taking effect elsewhere, such as a request whose
+// response was lost, is released to be tried again, so the key should
+// be passed on to the service it calls as well, for that service to
+// dedupe it: KeyFrom returns it in the operation, and the Header of
+// HTTP requests carries it, which also lets retry.Transport retry
+// such requests whatever their method.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// Header is the HTTP request header carrying idempotency keys.
+const Header = "Idempotency-Key"
+
+// ErrInProgress is the error of Do when the operation of its key is
+// being run by another caller. It is retryable, as the other caller
+// is likely to complete the operation, or to release it.
+var ErrInProgress = errors.New("operation in progress")
+
+// NewKey returns a new random key, of 26 letters and digits.
+func NewKey() string {
+	return rand.Text()
+}
+
+type keyContextKey struct{}
+
+// WithKey returns a copy of ctx carrying key.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFrom returns the key carried by ctx, as that of the operation Do
+// is running, if any.
+func KeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(string)
+	return key, ok
+}
+
+// A Store records which operations were run, by key, and their
+// results. Its methods may be called concurrently, by several
+// processes if it is shared by them, such as a table of a database.
+type Store interface {
+	// Reserve claims key for an operation about to run. If the
+	// operation of key has completed, it returns its result and
+	// done set; if it is claimed already, an error wrapping
+	// ErrInProgress.
+	Reserve(ctx context.Context, key string) (result []byte, done bool, err error)
+
+	// Complete records the result of the operation of key, which
+	// it has claimed.
+	Complete(ctx context.Context, key string, result []byte) error
+
+	// Release gives up the claim of key, so that the operation may
+	// be run again.
+	Release(ctx context.Context, key string) error
+}
+
+// Do runs op with a context carrying key, unless the operation of key
+// has completed already, as recorded in s, in which case it returns
+// the result recorded, or unless another caller is running it, in
+// which case it returns an error wrapping ErrInProgress. Results are
+// recorded as JSON. If op fails, its key is released, and Do returns
+// its error. With an empty key, Do uses that carried by ctx.
+func Do[T any](ctx context.Context, s Store, key string, op func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if key == "" {
+		var ok bool
+		if key, ok = KeyFrom(ctx); !ok || key == "" {
+			return zero, errclass.MarkPermanent(errors.New("no idempotency key"))
+		}
+	}
+	result, done, err := s.Reserve(ctx, key)
+	switch {
+	case errors.Is(err, ErrInProgress):
+		return zero, errclass.MarkRetryable(err)
+	case err != nil:
+		return zero, fmt.Errorf("reserving idempotency key: %w", err)
+	case done:
+		var v T
+		if err := json.Unmarshal(result, &v); err != nil {
+			return zero, errclass.MarkPermanent(fmt.Errorf("decoding result of idempotency key %s: %w", key, err))
+		}
+		return v, nil
+	}
+	v, err := op(WithKey(ctx, key))
+	if err != nil {
+		if releaseErr := s.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("releasing idempotency key: %w", releaseErr))
+		}
+		return v, err
+	}
+	if result, err = json.Marshal(v); err == nil {
+		err = s.Complete(context.WithoutCancel(ctx), key, result)
+	}
+	if err != nil {
+		// The operation took effect, so is not to be retried.
+		return v, errclass.MarkPermanent(fmt.Errorf("recording result of idempotency key %s: %w", key, err))
+	}
+	return v, nil
+}
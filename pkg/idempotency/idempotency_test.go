@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+func TestDo(t *testing.T) {
+	var store MemoryStore
+	key := NewKey()
+	runs := 0
+	charge := func(ctx context.Context) (int, error) {
+		if k, _ := KeyFrom(ctx); k != key {
+			t.Errorf("op got key %q, want %q", k, key)
+		}
+		runs++
+		if runs == 1 {
+			return 0, errors.New("declined")
+		}
+		return 42, nil
+	}
+	ctx := context.Background()
+	if _, err := Do(ctx, &store, key, charge); err == nil {
+		t.Fatal("Do of a failing op succeeded")
+	}
+	for range 2 {
+		v, err := Do(ctx, &store, key, charge)
+		if v != 42 || err != nil {
+			t.Errorf("Do = %v, %v, want 42", v, err)
+		}
+	}
+	if runs != 2 {
+		t.Errorf("op ran %d times, want 2: once failing, and once more", runs)
+	}
+}
+
+func TestDoInProgress(t *testing.T) {
+	var store MemoryStore
+	key := NewKey()
+	_, err := Do(WithKey(context.Background(), key), &store, "", func(ctx context.Context) (string, error) {
+		return Do(ctx, &store, key, func(context.Context) (string, error) { return "twice", nil })
+	})
+	if !errors.Is(err, ErrInProgress) || !errclass.Retryable(err) {
+		t.Errorf("nested Do returned %v, want a retryable ErrInProgress", err)
+	}
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := &MemoryStore{TTL: time.Minute, Clock: clk}
+	ctx := context.Background()
+	store.Reserve(ctx, "k")
+	store.Complete(ctx, "k", []byte(`1`))
+	if _, done, _ := store.Reserve(ctx, "k"); !done {
+		t.Error("Reserve of a completed key found it not done")
+	}
+	clk.Advance(2 * time.Minute)
+	if _, done, err := store.Reserve(ctx, "k"); done || err != nil {
+		t.Errorf("Reserve of an expired key = %t, %v; want it claimed anew", done, err)
+	}
+}
@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// A MemoryStore is a Store keeping its records in memory, for the
+// operations of a single process. The zero MemoryStore keeps them
+// forever.
+type MemoryStore struct {
+	// TTL, if positive, is how long records are kept, after which
+	// the operations of their keys may be run again. It also limits
+	// how long a claim lasts, in case its caller never completes or
+	// releases it.
+	TTL time.Duration
+
+	// Clock, if non-nil, tells the time instead of clock.Real.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	records map[string]*record
+	swept   time.Time // when expired records were last deleted
+}
+
+type record struct {
+	result  []byte
+	done    bool
+	expires time.Time // or zero
+}
+
+func (s *MemoryStore) now() time.Time {
+	if s.Clock == nil {
+		return clock.Real.Now()
+	}
+	return s.Clock.Now()
+}
+
+// Reserve claims key, as described for Store.
+func (s *MemoryStore) Reserve(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	if r, ok := s.records[key]; ok && (r.expires.IsZero() || now.Before(r.expires)) {
+		if !r.done {
+			return nil, false, fmt.Errorf("%w: idempotency key %s", ErrInProgress, key)
+		}
+		return r.result, true, nil
+	}
+	if s.records == nil {
+		s.records = make(map[string]*record)
+	}
+	s.records[key] = &record{expires: s.expiry(now)}
+	return nil, false, nil
+}
+
+// Complete records the result of key, as described for Store.
+func (s *MemoryStore) Complete(_ context.Context, key string, result []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records == nil {
+		s.records = make(map[string]*record)
+	}
+	s.records[key] = &record{result: result, done: true, expires: s.expiry(s.now())}
+	return nil
+}
+
+// Release gives up the claim of key, as described for Store.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.records[key]; ok && !r.done {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+// expiry returns when a record made at now expires, or zero.
+func (s *MemoryStore) expiry(now time.Time) time.Time {
+	if s.TTL <= 0 {
+		return time.Time{}
+	}
+	return now.Add(s.TTL)
+}
+
+// sweep deletes the expired records, at most once per TTL.
+func (s *MemoryStore) sweep(now time.Time) {
+	if s.TTL <= 0 || now.Sub(s.swept) < s.TTL {
+		return
+	}
+	for key, r := range s.records {
+		if !now.Before(r.expires) {
+			delete(s.records, key)
+		}
+	}
+	s.swept = now
+}
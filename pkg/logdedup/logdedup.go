@@ -0,0 +1,207 @@
+// Package logdedup collapses the records of a log/slog logger that
+// repeat one another, such as those logged by the hooks of a retried
+// operation that keeps failing with the same error, so that a server
+// refusing connections for a minute shows up as a few lines saying so
+// rather than as one per attempt:
+//
+//	h := logdedup.NewHandler(slog.NewTextHandler(os.Stderr, nil), &logdedup.Options{
+//		Interval: 30 * time.Second,
+//		Vary:     []string{"attempt", "delay", "elapsed"},
+//	})
+//	logger := slog.New(h)
+//	defer h.Flush(context.Background())
+//
+// The first of the records that repeat one another is logged as it
+// is, and the following ones at most once per Interval, with the
+// number of records they stand for as an attribute named by
+// RepeatedKey.
+package logdedup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// RepeatedKey is the key of the attribute added to the records that
+// stand for those suppressed: the number of records, since the last
+// one logged, that it stands for, itself included.
+const RepeatedKey = "repeated"
+
+// maxKeys limits the different records remembered, beyond which
+// those suppressed are flushed, and all are forgotten.
+const maxKeys = 1024
+
+// Options configure a Handler.
+type Options struct {
+	// Interval is how often records repeating one another are
+	// logged. Zero means that only the first one is, and the others
+	// only once Flush is called.
+	Interval time.Duration
+
+	// Vary names the attributes whose values are ignored when
+	// comparing records, such as the numbers and timings of
+	// attempts. Records repeat one another if they have the same
+	// level, message, and other attributes.
+	Vary []string
+
+	// Clock, if non-nil, tells the time instead of clock.Real.
+	Clock clock.Clock
+}
+
+// A Handler is a slog.Handler passing the records it handles on to
+// another, except for those repeating one another, as described for
+// the package. Its methods may be called concurrently.
+type Handler struct {
+	h      slog.Handler
+	prefix string // the groups and attributes added by WithGroup and WithAttrs
+	s      *state
+}
+
+// state is what the Handlers derived from one by WithAttrs and
+// WithGroup share.
+type state struct {
+	opts Options
+	mu   sync.Mutex
+	seen map[string]*entry
+}
+
+// An entry is what a Handler remembers of the records having a key.
+type entry struct {
+	h       slog.Handler // to handle the records
+	logged  time.Time    // when one of the records was last logged
+	repeats int          // the records suppressed since
+	last    slog.Record  // the last of those
+}
+
+// NewHandler returns a Handler passing records to h, configured by
+// opts, which may be nil for the zero Options.
+func NewHandler(h slog.Handler, opts *Options) *Handler {
+	s := &state{seen: make(map[string]*entry)}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Clock == nil {
+		s.opts.Clock = clock.Real
+	}
+	return &Handler{h: h, s: s}
+}
+
+// Enabled reports whether the handler passed records handles level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle passes r on, unless it repeats a record logged less than the
+// Interval ago.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	key := h.key(r)
+	s := h.s
+	s.mu.Lock()
+	now := s.opts.Clock.Now()
+	e, ok := s.seen[key]
+	if !ok {
+		var flushed []*entry
+		if len(s.seen) >= maxKeys {
+			flushed = s.forget()
+		}
+		s.seen[key] = &entry{h: h.h, logged: now}
+		s.mu.Unlock()
+		return errors.Join(h.h.Handle(ctx, r), logRepeats(ctx, flushed))
+	}
+	e.repeats++
+	if now.Sub(e.logged) < s.opts.Interval || s.opts.Interval == 0 {
+		e.last = r.Clone()
+		s.mu.Unlock()
+		return nil
+	}
+	n := e.repeats
+	e.repeats, e.last, e.logged = 0, slog.Record{}, now
+	s.mu.Unlock()
+	r = r.Clone()
+	r.AddAttrs(slog.Int(RepeatedKey, n))
+	return h.h.Handle(ctx, r)
+}
+
+// Flush logs the last of the records suppressed since one was logged,
+// for each record repeated, and forgets all records, so that the next
+// ones are logged as they come.
+func (h *Handler) Flush(ctx context.Context) error {
+	h.s.mu.Lock()
+	flushed := h.s.forget()
+	h.s.mu.Unlock()
+	return logRepeats(ctx, flushed)
+}
+
+// forget empties s.seen, and returns its entries having records
+// suppressed.
+func (s *state) forget() []*entry {
+	var flushed []*entry
+	for _, e := range s.seen {
+		if e.repeats > 0 {
+			flushed = append(flushed, e)
+		}
+	}
+	clear(s.seen)
+	return flushed
+}
+
+// logRepeats logs the last record suppressed of each entry, in the
+// order they were made.
+func logRepeats(ctx context.Context, entries []*entry) error {
+	slices.SortFunc(entries, func(a, b *entry) int { return a.last.Time.Compare(b.last.Time) })
+	var errs []error
+	for _, e := range entries {
+		r := e.last
+		r.AddAttrs(slog.Int(RepeatedKey, e.repeats))
+		errs = append(errs, e.h.Handle(ctx, r))
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a Handler passing records on to the handler made
+// by the WithAttrs of that of h, and sharing what it remembers with h.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.prefix)
+	for _, a := range attrs {
+		writeAttr(&b, a)
+	}
+	return &Handler{h: h.h.WithAttrs(attrs), prefix: b.String(), s: h.s}
+}
+
+// WithGroup returns a Handler passing records on to the handler made
+// by the WithGroup of that of h, and sharing what it remembers with h.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{h: h.h.WithGroup(name), prefix: h.prefix + name + ".", s: h.s}
+}
+
+// key returns what records repeating r have in common with it.
+func (h *Handler) key(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	b.WriteByte(' ')
+	b.WriteString(h.prefix)
+	r.Attrs(func(a slog.Attr) bool {
+		if !slices.Contains(h.s.opts.Vary, a.Key) {
+			writeAttr(&b, a)
+		}
+		return true
+	})
+	return b.String()
+}
+
+func writeAttr(b *strings.Builder, a slog.Attr) {
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(a.Value.Resolve().String())
+	b.WriteByte(' ')
+}
@@ -0,0 +1,53 @@
+package logdedup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	clk := clock.NewInstant(time.Unix(0, 0))
+	text := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	h := NewHandler(text, &Options{Interval: 10 * time.Second, Vary: []string{"attempt"}, Clock: clk})
+	logger := slog.New(h).With("target", "db:5432")
+	refused := errors.New("connection refused")
+	for i := 1; i <= 40; i++ {
+		err := refused
+		if i == 25 {
+			err = errors.New("timeout")
+		}
+		logger.Warn("not ready", "attempt", i, "err", err)
+		clk.Advance(time.Second)
+	}
+	logger.Info("ready")
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`level=WARN msg="not ready" target=db:5432 attempt=1 err="connection refused"`,
+		`level=WARN msg="not ready" target=db:5432 attempt=11 err="connection refused" repeated=10`,
+		`level=WARN msg="not ready" target=db:5432 attempt=21 err="connection refused" repeated=10`,
+		`level=WARN msg="not ready" target=db:5432 attempt=25 err=timeout`,
+		`level=WARN msg="not ready" target=db:5432 attempt=31 err="connection refused" repeated=9`,
+		`level=INFO msg=ready target=db:5432`,
+		`level=WARN msg="not ready" target=db:5432 attempt=40 err="connection refused" repeated=9`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
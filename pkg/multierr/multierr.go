@@ -0,0 +1,103 @@
+// Package multierr combines several errors into one, for operations
+// that go on after a failure, such as checking many files, and
+// report all the failures at the end.
+//
+// Unlike the errors of errors.Join, those of this package list each
+// distinct message once, with the number of errors that had it, as
+// in
+//
+//	3 errors:
+//		* open /etc/shadow: permission denied (2 times)
+//		* open /does/not/exist: no such file or directory
+//
+// Filter, Count, and AllAs look at all the errors combined, in those
+// of this package and of errors.Join alike, where errors.Is and
+// errors.As stop at the first that matches.
+package multierr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Error combines several errors. Like the errors of errors.Join,
+// it makes errors.Is and errors.As look at each of them.
+type Error struct {
+	Errs []error // non-nil, in the order they were added
+}
+
+func (e *Error) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	var msgs []string
+	counts := make(map[string]int)
+	for _, err := range e.Errs {
+		msg := err.Error()
+		if counts[msg] == 0 {
+			msgs = append(msgs, msg)
+		}
+		counts[msg]++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors:", len(e.Errs))
+	for _, msg := range msgs {
+		// Indent continuation lines under the bullet.
+		fmt.Fprintf(&b, "\n\t* %s", strings.ReplaceAll(msg, "\n", "\n\t  "))
+		if n := counts[msg]; n > 1 {
+			fmt.Fprintf(&b, " (%d times)", n)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors e combines.
+func (e *Error) Unwrap() []error { return e.Errs }
+
+// Append returns an error combining err and errs, leaving out nil
+// errors and flattening those that are *Error. It returns nil if all
+// of them are nil, and the only error if there is just one.
+func Append(err error, errs ...error) error {
+	var all []error
+	for _, err := range append([]error{err}, errs...) {
+		switch e := err.(type) {
+		case nil:
+		case *Error:
+			all = append(all, e.Errs...)
+		default:
+			all = append(all, err)
+		}
+	}
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+	return &Error{all}
+}
+
+// Join returns an error combining errs, as by Append. It is a drop-in
+// replacement for errors.Join.
+func Join(errs ...error) error {
+	return Append(nil, errs...)
+}
+
+// A Collector collects errors one at a time. The zero Collector is
+// ready to use.
+type Collector struct {
+	errs []error
+}
+
+// Add adds err, unless it is nil.
+func (c *Collector) Add(err error) {
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+// Err returns an error combining the errors added, as by Join, or nil
+// if none were.
+func (c *Collector) Err() error {
+	return Join(c.errs...)
+}
@@ -0,0 +1,75 @@
+package multierr
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	if err := c.Err(); err != nil {
+		t.Fatalf("Err of an empty Collector = %v, want nil", err)
+	}
+	denied := &fs.PathError{Op: "open", Path: "/etc/shadow", Err: fs.ErrPermission}
+	c.Add(denied)
+	c.Add(nil)
+	c.Add(Join(denied, &fs.PathError{Op: "open", Path: "/nope", Err: fs.ErrNotExist}))
+
+	err := c.Err()
+	want := "3 errors:\n" +
+		"\t* open /etc/shadow: permission denied (2 times)\n" +
+		"\t* open /nope: file does not exist"
+	if err == nil || err.Error() != want {
+		t.Errorf("Err() = %q, want %q", err, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is does not find fs.ErrNotExist")
+	}
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "/etc/shadow" {
+		t.Errorf("errors.As found %v, want the first *fs.PathError", pe)
+	}
+}
+
+func TestAppendOne(t *testing.T) {
+	err := errors.New("only")
+	if got := Append(nil, nil, err, nil); got != err {
+		t.Errorf("Append of one error = %v, want that error itself", got)
+	}
+}
+
+func TestTree(t *testing.T) {
+	denied := func(path string) error { return &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission} }
+	missing := &fs.PathError{Op: "open", Path: "/nope", Err: fs.ErrNotExist}
+	err := Join(
+		denied("/etc/shadow"),
+		fmt.Errorf("reading config: %w", errors.Join(missing, denied("/root/.netrc"))),
+		errors.New("disk full"),
+	)
+	if n := len(Leaves(err)); n != 4 {
+		t.Errorf("%d leaves, want 4", n)
+	}
+	if n := Count(err, Is(fs.ErrPermission)); n != 2 {
+		t.Errorf("Count of fs.ErrPermission = %d, want 2", n)
+	}
+	var paths []string
+	for _, pe := range AllAs[*fs.PathError](err) {
+		paths = append(paths, pe.Path)
+	}
+	if want := []string{"/etc/shadow", "/nope", "/root/.netrc"}; !slices.Equal(paths, want) {
+		t.Errorf("AllAs found %v, want %v", paths, want)
+	}
+	if !AnyIs(err, fs.ErrExist, fs.ErrNotExist) || AnyIs(err, fs.ErrExist) {
+		t.Error("AnyIs is wrong")
+	}
+	rest := Filter(err, func(err error) bool { return !errors.Is(err, fs.ErrPermission) })
+	if want := "2 errors:\n\t* open /nope: file does not exist\n\t* disk full"; rest == nil || rest.Error() != want {
+		t.Errorf("Filter = %q, want %q", rest, want)
+	}
+	if Filter(err, Is(fs.ErrClosed)) != nil || Leaves(nil) != nil {
+		t.Error("Filter or Leaves of no errors is not nil")
+	}
+}
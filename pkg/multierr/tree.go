@@ -0,0 +1,90 @@
+package multierr
+
+import "errors"
+
+// The functions below look at each of the errors combined in a tree
+// of errors, where errors.Is and errors.As stop at the first match.
+// The leaves of the tree are the errors combined by the errors that
+// have an Unwrap() []error method, such as those of this package and
+// of errors.Join, found through those wrapping them, recursively. An
+// error that combines none is a leaf by itself.
+
+// Leaves returns the leaves of the tree of err, in order, or nil if
+// err is nil.
+func Leaves(err error) []error {
+	var leaves []error
+	walk(err, func(leaf error) { leaves = append(leaves, leaf) })
+	return leaves
+}
+
+// walk calls f with each leaf of the tree of err.
+func walk(err error, f func(error)) {
+	if err == nil {
+		return
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if multi, ok := e.(interface{ Unwrap() []error }); ok {
+			for _, e := range multi.Unwrap() {
+				walk(e, f)
+			}
+			return
+		}
+	}
+	f(err)
+}
+
+// Is returns a function reporting whether an error is target, as by
+// errors.Is, for Filter and Count.
+func Is(target error) func(error) bool {
+	return func(err error) bool { return errors.Is(err, target) }
+}
+
+// AnyIs reports whether err is any of targets, as by errors.Is.
+func AnyIs(err error, targets ...error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllAs returns the first error of type E, as found by errors.As, in
+// each leaf of the tree of err that has one, in order.
+func AllAs[E error](err error) []E {
+	var all []E
+	walk(err, func(leaf error) {
+		var e E
+		if errors.As(leaf, &e) {
+			all = append(all, e)
+		}
+	})
+	return all
+}
+
+// Filter returns an error combining the leaves of the tree of err for
+// which match reports true, as by Join, or nil if there are none, for
+// example to report the failures other than those expected:
+//
+//	err = multierr.Filter(err, func(err error) bool { return !errors.Is(err, fs.ErrNotExist) })
+func Filter(err error, match func(error) bool) error {
+	var c Collector
+	walk(err, func(leaf error) {
+		if match(leaf) {
+			c.Add(leaf)
+		}
+	})
+	return c.Err()
+}
+
+// Count returns the number of leaves of the tree of err for which
+// match reports true, for example Is(fs.ErrPermission).
+func Count(err error, match func(error) bool) int {
+	n := 0
+	walk(err, func(leaf error) {
+		if match(leaf) {
+			n++
+		}
+	})
+	return n
+}
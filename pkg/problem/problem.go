@@ -0,0 +1,194 @@
+// Package problem renders errors as the problem details of RFC 7807,
+// the application/problem+json documents that HTTP APIs respond with
+// to report failures, and parses such documents back into errors.
+//
+// The code of an error, as given by errcode.Code, sets the status of
+// its document and appears as its code member; the fields of an
+// error, as given by errfields.Attrs, appear as other extension
+// members; and the errors combined by a multi-error, such as those of
+// errors.Join and multierr, appear as its errors member.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+)
+
+// ContentType is the media type of problem details documents.
+const ContentType = "application/problem+json"
+
+// A Details is a problem details document. As parsed by Parse, it is
+// an error describing a failure reported by a server.
+type Details struct {
+	Type     string // a URI reference identifying the type of problem
+	Title    string // a short summary of the type of problem
+	Status   int    // the HTTP status code
+	Detail   string // an explanation of this occurrence of the problem
+	Instance string // a URI reference identifying this occurrence
+
+	// Code is the name of the errcode.Entry of the error.
+	Code string
+
+	// Errors describe the errors combined by a multi-error.
+	Errors []*Details
+
+	// Extensions holds any other members.
+	Extensions map[string]any
+}
+
+// FromError returns the problem details of err. Its status is that of
+// the code of err, or 500 Internal Server Error.
+func FromError(err error) *Details {
+	d := &Details{
+		Type:   "about:blank",
+		Status: http.StatusInternalServerError,
+		Detail: err.Error(),
+	}
+	if c := errcode.Code(err); c != nil {
+		d.Code = c.Name
+		if c.HTTPStatus != 0 {
+			d.Status = c.HTTPStatus
+		}
+	}
+	d.Title = http.StatusText(d.Status)
+	for _, a := range errfields.Attrs(err) {
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any)
+		}
+		if _, ok := d.Extensions[a.Key]; !ok { // the outermost field wins
+			d.Extensions[a.Key] = a.Value.Resolve().Any()
+		}
+	}
+	var multi interface{ Unwrap() []error }
+	if errors.As(err, &multi) {
+		for _, err := range multi.Unwrap() {
+			d.Errors = append(d.Errors, FromError(err))
+		}
+	}
+	return d
+}
+
+// Write responds to an HTTP request with the problem details of err.
+func Write(w http.ResponseWriter, err error) {
+	d := FromError(err)
+	b, e := json.Marshal(d)
+	if e != nil {
+		http.Error(w, err.Error(), d.Status)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(d.Status)
+	w.Write(b)
+}
+
+// Parse parses a problem details document.
+func Parse(r io.Reader) (*Details, error) {
+	var d Details
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, fmt.Errorf("parsing problem details: %w", err)
+	}
+	return &d, nil
+}
+
+// FromResponse parses the body of resp if it is a problem details
+// document, and reports false if it is not.
+func FromResponse(resp *http.Response) (*Details, bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != ContentType {
+		return nil, false, nil
+	}
+	d, err := Parse(resp.Body)
+	return d, true, err
+}
+
+// Error returns the detail of d, or else its title.
+func (d *Details) Error() string {
+	msg := d.Detail
+	if msg == "" {
+		msg = d.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(d.Status)
+	}
+	return msg
+}
+
+// Unwrap returns the errors of d.
+func (d *Details) Unwrap() []error {
+	errs := make([]error, len(d.Errors))
+	for i, e := range d.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// members are the members of Details other than its extensions.
+type members struct {
+	Type     string     `json:"type,omitempty"`
+	Title    string     `json:"title,omitempty"`
+	Status   int        `json:"status,omitempty"`
+	Detail   string     `json:"detail,omitempty"`
+	Instance string     `json:"instance,omitempty"`
+	Code     string     `json:"code,omitempty"`
+	Errors   []*Details `json:"errors,omitempty"`
+}
+
+var memberNames = []string{"type", "title", "status", "detail", "instance", "code", "errors"}
+
+func (d *Details) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(d.Extensions)+len(memberNames))
+	for k, v := range d.Extensions {
+		m[k] = v
+	}
+	b, err := json.Marshal(members{d.Type, d.Title, d.Status, d.Detail, d.Instance, d.Code, d.Errors})
+	if err != nil {
+		return nil, err
+	}
+	var fixed map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fixed); err != nil {
+		return nil, err
+	}
+	for k, v := range fixed {
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+func (d *Details) UnmarshalJSON(b []byte) error {
+	var fixed members
+	if err := json.Unmarshal(b, &fixed); err != nil {
+		return err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	*d = Details{fixed.Type, fixed.Title, fixed.Status, fixed.Detail, fixed.Instance, fixed.Code, fixed.Errors, nil}
+	for k, v := range all {
+		if isMember(k) {
+			continue
+		}
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any)
+		}
+		d.Extensions[k] = v
+	}
+	return nil
+}
+
+func isMember(name string) bool {
+	for _, m := range memberNames {
+		if strings.EqualFold(m, name) {
+			return true
+		}
+	}
+	return false
+}
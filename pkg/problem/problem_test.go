@@ -0,0 +1,52 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+var testUnavailable = errcode.Register(errcode.Entry{Name: "test.unavailable", HTTPStatus: http.StatusServiceUnavailable})
+
+func TestRoundTrip(t *testing.T) {
+	err := errcode.With(errfields.With(
+		fmt.Errorf("dependencies not ready: %w", multierr.Join(errors.New("db down"), errors.New("cache down"))),
+		"attempts", 3), testUnavailable)
+
+	rec := httptest.NewRecorder()
+	Write(rec, err)
+	resp := rec.Result()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	d, ok, perr := FromResponse(resp)
+	if !ok || perr != nil {
+		t.Fatalf("FromResponse = %v, %v, %v", d, ok, perr)
+	}
+	if d.Code != "test.unavailable" || d.Title != "Service Unavailable" || d.Detail != err.Error() {
+		t.Errorf("parsed %+v, want code, title, and detail of the error", d)
+	}
+	if d.Extensions["attempts"] != float64(3) {
+		t.Errorf("extensions %v, want attempts 3", d.Extensions)
+	}
+	if len(d.Errors) != 2 || d.Errors[1].Detail != "cache down" {
+		t.Errorf("errors %v, want db down and cache down", d.Errors)
+	}
+	if d.Error() != err.Error() {
+		t.Errorf("Error() = %q, want %q", d.Error(), err.Error())
+	}
+}
+
+func TestFromResponseOther(t *testing.T) {
+	rec := httptest.NewRecorder()
+	http.Error(rec, "nope", http.StatusNotFound)
+	if _, ok, _ := FromResponse(rec.Result()); ok {
+		t.Error("FromResponse parsed a text/plain response")
+	}
+}
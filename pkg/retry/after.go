@@ -0,0 +1,62 @@
+package retry
+
+import "time"
+
+// A retryAfterer is an error that knows how long to wait before the
+// failed operation should be retried, like an HTTP response with a
+// Retry-After header.
+type retryAfterer interface {
+	RetryAfter() time.Duration
+}
+
+// After returns an error wrapping err that makes Do wait d before
+// the next attempt instead of the delay computed by the Backoff.
+func After(err error, d time.Duration) error {
+	return &afterError{err, d}
+}
+
+type afterError struct {
+	err error
+	d   time.Duration
+}
+
+func (e *afterError) Error() string             { return e.err.Error() }
+func (e *afterError) Unwrap() error             { return e.err }
+func (e *afterError) RetryAfter() time.Duration { return e.d }
+
+// retryAfter returns the delay requested by err, if any. Any error in
+// err's chain with a RetryAfter() time.Duration method requests one.
+func retryAfter(err error) (time.Duration, bool) {
+	// Walked here rather than with errors.As, which would allocate
+	// for every failed attempt.
+	for err != nil {
+		if ra, ok := err.(retryAfterer); ok {
+			return positive(ra.RetryAfter())
+		}
+		if x, ok := err.(interface{ As(any) bool }); ok {
+			var ra retryAfterer
+			if x.As(&ra) {
+				return positive(ra.RetryAfter())
+			}
+		}
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range x.Unwrap() {
+				if d, ok := retryAfter(err); ok {
+					return d, true
+				}
+			}
+			return 0, false
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// positive returns d, and whether it is positive.
+func positive(d time.Duration) (time.Duration, bool) {
+	return d, d > 0
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"context"
+	"iter"
+)
+
+// An Attempt is one of the attempts of a loop over Policy.Attempts.
+type Attempt struct {
+	Number int // of the attempt, from 1
+
+	ctx context.Context
+	err error
+}
+
+// Context returns the context of the attempt, which is done when the
+// attempt has exceeded the AttemptTimeout of the Policy, or the
+// retrying has to stop.
+func (a *Attempt) Context() context.Context { return a.ctx }
+
+// Fail reports that the attempt failed with err, to be retried, after
+// the loop body ends, if the Policy allows it. An attempt that does
+// not fail, or fails with a nil err, ends the loop.
+func (a *Attempt) Fail(err error) { a.err = err }
+
+// Attempts returns the attempts of an operation retried as directed
+// by p, for the caller to make in the body of a loop, as an
+// alternative to Do when the operation is not one function:
+//
+//	var err error
+//	for a := range p.Attempts(ctx) {
+//		if err = send(a.Context(), msg); err != nil {
+//			a.Fail(err)
+//			continue
+//		}
+//		break
+//	}
+//
+// The loop is run as Do runs op, with the same delays, deadlines, and
+// limits, and ends once an attempt does not fail, the attempts allowed
+// by p are used up, the body breaks out of it, or ctx is done; the
+// caller tells which by the error of its last attempt and ctx.Err. A
+// Policy that is Strict and invalid makes no attempts.
+func (p Policy) Attempts(ctx context.Context) iter.Seq[*Attempt] {
+	return func(yield func(*Attempt) bool) {
+		n := 0
+		Do(ctx, p, func(ctx context.Context) (struct{}, error) {
+			n++
+			a := &Attempt{Number: n, ctx: ctx}
+			if !yield(a) {
+				return struct{}{}, nil
+			}
+			return struct{}{}, a.err
+		})
+	}
+}
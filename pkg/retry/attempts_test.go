@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestAttempts(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	p := Policy{Backoff: backoff.Exponential(time.Second, 10*time.Second), MaxAttempts: 4, Clock: clk}
+	var numbers []int
+	for a := range p.Attempts(context.Background()) {
+		numbers = append(numbers, a.Number)
+		if a.Context().Err() != nil {
+			t.Errorf("attempt %d is done already", a.Number)
+		}
+		if a.Number < 3 {
+			a.Fail(errors.New("not yet"))
+		}
+	}
+	if !slices.Equal(numbers, []int{1, 2, 3}) {
+		t.Errorf("made attempts %v, want 1 to 3", numbers)
+	}
+	if got, want := clk.Timers(), []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+
+	for _, test := range []struct {
+		name string
+		fail error
+		stop int // break out of the loop at this attempt
+		want int
+	}{
+		{"run out", errors.New("no"), 0, 4},
+		{"permanent", Permanent(errors.New("no")), 0, 1},
+		{"break", errors.New("no"), 2, 2},
+	} {
+		n := 0
+		for a := range p.Attempts(context.Background()) {
+			n++
+			a.Fail(test.fail)
+			if a.Number == test.stop {
+				break
+			}
+		}
+		if n != test.want {
+			t.Errorf("%s: made %d attempts, want %d", test.name, n, test.want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	for a := range (Policy{Backoff: backoff.Constant(time.Millisecond)}).Attempts(ctx) {
+		n++
+		a.Fail(errors.New("no"))
+		if n == 2 {
+			cancel()
+		}
+	}
+	if n != 2 {
+		t.Errorf("made %d attempts until canceled, want 2", n)
+	}
+}
@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+var errBench = errors.New("not yet")
+
+func BenchmarkDoSuccess(b *testing.B) {
+	ctx := context.Background()
+	p := Policy{MaxAttempts: 3}
+	op := func(context.Context) (int, error) { return 1, nil }
+	b.ReportAllocs()
+	for b.Loop() {
+		Do(ctx, p, op)
+	}
+}
+
+// BenchmarkDoRetries measures the cost of the retrying itself, with
+// delays of zero.
+func BenchmarkDoRetries(b *testing.B) {
+	ctx := context.Background()
+	p := Policy{Backoff: backoff.Constant(0)}
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			calls := 0
+			op := func(context.Context) (int, error) {
+				if calls++; calls%n != 0 {
+					return 0, errBench
+				}
+				return 1, nil
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				Do(ctx, p, op)
+			}
+		})
+	}
+}
+
+// BenchmarkDoSleeps measures retrying with delays, which sleep on
+// timers.
+func BenchmarkDoSleeps(b *testing.B) {
+	ctx := context.Background()
+	p := Policy{Backoff: backoff.Constant(time.Nanosecond), MaxAttempts: 10}
+	op := func(context.Context) (int, error) { return 0, errBench }
+	b.ReportAllocs()
+	for b.Loop() {
+		Do(ctx, p, op)
+	}
+}
+
+func TestRetryAfterAllocs(t *testing.T) {
+	err := fmt.Errorf("attempt: %w", errBench)
+	if n := testing.AllocsPerRun(100, func() { retryAfter(err) }); n != 0 {
+		t.Errorf("retryAfter allocates %v times, want 0", n)
+	}
+}
@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A Budget limits the rate of retries of any number of operations
+// retried at the same time, such as waits for dozens of servers, so
+// that together they don't flood the network. Each retry takes a
+// token from the Budget, waiting for one if there is none left.
+// A Budget may be shared by concurrent calls of Do.
+type Budget struct {
+	rate  float64 // tokens added per second
+	burst float64 // tokens held at most
+
+	mu     sync.Mutex
+	tokens float64 // negative when retries are waiting for tokens
+	last   time.Time
+}
+
+// NewBudget returns a Budget allowing rate retries per second on
+// average, and bursts of up to burst retries, of which it starts
+// with as many.
+func NewBudget(rate float64, burst int) *Budget {
+	return &Budget{rate: rate, burst: float64(max(burst, 1)), tokens: float64(max(burst, 1))}
+}
+
+// reserve takes a token at time now and returns how long the retry
+// has to wait for it.
+func (b *Budget) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	if b.rate <= 0 {
+		return math.MaxInt64
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
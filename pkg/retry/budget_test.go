@@ -0,0 +1,28 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetReserve(t *testing.T) {
+	b := NewBudget(2, 2)
+	t0 := time.Unix(0, 0)
+	for i, test := range []struct {
+		at   time.Duration // since t0
+		want time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+		{0, time.Second},
+		{time.Second, 500 * time.Millisecond}, // the tokens added went to the waiting retries
+		{10 * time.Second, 0},                 // refilled up to the burst
+		{10 * time.Second, 0},
+		{10 * time.Second, 500 * time.Millisecond},
+	} {
+		if got := b.reserve(t0.Add(test.at)); got != test.want {
+			t.Errorf("reserve %d at %v: wait %v, want %v", i+1, test.at, got, test.want)
+		}
+	}
+}
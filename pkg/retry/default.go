@@ -0,0 +1,75 @@
+package retry
+
+import "sync/atomic"
+
+// defaultPolicy is the Policy set by SetDefault, or nil.
+var defaultPolicy atomic.Pointer[Policy]
+
+// SetDefault sets the Policy that the Policies given to Do inherit
+// their settings from: each field of a Policy that is left zero takes
+// the value of the same field of p, and Strict is set if either sets
+// it, so that a program can set how all its operations are retried,
+// such as with a Recorder, a Budget, or a Timeout, in one place.
+// Settings that are given still override those of p.
+//
+// SetDefault is meant to be called once, as the program starts,
+// before Do is called. It panics if it is called again.
+func SetDefault(p Policy) {
+	if !defaultPolicy.CompareAndSwap(nil, &p) {
+		panic("retry: SetDefault called twice")
+	}
+}
+
+// Default returns the Policy set by SetDefault, or the zero Policy.
+func Default() Policy {
+	if d := defaultPolicy.Load(); d != nil {
+		return *d
+	}
+	return Policy{}
+}
+
+// inherit returns p with its zero fields set from the default Policy.
+func (p Policy) inherit() Policy {
+	d := defaultPolicy.Load()
+	if d == nil {
+		return p
+	}
+	if p.Backoff == nil {
+		p.Backoff = d.Backoff
+	}
+	if p.Timeout == 0 {
+		p.Timeout = d.Timeout
+	}
+	if p.AttemptTimeout == 0 {
+		p.AttemptTimeout = d.AttemptTimeout
+	}
+	if p.Slack == 0 {
+		p.Slack = d.Slack
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = d.MaxAttempts
+	}
+	if p.StartDelay == 0 {
+		p.StartDelay = d.StartDelay
+	}
+	if p.RetryIf == nil {
+		p.RetryIf = d.RetryIf
+	}
+	if p.OnRetry == nil {
+		p.OnRetry = d.OnRetry
+	}
+	if p.Budget == nil {
+		p.Budget = d.Budget
+	}
+	if p.Rate == 0 {
+		p.Rate, p.RateBurst = d.Rate, d.RateBurst
+	}
+	if p.Recorder == nil {
+		p.Recorder = d.Recorder
+	}
+	if p.Clock == nil {
+		p.Clock = d.Clock
+	}
+	p.Strict = p.Strict || d.Strict
+	return p
+}
@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestSetDefault(t *testing.T) {
+	t.Cleanup(func() { defaultPolicy.Store(nil) })
+	clk := clock.NewInstant(time.Unix(0, 0))
+	var retries int
+	SetDefault(Policy{
+		Backoff:     backoff.Constant(time.Second),
+		MaxAttempts: 5,
+		OnRetry:     func(int, time.Duration, error) { retries++ },
+		Clock:       clk,
+	})
+	op := func(context.Context) (int, error) { return 0, errors.New("not yet") }
+
+	var retryErr *Error
+	if _, err := Do(context.Background(), Policy{}, op); !errors.As(err, &retryErr) || len(retryErr.Attempts) != 5 {
+		t.Errorf("Do with the default Policy = %v, want 5 attempts", err)
+	}
+	if _, err := Do(context.Background(), Policy{MaxAttempts: 2}, op); !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Errorf("Do overriding MaxAttempts = %v, want 2 attempts", err)
+	}
+	if retries != 5 {
+		t.Errorf("default OnRetry called %d times, want 5", retries)
+	}
+	if got, want := clk.Now(), time.Unix(5, 0); !got.Equal(want) {
+		t.Errorf("clock at %v, want %v", got, want)
+	}
+	if Default().MaxAttempts != 5 {
+		t.Errorf("Default() = %+v", Default())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("second SetDefault did not panic")
+		}
+	}()
+	SetDefault(Policy{})
+}
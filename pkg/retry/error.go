@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Error is returned by Do when an operation never succeeded.
+// It records the error of every attempt made.
+type Error struct {
+	// Err is the reason retrying stopped: the context's error, or
+	// the error of the last attempt.
+	Err error
+
+	// Attempts holds the error of each attempt, in order.
+	Attempts []error
+
+	ctxDone   bool // Err is the context's error
+	permanent bool // the last error was not to be retried
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	verb := "giving up"
+	if e.permanent {
+		verb = "not retrying"
+	}
+	fmt.Fprintf(&b, "%s after %d attempt%s: %v", verb, len(e.Attempts), plural(len(e.Attempts)), e.Err)
+	if len(e.Attempts) > 1 || e.ctxDone {
+		b.WriteString(e.Summary())
+	}
+	return b.String()
+}
+
+// Summary returns the errors of the attempts of e as lines, each
+// starting with a newline and a tab, such as "\n\tattempts 1-3:
+// connection refused", for the messages of errors that wrap e.
+// Consecutive attempts usually fail the same way, so it lists each
+// distinct message once with the range of attempts it covers.
+func (e *Error) Summary() string {
+	var b strings.Builder
+	for i := 0; i < len(e.Attempts); {
+		msg := e.Attempts[i].Error()
+		j := i + 1
+		for j < len(e.Attempts) && e.Attempts[j].Error() == msg {
+			j++
+		}
+		if j-i == 1 {
+			fmt.Fprintf(&b, "\n\tattempt %d: %s", i+1, msg)
+		} else {
+			fmt.Fprintf(&b, "\n\tattempts %d-%d: %s", i+1, j, msg)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// ContextDone reports whether retrying stopped because the context
+// was done, in which case Err is the context's error.
+func (e *Error) ContextDone() bool { return e.ctxDone }
+
+// Permanent reports whether retrying stopped because the error of the
+// last attempt was not to be retried.
+func (e *Error) Permanent() bool { return e.permanent }
+
+// Unwrap returns Err followed by the errors of all attempts, so that
+// errors.Is and errors.As look at every one of them.
+func (e *Error) Unwrap() []error {
+	return append([]error{e.Err}, e.Attempts...)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
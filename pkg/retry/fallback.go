@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+// Permanent returns an error wrapping err that makes Do stop
+// retrying and Fallback stop trying alternatives, whatever RetryIf
+// says, for failures that no retry can fix. It is the same as
+// errclass.MarkPermanent.
+func Permanent(err error) error {
+	return errclass.MarkPermanent(err)
+}
+
+// isPermanent reports whether err was marked as permanent, as by
+// Permanent.
+func isPermanent(err error) bool {
+	retryable, ok := errclass.Marked(err)
+	return ok && !retryable
+}
+
+// Fallback returns an operation that calls primary and, should it
+// fail, each of secondaries in turn until one succeeds, such as a
+// replica or a cache standing in for a primary server. It stops at
+// the first error that is not errclass.Retryable, such as one made by
+// Permanent, or when the context is done.
+// If all of them fail, it returns a *FallbackError.
+func Fallback[T any](primary func(context.Context) (T, error), secondaries ...func(context.Context) (T, error)) func(context.Context) (T, error) {
+	ops := append([]func(context.Context) (T, error){primary}, secondaries...)
+	return func(ctx context.Context) (T, error) {
+		var errs []error
+		for _, op := range ops {
+			v, err := op(ctx)
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, err)
+			if !errclass.Retryable(err) || ctx.Err() != nil {
+				break
+			}
+		}
+		return *new(T), &FallbackError{errs}
+	}
+}
+
+// A FallbackError is returned by an operation made by Fallback when
+// none of the alternatives succeeded.
+type FallbackError struct {
+	// Errs holds the error of each alternative tried, in order.
+	Errs []error
+}
+
+// Error lists the errors of the alternatives as a *multierr.Error
+// does.
+func (e *FallbackError) Error() string {
+	return (&multierr.Error{Errs: e.Errs}).Error()
+}
+
+// Unwrap returns the errors of all alternatives tried.
+func (e *FallbackError) Unwrap() []error { return e.Errs }
@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFallback(t *testing.T) {
+	var tried []string
+	op := func(name string, err error) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			tried = append(tried, name)
+			return name, err
+		}
+	}
+	down := errors.New("down")
+
+	v, err := Fallback(op("primary", down), op("replica", nil), op("cache", nil))(context.Background())
+	if v != "replica" || err != nil {
+		t.Errorf("Fallback returned %q, %v; want replica, nil", v, err)
+	}
+	if len(tried) != 2 {
+		t.Errorf("tried %v, want primary and replica", tried)
+	}
+
+	tried = nil
+	denied := Permanent(errors.New("denied"))
+	_, err = Fallback(op("primary", down), op("replica", denied), op("cache", nil))(context.Background())
+	var fe *FallbackError
+	if !errors.As(err, &fe) || len(fe.Errs) != 2 || !errors.Is(err, down) {
+		t.Fatalf("Fallback returned %v, want a *FallbackError of primary and replica", err)
+	}
+	if len(tried) != 2 {
+		t.Errorf("tried %v after a permanent error, want primary and replica", tried)
+	}
+	calls := 0
+	Do(context.Background(), Policy{}, func(context.Context) (int, error) {
+		calls++
+		return 0, err
+	})
+	if calls != 1 {
+		t.Errorf("Do made %d attempts of an operation failing permanently, want 1", calls)
+	}
+}
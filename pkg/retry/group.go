@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+// A Mode says how a Group handles the failure of a task.
+type Mode int
+
+const (
+	// CollectAll lets the other tasks go on, and makes Wait return
+	// the errors of all the tasks that failed.
+	CollectAll Mode = iota
+
+	// FailFast cancels the other tasks, and makes Wait return the
+	// error of the first task that failed.
+	FailFast
+)
+
+// A Group runs tasks concurrently, retrying each with its own Policy,
+// like an errgroup.Group whose tasks are retried by Do.
+type Group struct {
+	mode   Mode
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup returns a Group whose tasks are handled as mode says, and
+// a context derived from ctx for them, which is canceled when a task
+// fails in FailFast mode, or when Wait returns.
+func NewGroup(ctx context.Context, mode Mode) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{mode: mode, cancel: cancel}, ctx
+}
+
+// Go runs op in a new goroutine, retrying it as by Do with p. The
+// task should use the context of the Group (or one derived from it).
+func (g *Group) Go(ctx context.Context, p Policy, op func(context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		_, err := Do(ctx, p, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, op(ctx)
+		})
+		if err == nil {
+			return
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.mode == FailFast && len(g.errs) > 0 {
+			return // canceled by the first failure
+		}
+		g.errs = append(g.errs, err)
+		if g.mode == FailFast {
+			g.cancel(err)
+		}
+	}()
+}
+
+// Wait waits for all the tasks to end, and returns a *multierr.Error
+// of the errors of those that failed, or in FailFast mode the error
+// of the first one that did. It returns nil if all succeeded.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return multierr.Join(g.errs...)
+}
@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+func TestGroupCollectAll(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), CollectAll)
+	once := Policy{MaxAttempts: 1}
+	calls := 0
+	g.Go(ctx, Policy{Backoff: backoff.Constant(0), MaxAttempts: 3}, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	g.Go(ctx, once, func(context.Context) error { return errors.New("db down") })
+	g.Go(ctx, once, func(context.Context) error { return errors.New("cache down") })
+	err := g.Wait()
+	var me *multierr.Error
+	if !errors.As(err, &me) || len(me.Errs) != 2 {
+		t.Fatalf("Wait returned %v, want the errors of 2 tasks", err)
+	}
+	if calls != 3 {
+		t.Errorf("flaky task called %d times, want 3", calls)
+	}
+}
+
+func TestGroupFailFast(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), FailFast)
+	down := errors.New("down")
+	g.Go(ctx, Policy{MaxAttempts: 1}, func(context.Context) error { return down })
+	g.Go(ctx, Policy{}, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	done := make(chan error)
+	go func() { done <- g.Wait() }()
+	select {
+	case err := <-done:
+		var retryErr *Error
+		if !errors.As(err, &retryErr) || !errors.Is(err, down) {
+			t.Errorf("Wait returned %v, want the error of the failed task", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure did not cancel the other task")
+	}
+}
@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Hedge calls op and, if it hasn't returned after delay, calls it
+// again concurrently, to cut the latency of an operation that is
+// only sometimes slow. It returns the result of the first call to
+// succeed, and cancels the context of the other. If the first call
+// fails before delay, its error is returned at once; if both calls
+// fail, Hedge returns the error of the first to fail.
+func Hedge[T any](ctx context.Context, delay time.Duration, op func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		v   T
+		err error
+	}
+	results := make(chan result, 2)
+	call := func() {
+		v, err := op(ctx)
+		results <- result{v, err}
+	}
+	go call()
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	hedge := t.C
+	running := 1
+	var failed *result
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			running++
+			go call()
+		case r := <-results:
+			running--
+			if r.err == nil {
+				return r.v, nil
+			}
+			if failed == nil {
+				failed = &r
+			}
+			if running == 0 {
+				return failed.v, failed.err
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var calls atomic.Int32
+	canceled := make(chan bool, 1)
+	v, err := Hedge(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		n := calls.Add(1)
+		if n == 1 { // slow
+			<-ctx.Done()
+			canceled <- true
+			return 0, ctx.Err()
+		}
+		return int(n), nil
+	})
+	if v != 2 || err != nil {
+		t.Fatalf("Hedge returned %d, %v; want 2, nil", v, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("slow call not canceled")
+	}
+}
+
+func TestHedgeFastFailure(t *testing.T) {
+	calls := 0
+	fail := errors.New("refused")
+	_, err := Hedge(context.Background(), time.Hour, func(context.Context) (int, error) {
+		calls++
+		return 0, fail
+	})
+	if err != fail || calls != 1 {
+		t.Errorf("Hedge returned %v after %d calls, want %v after 1", err, calls, fail)
+	}
+}
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Event records one attempt of an operation retried by Do.
+type Event struct {
+	Attempt int           // number of the attempt, from 1
+	Time    time.Time     // when the attempt started, by the Clock of the Policy
+	Latency time.Duration // time taken by the attempt
+	Err     error         // why the attempt failed, or nil
+
+	// Retry tells whether another attempt follows, after Delay.
+	Retry bool
+	Delay time.Duration
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("attempt %d at %s took %s: ", e.Attempt, e.Time.Format("15:04:05.000"), e.Latency.Round(time.Microsecond))
+	switch {
+	case e.Err == nil:
+		return s + "ok"
+	case e.Retry:
+		return fmt.Sprintf("%s%v; retrying in %s", s, e.Err, e.Delay.Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("%s%v; giving up", s, e.Err)
+	}
+}
+
+// A Recorder records the attempts of the operations retried by Do
+// with a Policy that has it, for example to ship them to a logging
+// system. Do calls Record after each attempt, once the delay before
+// the next one, if any, is known. A Recorder shared by concurrent
+// calls of Do must be safe for concurrent use.
+type Recorder interface {
+	Record(Event)
+}
+
+// A RecorderFunc is a function used as a Recorder.
+type RecorderFunc func(Event)
+
+// Record calls f(e).
+func (f RecorderFunc) Record(e Event) { f(e) }
+
+// A History is a Recorder that keeps every Event, for callers to dump
+// or to assert on in tests. A History is safe for concurrent use; the
+// zero History is empty and ready to use.
+type History struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Record appends e to h.
+func (h *History) Record(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, e)
+}
+
+// Events returns the events recorded so far, in order.
+func (h *History) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Event(nil), h.events...)
+}
+
+// String returns the events of h, one per line.
+func (h *History) String() string {
+	var b strings.Builder
+	for _, e := range h.Events() {
+		fmt.Fprintln(&b, e)
+	}
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestHistory(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	var h History
+	p := Policy{Backoff: backoff.Constant(time.Second), MaxAttempts: 3, Recorder: &h, Clock: clk}
+	Do(context.Background(), p, func(context.Context) (int, error) {
+		return 0, errors.New("not yet")
+	})
+	events := h.Events()
+	if len(events) != 3 {
+		t.Fatalf("recorded %d events, want 3:\n%s", len(events), &h)
+	}
+	for i, e := range events {
+		last := i == len(events)-1
+		if e.Attempt != i+1 || e.Err == nil || e.Retry == last || !e.Time.Equal(time.Unix(int64(i), 0)) {
+			t.Errorf("event %d is %+v", i, e)
+		}
+		if want := time.Second; !last && e.Delay != want {
+			t.Errorf("event %d has delay %v, want %v", i, e.Delay, want)
+		}
+	}
+}
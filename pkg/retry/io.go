@@ -0,0 +1,188 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// A Reader reads from an io.Reader, retrying the reads that fail as
+// directed by a Policy, so that copying from a flaky file or
+// connection, as with io.Copy, recovers from its transient failures
+// the same way as other operations. Reads that return data are not
+// retried, even if they fail too: the data is returned, and the read
+// that follows fails again if the failure lasts. io.EOF ends the
+// reading rather than being retried.
+//
+// If the source is an io.Seeker, each retry first seeks it back to
+// the offset after the data read so far, in case the failed read
+// consumed some of it, as reads of files on network file systems and
+// of objects that are streamed can. Other sources are read again as
+// they are, which suits those whose failed reads consume nothing,
+// such as connections whose reads time out.
+//
+// If the source has a SetReadDeadline method, as net.Conn does, each
+// read is limited to the deadline of its attempt, so that the
+// AttemptTimeout and Timeout of the Policy stop reads that hang.
+// Reads of other sources that hang are not stopped by them.
+//
+// Once the attempts of a read fail for good, the Reader keeps
+// returning the *Error they failed with. A Reader is not safe for
+// concurrent use.
+type Reader struct {
+	ctx    context.Context
+	r      io.Reader
+	policy Policy
+	offset int64 // of the source after the data read, if seekable
+	seeked bool  // whether offset is known
+	err    error // of a read that failed for good
+}
+
+// NewReader returns a Reader reading from r as directed by p, for as
+// long as ctx allows.
+func NewReader(ctx context.Context, r io.Reader, p Policy) *Reader {
+	return &Reader{ctx: ctx, r: r, policy: p}
+}
+
+// Read reads into b from the source of r, retrying as described for
+// Reader.
+func (r *Reader) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(b) == 0 {
+		return r.r.Read(b)
+	}
+	seeker, _ := r.r.(io.Seeker)
+	if seeker != nil && !r.seeked {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			seeker = nil // a pipe or the like, which cannot seek
+		} else {
+			r.offset, r.seeked = offset, true
+		}
+	}
+	eof := false
+	attempts := 0
+	n, err := Do(r.ctx, r.policy, func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts > 1 && r.seeked {
+			if _, err := seeker.Seek(r.offset, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+		if d, ok := r.r.(interface{ SetReadDeadline(time.Time) error }); ok {
+			defer setDeadline(ctx, d.SetReadDeadline)()
+		}
+		n, err := r.r.Read(b)
+		if n > 0 || errors.Is(err, io.EOF) {
+			eof = errors.Is(err, io.EOF)
+			return n, nil
+		}
+		return n, err
+	})
+	if err != nil {
+		r.err = err
+		return 0, err
+	}
+	r.offset += int64(n)
+	if eof {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek sets the offset of the source of r for the next read, as
+// io.Seeker does, if it is an io.Seeker.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("retry: source of Reader is not an io.Seeker")
+	}
+	offset, err := seeker.Seek(offset, whence)
+	if err != nil {
+		r.seeked = false
+		return offset, err
+	}
+	r.offset, r.seeked = offset, true
+	return offset, nil
+}
+
+// A Writer writes to an io.Writer, retrying the writes that fail as
+// directed by a Policy, in the same way as a Reader reads: each retry
+// writes what the failed attempts left unwritten, after seeking the
+// destination, if it is an io.Seeker, back to the offset after the
+// data written so far, and is limited to the deadline of the attempt
+// if it has a SetWriteDeadline method, as net.Conn does. A write that
+// writes less than it is given without failing is retried with the
+// rest, as failing with io.ErrShortWrite.
+//
+// Once the attempts of a write fail for good, the Writer keeps
+// returning the *Error they failed with. A Writer is not safe for
+// concurrent use.
+type Writer struct {
+	ctx    context.Context
+	w      io.Writer
+	policy Policy
+	offset int64 // of the destination after the data written, if seekable
+	seeked bool  // whether offset is known
+	err    error // of a write that failed for good
+}
+
+// NewWriter returns a Writer writing to w as directed by p, for as
+// long as ctx allows.
+func NewWriter(ctx context.Context, w io.Writer, p Policy) *Writer {
+	return &Writer{ctx: ctx, w: w, policy: p}
+}
+
+// Write writes b to the destination of w, retrying as described for
+// Writer. It returns how much of b was written, all of it unless it
+// fails.
+func (w *Writer) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	seeker, _ := w.w.(io.Seeker)
+	if seeker != nil && !w.seeked {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			w.offset, w.seeked = offset, true
+		}
+	}
+	written := 0
+	attempts := 0
+	_, err := Do(w.ctx, w.policy, func(ctx context.Context) (struct{}, error) {
+		attempts++
+		if attempts > 1 && w.seeked {
+			if _, err := seeker.Seek(w.offset+int64(written), io.SeekStart); err != nil {
+				return struct{}{}, err
+			}
+		}
+		if d, ok := w.w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+			defer setDeadline(ctx, d.SetWriteDeadline)()
+		}
+		n, err := w.w.Write(b[written:])
+		written += n
+		if err == nil && written < len(b) {
+			err = io.ErrShortWrite
+		}
+		return struct{}{}, err
+	})
+	w.offset += int64(written)
+	if err != nil {
+		w.err = err
+	}
+	return written, err
+}
+
+// setDeadline sets a read or write deadline with set to that of ctx,
+// if it has one, and returns a function that clears it.
+func setDeadline(ctx context.Context, set func(time.Time) error) (clear func()) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return func() {}
+	}
+	set(deadline)
+	return func() { set(time.Time{}) }
+}
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+var ioPolicy = Policy{Backoff: backoff.Constant(time.Millisecond), MaxAttempts: 3}
+
+// A flakyReader fails every other read, after consuming a byte.
+type flakyReader struct {
+	*strings.Reader
+	reads int
+}
+
+func (r *flakyReader) Read(b []byte) (int, error) {
+	r.reads++
+	if r.reads%2 == 1 {
+		r.Reader.ReadByte()
+		return 0, errors.New("connection reset")
+	}
+	return r.Reader.Read(b[:min(len(b), 3)])
+}
+
+func TestReader(t *testing.T) {
+	src := &flakyReader{Reader: strings.NewReader("hello, world")}
+	got, err := io.ReadAll(NewReader(context.Background(), src, ioPolicy))
+	if string(got) != "hello, world" || err != nil {
+		t.Errorf("read %q, %v; want hello, world", got, err)
+	}
+
+	// Not seekable, so the bytes consumed by failed reads are lost,
+	// and the attempts run out on reads that always fail.
+	r := NewReader(context.Background(), struct{ io.Reader }{&flakyReader{Reader: strings.NewReader("hello")}}, ioPolicy)
+	if got, _ := io.ReadAll(r); string(got) != "ell" {
+		t.Errorf("read %q from a flaky pipe, want ell", got)
+	}
+	r = NewReader(context.Background(), errorReader{}, ioPolicy)
+	_, err = r.Read(make([]byte, 8))
+	var retryErr *Error
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Fatalf("Read returned %v, want an *Error of 3 attempts", err)
+	}
+	if _, again := r.Read(make([]byte, 8)); again != err {
+		t.Errorf("Read after failing returned %v, want %v", again, err)
+	}
+}
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+// A flakyFile fails every other write, after writing half of what it
+// is given and then a wrong byte, which it does not count.
+type flakyFile struct {
+	*os.File
+	writes int
+}
+
+func (f *flakyFile) Write(b []byte) (int, error) {
+	f.writes++
+	if f.writes%2 == 1 {
+		n, _ := f.File.Write(b[:len(b)/2])
+		f.File.Write([]byte("?"))
+		return n, errors.New("no space left, for now")
+	}
+	return f.File.Write(b)
+}
+
+func TestWriter(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := NewWriter(context.Background(), &flakyFile{File: f}, ioPolicy)
+	if n, err := io.Copy(w, bytes.NewBufferString("hello, world")); n != 12 || err != nil {
+		t.Fatalf("copied %d bytes, %v; want 12", n, err)
+	}
+	if got, _ := os.ReadFile(name); string(got) != "hello, world" {
+		t.Errorf("wrote %q, want hello, world", got)
+	}
+}
@@ -0,0 +1,46 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// A PanicError reports a panic of an operation wrapped by Safe.
+type PanicError struct {
+	Value any    // passed to panic
+	Stack []byte // of the panicking goroutine, as by debug.Stack
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic, if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Safe returns an operation that calls op, and turns its panics into
+// a *PanicError, so that one panicking attempt doesn't bring down Do
+// and the program with it. Do retries a panicking operation like a
+// failing one, unless told otherwise by a RetryIf such as NotPanic.
+func Safe[T any](op func(context.Context) (T, error)) func(context.Context) (T, error) {
+	return func(ctx context.Context) (v T, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return op(ctx)
+	}
+}
+
+// NotPanic is a RetryIf predicate that retries every error but those
+// of panics caught by Safe.
+func NotPanic(err error) bool {
+	var pe *PanicError
+	return !errors.As(err, &pe)
+}
@@ -0,0 +1,26 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSafe(t *testing.T) {
+	calls := 0
+	_, err := Do(context.Background(), Policy{RetryIf: NotPanic}, Safe(func(context.Context) (int, error) {
+		calls++
+		panic("boom")
+	}))
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("Do returned %v, want a *PanicError of boom", err)
+	}
+	if !bytes.Contains(pe.Stack, []byte("TestSafe")) {
+		t.Errorf("stack of the panic does not include TestSafe:\n%s", pe.Stack)
+	}
+	if calls != 1 {
+		t.Errorf("op called %d times, want 1", calls)
+	}
+}
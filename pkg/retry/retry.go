@@ -0,0 +1,293 @@
+// Package retry retries operations that fail with transient errors,
+// waiting between attempts as directed by a backoff.Backoff.
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// DefaultBackoff is used by Do when a Policy has no Backoff.
+var DefaultBackoff = backoff.Exponential(1*time.Second, 1*time.Minute)
+
+// minFinalAttempt is the least time left for the final attempt
+// before a deadline.
+const minFinalAttempt = 100 * time.Millisecond
+
+// A Policy describes how an operation is retried.
+// The zero Policy retries errors that are errclass.Retryable until
+// the context is done, using DefaultBackoff, unless SetDefault has set
+// a default Policy, whose settings it then inherits.
+type Policy struct {
+	// Backoff computes the delay after each failed attempt.
+	Backoff backoff.Backoff
+
+	// Timeout limits the overall time spent, delays included.
+	// Zero means no limit other than the context's. However short
+	// it is, the first attempt still gets minFinalAttempt (100ms),
+	// so that one attempt is always made.
+	Timeout time.Duration
+
+	// AttemptTimeout limits the time each attempt may take.
+	// Zero means no limit other than Timeout.
+	AttemptTimeout time.Duration
+
+	// Slack, if positive, ends the attempts Slack before the deadline
+	// of the context or of Timeout, as by WithSlack, leaving time to
+	// clean up after the last of them. Do makes no attempt with less
+	// than minFinalAttempt left before that earlier deadline, and
+	// fails with a *DeadlineError instead.
+	Slack time.Duration
+
+	// MaxAttempts limits the number of attempts. Zero means no limit.
+	MaxAttempts int
+
+	// StartDelay is the time to wait before the first attempt,
+	// counted in Timeout.
+	StartDelay time.Duration
+
+	// RetryIf, if non-nil, reports whether an attempt that failed
+	// with err should be retried. By default errclass.Retryable
+	// decides. Errors made by Permanent are never retried.
+	RetryIf func(err error) bool
+
+	// OnRetry, if non-nil, is called after each failed attempt that
+	// is going to be retried, before sleeping for delay.
+	OnRetry func(attempt int, delay time.Duration, err error)
+
+	// Budget, if non-nil, limits the rate of retries together with
+	// the other operations retried with the same Budget. The delay
+	// before each retry is extended until the Budget allows it.
+	Budget *Budget
+
+	// Rate, if positive, limits the attempts of the operation to
+	// Rate per second on average, and to RateBurst in quick
+	// succession, however short the delays of Backoff are. With a
+	// Backoff of constant zero delays, the operation is attempted
+	// at a steady Rate.
+	Rate      float64
+	RateBurst int
+
+	// Recorder, if non-nil, records every attempt, as an Event.
+	Recorder Recorder
+
+	// Strict makes Do refuse to run op, and return an error
+	// wrapping ErrInvalidPolicy, if Validate finds fault with the
+	// Policy, except for retrying forever when the context has a
+	// deadline.
+	Strict bool
+
+	// Clock, if non-nil, times the attempts and the delays between
+	// them instead of clock.Real, for example to test a Policy
+	// without waiting. Timeout, AttemptTimeout, and the deadline of
+	// the context are still measured in real time.
+	Clock clock.Clock
+}
+
+// Do calls op until it succeeds, the attempts allowed by p are used
+// up, or ctx is done, and returns the result of the last call.
+// Between attempts it sleeps for the delay computed by p.Backoff, or
+// for the delay requested by the error, if it was created by After or
+// has a RetryAfter() time.Duration method. A delay that would end
+// past the deadline of ctx is shortened so that a final attempt is
+// made just before it.
+// The context passed to op is done when the retrying has to stop or
+// the attempt has exceeded p.AttemptTimeout.
+//
+// If op never succeeds, the returned error is an *Error wrapping the
+// errors of all attempts and either op's last error, when the
+// attempts ran out or p.RetryIf rejected it or it was made by
+// Permanent, or the context's error.
+//
+// The settings that p leaves zero are those of the Policy set by
+// SetDefault, if any.
+func Do[T any](ctx context.Context, p Policy, op func(context.Context) (T, error)) (T, error) {
+	p = p.inherit()
+	if p.Strict {
+		_, deadline := ctx.Deadline()
+		if err := p.validate(deadline); err != nil {
+			return *new(T), Permanent(err)
+		}
+	}
+	parent := ctx // without p.Timeout
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	b := p.Backoff
+	if b == nil {
+		b = DefaultBackoff
+	}
+	clk := p.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+
+	s := &sleeper{clk: clk}
+	defer s.stop()
+	if p.StartDelay > 0 && !s.sleep(ctx, p.StartDelay) {
+		return *new(T), &Error{Err: ctx.Err(), ctxDone: true}
+	}
+
+	var limit *Budget
+	if p.Rate > 0 {
+		limit = NewBudget(p.Rate, p.RateBurst)
+	}
+
+	var v T
+	var errs []error
+	final := false // the last attempt before the deadline was made
+	for attempt := 0; ; attempt++ {
+		if limit != nil {
+			if d := limit.reserve(clk.Now()); d > 0 && !s.sleep(ctx, d) {
+				return v, &Error{Err: ctx.Err(), Attempts: errs, ctxDone: true}
+			}
+		}
+		start := clk.Now()
+		actx, timeout := ctx, p.AttemptTimeout
+		if deadline, ok := ctx.Deadline(); ok && attempt == 0 && p.Timeout > 0 && time.Until(deadline) < minFinalAttempt {
+			// Too short a Timeout must not leave the first
+			// attempt no time at all.
+			actx = parent
+			if timeout <= 0 || timeout > minFinalAttempt {
+				timeout = minFinalAttempt
+			}
+		}
+		cancel := func() {}
+		if p.Slack > 0 {
+			var err error
+			if actx, cancel, err = WithSlack(actx, p.Slack, minFinalAttempt); err != nil {
+				return v, &Error{Err: err, Attempts: errs}
+			}
+		}
+		var err error
+		v, err = attempt1(actx, timeout, op)
+		cancel()
+		took := clk.Now().Sub(start)
+		event := Event{Attempt: attempt + 1, Time: start, Latency: took, Err: err}
+		if err == nil {
+			p.record(event)
+			return v, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			p.record(event)
+			return v, &Error{Err: ctx.Err(), Attempts: errs, ctxDone: true}
+		}
+		if !p.retryable(err) {
+			p.record(event)
+			return v, &Error{Err: err, Attempts: errs, permanent: true}
+		}
+		if p.MaxAttempts > 0 && attempt+1 >= p.MaxAttempts {
+			p.record(event)
+			return v, &Error{Err: err, Attempts: errs}
+		}
+		delay := b.NextDelay(attempt)
+		if d, ok := retryAfter(err); ok {
+			delay = d // the sleep still ends when ctx is done
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			// Rather than sleep past the deadline, make a final
+			// attempt just before it, or before the Slack left
+			// before it, leaving that attempt as much time as this
+			// one took, and at least minFinalAttempt.
+			if final && p.Slack > 0 {
+				p.record(event)
+				return v, &Error{Err: &DeadlineError{Left: time.Until(deadline), Slack: p.Slack, Min: minFinalAttempt}, Attempts: errs}
+			}
+			if final {
+				p.record(event)
+				<-ctx.Done()
+				return v, &Error{Err: ctx.Err(), Attempts: errs, ctxDone: true}
+			}
+			if last := time.Until(deadline) - p.Slack - max(took, minFinalAttempt); delay >= last {
+				delay, final = max(last, 0), true
+			}
+		}
+		event.Retry, event.Delay = true, delay
+		p.record(event)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+		if !s.sleep(ctx, delay) {
+			return v, &Error{Err: ctx.Err(), Attempts: errs, ctxDone: true}
+		}
+		if p.Budget != nil {
+			if d := p.Budget.reserve(clk.Now()); d > 0 && !s.sleep(ctx, d) {
+				return v, &Error{Err: ctx.Err(), Attempts: errs, ctxDone: true}
+			}
+		}
+	}
+}
+
+// retryable reports whether an attempt that failed with err is to be
+// retried.
+func (p *Policy) retryable(err error) bool {
+	if p.RetryIf == nil {
+		return errclass.Retryable(err)
+	}
+	return !isPermanent(err) && p.RetryIf(err)
+}
+
+// record passes e to the Recorder of p, if any.
+func (p *Policy) record(e Event) {
+	if p.Recorder != nil {
+		p.Recorder.Record(e)
+	}
+}
+
+// attempt1 calls op once, limited to timeout if it is positive.
+func attempt1[T any](ctx context.Context, timeout time.Duration, op func(context.Context) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return op(ctx)
+}
+
+// A sleeper makes the sleeps of a call of Do, with one timer for all
+// of them if the timers of its clock can be reset, so that retrying
+// does not allocate a timer per attempt.
+type sleeper struct {
+	clk clock.Clock
+	t   clock.Timer // to be reset, if not nil
+}
+
+// sleep pauses for d or until ctx is done, whichever comes first.
+// It reports whether the full duration elapsed.
+func (s *sleeper) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := s.t
+	if t == nil {
+		t = s.clk.NewTimer(d)
+		if _, ok := t.(interface{ Reset(time.Duration) bool }); ok {
+			s.t = t
+		} else {
+			defer t.Stop()
+		}
+	} else {
+		t.(interface{ Reset(time.Duration) bool }).Reset(d)
+	}
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		t.Stop()
+		return false
+	}
+}
+
+// stop stops the timer of s, if any.
+func (s *sleeper) stop() {
+	if s.t != nil {
+		s.t.Stop()
+	}
+}
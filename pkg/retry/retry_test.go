@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestDoSchedule(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	p := Policy{
+		Backoff:     backoff.Exponential(time.Second, 10*time.Second),
+		MaxAttempts: 6,
+		Clock:       clk,
+	}
+	calls := 0
+	_, err := Do(context.Background(), p, func(context.Context) (int, error) {
+		calls++
+		return 0, errors.New("not yet")
+	})
+	var retryErr *Error
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 6 {
+		t.Fatalf("Do returned %v, want an *Error with 6 attempts", err)
+	}
+	if calls != 6 {
+		t.Errorf("op called %d times, want 6", calls)
+	}
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	if got := clk.Timers(); !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+	if got, want := clk.Now(), time.Unix(25, 0); !got.Equal(want) {
+		t.Errorf("clock at %v after Do, want %v", got, want)
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	p := Policy{Backoff: backoff.Constant(time.Second), Clock: clk}
+	calls := 0
+	v, err := Do(context.Background(), p, func(context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", After(errors.New("busy"), time.Minute)
+		}
+		return "ok", nil
+	})
+	if v != "ok" || err != nil {
+		t.Fatalf("Do returned %q, %v; want ok, nil", v, err)
+	}
+	want := []time.Duration{time.Minute, time.Minute}
+	if got := clk.Timers(); !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+}
+
+func TestDoRate(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	p := Policy{
+		Backoff:     backoff.Exponential(100*time.Millisecond, time.Second),
+		MaxAttempts: 5,
+		Rate:        2,
+		Clock:       clk,
+	}
+	Do(context.Background(), p, func(context.Context) (int, error) {
+		return 0, errors.New("not yet")
+	})
+	// Each delay shorter than 500ms is followed by a wait for the
+	// rest of it.
+	want := []time.Duration{100 * time.Millisecond, 400 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond, 100 * time.Millisecond, 800 * time.Millisecond}
+	if got := clk.Timers(); !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+}
+
+func TestDoTinyTimeout(t *testing.T) {
+	p := Policy{Timeout: time.Nanosecond}
+	var attemptErr error
+	_, err := Do(context.Background(), p, func(ctx context.Context) (int, error) {
+		attemptErr = ctx.Err()
+		return 0, errors.New("not yet")
+	})
+	if attemptErr != nil {
+		t.Errorf("first attempt started with %v, want time to run", attemptErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do returned %v, want a deadline error", err)
+	}
+}
@@ -0,0 +1,337 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+// A Kind is a kind of backoff.Backoff that a Spec describes.
+type Kind int
+
+const (
+	Exponential Kind = iota // backoff.Exponential
+	Constant                // backoff.Constant
+	Linear                  // backoff.Linear
+	Fibonacci               // backoff.Fibonacci
+)
+
+var kindNames = []string{"exp", "const", "linear", "fib"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// MarshalText returns the name of k: exp, const, linear, or fib.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText sets k to the Kind named by text.
+func (k *Kind) UnmarshalText(text []byte) error {
+	i := slices.Index(kindNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown backoff %q", text)
+	}
+	*k = Kind(i)
+	return nil
+}
+
+// A Duration is a time.Duration written as a string such as "30s" in
+// a Spec.
+type Duration time.Duration
+
+// MarshalText formats d like time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses a duration as by time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// A Spec describes a Policy in a form that can be written in
+// configuration files, as JSON or YAML, and in flags, so that how
+// operations are retried can be changed without changing code. Its
+// Policy method returns the Policy it describes.
+//
+// Specs are written in JSON and YAML as objects, with members named
+// like the tags of their fields, such as
+//
+//	{"backoff": "exp", "initial": "1s", "max_delay": "30s", "jitter": "full", "max_attempts": 10}
+//
+// or as strings in their compact form, as parsed by ParseSpec.
+type Spec struct {
+	// Backoff, Initial, MaxDelay, and Step describe the Backoff of
+	// the Policy, as made by the function of package backoff that
+	// Backoff names: Exponential(Initial, MaxDelay),
+	// Constant(Initial), Linear(Initial, Step, MaxDelay), where Step
+	// defaults to Initial, or Fibonacci(Initial, MaxDelay). An
+	// exponential backoff with no Initial delay is DefaultBackoff.
+	Backoff  Kind     `json:"backoff" yaml:"backoff"`
+	Initial  Duration `json:"initial,omitempty" yaml:"initial,omitempty"`
+	MaxDelay Duration `json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+	Step     Duration `json:"step,omitempty" yaml:"step,omitempty"`
+
+	// Jitter randomizes the delays of the Backoff, as by
+	// backoff.WithJitter.
+	Jitter backoff.Jitter `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+
+	// The other fields set those of the same names of the Policy.
+	MaxAttempts    int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	Timeout        Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	AttemptTimeout Duration `json:"attempt_timeout,omitempty" yaml:"attempt_timeout,omitempty"`
+	StartDelay     Duration `json:"start_delay,omitempty" yaml:"start_delay,omitempty"`
+	Rate           float64  `json:"rate,omitempty" yaml:"rate,omitempty"`
+}
+
+// ParseSpec parses a Spec written in its compact form: the name of its
+// backoff, followed by a colon and its initial delay, and two dots
+// and its maximum delay, if any, and then by its other settings,
+// separated by commas, such as
+//
+//	exp:1s..30s,jitter=full,max_attempts=10,timeout=2m
+//
+// The settings are named like the members of a Spec in JSON, except
+// that max_attempts may be written as max.
+func ParseSpec(s string) (Spec, error) {
+	var spec Spec
+	head, settings, _ := strings.Cut(s, ",")
+	kind, delays, hasDelays := strings.Cut(head, ":")
+	if err := spec.Backoff.UnmarshalText([]byte(kind)); err != nil {
+		return Spec{}, err
+	}
+	if hasDelays {
+		initial, maxDelay, hasMax := strings.Cut(delays, "..")
+		if err := spec.Initial.UnmarshalText([]byte(initial)); err != nil {
+			return Spec{}, fmt.Errorf("initial delay: %w", err)
+		}
+		if hasMax {
+			if err := spec.MaxDelay.UnmarshalText([]byte(maxDelay)); err != nil {
+				return Spec{}, fmt.Errorf("maximum delay: %w", err)
+			}
+		}
+	}
+	for setting := range strings.SplitSeq(settings, ",") {
+		if setting == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return Spec{}, fmt.Errorf("missing = in setting %q", setting)
+		}
+		if err := spec.set(key, value); err != nil {
+			return Spec{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return spec, spec.validate()
+}
+
+// Validate returns an error listing the settings of s that are out of
+// range, such as a MaxDelay shorter than the Initial delay, or likely
+// mistakes, as found by the Validate method of its Policy.
+func (s Spec) Validate() error {
+	p := s.Policy()
+	return multierr.Append(s.validate(), p.Validate())
+}
+
+// set sets the setting key of s to value, as written in the compact
+// form of s.
+func (s *Spec) set(key, value string) error {
+	switch key {
+	case "step":
+		return s.Step.UnmarshalText([]byte(value))
+	case "jitter":
+		return s.Jitter.UnmarshalText([]byte(value))
+	case "max_attempts", "max":
+		n, err := strconv.Atoi(value)
+		s.MaxAttempts = n
+		return err
+	case "timeout":
+		return s.Timeout.UnmarshalText([]byte(value))
+	case "attempt_timeout":
+		return s.AttemptTimeout.UnmarshalText([]byte(value))
+	case "start_delay":
+		return s.StartDelay.UnmarshalText([]byte(value))
+	case "rate":
+		r, err := strconv.ParseFloat(value, 64)
+		s.Rate = r
+		return err
+	}
+	return errors.New("unknown setting")
+}
+
+// validate returns an error if s has settings out of range.
+func (s *Spec) validate() error {
+	for _, d := range []struct {
+		name string
+		d    Duration
+	}{
+		{"initial delay", s.Initial}, {"maximum delay", s.MaxDelay}, {"step", s.Step},
+		{"timeout", s.Timeout}, {"attempt timeout", s.AttemptTimeout}, {"start delay", s.StartDelay},
+	} {
+		if d.d < 0 {
+			return fmt.Errorf("negative %s %v", d.name, time.Duration(d.d))
+		}
+	}
+	switch {
+	case s.MaxDelay > 0 && s.MaxDelay < s.Initial:
+		return fmt.Errorf("maximum delay %v is shorter than initial delay %v", time.Duration(s.MaxDelay), time.Duration(s.Initial))
+	case s.MaxAttempts < 0:
+		return fmt.Errorf("negative max attempts %d", s.MaxAttempts)
+	case s.Rate < 0:
+		return fmt.Errorf("negative rate %v", s.Rate)
+	case math.IsNaN(s.Rate) || math.IsInf(s.Rate, 0):
+		return fmt.Errorf("invalid rate %v", s.Rate)
+	}
+	return nil
+}
+
+// String returns the compact form of s, as parsed by ParseSpec.
+func (s Spec) String() string {
+	var b strings.Builder
+	b.WriteString(s.Backoff.String())
+	if s.Initial != 0 || s.MaxDelay != 0 {
+		fmt.Fprintf(&b, ":%v", time.Duration(s.Initial))
+	}
+	if s.MaxDelay != 0 {
+		fmt.Fprintf(&b, "..%v", time.Duration(s.MaxDelay))
+	}
+	setting := func(key string, value any) { fmt.Fprintf(&b, ",%s=%v", key, value) }
+	if s.Step != 0 {
+		setting("step", time.Duration(s.Step))
+	}
+	if s.Jitter != backoff.NoJitter {
+		setting("jitter", s.Jitter)
+	}
+	if s.MaxAttempts != 0 {
+		setting("max", s.MaxAttempts)
+	}
+	if s.Timeout != 0 {
+		setting("timeout", time.Duration(s.Timeout))
+	}
+	if s.AttemptTimeout != 0 {
+		setting("attempt_timeout", time.Duration(s.AttemptTimeout))
+	}
+	if s.StartDelay != 0 {
+		setting("start_delay", time.Duration(s.StartDelay))
+	}
+	if s.Rate != 0 {
+		setting("rate", strconv.FormatFloat(s.Rate, 'g', -1, 64))
+	}
+	return b.String()
+}
+
+// MarshalText returns the compact form of s, for flag.TextVar.
+func (s Spec) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses the compact form of a Spec into s, as by
+// ParseSpec.
+func (s *Spec) UnmarshalText(text []byte) error {
+	v, err := ParseSpec(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// spec is a Spec without its methods, for encoding/json and yaml.v3.
+type spec Spec
+
+// MarshalJSON writes s as a JSON object.
+func (s Spec) MarshalJSON() ([]byte, error) {
+	return json.Marshal(spec(s))
+}
+
+// UnmarshalJSON reads s from a JSON object, or from a string in its
+// compact form.
+func (s *Spec) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return s.UnmarshalText([]byte(text))
+	}
+	var v spec
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	*s = Spec(v)
+	return s.validate()
+}
+
+// MarshalYAML writes s as a YAML mapping.
+func (s Spec) MarshalYAML() (any, error) {
+	return spec(s), nil
+}
+
+// UnmarshalYAML reads s from a YAML mapping, or from a string in its
+// compact form.
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return s.UnmarshalText([]byte(node.Value))
+	}
+	var v spec
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	*s = Spec(v)
+	return s.validate()
+}
+
+// Policy returns the Policy described by s.
+func (s Spec) Policy() Policy {
+	p := Policy{
+		MaxAttempts:    s.MaxAttempts,
+		Timeout:        time.Duration(s.Timeout),
+		AttemptTimeout: time.Duration(s.AttemptTimeout),
+		StartDelay:     time.Duration(s.StartDelay),
+		Rate:           s.Rate,
+	}
+	initial, maxDelay := time.Duration(s.Initial), time.Duration(s.MaxDelay)
+	var b backoff.Backoff
+	switch s.Backoff {
+	case Exponential:
+		b = DefaultBackoff
+		if initial > 0 {
+			b = backoff.Exponential(initial, maxDelay)
+		}
+	case Constant:
+		b = backoff.Constant(initial)
+	case Linear:
+		step := time.Duration(s.Step)
+		if step == 0 {
+			step = initial
+		}
+		b = backoff.Linear(initial, step, maxDelay)
+	case Fibonacci:
+		b = backoff.Fibonacci(initial, maxDelay)
+	}
+	p.Backoff = backoff.WithJitter(b, s.Jitter)
+	return p
+}
@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/backoff/backofftest"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseSpec(t *testing.T) {
+	s, err := ParseSpec("exp:1s..30s,jitter=full,max=10,timeout=2m")
+	want := Spec{Backoff: Exponential, Initial: Duration(time.Second), MaxDelay: Duration(30 * time.Second),
+		Jitter: backoff.FullJitter, MaxAttempts: 10, Timeout: Duration(2 * time.Minute)}
+	if err != nil || s != want {
+		t.Fatalf("ParseSpec = %+v, %v; want %+v", s, err, want)
+	}
+	if got := s.String(); got != "exp:1s..30s,jitter=full,max=10,timeout=2m0s" {
+		t.Errorf("String() = %q", got)
+	}
+	if again, err := ParseSpec(s.String()); err != nil || again != s {
+		t.Errorf("ParseSpec(String()) = %+v, %v", again, err)
+	}
+	for _, bad := range []string{"cubic:1s", "exp:1s..x", "exp:30s..1s", "const:1s,max", "linear:1s,steps=1s", "exp,max=-1", "exp,rate=NaN", "exp,rate=+Inf"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) succeeded", bad)
+		}
+	}
+}
+
+func FuzzParseSpec(f *testing.F) {
+	for _, s := range []string{
+		"exp:1s..30s,jitter=full,max=10,timeout=2m",
+		"const:2s,attempt_timeout=1s", "linear:1s..1m,step=5s,jitter=equal",
+		"fib:100ms..10s,jitter=decorrelated,rate=0.5,start_delay=1s", "exp", "exp:30s..1s", "exp,rate=NaN",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		spec, err := ParseSpec(s)
+		if err != nil {
+			return
+		}
+		if again, err := ParseSpec(spec.String()); err != nil || again != spec {
+			t.Fatalf("ParseSpec(%q) = %+v, but ParseSpec(%q) = %+v, %v", s, spec, spec.String(), again, err)
+		}
+		var decoded Spec
+		if data, err := json.Marshal(spec); err != nil || json.Unmarshal(data, &decoded) != nil || decoded != spec {
+			t.Fatalf("ParseSpec(%q) = %+v, which JSON %s decodes to %+v", s, spec, data, decoded)
+		}
+		// An exponential backoff with no initial delay is
+		// DefaultBackoff, whatever the maximum delay.
+		var bounds backofftest.Bounds
+		if spec.Backoff != Exponential || spec.Initial > 0 {
+			bounds.Max = time.Duration(spec.MaxDelay)
+		}
+		bounds.Attempts, bounds.Samples = 50, 2
+		if err := backofftest.Test(spec.Policy().Backoff, bounds); err != nil {
+			t.Fatalf("the Backoff of ParseSpec(%q) = %+v:\n%v", s, spec, err)
+		}
+	})
+}
+
+func TestSpecPolicy(t *testing.T) {
+	p := Spec{Backoff: Linear, Initial: Duration(time.Second), MaxDelay: Duration(3 * time.Second), MaxAttempts: 4}.Policy()
+	var delays []time.Duration
+	for i := range 4 {
+		delays = append(delays, p.Backoff.NextDelay(i))
+	}
+	if p.MaxAttempts != 4 || delays[0] != time.Second || delays[1] != 2*time.Second || delays[3] != 3*time.Second {
+		t.Errorf("Policy() has %d attempts, delays %v", p.MaxAttempts, delays)
+	}
+	if d := (Spec{}).Policy().Backoff.NextDelay(0); d != DefaultBackoff.NextDelay(0) {
+		t.Errorf("zero Spec delays %v first, want that of DefaultBackoff", d)
+	}
+}
+
+func TestSpecEncoding(t *testing.T) {
+	want := Spec{Backoff: Constant, Initial: Duration(2 * time.Second), AttemptTimeout: Duration(time.Second)}
+	for _, test := range []struct {
+		format string
+		data   string
+	}{
+		{"json", `{"backoff": "const", "initial": "2s", "attempt_timeout": "1s"}`},
+		{"json", `"const:2s,attempt_timeout=1s"`},
+		{"yaml", "backoff: const\ninitial: 2s\nattempt_timeout: 1s\n"},
+		{"yaml", "const:2s,attempt_timeout=1s"},
+	} {
+		var s Spec
+		var err error
+		if test.format == "json" {
+			err = json.Unmarshal([]byte(test.data), &s)
+		} else {
+			err = yaml.Unmarshal([]byte(test.data), &s)
+		}
+		if err != nil || s != want {
+			t.Errorf("%s %s: got %+v, %v; want %+v", test.format, test.data, s, err, want)
+		}
+	}
+	data, err := json.Marshal(want)
+	var s Spec
+	if err != nil || json.Unmarshal(data, &s) != nil || s != want {
+		t.Errorf("JSON %s of %+v decoded to %+v, %v", data, want, s, err)
+	}
+	data, err = yaml.Marshal(want)
+	s = Spec{}
+	if err != nil || yaml.Unmarshal(data, &s) != nil || s != want {
+		t.Errorf("YAML %s of %+v decoded to %+v, %v", data, want, s, err)
+	}
+	if err := json.Unmarshal([]byte(`{"backof": "exp"}`), &s); err == nil {
+		t.Error("unknown member decoded")
+	}
+}
@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOperationTimeout is matched by the errors of operations that
+// exceeded the timeout given to WithTimeout.
+var ErrOperationTimeout = errors.New("operation timed out")
+
+// WithTimeout calls op with a context that is done after d, and
+// returns its result. If op fails once d is over, the error is a
+// *TimeoutError, which matches both ErrOperationTimeout and
+// context.DeadlineExceeded, so that callers can tell that timeout
+// from the deadlines of ctx and from other failures.
+func WithTimeout[T any](ctx context.Context, d time.Duration, op func(context.Context) (T, error)) (T, error) {
+	cause := &TimeoutError{Timeout: d}
+	ctx, cancel := context.WithTimeoutCause(ctx, d, cause)
+	defer cancel()
+	v, err := op(ctx)
+	if err != nil && context.Cause(ctx) == cause {
+		cause.Err = err
+		return v, cause
+	}
+	return v, err
+}
+
+// A TimeoutError reports an operation that exceeded the timeout of
+// WithTimeout.
+type TimeoutError struct {
+	Timeout time.Duration
+	Err     error // returned by the operation
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("timed out after %s", e.Timeout)
+	}
+	return fmt.Sprintf("timed out after %s: %v", e.Timeout, e.Err)
+}
+
+// Is reports whether target is ErrOperationTimeout or
+// context.DeadlineExceeded.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrOperationTimeout || target == context.DeadlineExceeded
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// WithSlack returns a copy of ctx whose deadline is slack earlier than
+// that of ctx, so that there is time left to clean up after an
+// operation given it runs out of time, such as to roll back a
+// transaction or to write an error response. If less than min would be
+// left before the earlier deadline, it returns ctx and a
+// *DeadlineError, as the operation is not worth attempting at all. A
+// ctx without a deadline is returned as it is. The returned cancel
+// function must be called, as for context.WithDeadline.
+func WithSlack(ctx context.Context, slack, min time.Duration) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}, nil
+	}
+	if left := time.Until(deadline); left-slack < min {
+		return ctx, func() {}, &DeadlineError{Left: left, Slack: slack, Min: min}
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline.Add(-slack))
+	return ctx, cancel, nil
+}
+
+// A DeadlineError reports that too little time was left before the
+// deadline of a context to attempt an operation, keeping Slack for
+// cleaning up after it. It matches context.DeadlineExceeded.
+type DeadlineError struct {
+	Left  time.Duration // until the deadline
+	Slack time.Duration // to be left for cleaning up
+	Min   time.Duration // the least an attempt is to be given
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("only %v left before the deadline, less than the %v for an attempt and the %v of slack", e.Left.Round(time.Millisecond), e.Min, e.Slack)
+}
+
+// Is reports whether target is context.DeadlineExceeded.
+func (e *DeadlineError) Is(target error) bool { return target == context.DeadlineExceeded }
@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+func TestWithTimeout(t *testing.T) {
+	_, err := WithTimeout(context.Background(), time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	var te *TimeoutError
+	if !errors.As(err, &te) || !errors.Is(err, ErrOperationTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WithTimeout returned %v, want a *TimeoutError", err)
+	}
+
+	// The deadline of the parent context is not that of WithTimeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = WithTimeout(ctx, time.Hour, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("WithTimeout under an expiring context returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	fail := errors.New("refused")
+	if _, err := WithTimeout(context.Background(), time.Hour, func(context.Context) (int, error) { return 0, fail }); err != fail {
+		t.Errorf("WithTimeout returned %v, want %v", err, fail)
+	}
+}
+
+func TestWithSlack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	sctx, scancel, err := WithSlack(ctx, time.Minute, time.Second)
+	defer scancel()
+	deadline, _ := ctx.Deadline()
+	if got, ok := sctx.Deadline(); err != nil || !ok || !got.Equal(deadline.Add(-time.Minute)) {
+		t.Errorf("WithSlack deadline = %v, %v, want %v", got, err, deadline.Add(-time.Minute))
+	}
+
+	_, _, err = WithSlack(ctx, 59*time.Minute, 2*time.Minute)
+	var de *DeadlineError
+	if !errors.As(err, &de) || !errors.Is(err, context.DeadlineExceeded) || de.Slack != 59*time.Minute {
+		t.Errorf("WithSlack with too little time left returned %v, want a *DeadlineError", err)
+	}
+
+	if sctx, _, err := WithSlack(context.Background(), time.Minute, time.Second); sctx != context.Background() || err != nil {
+		t.Errorf("WithSlack without a deadline = %v, %v", sctx, err)
+	}
+}
+
+func TestDoSlack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	attempts := 0
+	start := time.Now()
+	_, err := Do(ctx, Policy{Backoff: backoff.Constant(50 * time.Millisecond), Slack: 200 * time.Millisecond},
+		func(ctx context.Context) (int, error) {
+			attempts++
+			if d, _ := ctx.Deadline(); !d.Equal(deadline.Add(-200 * time.Millisecond)) {
+				t.Errorf("attempt deadline %v, want 200ms before %v", d, deadline)
+			}
+			return 0, errors.New("refused")
+		})
+	var de *DeadlineError
+	if !errors.As(err, &de) || attempts == 0 {
+		t.Fatalf("Do after %d attempts returned %v, want a *DeadlineError", attempts, err)
+	}
+	if took := time.Since(start); took > 400*time.Millisecond {
+		t.Errorf("Do took %v, leaving less than the slack", took)
+	}
+
+	// Too little time to attempt at all.
+	ctx, cancel = context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	_, err = Do(ctx, Policy{Slack: 100 * time.Millisecond}, func(context.Context) (int, error) {
+		t.Error("attempted")
+		return 0, nil
+	})
+	if !errors.As(err, &de) {
+		t.Errorf("Do returned %v, want a *DeadlineError", err)
+	}
+}
@@ -0,0 +1,192 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Transport is an http.RoundTripper that retries the requests sent
+// through Base as directed by Policy, so that any http.Client can
+// retry failed requests by using it as its Transport:
+//
+//	client := &http.Client{Transport: &retry.Transport{
+//		Policy: retry.Policy{MaxAttempts: 5, Timeout: 30 * time.Second},
+//	}}
+//
+// Requests are retried if they are idempotent: if their method is
+// GET, HEAD, OPTIONS, TRACE, PUT, or DELETE, or they have an
+// Idempotency-Key or X-Idempotency-Key header, as net/http itself
+// assumes, or if RetryAll is set. Requests with a body are retried
+// only if they have a GetBody function to get a fresh copy of it for
+// each attempt, as those made by http.NewRequest for common kinds
+// of bodies do.
+//
+// Attempts fail when Base returns an error, and when the status of
+// the response is one that RetryStatus reports retryable, by default
+// 429 Too Many Requests, 502 Bad Gateway, 503 Service Unavailable,
+// and 504 Gateway Timeout. The delay before retrying such a response
+// is that of its Retry-After header, if it has one. When the attempts
+// run out on such a response, RoundTrip returns it, rather than an
+// error, as Base would have.
+//
+// Policy.Timeout and Policy.AttemptTimeout limit the time taken until
+// a response header is received; the body of a response can be read
+// for as long as the context of its request allows.
+type Transport struct {
+	// Base sends the requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	Policy Policy
+
+	// RetryStatus, if non-nil, reports whether a response with the
+	// status code is to be retried, rather than the default codes.
+	RetryStatus func(code int) bool
+
+	// RetryAll makes requests of any method be retried, not only
+	// those that are idempotent.
+	RetryAll bool
+}
+
+// RoundTrip sends req through t.Base, retrying it as described for
+// Transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if !t.RetryAll && !idempotent(req) || hasBody && req.GetBody == nil {
+		return base.RoundTrip(req)
+	}
+
+	var last *http.Response // with a status to be retried
+	attempts := 0
+	resp, err := Do(req.Context(), t.Policy, func(ctx context.Context) (*http.Response, error) {
+		attempts++
+		if last != nil {
+			discard(last)
+			last = nil
+		}
+		// The attempt is canceled if ctx is done before the
+		// response arrives, but the body of the response must
+		// outlive ctx, which is done once Do returns.
+		rctx, cancel := context.WithCancel(req.Context())
+		r := req.Clone(rctx)
+		if attempts > 1 && hasBody {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, Permanent(fmt.Errorf("getting request body: %w", err))
+			}
+			r.Body = body
+		}
+		stop := context.AfterFunc(ctx, cancel)
+		resp, err := base.RoundTrip(r)
+		stop()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelBody{resp.Body, cancel}
+		if t.retryStatus(resp.StatusCode) {
+			last = resp
+			return nil, &statusError{resp.Status, retryAfterHeader(resp, time.Now())}
+		}
+		return resp, nil
+	})
+	var statusErr *statusError
+	if err != nil && last != nil && errors.As(err, &statusErr) {
+		return last, nil
+	}
+	if last != nil {
+		discard(last)
+	}
+	return resp, err
+}
+
+// retryStatus reports whether a response with the status code is to
+// be retried.
+func (t *Transport) retryStatus(code int) bool {
+	if t.RetryStatus != nil {
+		return t.RetryStatus(code)
+	}
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent reports whether req may be sent more than once with the
+// same effect, as net/http decides when to retry requests itself.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// maxDiscard limits how much of the body of a response to be retried
+// is read, so that its connection can be reused.
+const maxDiscard = 64 << 10
+
+// discard drains and closes the body of resp.
+func discard(resp *http.Response) {
+	io.CopyN(io.Discard, resp.Body, maxDiscard) // ignore error; only for connection reuse
+	resp.Body.Close()
+}
+
+// A cancelBody is the body of a response that cancels the context of
+// its request once closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// A statusError fails an attempt whose response has a status to be
+// retried, after the delay its Retry-After header asks for, if any.
+type statusError struct {
+	status string
+	after  time.Duration
+}
+
+func (e *statusError) Error() string             { return "response status " + e.status }
+func (e *statusError) RetryAfter() time.Duration { return e.after }
+
+// maxRetryAfter guards against absurd Retry-After values.
+const maxRetryAfter = 24 * time.Hour
+
+// retryAfterHeader returns the delay asked for by the Retry-After header of
+// resp, in seconds or as an HTTP date, at the time now, or 0.
+func retryAfterHeader(resp *http.Response, now time.Time) time.Duration {
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(min(max(secs, 0), int(maxRetryAfter/time.Second))) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return min(max(t.Sub(now), 0), maxRetryAfter)
+	}
+	return 0
+}
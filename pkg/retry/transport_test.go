@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+func TestTransport(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &Transport{
+		Policy: Policy{Backoff: backoff.Constant(time.Millisecond), MaxAttempts: 5,
+			Timeout: 5 * time.Second, AttemptTimeout: time.Second},
+	}}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("data"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(b) != "ok" {
+		t.Errorf("body %q, %v; want ok", b, err)
+	}
+	if len(bodies) != 3 || bodies[0] != "data" || bodies[2] != "data" {
+		t.Errorf("server got bodies %q, want data thrice", bodies)
+	}
+
+	// Not idempotent, so sent once, and the response returned.
+	bodies = nil
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || len(bodies) != 1 {
+		t.Errorf("POST got %s after %d requests, want 503 after 1", resp.Status, len(bodies))
+	}
+}
+
+func TestTransportAttemptsRunOut(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "busy", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &Transport{
+		Policy: Policy{Backoff: backoff.Constant(time.Millisecond), MaxAttempts: 3},
+	}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || string(b) != "busy\n" || requests != 3 {
+		t.Errorf("got %s %q after %d requests, want the last 429 after 3", resp.Status, b, requests)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	for v, want := range map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-1":                            0,
+		"Wed, 14 Oct 2026 09:00:30 GMT": 30 * time.Second,
+		"soon":                          0,
+	} {
+		resp := &http.Response{Header: http.Header{"Retry-After": {v}}}
+		if got := retryAfterHeader(resp, now); got != want {
+			t.Errorf("retryAfterHeader(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
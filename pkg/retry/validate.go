@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/multierr"
+)
+
+// ErrInvalidPolicy is wrapped by the errors of Validate, and of Do for
+// a Strict Policy that is not valid.
+var ErrInvalidPolicy = errors.New("invalid retry policy")
+
+// Validate returns an error listing the settings of p that are out of
+// range, or that are likely mistakes: an AttemptTimeout longer than
+// the Timeout, a StartDelay as long as the Timeout, a Slack leaving
+// less than 100ms of the Timeout for attempts, a first delay of
+// the Backoff that leaves no time for a second attempt within the
+// Timeout, or neither a Timeout nor MaxAttempts, so that the operation
+// is retried forever unless its context has a deadline. It returns nil
+// if p is fine.
+func (p *Policy) Validate() error {
+	return p.validate(false)
+}
+
+// validate is Validate for a context having a deadline or not.
+func (p *Policy) validate(deadline bool) error {
+	var errs multierr.Collector
+	add := func(format string, args ...any) {
+		errs.Add(fmt.Errorf("%w: "+format, append([]any{ErrInvalidPolicy}, args...)...))
+	}
+	for _, d := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"Timeout", p.Timeout}, {"AttemptTimeout", p.AttemptTimeout}, {"StartDelay", p.StartDelay},
+		{"Slack", p.Slack},
+	} {
+		if d.d < 0 {
+			add("negative %s %v", d.name, d.d)
+		}
+	}
+	if p.MaxAttempts < 0 {
+		add("negative MaxAttempts %d", p.MaxAttempts)
+	}
+	if p.Rate < 0 {
+		add("negative Rate %v", p.Rate)
+	}
+	if p.RateBurst < 0 {
+		add("negative RateBurst %d", p.RateBurst)
+	}
+	if p.Timeout > 0 {
+		if p.AttemptTimeout > p.Timeout {
+			add("AttemptTimeout %v is longer than Timeout %v", p.AttemptTimeout, p.Timeout)
+		}
+		if p.Slack+minFinalAttempt > p.Timeout {
+			add("Slack %v leaves too little of Timeout %v for attempts", p.Slack, p.Timeout)
+		}
+		if p.StartDelay >= p.Timeout {
+			add("StartDelay %v leaves no time of Timeout %v for attempts", p.StartDelay, p.Timeout)
+		}
+		b := p.Backoff
+		if b == nil {
+			b = DefaultBackoff
+		}
+		if d := b.NextDelay(0); p.MaxAttempts != 1 && p.StartDelay+d >= p.Timeout {
+			add("first delay %v leaves no time of Timeout %v for retries", d, p.Timeout)
+		}
+	}
+	if p.Timeout == 0 && p.MaxAttempts == 0 && !deadline {
+		add("no Timeout or MaxAttempts, so retrying forever unless the context has a deadline")
+	}
+	return errs.Err()
+}
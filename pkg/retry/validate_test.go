@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		p    Policy
+		want int // problems
+	}{
+		{Policy{MaxAttempts: 5}, 0},
+		{Policy{Timeout: time.Minute}, 0},
+		{Policy{}, 1}, // forever
+		{Policy{Timeout: time.Second, AttemptTimeout: time.Minute, MaxAttempts: 1}, 1},
+		{Policy{Timeout: 10 * time.Second, Backoff: backoff.Constant(time.Minute)}, 1},
+		{Policy{Timeout: -time.Second, MaxAttempts: -1}, 2},
+	} {
+		err := test.p.Validate()
+		n := 0
+		if err != nil {
+			n = 1
+			if multi, ok := err.(interface{ Unwrap() []error }); ok {
+				n = len(multi.Unwrap())
+			}
+		}
+		if n != test.want || n > 0 && !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("Validate(%+v) = %v, want %d problems", test.p, err, test.want)
+		}
+	}
+	if err := (Spec{Initial: Duration(time.Minute), MaxDelay: Duration(time.Second), MaxAttempts: 3}).Validate(); err == nil {
+		t.Error("Validate of a Spec with MaxDelay shorter than Initial succeeded")
+	}
+}
+
+func TestDoStrict(t *testing.T) {
+	calls := 0
+	op := func(context.Context) (int, error) {
+		calls++
+		return 1, nil
+	}
+	if _, err := Do(context.Background(), Policy{Strict: true}, op); !errors.Is(err, ErrInvalidPolicy) || calls != 0 {
+		t.Errorf("strict Do of a Policy retrying forever = %v after %d calls", err, calls)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := Do(ctx, Policy{Strict: true}, op); err != nil || calls != 1 {
+		t.Errorf("strict Do with a deadline = %v after %d calls", err, calls)
+	}
+}
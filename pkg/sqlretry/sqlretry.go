@@ -0,0 +1,146 @@
+// Package sqlretry retries the connections that database/sql makes to
+// databases as directed by a retry.Policy, so that programs starting
+// along with their database, or meeting it at its connection limit,
+// connect once it lets them rather than fail:
+//
+//	db, err := sqlretry.Open("postgres", dsn, retry.Policy{Timeout: time.Minute})
+//
+// The errors of failed connections are classified by Classify, and
+// marked as retryable or permanent with package errclass, so that the
+// Policy retries them unless its RetryIf decides otherwise.
+package sqlretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// Open opens a database as sql.Open does, but with the connector of
+// NewConnector, retrying its connections as directed by p.
+func Open(driverName, dsn string, p retry.Policy) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn) // for the driver registered as driverName
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+	var c driver.Connector = dsnConnector{dsn, d}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(NewConnector(c, p)), nil
+}
+
+// A dsnConnector connects with a driver that has no connectors of its
+// own, as database/sql does.
+type dsnConnector struct {
+	dsn string
+	d   driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.d }
+
+// NewConnector returns a connector making the connections of c,
+// retrying those that fail as directed by p, for sql.OpenDB.
+func NewConnector(c driver.Connector, p retry.Policy) driver.Connector {
+	return &connector{c, p}
+}
+
+type connector struct {
+	c driver.Connector
+	p retry.Policy
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return retry.Do(ctx, c.p, func(ctx context.Context) (driver.Conn, error) {
+		conn, err := c.c.Connect(ctx)
+		return conn, Classify(err)
+	})
+}
+
+func (c *connector) Driver() driver.Driver { return c.c.Driver() }
+
+// Close closes the connector wrapped, if it is an io.Closer, as
+// sql.DB.Close does for connectors.
+func (c *connector) Close() error {
+	if closer, ok := c.c.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// transientStates are the SQLSTATE codes, or their classes of two
+// characters, of failures that may pass: connection exceptions,
+// insufficient resources, such as too many connections, and a server
+// that is starting up or shutting down.
+var transientStates = []string{"08", "53", "57P01", "57P02", "57P03"}
+
+// transientMessages are parts of the messages of transient failures
+// reported by drivers that give no SQLSTATE, in lower case.
+var transientMessages = []string{
+	"too many connections",                      // MySQL error 1040
+	"too many clients",                          // PostgreSQL
+	"the database system is starting up",        // PostgreSQL
+	"the database system is shutting down",      // PostgreSQL
+	"server closed the connection unexpectedly", // libpq
+	"connection reset by peer",
+	"connection refused",
+	"broken pipe",
+}
+
+// Transient reports whether the connection that failed with err may
+// succeed if made again: if err is driver.ErrBadConn, or, as for
+// errors with a SQLState() string method, such as those of pgx, it
+// has the SQLSTATE of a transient failure, such as 53300 (too many
+// connections), or if its message says as much.
+func Transient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	if state, ok := sqlState(err); ok {
+		return slices.ContainsFunc(transientStates, func(s string) bool { return strings.HasPrefix(state, s) })
+	}
+	msg := strings.ToLower(err.Error())
+	return slices.ContainsFunc(transientMessages, func(m string) bool { return strings.Contains(msg, m) })
+}
+
+// Classify returns err marked as retryable if it is Transient, or as
+// permanent if it has a SQLSTATE of another failure, such as failed
+// authentication, which the server reported definitively. Other
+// errors are returned as they are, for errclass to decide. Classify
+// returns nil if err is nil.
+func Classify(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case Transient(err):
+		return errclass.MarkRetryable(err)
+	}
+	if _, ok := sqlState(err); ok {
+		return errclass.MarkPermanent(err)
+	}
+	return err
+}
+
+// sqlState returns the SQLSTATE of err, if it has one.
+func sqlState(err error) (string, bool) {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) && e.SQLState() != "" {
+		return e.SQLState(), true
+	}
+	return "", false
+}
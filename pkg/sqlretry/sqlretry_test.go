@@ -0,0 +1,83 @@
+package sqlretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// A stateError is a driver error with a SQLSTATE.
+type stateError string
+
+func (e stateError) Error() string    { return "sqlstate " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+// A fakeConnector fails with errs in turn, and then connects.
+type fakeConnector struct {
+	errs  []error
+	calls int
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.calls++; c.calls <= len(c.errs) {
+		return nil, c.errs[c.calls-1]
+	}
+	return fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ driver.Conn }
+
+func (fakeConn) Close() error { return nil }
+
+func TestConnector(t *testing.T) {
+	p := retry.Policy{Backoff: backoff.Constant(time.Millisecond), MaxAttempts: 5}
+	for _, test := range []struct {
+		errs      []error
+		wantErr   bool
+		wantCalls int
+	}{
+		{[]error{stateError("53300"), fmt.Errorf("dial: %w", syscall.ECONNREFUSED)}, false, 3},
+		{[]error{errors.New("Error 1040: Too many connections"), driver.ErrBadConn}, false, 3},
+		{[]error{stateError("28P01")}, true, 1}, // invalid password
+	} {
+		c := &fakeConnector{errs: test.errs}
+		conn, err := NewConnector(c, p).Connect(context.Background())
+		if (err != nil) != test.wantErr || c.calls != test.wantCalls {
+			t.Errorf("%v: got %v, %v after %d calls; want error %t after %d", test.errs, conn, err, c.calls, test.wantErr, test.wantCalls)
+		}
+	}
+}
+
+func TestOpenDB(t *testing.T) {
+	c := &fakeConnector{errs: []error{stateError("57P03")}}
+	db := sql.OpenDB(NewConnector(c, retry.Policy{Backoff: backoff.Constant(time.Millisecond)}))
+	defer db.Close()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if c.calls != 2 {
+		t.Errorf("connected after %d calls, want 2", c.calls)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	if !errclass.Retryable(Classify(stateError("08006"))) || errclass.Retryable(Classify(stateError("42P01"))) {
+		t.Error("Classify marked SQLSTATEs wrongly")
+	}
+	if err := errors.New("syntax"); Classify(err) != err {
+		t.Error("Classify marked an unknown error")
+	}
+}
@@ -0,0 +1,50 @@
+package wait
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// amqpHeader is the protocol header an AMQP 0-9-1 client opens
+// a connection with.
+var amqpHeader = []byte("AMQP\x00\x00\x09\x01")
+
+// An amqpChecker checks whether an AMQP 0-9-1 broker, such as RabbitMQ,
+// answers the protocol header with the Connection.Start method that
+// begins the handshake. It closes the connection right after.
+type amqpChecker struct {
+	cfg    *config
+	addr   string // host:port
+	secure bool   // use TLS
+}
+
+func (p *amqpChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dial(ctx, p.addr, p.secure)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(amqpHeader); err != nil {
+		return err
+	}
+	// A frame starts with its type, channel, and payload size; the
+	// payload of a method frame starts with the class and method IDs.
+	var frame [11]byte
+	if _, err := io.ReadFull(conn, frame[:]); err != nil {
+		return fmt.Errorf("reading AMQP handshake: %w", err)
+	}
+	if string(frame[:4]) == "AMQP" {
+		// The broker rejected our version and sent its own header.
+		return fmt.Errorf("broker wants AMQP version %d-%d-%d", frame[5], frame[6], frame[7])
+	}
+	const methodFrame, connectionClass, startMethod = 1, 10, 10
+	class := binary.BigEndian.Uint16(frame[7:9])
+	method := binary.BigEndian.Uint16(frame[9:11])
+	if frame[0] != methodFrame || class != connectionClass || method != startMethod {
+		return fmt.Errorf("unexpected AMQP frame (type %d, method %d.%d)", frame[0], class, method)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package wait
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header in which the HTTP requests of checks
+// carry the ID of their attempt, unless WithHeader sets it.
+const RequestIDHeader = "X-Request-Id"
+
+type attemptIDKey struct{}
+
+// AttemptID returns the ID of the attempt that ctx, passed to the
+// Check method of a Checker, is for, or "" if it is not for one. Every
+// attempt of a wait gets a new random ID, which the requests of the
+// checks of this package carry in RequestIDHeader, or for gRPC in
+// x-request-id metadata, so that the logs of servers can be matched
+// with the attempts. It is logged with the attempt and given in
+// Attempt.ID, and that of the last attempt of a failed wait is
+// attached to its error as the errfields field "attempt_id". Checkers
+// of other protocols can pass it on too.
+func AttemptID(ctx context.Context) string {
+	id, _ := ctx.Value(attemptIDKey{}).(string)
+	return id
+}
+
+// withAttemptID returns a copy of ctx for the attempt of ID id.
+func withAttemptID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, attemptIDKey{}, id)
+}
+
+// newAttemptID returns a random attempt ID of 16 hex digits.
+func newAttemptID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// setRequestID sets the request ID header of req to the attempt ID of
+// its context, if it has one and the header is not set yet.
+func setRequestID(req *http.Request) {
+	if id := AttemptID(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
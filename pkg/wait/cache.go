@@ -0,0 +1,125 @@
+package wait
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// A Cache remembers which targets were found ready, for the waits
+// given it by WithCache, so that waits for a target found ready by
+// another within a TTL, before or while they wait, succeed without
+// checking the target again. The waits look in the Cache before each
+// attempt and do not share attempts in flight, so concurrent waits
+// for a target all check it until one of them finds it ready.
+// With a FailureTTL, it remembers too which targets failed for good,
+// such as with a 404 on their health path or a certificate for another
+// name, so that the waits for them within the TTL fail at once with
+// the same error. Targets are named as in the Result of their waits.
+// The zero Cache remembers ready targets until they are invalidated,
+// and no failures. A Cache may be used concurrently.
+type Cache struct {
+	// TTL, if positive, is how long targets found ready are taken
+	// to stay so.
+	TTL time.Duration
+
+	// FailureTTL, if positive, is how long targets whose waits
+	// failed with an error that is not retried, as matched by
+	// ErrNonRetryable, are taken to stay failed.
+	FailureTTL time.Duration
+
+	// Clock, if non-nil, tells the time instead of clock.Real.
+	Clock clock.Clock
+
+	mu     sync.Mutex
+	ready  map[string]time.Time // when each target was found ready
+	failed map[string]failure   // by target
+}
+
+// A failure is a target found to have failed for good.
+type failure struct {
+	at  time.Time
+	err error // of the wait
+}
+
+// WithCache makes WaitForServer succeed at once for targets that c
+// remembers as ready, and fail at once for those it remembers as
+// failed, and record in c those it finds ready or failed.
+func WithCache(c *Cache) Option {
+	return func(cfg *config) { cfg.cache = c }
+}
+
+func (c *Cache) now() time.Time {
+	if c.Clock == nil {
+		return clock.Real.Now()
+	}
+	return c.Clock.Now()
+}
+
+// Ready reports whether target was found ready less than the TTL ago.
+func (c *Cache) Ready(target string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.ready[target]
+	if ok && c.TTL > 0 && c.now().Sub(t) >= c.TTL {
+		delete(c.ready, target)
+		return false
+	}
+	return ok
+}
+
+// add records that target was found ready.
+func (c *Cache) add(target string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ready == nil {
+		c.ready = make(map[string]time.Time)
+	}
+	c.ready[target] = c.now()
+}
+
+// Failed returns the error of the wait that found target to have
+// failed for good less than the FailureTTL ago, or nil if none did.
+func (c *Cache) Failed(target string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.failed[target]
+	if ok && c.now().Sub(f.at) >= c.FailureTTL {
+		delete(c.failed, target)
+		return nil
+	}
+	return f.err
+}
+
+// fail records that target failed for good with err, if c remembers
+// failures.
+func (c *Cache) fail(target string, err error) {
+	if c.FailureTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed == nil {
+		c.failed = make(map[string]failure)
+	}
+	c.failed[target] = failure{c.now(), err}
+}
+
+// Invalidate forgets that target was found ready, or to have failed,
+// as when it is known to have gone down or been fixed, so that the
+// next waits check it again.
+func (c *Cache) Invalidate(target string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ready, target)
+	delete(c.failed, target)
+}
+
+// Clear forgets all targets.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.ready)
+	clear(c.failed)
+}
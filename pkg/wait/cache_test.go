@@ -0,0 +1,135 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// A namedChecker checks a target with a name shared by others, as
+// the waits for the same target in different goroutines do.
+type namedChecker struct {
+	name   string
+	checks atomic.Int32
+	err    error // of each check
+}
+
+func (c *namedChecker) Check(context.Context) error {
+	c.checks.Add(1)
+	return c.err
+}
+
+func (c *namedChecker) String() string { return c.name }
+
+func TestCacheReady(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	cache := &Cache{TTL: time.Minute, Clock: clk}
+	opts := []Option{WithCache(cache), WithClock(clk), WithLogger(slog.New(slog.DiscardHandler))}
+
+	first := &namedChecker{name: "db"}
+	if r, err := Wait(context.Background(), first, opts...); err != nil || r.Cached {
+		t.Fatalf("first wait: %+v, %v", r, err)
+	}
+	// Found ready by the first wait, so not checked by the second.
+	second := &namedChecker{name: "db", err: errors.New("down")}
+	if r, err := Wait(context.Background(), second, opts...); err != nil || !r.Cached || r.Attempts != 0 {
+		t.Errorf("second wait: %+v, %v; want it cached, with no attempts", r, err)
+	}
+	if n := second.checks.Load(); n != 0 {
+		t.Errorf("second wait checked the target %d times", n)
+	}
+	if !cache.Ready("db") || cache.Ready("queue") {
+		t.Errorf("Ready(db) = %t, Ready(queue) = %t; want only db", cache.Ready("db"), cache.Ready("queue"))
+	}
+
+	clk.Advance(time.Minute) // the TTL
+	if cache.Ready("db") {
+		t.Error("db still ready after the TTL")
+	}
+	third := &namedChecker{name: "db"}
+	if r, err := Wait(context.Background(), third, opts...); err != nil || r.Cached || third.checks.Load() != 1 {
+		t.Errorf("wait after the TTL: %+v, %v, %d checks; want one, not cached", r, err, third.checks.Load())
+	}
+
+	cache.Invalidate("db")
+	if cache.Ready("db") {
+		t.Error("db still ready after being invalidated")
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	cache := new(Cache)
+	opts := []Option{WithCache(cache), WithTimeout(5 * time.Second), WithInitialDelay(5 * time.Millisecond),
+		WithMaxDelay(5 * time.Millisecond), WithLogger(slog.New(slog.DiscardHandler))}
+
+	// Waits whose own checks never succeed, which end once another
+	// wait finds the target ready.
+	var wg sync.WaitGroup
+	results := make([]Result, 5)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = Wait(context.Background(), &namedChecker{name: "db", err: errors.New("down")}, opts...)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // for the waits to make attempts
+	if _, err := Wait(context.Background(), &namedChecker{name: "db"}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for i, r := range results {
+		if errs[i] != nil || !r.Cached || r.Attempts == 0 {
+			t.Errorf("concurrent wait: %+v, %v; want it to have checked, then found db ready by another", r, errs[i])
+		}
+	}
+}
+
+func TestCacheFailed(t *testing.T) {
+	clk := clock.NewInstant(time.Unix(0, 0))
+	cache := &Cache{FailureTTL: time.Minute, Clock: clk}
+	opts := []Option{WithCache(cache), WithClock(clk), WithMaxAttempts(3), WithLogger(slog.New(slog.DiscardHandler))}
+
+	broken := &namedChecker{name: "api", err: errclass.MarkPermanent(errors.New("404 Not Found"))}
+	_, firstErr := Wait(context.Background(), broken, opts...)
+	if !errors.Is(firstErr, ErrNonRetryable) {
+		t.Fatalf("first wait: %v, want %v", firstErr, ErrNonRetryable)
+	}
+	// Failed for good, so the second fails at once with the same error.
+	again := &namedChecker{name: "api"}
+	r, err := Wait(context.Background(), again, opts...)
+	if err != firstErr || !r.Cached || again.checks.Load() != 0 {
+		t.Errorf("second wait: %+v, %v, %d checks; want the cached error %v", r, err, again.checks.Load(), firstErr)
+	}
+	clk.Advance(time.Minute) // the FailureTTL
+	if r, err := Wait(context.Background(), again, opts...); err != nil || r.Cached || again.checks.Load() != 1 {
+		t.Errorf("wait after the FailureTTL: %+v, %v; want it checked and ready", r, err)
+	}
+
+	// Failures that are retried until the waits give up are not
+	// remembered, as the targets may yet come up.
+	flaky := &namedChecker{name: "queue", err: errors.New("connection refused")}
+	if _, err := Wait(context.Background(), flaky, opts...); !errors.Is(err, ErrMaxAttempts) {
+		t.Fatalf("wait for queue: %v, want %v", err, ErrMaxAttempts)
+	}
+	if err := cache.Failed("queue"); err != nil {
+		t.Errorf("queue remembered as failed with %v", err)
+	}
+
+	// Nor are failures without a FailureTTL.
+	plain := &Cache{Clock: clk}
+	if _, err := Wait(context.Background(), broken, append(opts, WithCache(plain))...); err == nil {
+		t.Fatal("wait for a broken api succeeded")
+	}
+	if err := plain.Failed("api"); err != nil {
+		t.Errorf("api remembered as failed without a FailureTTL: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A Checker checks once whether something, typically a server, is ready.
+// Check returns nil if it is, and otherwise an error saying why not.
+//
+// Wait retries any Checker, so programs can wait for things this
+// package knows nothing about by implementing Checker themselves.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts an ordinary function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+// Check returns f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+// A statuser is a Checker that can describe the last response it got,
+// for logging and Results.
+type statuser interface {
+	lastStatus() (code int, status string)
+}
+
+// A responseStatus makes the Checkers embedding it statusers, which
+// record the statuses of their responses with set.
+type responseStatus struct {
+	mu     sync.Mutex // for hedged checks
+	code   int
+	status string
+}
+
+func (s *responseStatus) set(code int, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code, s.status = code, status
+}
+
+func (s *responseStatus) lastStatus() (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.code, s.status
+}
+
+// NewChecker returns a Checker for target, chosen by the scheme of the
+// target URL as described for WaitForServer and configured by opts.
+// The Checker's String method returns target.
+func NewChecker(target string, opts ...Option) (Checker, error) {
+	cfg := newConfig(opts)
+	return newChecker(&cfg, target)
+}
+
+// A TargetError reports a target that cannot be waited for at all,
+// such as a URL with an unsupported scheme or missing a host.
+type TargetError struct {
+	Target string
+	Err    error
+}
+
+func (e *TargetError) Error() string { return e.Err.Error() }
+
+func (e *TargetError) Unwrap() error { return e.Err }
+
+// named gives a Checker a name for logs and errors, and redacts the
+// credentials in its errors.
+type named struct {
+	Checker
+	name   string
+	redact *strings.Replacer
+}
+
+func (n named) Check(ctx context.Context) error {
+	return redactError(n.Checker.Check(ctx), n.redact)
+}
+
+func (n named) String() string { return n.name }
+
+func (n named) lastStatus() (int, string) { return lastStatus(n.Checker) }
+
+func (n named) lastResponse() *ResponseSnapshot {
+	s := lastResponse(n.Checker)
+	if s != nil && n.redact != nil {
+		s = s.redacted(n.redact)
+	}
+	return s
+}
+
+// lastStatus returns the status code and status of the last response
+// c got, if c keeps track of it.
+func lastStatus(c Checker) (code int, status string) {
+	if s, ok := c.(statuser); ok {
+		return s.lastStatus()
+	}
+	return 0, ""
+}
+
+// checkerName returns the name of c for logs and errors.
+func checkerName(c Checker) string {
+	if s, ok := c.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+// newChecker returns the Checker for target, chosen by the scheme of
+// the target URL.
+func newChecker(cfg *config, target string) (Checker, error) {
+	r := newRedacter(target, cfg.header)
+	c, err := newSchemeChecker(cfg, target)
+	if err != nil {
+		return nil, &TargetError{r.Replace(target), redactError(err, r)}
+	}
+	return named{c, r.Replace(target), r}, nil
+}
+
+func newSchemeChecker(cfg *config, target string) (Checker, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if factory, ok := registeredScheme(u.Scheme); ok {
+		return factory(u)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return newHTTPChecker(cfg, target), nil
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host:port in %s", target)
+		}
+		return &dialChecker{cfg: cfg, network: "tcp", addr: u.Host}, nil
+	case "unix":
+		if u.Host != "" || u.Path == "" {
+			return nil, fmt.Errorf("want unix:///path/to/socket, not %s", target)
+		}
+		return &dialChecker{cfg: cfg, network: "unix", addr: u.Path}, nil
+	case "tls":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host:port in %s", target)
+		}
+		return &tlsChecker{cfg: cfg, addr: u.Host, serverName: u.Query().Get("servername")}, nil
+	case "grpc", "grpcs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host:port in %s", target)
+		}
+		service := cfg.grpcService
+		if q := u.Query(); q.Has("service") {
+			service = q.Get("service")
+		}
+		return &grpcChecker{cfg: cfg, addr: u.Host, secure: u.Scheme == "grpcs", service: service}, nil
+	case "redis", "rediss":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return newRedisChecker(cfg, u), nil
+	case "amqp", "amqps":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		port := "5672"
+		if u.Scheme == "amqps" {
+			port = "5671"
+		}
+		return &amqpChecker{cfg: cfg, addr: withDefaultPort(u.Host, port), secure: u.Scheme == "amqps"}, nil
+	case "kafka", "kafkas":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return newKafkaChecker(cfg, u), nil
+	case "es", "ess":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return newClusterHealthChecker(cfg, u)
+	case "ssh":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return newSSHChecker(cfg, u)
+	case "ntp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return newNTPChecker(cfg, u)
+	case "file":
+		if u.Host != "" || u.Path == "" {
+			return nil, fmt.Errorf("want file:///path, not %s", target)
+		}
+		return newFileChecker(u)
+	case "ping":
+		if u.Hostname() == "" {
+			return nil, fmt.Errorf("missing host in %s", target)
+		}
+		return &pingChecker{cfg: cfg, host: u.Hostname()}, nil
+	case "dns":
+		if u.Hostname() == "" {
+			return nil, fmt.Errorf("missing host name in %s", target)
+		}
+		return newDNSChecker(cfg, u.Hostname(), u.Query())
+	case "process":
+		return newProcessChecker(u)
+	case "winsvc":
+		return newServiceChecker(u)
+	case "s3":
+		return newS3Checker(cfg, u)
+	case "metadata":
+		return newMetadataChecker(cfg, u)
+	}
+	if cfg.plugins && u.Scheme != "" {
+		return newPluginChecker(u.Scheme, target)
+	}
+	return nil, fmt.Errorf("unsupported scheme %q in %s", u.Scheme, target)
+}
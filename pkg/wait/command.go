@@ -0,0 +1,56 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// WaitForCommand runs the command described by args, a program name
+// followed by its arguments, until it exits with status 0. It allows
+// any custom readiness check, such as pg_isready, to be retried with
+// the options of WaitForServer.
+func WaitForCommand(ctx context.Context, args []string, opts ...Option) (Result, error) {
+	if len(args) == 0 {
+		err := errors.New("wait: empty command")
+		return Result{Err: err}, err
+	}
+	return Wait(ctx, CommandChecker(args...), opts...)
+}
+
+// CommandChecker returns a Checker that runs the program args[0] with
+// the arguments args[1:] and succeeds if it exits with status 0.
+func CommandChecker(args ...string) Checker {
+	return named{&commandChecker{args}, strings.Join(args, " "), nil}
+}
+
+// A commandChecker checks whether a command exits successfully.
+type commandChecker struct {
+	args []string
+}
+
+// maxOutput limits how much of a failed command's output goes into
+// the error.
+const maxOutput = 200
+
+func (p *commandChecker) Check(ctx context.Context) error {
+	if len(p.args) == 0 {
+		return errors.New("empty command")
+	}
+	cmd := exec.CommandContext(ctx, p.args[0], p.args[1:]...)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	out = bytes.TrimSpace(out)
+	if len(out) > maxOutput {
+		out = append([]byte("..."), out[len(out)-maxOutput:]...)
+	}
+	if len(out) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, out)
+}
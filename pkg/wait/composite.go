@@ -0,0 +1,180 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// A CheckError reports why one of the checks of a composite Checker
+// failed.
+type CheckError struct {
+	Name string // name of the failed Checker
+	Err  error
+}
+
+func (e *CheckError) Error() string { return e.Name + ": " + e.Err.Error() }
+func (e *CheckError) Unwrap() error { return e.Err }
+
+// A CompositeError reports which checks of a composite Checker made by
+// All, Any, or Sequence failed, and why.
+type CompositeError struct {
+	Total  int           // number of checks in the composite
+	Failed []*CheckError // failed checks, in the order they were given
+}
+
+func (e *CompositeError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d of %d checks not ready: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed checks, so that errors.Is
+// and errors.As look at each of them.
+func (e *CompositeError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// All returns a Checker that is ready when all of cs are. It runs
+// their checks concurrently and reports the failed ones in a
+// *CompositeError.
+func All(cs ...Checker) Checker {
+	return &composite{"all", cs, checkAll}
+}
+
+// Any returns a Checker that is ready as soon as one of cs is. It runs
+// their checks concurrently, cancels the others once one succeeds,
+// and reports the failures in a *CompositeError if none does.
+func Any(cs ...Checker) Checker {
+	return &composite{"any", cs, checkAny}
+}
+
+// Sequence returns a Checker that checks cs one after the other, each
+// only once the previous one is ready, and is ready when the last one
+// is. It reports the first failed check in a *CompositeError.
+func Sequence(cs ...Checker) Checker {
+	return &composite{"sequence", cs, checkSequence}
+}
+
+// ErrUp is the error of the Checkers made by Not when the target is
+// still up.
+var ErrUp = errors.New("still responding")
+
+// Not returns a Checker that is ready when c is not: once its check
+// fails, as when a server no longer accepts connections, so as to wait
+// for the target of c to go down, such as the old instance of a server
+// releasing its port. A check cut short by its context, as by the
+// attempt timeout, does not count as failed, as the target may just
+// be slow.
+func Not(c Checker) Checker {
+	return not{c}
+}
+
+type not struct{ c Checker }
+
+func (n not) Check(ctx context.Context) error {
+	err := n.c.Check(ctx)
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case err != nil:
+		return nil
+	}
+	return errclass.MarkRetryable(ErrUp)
+}
+
+func (n not) String() string { return "not(" + checkerName(n.c) + ")" }
+
+type composite struct {
+	kind  string
+	cs    []Checker
+	check func(ctx context.Context, cs []Checker) []*CheckError
+}
+
+func (c *composite) Check(ctx context.Context) error {
+	failed := c.check(ctx, c.cs)
+	if len(failed) == 0 {
+		return nil
+	}
+	// Any of none fails as if it were a check.
+	return &CompositeError{Total: max(len(c.cs), len(failed)), Failed: failed}
+}
+
+func (c *composite) String() string {
+	names := make([]string, len(c.cs))
+	for i, sub := range c.cs {
+		names[i] = checkerName(sub)
+	}
+	return c.kind + "(" + strings.Join(names, ", ") + ")"
+}
+
+// checkConcurrently checks each of cs in its own goroutine and returns
+// the errors in the order of cs, nil for the ready ones.
+func checkConcurrently(ctx context.Context, cs []Checker) []error {
+	errs := make([]error, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Check(ctx)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func checkAll(ctx context.Context, cs []Checker) []*CheckError {
+	var failed []*CheckError
+	for i, err := range checkConcurrently(ctx, cs) {
+		if err != nil {
+			failed = append(failed, &CheckError{checkerName(cs[i]), err})
+		}
+	}
+	return failed
+}
+
+func checkAny(ctx context.Context, cs []Checker) []*CheckError {
+	if len(cs) == 0 {
+		return []*CheckError{{"any", errors.New("nothing to check")}}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(cs))
+	done := make(chan int, len(cs))
+	for i, c := range cs {
+		go func() {
+			errs[i] = c.Check(ctx)
+			done <- i
+		}()
+	}
+	for range cs {
+		if errs[<-done] == nil {
+			return nil // the others are canceled and will finish by themselves
+		}
+	}
+	failed := make([]*CheckError, len(cs))
+	for i, err := range errs {
+		failed[i] = &CheckError{checkerName(cs[i]), err}
+	}
+	return failed
+}
+
+func checkSequence(ctx context.Context, cs []Checker) []*CheckError {
+	for _, c := range cs {
+		if err := c.Check(ctx); err != nil {
+			return []*CheckError{{checkerName(c), err}}
+		}
+	}
+	return nil
+}
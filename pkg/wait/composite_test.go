@@ -0,0 +1,86 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestComposite(t *testing.T) {
+	errDown := errors.New("down")
+	up := func(name string) *namedChecker { return &namedChecker{name: name} }
+	down := func(name string) *namedChecker { return &namedChecker{name: name, err: errDown} }
+
+	for _, test := range []struct {
+		name      string
+		make      func(...Checker) Checker
+		cs        []*namedChecker
+		err       string   // or "" if ready
+		checked   []string // the checks made
+		unchecked []string // and those not, of the others maybe made
+	}{
+		{"all ready", All, []*namedChecker{up("a"), up("b"), up("c")}, "", []string{"a", "b", "c"}, nil},
+		{"all but one", All, []*namedChecker{up("a"), down("b"), up("c")}, "1 of 3 checks not ready: b: down", []string{"a", "b", "c"}, nil},
+		{"all down", All, []*namedChecker{down("a"), down("b")}, "2 of 2 checks not ready: a: down; b: down", []string{"a", "b"}, nil},
+		{"all of none", All, nil, "", nil, nil},
+		{"any ready", Any, []*namedChecker{down("a"), up("b")}, "", []string{"b"}, nil},
+		{"any down", Any, []*namedChecker{down("a"), down("b")}, "2 of 2 checks not ready: a: down; b: down", []string{"a", "b"}, nil},
+		{"any of none", Any, nil, "1 of 1 checks not ready: any: nothing to check", nil, nil},
+		{"sequence ready", Sequence, []*namedChecker{up("a"), up("b")}, "", []string{"a", "b"}, nil},
+		{"sequence stopped", Sequence, []*namedChecker{up("a"), down("b"), up("c")}, "1 of 3 checks not ready: b: down", []string{"a", "b"}, []string{"c"}},
+	} {
+		cs := make([]Checker, len(test.cs))
+		for i, c := range test.cs {
+			cs[i] = c
+		}
+		err := test.make(cs...).Check(context.Background())
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%s: got error %v, want %s", test.name, err, test.err)
+		case test.err != "" && len(test.cs) > 0 && !errors.Is(err, errDown):
+			t.Errorf("%s: %v is not %v", test.name, err, errDown)
+		}
+		for _, c := range test.cs {
+			checked := c.checks.Load() > 0
+			if !checked && slices.Contains(test.checked, c.name) || checked && slices.Contains(test.unchecked, c.name) {
+				t.Errorf("%s: %s checked: %t, want %t", test.name, c.name, checked, !checked)
+			}
+		}
+	}
+}
+
+func TestCompositeError(t *testing.T) {
+	err := All(&namedChecker{name: "db"}, &namedChecker{name: "api", err: ErrUp}).Check(context.Background())
+	var ce *CompositeError
+	if !errors.As(err, &ce) || ce.Total != 2 || len(ce.Failed) != 1 {
+		t.Fatalf("got %v, want a CompositeError of 1 of 2 checks", err)
+	}
+	var failed *CheckError
+	if !errors.As(err, &failed) || failed.Name != "api" || !errors.Is(failed, ErrUp) {
+		t.Errorf("got %v, want the CheckError of api", err)
+	}
+	if got, want := checkerName(Sequence(All(&namedChecker{name: "a"}), Any(&namedChecker{name: "b"}))), "sequence(all(a), any(b))"; got != want {
+		t.Errorf("named %q, want %q", got, want)
+	}
+}
+
+func TestAnyCancels(t *testing.T) {
+	canceled := make(chan struct{})
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	if err := Any(slow, &namedChecker{name: "fast"}).Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("slow check not canceled once another succeeded")
+	}
+}
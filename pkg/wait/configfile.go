@@ -0,0 +1,479 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Config describes a set of targets to wait for, as read from a
+// configuration file by LoadConfig.
+type Config struct {
+	// Timeout limits the whole wait, like WithTimeout.
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+	Targets []Target `json:"targets" yaml:"targets"`
+}
+
+// A Target is a URL to wait for, as by WaitForServer, with settings
+// that override the options given to Config.Wait.
+type Target struct {
+	// Name identifies the target in DependsOn and in errors. It
+	// defaults to URL.
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+
+	// Timeout limits the wait for this target, counted from when its
+	// dependencies are ready.
+	Timeout        Duration          `json:"timeout" yaml:"timeout"`
+	AttemptTimeout Duration          `json:"attempt_timeout" yaml:"attempt_timeout"`
+	Method         string            `json:"method" yaml:"method"`
+	ExpectStatus   Statuses          `json:"expect_status" yaml:"expect_status"`
+	Headers        map[string]string `json:"headers" yaml:"headers"`
+	ExpectBody     string            `json:"expect_body" yaml:"expect_body"`       // a regular expression
+	ExpectHeaders  map[string]string `json:"expect_headers" yaml:"expect_headers"` // regular expressions matching values in full
+	SuccessCount   int               `json:"success_count" yaml:"success_count"`
+
+	// DependsOn names the targets that must be ready before this one
+	// is waited for.
+	DependsOn []string `json:"depends_on" yaml:"depends_on"`
+}
+
+// A Duration is a time.Duration written as a string such as "30s" in
+// configuration files.
+type Duration time.Duration
+
+// MarshalText formats d like time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses a duration as by time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadConfig reads a Config from the named file, as JSON if its name
+// ends in .json and as YAML otherwise.
+func LoadConfig(name string) (*Config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var cfg *Config
+	if filepath.Ext(name) == ".json" {
+		cfg, err = ParseConfigJSON(data)
+	} else {
+		cfg, err = ParseConfigYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfigJSON parses and validates a Config written in JSON.
+func ParseConfigJSON(data []byte) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, cfg.validate()
+}
+
+// ParseConfigYAML parses and validates a Config written in YAML.
+func ParseConfigYAML(data []byte) (*Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, cfg.validate()
+}
+
+// ParseTarget parses a Target written on one line, as a URL followed
+// by settings separated by commas, such as
+//
+//	http://db:8080/health,timeout=30s,status=200-299,301,method=GET
+//
+// The settings are named like the members of a target in a
+// configuration file, except that expect_status may be written as
+// status, and each of headers and expect_headers sets one header, as
+// in headers=Authorization: Bearer token, and may be repeated. The URL
+// ends at the first comma followed by the name of a setting and =, so
+// that URLs may have commas of their own. The values of settings go on
+// after commas until the next setting, as for lists of statuses and of
+// depends_on.
+func ParseTarget(s string) (Target, error) {
+	url, rest := splitTargetURL(s)
+	t := Target{URL: url}
+	if t.URL == "" {
+		return t, errors.New("missing url")
+	}
+	var settings [][2]string
+	if rest != "" {
+		for _, f := range strings.Split(rest, ",") {
+			key, value, ok := strings.Cut(f, "=")
+			if (!ok || !isSettingName(key)) && len(settings) > 0 {
+				settings[len(settings)-1][1] += "," + f // a value goes on
+				continue
+			}
+			settings = append(settings, [2]string{key, value})
+		}
+	}
+	for _, kv := range settings {
+		key, value := kv[0], kv[1]
+		var err error
+		switch key {
+		case "name":
+			t.Name = value
+		case "timeout":
+			err = t.Timeout.UnmarshalText([]byte(value))
+		case "attempt_timeout":
+			err = t.AttemptTimeout.UnmarshalText([]byte(value))
+		case "method":
+			t.Method = value
+		case "status", "expect_status":
+			t.ExpectStatus, err = ParseStatuses(value)
+		case "expect_body":
+			t.ExpectBody = value
+		case "success_count":
+			t.SuccessCount, err = strconv.Atoi(value)
+		case "headers":
+			err = addHeader(&t.Headers, value)
+		case "expect_headers":
+			err = addHeader(&t.ExpectHeaders, value)
+		case "depends_on":
+			t.DependsOn = strings.Split(value, ",")
+		default:
+			err = errors.New("unknown setting")
+		}
+		if err != nil {
+			return t, fmt.Errorf("%s: %s: %w", RedactURL(t.URL), key, err)
+		}
+	}
+	return t, nil
+}
+
+// targetSettings are the names of the settings of ParseTarget.
+var targetSettings = []string{
+	"name", "timeout", "attempt_timeout", "method", "status", "expect_status", "expect_body",
+	"success_count", "headers", "expect_headers", "depends_on",
+}
+
+// splitTargetURL splits s, as parsed by ParseTarget, into its URL and
+// its settings, without the comma between them.
+func splitTargetURL(s string) (url, settings string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != ',' {
+			continue
+		}
+		if key, _, ok := strings.Cut(s[i+1:], "="); ok && slices.Contains(targetSettings, key) {
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// isSettingName reports whether key is named like a setting of
+// ParseTarget, if not necessarily one, as opposed to a value going
+// on after a comma: lower case letters and underscores.
+func isSettingName(key string) bool {
+	return key != "" && strings.Trim(key, "abcdefghijklmnopqrstuvwxyz_") == ""
+}
+
+// addHeader adds the header of h, written as Name: value, to headers.
+func addHeader(headers *map[string]string, h string) error {
+	name, value, ok := strings.Cut(h, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("want Name: value, not %q", h)
+	}
+	if *headers == nil {
+		*headers = make(map[string]string)
+	}
+	(*headers)[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	return nil
+}
+
+// AddTarget adds t to the targets of cfg, such as one parsed by
+// ParseTarget, checking it as the targets of a configuration file
+// are checked.
+func (cfg *Config) AddTarget(t Target) error {
+	cfg.Targets = append(cfg.Targets, t)
+	if err := cfg.validate(); err != nil {
+		cfg.Targets = cfg.Targets[:len(cfg.Targets)-1]
+		return err
+	}
+	return nil
+}
+
+// validate fills in the default target names, and checks that they
+// are unique and that the dependencies exist and have no cycles.
+func (cfg *Config) validate() error {
+	index := make(map[string]int)
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		if t.URL == "" {
+			return fmt.Errorf("target %d: missing url", i+1)
+		}
+		if t.Name == "" {
+			t.Name = RedactURL(t.URL)
+		}
+		if _, ok := index[t.Name]; ok {
+			return fmt.Errorf("duplicate target %q", t.Name)
+		}
+		if _, err := regexp.Compile(t.ExpectBody); err != nil {
+			return fmt.Errorf("target %q: expect_body: %w", t.Name, err)
+		}
+		for key, value := range t.ExpectHeaders {
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("target %q: expect_headers: %s: %w", t.Name, key, err)
+			}
+		}
+		index[t.Name] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(cfg.Targets))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("dependency cycle through %q", cfg.Targets[i].Name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range cfg.Targets[i].DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("target %q: unknown dependency %q", cfg.Targets[i].Name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range cfg.Targets {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait waits for all targets of cfg concurrently, each one once its
+// dependencies are ready, using opts overridden by the settings of
+// cfg and of the target, and returns the Results of the waits in the
+// order of the targets. It reports an error, joining those of the
+// targets that failed, if any of them does; a target whose
+// dependencies fail is not waited for and fails as well. See
+// WithFailFast.
+func (cfg *Config) Wait(ctx context.Context, opts ...Option) ([]Result, error) {
+	if cfg.Timeout != 0 {
+		opts = append(opts[:len(opts):len(opts)], WithTimeout(time.Duration(cfg.Timeout)))
+	}
+	ctx, cancel, opts := shareDeadline(ctx, opts)
+	defer cancel()
+	ctx, abort := abortOnPermanent(ctx, opts)
+
+	index := make(map[string]int, len(cfg.Targets))
+	done := make([]chan struct{}, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		index[t.Name] = i
+		done[i] = make(chan struct{})
+	}
+	results := make([]Result, len(cfg.Targets))
+	errs := make([]error, len(cfg.Targets))
+	blocking := make([]bool, len(cfg.Targets)) // failed with dependencies ready
+	var wg sync.WaitGroup
+	for i, t := range cfg.Targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dep := range t.DependsOn {
+				j := index[dep]
+				<-done[j]
+				if errs[j] != nil {
+					errs[i] = fmt.Errorf("%s not waited for: dependency %s not ready", t.Name, dep)
+					results[i] = Result{Target: t.URL, Err: errs[i]}
+					return
+				}
+			}
+			r, err := WaitForServer(ctx, t.URL, t.options(opts)...)
+			if err != nil && t.Name != t.URL {
+				err = fmt.Errorf("%s: %w", t.Name, err)
+			}
+			r.Err = err
+			results[i], errs[i], blocking[i] = r, err, err != nil
+			abort(t.Name, err)
+		}()
+	}
+	wg.Wait()
+	err := errors.Join(errs...)
+	if layers := cfg.layers(); err != nil && slices.Max(layers) > 0 {
+		e := &BlockedError{Layers: slices.Max(layers) + 1, Layer: -1, Err: err}
+		for i, t := range cfg.Targets {
+			if !blocking[i] {
+				continue
+			}
+			switch {
+			case e.Layer < 0 || layers[i] < e.Layer:
+				e.Layer, e.Targets = layers[i], []string{t.Name}
+			case layers[i] == e.Layer:
+				e.Targets = append(e.Targets, t.Name)
+			}
+		}
+		if e.Layer >= 0 { // else all that failed were not waited for
+			err = e
+		}
+	}
+	return results, err
+}
+
+// A BlockedError reports the targets that keep Config.Wait from
+// succeeding, for targets with dependencies: those of the lowest
+// layer of the dependency graph that failed although their own
+// dependencies were ready. The targets without dependencies make up
+// layer 0, and those depending on targets of layers up to n at most
+// make up layer n+1.
+type BlockedError struct {
+	Layer   int      // the layer of Targets
+	Layers  int      // the number of layers
+	Targets []string // the names of the failed targets of Layer
+	Err     error    // the errors of all targets that failed
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("blocked at dependency layer %d of %d by %s: %v",
+		e.Layer, e.Layers, strings.Join(e.Targets, ", "), e.Err)
+}
+
+func (e *BlockedError) Unwrap() error { return e.Err }
+
+// Layers returns the names of the targets of cfg by layer of their
+// dependency graph, as described by BlockedError, in the order that
+// Wait gets to them.
+func (cfg *Config) Layers() [][]string {
+	var names [][]string
+	for i, layer := range cfg.layers() {
+		for len(names) <= layer {
+			names = append(names, nil)
+		}
+		names[layer] = append(names[layer], cfg.Targets[i].Name)
+	}
+	return names
+}
+
+// layers returns the layer of each target of cfg, whose dependencies
+// have been validated.
+func (cfg *Config) layers() []int {
+	index := make(map[string]int, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		index[t.Name] = i
+	}
+	layers := make([]int, len(cfg.Targets))
+	for i := range layers {
+		layers[i] = -1
+	}
+	var layer func(i int) int
+	layer = func(i int) int {
+		if layers[i] < 0 {
+			layers[i] = 0
+			for _, dep := range cfg.Targets[i].DependsOn {
+				layers[i] = max(layers[i], layer(index[dep])+1)
+			}
+		}
+		return layers[i]
+	}
+	for i := range layers {
+		layer(i)
+	}
+	return layers
+}
+
+// Watch watches all targets of cfg concurrently, as by Watch, until
+// ctx is done, using opts overridden by the settings of the targets
+// that apply to single checks. Dependencies are ignored; a Change
+// names its target by its Name, and f may be called concurrently for
+// different targets. Watch returns the error of ctx, or
+// that of the first target that cannot be checked at all.
+func (cfg *Config) Watch(ctx context.Context, interval time.Duration, f func(Change), opts ...Option) error {
+	checkers := make([]Checker, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		c, err := NewChecker(t.URL, t.options(opts)...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.Name, err)
+		}
+		checkers[i] = named{c, t.Name, nil}
+	}
+	var wg sync.WaitGroup
+	for i, t := range cfg.Targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Watch(ctx, checkers[i], interval, f, t.options(opts)...)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// options returns opts extended with the settings of t.
+func (t *Target) options(opts []Option) []Option {
+	opts = opts[:len(opts):len(opts)]
+	if t.Timeout != 0 {
+		opts = append(opts, WithTimeout(time.Duration(t.Timeout)))
+	}
+	if t.AttemptTimeout != 0 {
+		opts = append(opts, WithAttemptTimeout(time.Duration(t.AttemptTimeout)))
+	}
+	if t.Method != "" {
+		opts = append(opts, WithMethod(t.Method))
+	}
+	if t.ExpectStatus != nil {
+		opts = append(opts, WithExpectStatus(t.ExpectStatus))
+	}
+	if t.ExpectBody != "" {
+		opts = append(opts, WithExpectBody(regexp.MustCompile(t.ExpectBody))) // checked by validate
+	}
+	for key, value := range t.Headers {
+		opts = append(opts, WithHeader(key, value))
+	}
+	for key, value := range t.ExpectHeaders {
+		var re *regexp.Regexp
+		if value != "" {
+			re = regexp.MustCompile(value) // checked by validate
+		}
+		opts = append(opts, WithExpectHeader(key, re))
+	}
+	if t.SuccessCount != 0 {
+		opts = append(opts, WithSuccessCount(t.SuccessCount))
+	}
+	return opts
+}
@@ -0,0 +1,94 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// A Dialer makes network connections, like net.Dialer and the SOCKS5
+// dialers of golang.org/x/net/proxy.
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// WithDialer makes checks connect to TCP addresses with d, whether
+// directly, over TLS, or for HTTP, gRPC, Redis, or AMQP: for example
+// through a SOCKS5 proxy to reach servers behind a bastion host, or
+// from a source address, as set by the LocalAddr of a net.Dialer. The
+// resolver of WithResolver is then used only for WithAllAddresses
+// and ResolvePin. By default a net.Dialer does. The UDP packets of
+// ntp:// targets are still sent directly.
+func WithDialer(d Dialer) Option {
+	return func(c *config) { c.dialer = d }
+}
+
+// A dialChecker checks whether a server accepts connections on a TCP
+// address or Unix domain socket. It suits databases, local daemons,
+// and other services that don't speak HTTP.
+type dialChecker struct {
+	cfg     *config
+	network string // "tcp" or "unix"
+	addr    string // host:port or socket path
+}
+
+func (p *dialChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dialContext(ctx, p.network, p.addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dial connects to the TCP address addr, over TLS if secure.
+// The connection's deadline is that of ctx, if any, so that protocol
+// exchanges on it end when ctx does.
+func (c *config) dial(ctx context.Context, addr string, secure bool) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if secure {
+		conn, err = c.dialTLS(ctx, addr, c.tlsConfig)
+	} else {
+		conn, err = c.dialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// dialTLS connects to the TCP address addr and completes a TLS
+// handshake configured by conf, which may be nil. Like tls.Dialer, it
+// verifies the certificate for the host of addr unless conf names
+// another server.
+func (c *config) dialTLS(ctx context.Context, addr string, conf *tls.Config) (*tls.Conn, error) {
+	conn, err := c.dialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conf = conf.Clone()
+	if conf == nil {
+		conf = new(tls.Config)
+	}
+	if conf.ServerName == "" {
+		conf.ServerName = hostOf(addr, "")
+	}
+	tlsConn := tls.Client(conn, conf)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// withDefaultPort returns host:port, adding port to hostport if it
+// has none.
+func withDefaultPort(hostport, port string) string {
+	if _, _, err := net.SplitHostPort(hostport); err == nil {
+		return hostport
+	}
+	return net.JoinHostPort(hostport, port)
+}
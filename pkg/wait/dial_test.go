@@ -0,0 +1,36 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+)
+
+// A recordingDialer fails to connect, recording the networks it was
+// asked to connect on.
+type recordingDialer struct {
+	networks []string
+}
+
+func (d *recordingDialer) DialContext(_ context.Context, network, _ string) (net.Conn, error) {
+	d.networks = append(d.networks, network)
+	return nil, errors.New("proxy refused")
+}
+
+func TestDialerOnlyForTCP(t *testing.T) {
+	d := new(recordingDialer)
+	cfg := newConfig([]Option{WithDialer(d)})
+	if _, err := cfg.dialContext(context.Background(), "tcp", "127.0.0.1:1"); err == nil {
+		t.Error("connected over TCP, want the error of the Dialer")
+	}
+	conn, err := cfg.dialContext(context.Background(), "udp", "127.0.0.1:123")
+	if err != nil {
+		t.Fatalf("UDP: %v, want it sent directly", err)
+	}
+	conn.Close()
+	if !slices.Equal(d.networks, []string{"tcp"}) {
+		t.Errorf("Dialer asked for %v, want only tcp", d.networks)
+	}
+}
@@ -0,0 +1,122 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// A dnsChecker checks whether a host name resolves, optionally to a
+// particular value. It suits environments where DNS records appear
+// some time after the service they point to, as in Kubernetes.
+type dnsChecker struct {
+	cfg   *config
+	host  string
+	rtype string // A, AAAA, IP (either), CNAME, MX, NS, SRV, or TXT
+	want  string // value one of the records must have, if not empty
+}
+
+// newDNSChecker returns a checker for a dns://host?type=T&want=V target.
+func newDNSChecker(cfg *config, host string, query map[string][]string) (*dnsChecker, error) {
+	p := &dnsChecker{cfg: cfg, host: host, rtype: "IP"}
+	if t := first(query["type"]); t != "" {
+		p.rtype = strings.ToUpper(t)
+	}
+	switch p.rtype {
+	case "A", "AAAA", "IP", "CNAME", "MX", "NS", "SRV", "TXT":
+	default:
+		return nil, fmt.Errorf("unsupported DNS record type %q", p.rtype)
+	}
+	p.want = first(query["want"])
+	return p, nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (p *dnsChecker) Check(ctx context.Context) error {
+	values, err := p.lookup(ctx)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no %s records for %s", p.rtype, p.host)
+	}
+	if p.want != "" && !slices.ContainsFunc(values, p.matches) {
+		return fmt.Errorf("%s records for %s are %s, want %s",
+			p.rtype, p.host, strings.Join(values, ", "), p.want)
+	}
+	return nil
+}
+
+// matches reports whether the record value v is the wanted one.
+func (p *dnsChecker) matches(v string) bool {
+	if a, err := netip.ParseAddr(v); err == nil {
+		if b, err := netip.ParseAddr(p.want); err == nil {
+			return a == b
+		}
+	}
+	// Names may or may not carry the trailing dot of the root.
+	return strings.EqualFold(strings.TrimSuffix(v, "."), strings.TrimSuffix(p.want, "."))
+}
+
+// lookup returns the values of the records of the probed type.
+func (p *dnsChecker) lookup(ctx context.Context) ([]string, error) {
+	r := p.cfg.resolver
+	var values []string
+	switch p.rtype {
+	case "A", "AAAA", "IP":
+		network := map[string]string{"A": "ip4", "AAAA": "ip6", "IP": "ip"}[p.rtype]
+		ips, err := r.LookupIP(ctx, network, p.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			values = append(values, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, p.host)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, p.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			values = append(values, mx.Host)
+		}
+	case "NS":
+		nss, err := r.LookupNS(ctx, p.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			values = append(values, ns.Host)
+		}
+	case "SRV":
+		_, srvs, err := r.LookupSRV(ctx, "", "", p.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range srvs {
+			values = append(values, net.JoinHostPort(srv.Target, fmt.Sprint(srv.Port)))
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, p.host)
+		if err != nil {
+			return nil, err
+		}
+		values = txts
+	}
+	return values, nil
+}
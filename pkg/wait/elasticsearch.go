@@ -0,0 +1,107 @@
+package wait
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clusterStatuses are the statuses of the health of Elasticsearch and
+// OpenSearch clusters, from worst to best.
+var clusterStatuses = []string{"red", "yellow", "green"}
+
+// A clusterHealthChecker checks whether an Elasticsearch or OpenSearch
+// cluster reports a health of at least a status, with enough nodes,
+// at /_cluster/health.
+type clusterHealthChecker struct {
+	cfg    *config
+	url    string // of the health
+	user   *url.Userinfo
+	status int // the index in clusterStatuses of the minimum
+	nodes  int // the minimum
+
+	responseStatus
+}
+
+// newClusterHealthChecker returns a checker for an
+// es://[user:password@]host[:port][/prefix] target, over HTTPS for
+// ess, with optional status=S and nodes=N settings, yellow and 1 by
+// default.
+func newClusterHealthChecker(cfg *config, u *url.URL) (*clusterHealthChecker, error) {
+	q := u.Query()
+	p := &clusterHealthChecker{cfg: cfg, user: u.User, status: 1, nodes: 1}
+	if s := q.Get("status"); s != "" {
+		if p.status = slices.Index(clusterStatuses, strings.ToLower(s)); p.status < 0 {
+			return nil, fmt.Errorf("unknown cluster status %q in %s; want red, yellow, or green", s, u.Redacted())
+		}
+	}
+	if s := q.Get("nodes"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid node count %q in %s", s, u.Redacted())
+		}
+		p.nodes = n
+	}
+	scheme := "http"
+	if u.Scheme == "ess" {
+		scheme = "https"
+	}
+	health := url.URL{Scheme: scheme, Host: withDefaultPort(u.Host, "9200"), Path: strings.TrimSuffix(u.Path, "/") + "/_cluster/health"}
+	p.url = health.String()
+	return p, nil
+}
+
+func (p *clusterHealthChecker) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range p.cfg.header {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[key] = values
+	}
+	setRequestID(req)
+	if p.user != nil {
+		password, _ := p.user.Password()
+		req.SetBasicAuth(p.user.Username(), password)
+	} else if p.cfg.basicAuth {
+		req.SetBasicAuth(p.cfg.username, p.cfg.password)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, body, err := fetch(p.cfg.client, req, maxBody, p.cfg.maxResponseSize)
+	if err != nil {
+		return err
+	}
+	p.set(resp.StatusCode, resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Wait: parseRetryAfter(resp, time.Now())}
+	}
+	var health struct {
+		Status string `json:"status"`
+		Nodes  int    `json:"number_of_nodes"`
+	}
+	if err := json.Unmarshal(body, &health); err != nil {
+		return fmt.Errorf("parsing cluster health: %w", err)
+	}
+	status := slices.Index(clusterStatuses, health.Status)
+	if status < 0 {
+		return fmt.Errorf("unknown cluster status %q", health.Status)
+	}
+	p.set(resp.StatusCode, resp.Status+", cluster "+health.Status)
+	if status < p.status {
+		return fmt.Errorf("cluster status is %s, want %s", health.Status, clusterStatuses[p.status])
+	}
+	if health.Nodes < p.nodes {
+		return fmt.Errorf("cluster has %d node%s, want %d", health.Nodes, plural(health.Nodes), p.nodes)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClusterHealthChecker(t *testing.T) {
+	var health string // served
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req
+		if health == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(health))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, test := range []struct {
+		target string
+		health string
+		err    string // of the check, if any
+	}{
+		{"es://" + host, `{"status": "yellow", "number_of_nodes": 1}`, ""},
+		{"es://" + host, `{"status": "green", "number_of_nodes": 3}`, ""},
+		{"es://" + host, `{"status": "red", "number_of_nodes": 3}`, "cluster status is red, want yellow"},
+		{"es://" + host + "?status=GREEN", `{"status": "yellow", "number_of_nodes": 3}`, "cluster status is yellow, want green"},
+		{"es://" + host + "?status=red", `{"status": "red", "number_of_nodes": 1}`, ""},
+		{"es://" + host + "?nodes=3", `{"status": "green", "number_of_nodes": 2}`, "cluster has 2 nodes, want 3"},
+		{"es://" + host + "?nodes=0", `{"status": "green", "number_of_nodes": 0}`, ""},
+		{"es://" + host, `{"status": "purple"}`, `unknown cluster status "purple"`},
+		{"es://" + host, `<html>`, "parsing cluster health"},
+		{"es://" + host, "", "503"},
+	} {
+		health = test.health
+		err := mustCheck(t, test.target)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s with %s: got %v, want %q", test.target, test.health, err, test.err)
+		}
+		if got.URL.Path != "/_cluster/health" || got.Header.Get("Accept") != "application/json" {
+			t.Errorf("%s: requested %s, accepting %q", test.target, got.URL.Path, got.Header.Get("Accept"))
+		}
+	}
+	var se *StatusError
+	if err := mustCheck(t, "es://"+host); !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want a StatusError", err)
+	}
+
+	// The prefix and the credentials of the URL are those of the
+	// request.
+	health = `{"status": "green", "number_of_nodes": 1}`
+	if err := mustCheck(t, "es://elastic:secret@"+host+"/search/"); err != nil {
+		t.Fatal(err)
+	}
+	if user, password, _ := got.BasicAuth(); got.URL.Path != "/search/_cluster/health" || user != "elastic" || password != "secret" {
+		t.Errorf("requested %s as %s:%s", got.URL.Path, user, password)
+	}
+
+	cfg := newConfig(nil)
+	c, err := newClusterHealthChecker(&cfg, &url.URL{Scheme: "ess", Host: "search.example"})
+	if want := "https://search.example:9200/_cluster/health"; err != nil || c.url != want {
+		t.Errorf("ess checks %s, want %s", c.url, want)
+	}
+
+	for _, target := range []string{"es://" + host + "?status=blue", "es://" + host + "?nodes=-1", "es://" + host + "?nodes=x"} {
+		if _, err := NewChecker(target); err == nil {
+			t.Errorf("%s: no error", target)
+		}
+	}
+}
+
+// mustCheck checks target once.
+func mustCheck(t *testing.T, target string, opts ...Option) error {
+	t.Helper()
+	c, err := NewChecker(target, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Check(context.Background())
+}
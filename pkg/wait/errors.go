@@ -0,0 +1,139 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// Errors matched by the errors of failed waits, which say why the
+// waits stopped, so that callers can tell with errors.Is. The errors
+// of the attempts, such as those of attempts that timed out, don't
+// matter: only ErrTimeout means that the wait itself did.
+var (
+	ErrTimeout      = errors.New("wait timed out")                        // the timeout or the context's deadline expired
+	ErrCanceled     = errors.New("wait canceled")                         // the context was canceled
+	ErrMaxAttempts  = errors.New("wait ran out of attempts")              // see WithMaxAttempts and WithFailureThreshold
+	ErrNonRetryable = errors.New("wait stopped at a non-retryable error") // see WithRetryIf
+)
+
+// A stopError is the error of a failed wait, matching the reason it
+// stopped.
+type stopError struct {
+	reason error // one of the errors above
+	err    error
+}
+
+func (e *stopError) Error() string        { return e.err.Error() }
+func (e *stopError) Unwrap() error        { return e.err }
+func (e *stopError) Is(target error) bool { return target == e.reason }
+
+// A TimeoutError is the error of a wait that ran out of time. It
+// tells how long the wait took, on the monotonic clock, apart from the
+// time it was allowed, as a final attempt may run past the deadline,
+// and why each attempt failed.
+type TimeoutError struct {
+	Attempts int
+	Elapsed  time.Duration
+	Timeout  time.Duration // the time allowed, or 0 if not known
+	LastErr  error         // of the last attempt, or nil if none was made
+	Err      error         // the error of the retrying, such as a *retry.Error
+}
+
+func (e *TimeoutError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed after %d attempt%s over %s", e.Attempts, plural(e.Attempts), e.Elapsed.Round(time.Millisecond))
+	if e.Timeout > 0 {
+		fmt.Fprintf(&b, " (deadline %s)", e.Timeout)
+	}
+	if e.LastErr != nil {
+		fmt.Fprintf(&b, "; last attempt: %v", e.LastErr)
+	}
+	var retryErr *retry.Error
+	if errors.As(e.Err, &retryErr) {
+		b.WriteString(retryErr.Summary())
+	}
+	return b.String()
+}
+
+// Unwrap returns Err, so that errors.Is finds context.DeadlineExceeded
+// and the errors of the attempts.
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// A CanceledError is the error of a wait whose context was canceled.
+// It keeps what the wait found out before, so that the cancellation
+// doesn't hide why the target was not ready.
+type CanceledError struct {
+	Attempts int
+	Elapsed  time.Duration
+	LastErr  error // of the last attempt, or nil if none was made
+	Cause    error // the cause of the cancellation, as by context.Cause
+}
+
+func (e *CanceledError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v after %d attempt%s in %s", e.Cause, e.Attempts, plural(e.Attempts), e.Elapsed.Round(time.Millisecond))
+	if e.LastErr != nil {
+		fmt.Fprintf(&b, "; last attempt: %v", e.LastErr)
+	}
+	return b.String()
+}
+
+// Is reports whether target is context.Canceled, even if the cause is
+// another error.
+func (e *CanceledError) Is(target error) bool { return target == context.Canceled }
+
+// Unwrap returns the cause and the error of the last attempt.
+func (e *CanceledError) Unwrap() []error {
+	if e.LastErr == nil {
+		return []error{e.Cause}
+	}
+	return []error{e.Cause, e.LastErr}
+}
+
+// stopped returns err, returned by retry.Do for the wait of r under
+// ctx that was allowed timeout, wrapped with the reason the retrying
+// stopped.
+func stopped(ctx context.Context, r Result, timeout time.Duration, err error) error {
+	var retryErr *retry.Error
+	if !errors.As(err, &retryErr) {
+		return err
+	}
+	var fe *failuresError
+	var reason error
+	switch {
+	case retryErr.ContextDone() && errors.Is(retryErr.Err, context.DeadlineExceeded):
+		reason = ErrTimeout
+		te := &TimeoutError{Attempts: r.Attempts, Elapsed: r.Elapsed, Timeout: timeout, Err: err}
+		if n := len(retryErr.Attempts); n > 0 {
+			te.LastErr = retryErr.Attempts[n-1]
+		}
+		err = te
+	case retryErr.ContextDone():
+		reason = ErrCanceled
+		ce := &CanceledError{Attempts: r.Attempts, Elapsed: r.Elapsed, Cause: context.Cause(ctx)}
+		if ce.Cause == nil {
+			ce.Cause = retryErr.Err
+		}
+		if n := len(retryErr.Attempts); n > 0 {
+			ce.LastErr = retryErr.Attempts[n-1]
+		}
+		err = ce
+	case errors.As(retryErr.Err, &fe), !retryErr.Permanent():
+		reason = ErrMaxAttempts
+	default:
+		reason = ErrNonRetryable
+	}
+	return &stopError{reason, err}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
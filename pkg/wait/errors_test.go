@@ -0,0 +1,33 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimeoutErrorSummary(t *testing.T) {
+	n := 0
+	c := CheckerFunc(func(context.Context) error {
+		if n++; n <= 3 {
+			return errors.New("connection refused")
+		}
+		return errors.New("503 Service Unavailable")
+	})
+	_, err := Wait(context.Background(), c, WithTimeout(200*time.Millisecond), WithInitialDelay(10*time.Millisecond),
+		WithMaxDelay(10*time.Millisecond), WithLogger(slog.New(slog.DiscardHandler)))
+	var te *TimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v, want a TimeoutError", err)
+	}
+	// The first line tells the deadline, then consecutive attempts that
+	// failed the same way are listed once.
+	want := regexp.MustCompile(`^failed after \d+ attempts over \S+ \(deadline 200ms\); last attempt: 503 Service Unavailable` +
+		`\n\tattempts 1-3: connection refused\n\tattempts 4-\d+: 503 Service Unavailable$`)
+	if !want.MatchString(te.Error()) {
+		t.Errorf("got %q, want it to match %s", te.Error(), want)
+	}
+}
@@ -0,0 +1,113 @@
+package wait
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/events"
+)
+
+// The events of waits, which are published on the Bus given by
+// WithEvents along with an Attempt after every attempt, and a
+// Notification for the outcome of every wait and every change of the
+// targets of Watch. The metrics, logs, progress, and notifications of
+// WithMetrics, WithLogger, WithProgress, and WithNotifier are made
+// from them.
+type (
+	// A Started is the event of a wait starting, unless the Cache
+	// of WithCache settles it.
+	Started struct {
+		Target string
+		Time   time.Time
+	}
+
+	// An AttemptStarted is the event of an attempt starting, after
+	// Delay since the one before, if any.
+	AttemptStarted struct {
+		Target string
+		Number int    // number of the attempt, from 1
+		ID     string // of the attempt, as by AttemptID
+		Delay  time.Duration
+	}
+
+	// A Retrying is the event of an attempt failing, and of another
+	// being made after Delay.
+	Retrying struct {
+		Target  string
+		Number  int    // number of the attempt that failed, from 1
+		ID      string // of the attempt that failed
+		Delay   time.Duration
+		Elapsed time.Duration // time spent waiting so far
+		Err     error
+	}
+
+	// A Done is the event of a wait ending, unless the Cache of
+	// WithCache settles it.
+	Done struct {
+		Target   string
+		Attempts int
+		Elapsed  time.Duration
+		Err      error // why the wait failed, or nil
+	}
+)
+
+// WithEvents makes waits publish their events on b, as well as the
+// subscribers that make their metrics, logs, progress, notifications,
+// and the calls of WithOnAttempt and WithOnRetry, so that b may be
+// shared by many waits and other subsystems.
+func WithEvents(b *events.Bus) Option {
+	return func(c *config) { c.events = b }
+}
+
+// publish publishes e on the bus of c.
+func (c *config) publish(ctx context.Context, e any) {
+	c.bus.Publish(ctx, e)
+}
+
+// subscribe makes the bus of the waits of c, which passes their
+// events on to that of WithEvents, and to the subscribers for its
+// other options.
+func (c *config) subscribe() {
+	c.bus = new(events.Bus)
+	if c.events != nil {
+		c.bus.SubscribeAll(c.events.Publish)
+	}
+	events.Subscribe(c.bus, func(ctx context.Context, a Attempt) {
+		c.logger.DebugContext(ctx, "check done",
+			"target", a.Target, "attempt", a.Number, "attempt_id", a.ID, "status", a.Status,
+			"latency", a.Latency.Round(time.Millisecond), "err", a.Err)
+	})
+	if c.onRetry != nil {
+		events.Subscribe(c.bus, func(_ context.Context, r Retrying) { c.onRetry(r.Number-1, r.Delay, r.Err) })
+	} else {
+		events.Subscribe(c.bus, func(ctx context.Context, r Retrying) {
+			notReady := "not ready"
+			if c.untilDown {
+				notReady = "still up"
+			}
+			c.logger.WarnContext(ctx, "target "+notReady+"; retrying",
+				"target", r.Target, "attempt", r.Number, "attempt_id", r.ID, "delay", r.Delay,
+				"elapsed", r.Elapsed.Round(time.Millisecond), "err", r.Err)
+		})
+	}
+	if c.onAttempt != nil {
+		events.Subscribe(c.bus, func(_ context.Context, a Attempt) { c.onAttempt(a) })
+	}
+	if m := c.metrics; m != nil {
+		events.Subscribe(c.bus, func(_ context.Context, a Attempt) { m.attempt(a.Target, a.Latency, a.Err) })
+		events.Subscribe(c.bus, func(_ context.Context, d Done) {
+			if d.Err == nil {
+				m.ready(d.Target, d.Elapsed)
+			}
+		})
+	}
+	if p := c.progress; p != nil {
+		events.Subscribe(c.bus, func(_ context.Context, s Started) { p.start(s.Target) })
+		events.Subscribe(c.bus, func(_ context.Context, a Attempt) { p.attempt(a.Target, a.Err) })
+		events.Subscribe(c.bus, func(_ context.Context, r Retrying) { p.retry(r.Target, r.Delay) })
+		events.Subscribe(c.bus, func(_ context.Context, d Done) { p.done(d.Target, d.Err) })
+	}
+	if len(c.notifiers) > 0 {
+		events.Subscribe(c.bus, c.sendNotification)
+	}
+}
@@ -0,0 +1,182 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithIPVersion makes the probes of network servers use only IPv4
+// addresses if v is 4, or only IPv6 addresses if v is 6. By default
+// either is used.
+func WithIPVersion(v int) Option {
+	return func(c *config) { c.ipVersion = v }
+}
+
+// WithAllAddresses makes the probes of network servers count as
+// successful only if every address that the host name resolves to,
+// rather than just one of them, accepts connections, to find out
+// whether a dual-stack server is reachable both ways.
+func WithAllAddresses() Option {
+	return func(c *config) { c.allAddresses = true }
+}
+
+// network returns network, such as "tcp" or "ip", restricted to the
+// IP version of c.
+func (c *config) network(network string) string {
+	if network != "tcp" && network != "udp" && network != "ip" {
+		return network
+	}
+	switch c.ipVersion {
+	case 4:
+		return network + "4"
+	case 6:
+		return network + "6"
+	}
+	return network
+}
+
+// A Resolution says when the host names of targets are resolved.
+type Resolution int
+
+const (
+	// ResolveDefault resolves host names whenever a connection is
+	// made, but lets HTTP requests reuse connections.
+	ResolveDefault Resolution = iota
+
+	// ResolveFresh makes every attempt open a new connection, and
+	// so resolve the host name again, for servers whose addresses
+	// change while they start.
+	ResolveFresh
+
+	// ResolvePin resolves each host name once, and uses the
+	// addresses it first resolved to for all later attempts.
+	ResolvePin
+)
+
+var resolutionNames = []string{"default", "fresh", "pin"}
+
+func (r Resolution) String() string {
+	if r < 0 || int(r) >= len(resolutionNames) {
+		return fmt.Sprintf("Resolution(%d)", int(r))
+	}
+	return resolutionNames[r]
+}
+
+// MarshalText returns the name of r: default, fresh, or pin.
+func (r Resolution) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText sets r to the Resolution named by text.
+func (r *Resolution) UnmarshalText(text []byte) error {
+	i := slices.Index(resolutionNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown resolution %q", text)
+	}
+	*r = Resolution(i)
+	return nil
+}
+
+// WithResolution sets when the host names of targets are resolved,
+// ResolveDefault by default.
+func WithResolution(r Resolution) Option {
+	return func(c *config) { c.resolution = r }
+}
+
+// pinnedAddrs holds the addresses that host names resolved to first,
+// for ResolvePin.
+type pinnedAddrs struct {
+	mu    sync.Mutex
+	addrs map[string][]netip.Addr
+}
+
+// lookupIPs returns the addresses of host of the IP version of c,
+// only the first one unless WithAllAddresses was used.
+func (c *config) lookupIPs(ctx context.Context, host string) ([]netip.Addr, error) {
+	if c.pinned != nil {
+		c.pinned.mu.Lock()
+		ips, ok := c.pinned.addrs[host]
+		c.pinned.mu.Unlock()
+		if ok {
+			return ips, nil
+		}
+	}
+	// Not holding the lock, so that concurrent checks, as hedged
+	// ones, do not wait for each other's lookups past their contexts.
+	ips, err := c.resolver.LookupNetIP(ctx, c.network("ip"), host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+	if !c.allAddresses {
+		ips = ips[:1]
+	}
+	for i, ip := range ips {
+		ips[i] = ip.Unmap()
+	}
+	if c.pinned != nil {
+		c.pinned.mu.Lock()
+		defer c.pinned.mu.Unlock()
+		if first, ok := c.pinned.addrs[host]; ok {
+			return first, nil // pinned by a concurrent lookup
+		}
+		c.pinned.addrs[host] = ips
+	}
+	return ips, nil
+}
+
+// dialContext connects to addr on network, as configured by c: with
+// its resolver, IP version, pinned addresses, the Dialer of WithDialer
+// for TCP, and, if WithAllAddresses was used, only once every address
+// of the host accepted a connection.
+func (c *config) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	network = c.network(network)
+	var d Dialer = &net.Dialer{Resolver: c.resolver, Control: c.control()}
+	// Not for UDP, as of ntp:// targets, which the SOCKS5 dialers of
+	// WithDialer cannot carry.
+	if c.dialer != nil && strings.HasPrefix(network, "tcp") {
+		if err := c.checkHost(ctx, network, addr); err != nil {
+			return nil, err
+		}
+		d = c.dialer
+	}
+	if !c.allAddresses && c.pinned == nil || network == "unix" {
+		return d.DialContext(ctx, network, addr)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := c.lookupIPs(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var first net.Conn
+	var errs []error
+	for _, ip := range ips {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case first == nil:
+			first = conn
+		default:
+			conn.Close()
+		}
+	}
+	if len(errs) > 0 {
+		if first != nil {
+			first.Close()
+		}
+		return nil, errors.Join(errs...)
+	}
+	return first, nil
+}
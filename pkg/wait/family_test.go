@@ -0,0 +1,52 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLookupIPsConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	resolver := &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil, errors.New("no DNS server")
+	}}
+	cfg := newConfig([]Option{WithResolution(ResolvePin), WithResolver(resolver)})
+
+	slow := make(chan error)
+	go func() {
+		_, err := cfg.lookupIPs(context.Background(), "slow.test")
+		slow <- err
+	}()
+	time.Sleep(20 * time.Millisecond) // for the slow lookup to start
+
+	// A lookup that cannot wait gives up by its own deadline, rather
+	// than waiting for the slow one to end.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cfg.lookupIPs(ctx, "slow.test"); err == nil {
+		t.Error("lookup succeeded without a DNS server")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("lookup with a deadline of 50ms took %v", took)
+	}
+	close(release)
+	if err := <-slow; err == nil {
+		t.Error("slow lookup succeeded without a DNS server")
+	}
+
+	ips, err := cfg.lookupIPs(context.Background(), "localhost") // from the hosts file
+	if err != nil {
+		t.Skipf("cannot resolve localhost: %v", err)
+	}
+	if again, err := cfg.lookupIPs(context.Background(), "localhost"); err != nil || again[0] != ips[0] {
+		t.Errorf("pinned %v, then looked up %v, %v", ips, again, err)
+	}
+}
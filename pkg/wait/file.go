@@ -0,0 +1,68 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// A fileChecker checks whether a file exists, optionally of a certain
+// type, with content, or with certain permission bits set. It suits
+// init scripts waiting for generated configuration or PID files.
+type fileChecker struct {
+	path     string
+	kind     string      // "", "file", "dir", or "socket"
+	nonEmpty bool        // require a size above zero
+	perm     fs.FileMode // permission bits that must all be set
+}
+
+// newFileChecker returns a checker for a
+// file:///path?type=T&nonempty=true&mode=0640 target.
+func newFileChecker(u *url.URL) (*fileChecker, error) {
+	p := &fileChecker{path: u.Path}
+	q := u.Query()
+	switch p.kind = q.Get("type"); p.kind {
+	case "", "file", "dir", "socket":
+	default:
+		return nil, fmt.Errorf("unsupported file type %q", p.kind)
+	}
+	if v := q.Get("nonempty"); v != "" {
+		var err error
+		if p.nonEmpty, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid nonempty value %q", v)
+		}
+	}
+	if v := q.Get("mode"); v != "" {
+		mode, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || mode > 0o777 {
+			return nil, fmt.Errorf("invalid mode %q", v)
+		}
+		p.perm = fs.FileMode(mode)
+	}
+	return p, nil
+}
+
+func (p *fileChecker) Check(ctx context.Context) error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case p.kind == "file" && !info.Mode().IsRegular():
+		return fmt.Errorf("%s is not a regular file", p.path)
+	case p.kind == "dir" && !info.IsDir():
+		return fmt.Errorf("%s is not a directory", p.path)
+	case p.kind == "socket" && info.Mode().Type() != fs.ModeSocket:
+		return fmt.Errorf("%s is not a socket", p.path)
+	}
+	if p.nonEmpty && info.Size() == 0 {
+		return fmt.Errorf("%s is empty", p.path)
+	}
+	if got := info.Mode().Perm(); got&p.perm != p.perm {
+		return fmt.Errorf("%s has mode %v, want at least %v", p.path, got, p.perm)
+	}
+	return nil
+}
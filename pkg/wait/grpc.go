@@ -0,0 +1,68 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// A grpcChecker checks whether a gRPC server reports a service as
+// SERVING through the standard grpc.health.v1.Health/Check method.
+type grpcChecker struct {
+	cfg     *config
+	addr    string // host:port
+	secure  bool   // use TLS
+	service string // service to check; empty means the whole server
+}
+
+func (p *grpcChecker) Check(ctx context.Context) error {
+	creds := insecure.NewCredentials()
+	if p.secure {
+		conf := p.cfg.tlsConfig
+		if conf == nil {
+			conf = new(tls.Config)
+		}
+		creds = credentials.NewTLS(conf)
+	}
+	conn, err := grpc.NewClient(p.addr, grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return p.cfg.dialContext(ctx, "tcp", addr)
+		}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if id := AttemptID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", id)
+	}
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.service})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unimplemented:
+		// Misconfigured, rather than not ready yet, as is a server
+		// that does not know the service.
+		return errclass.MarkPermanent(fmt.Errorf("server has no health service: %w", err))
+	case codes.NotFound:
+		return errclass.MarkPermanent(fmt.Errorf("server has no service %q: %w", p.service, err))
+	default:
+		return err
+	}
+	if s := resp.GetStatus(); s != healthpb.HealthCheckResponse_SERVING {
+		if p.service == "" {
+			return fmt.Errorf("server is %s", s)
+		}
+		return fmt.Errorf("service %q is %s", p.service, s)
+	}
+	return nil
+}
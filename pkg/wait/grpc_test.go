@@ -0,0 +1,57 @@
+package wait
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPCChecker(t *testing.T) {
+	serve := func(withHealth bool) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := grpc.NewServer()
+		if withHealth {
+			h := health.NewServer()
+			h.SetServingStatus("api", healthpb.HealthCheckResponse_NOT_SERVING)
+			healthpb.RegisterHealthServer(s, h)
+		}
+		go s.Serve(l)
+		t.Cleanup(s.Stop)
+		return l.Addr().String()
+	}
+	healthy, bare := serve(true), serve(false)
+
+	cfg := newConfig(nil)
+	for _, test := range []struct {
+		addr, service string
+		want          string // the error, or "" for none
+		permanent     bool
+	}{
+		{healthy, "", "", false},
+		{healthy, "api", `service "api" is NOT_SERVING`, false},
+		{healthy, "billing", `server has no service "billing": rpc error: code = NotFound desc = unknown service`, true},
+		{bare, "", "server has no health service: rpc error: code = Unimplemented desc = unknown service grpc.health.v1.Health", true},
+	} {
+		err := (&grpcChecker{cfg: &cfg, addr: test.addr, service: test.service}).Check(context.Background())
+		if test.want == "" {
+			if err != nil {
+				t.Errorf("service %q: %v, want no error", test.service, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.want {
+			t.Errorf("service %q: %v, want %s", test.service, err, test.want)
+		}
+		if retryable, marked := errclass.Marked(err); test.permanent != (marked && !retryable) {
+			t.Errorf("service %q: permanent is %v, want %v", test.service, marked && !retryable, test.permanent)
+		}
+	}
+}
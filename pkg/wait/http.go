@@ -0,0 +1,349 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// MethodAuto makes WaitForServer send HEAD requests, falling back to
+// GET for good once the server reports that it doesn't support HEAD.
+const MethodAuto = "auto"
+
+// maxDrain limits how much of a response body is read before the
+// connection is closed, so that the connection can be reused.
+const maxDrain = 64 << 10
+
+// maxBody is how much of a response body is matched against the
+// pattern of WithExpectBody.
+const maxBody = 1 << 20
+
+// Connections says whether HTTP checks reuse the connections of
+// earlier attempts.
+type Connections int
+
+const (
+	// ReuseConnections lets each attempt send its request over a
+	// connection kept alive since an earlier one, if any, so that
+	// attempts are quicker, but, once a server has accepted a
+	// connection, only show whether it still answers on it.
+	ReuseConnections Connections = iota
+
+	// FreshConnections makes each attempt open a new connection and
+	// close it after the response, to show that the server accepts
+	// new connections, as its clients will need it to.
+	FreshConnections
+)
+
+var connectionsNames = []string{"reuse", "fresh"}
+
+func (c Connections) String() string {
+	if c < 0 || int(c) >= len(connectionsNames) {
+		return fmt.Sprintf("Connections(%d)", int(c))
+	}
+	return connectionsNames[c]
+}
+
+// MarshalText returns the name of c: reuse or fresh.
+func (c Connections) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText sets c to the Connections named by text.
+func (c *Connections) UnmarshalText(text []byte) error {
+	i := slices.Index(connectionsNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown connections %q", text)
+	}
+	*c = Connections(i)
+	return nil
+}
+
+// WithConnections sets whether HTTP checks reuse connections,
+// ReuseConnections by default. FreshConnections applies to the
+// client of WithClient too.
+func WithConnections(c Connections) Option {
+	return func(cfg *config) { cfg.connections = c }
+}
+
+// A Protocol is the version of HTTP used by HTTP checks.
+type Protocol int
+
+const (
+	// ProtocolAuto uses HTTP/2 with servers that support it, as
+	// negotiated over TLS, and HTTP/1.1 with the others.
+	ProtocolAuto Protocol = iota
+
+	// ProtocolHTTP1 uses HTTP/1.1 only.
+	ProtocolHTTP1
+
+	// ProtocolHTTP2 uses HTTP/2 only: as negotiated by ALPN over
+	// TLS, so that a server that fails to offer it is not ready,
+	// and over cleartext (h2c) for http:// targets.
+	ProtocolHTTP2
+
+	// ProtocolHTTP3 requires responses over HTTP/3, which is
+	// experimental: the client set by WithClient must have an
+	// HTTP/3 transport, such as that of quic-go, as net/http has
+	// none.
+	ProtocolHTTP3
+)
+
+var protocolNames = []string{"auto", "http1", "http2", "http3"}
+
+func (p Protocol) String() string {
+	if p < 0 || int(p) >= len(protocolNames) {
+		return fmt.Sprintf("Protocol(%d)", int(p))
+	}
+	return protocolNames[p]
+}
+
+// MarshalText returns the name of p: auto, http1, http2, or http3.
+func (p Protocol) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText sets p to the Protocol named by text.
+func (p *Protocol) UnmarshalText(text []byte) error {
+	i := slices.Index(protocolNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown protocol %q", text)
+	}
+	*p = Protocol(i)
+	return nil
+}
+
+// WithProtocol makes HTTP checks use the version of HTTP p, so that
+// servers are found ready over the protocol their clients will use:
+// an HTTP/1.1 response may hide a TLS configuration that fails to
+// offer HTTP/2. A response over another version fails the check.
+// ProtocolAuto is the default.
+func WithProtocol(p Protocol) Option {
+	return func(c *config) { c.protocol = p }
+}
+
+// protocols returns the protocols of an http.Transport for p, which is
+// ProtocolHTTP1 or ProtocolHTTP2.
+func (p Protocol) protocols() *http.Protocols {
+	protocols := new(http.Protocols)
+	if p == ProtocolHTTP1 {
+		protocols.SetHTTP1(true)
+	} else {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	return protocols
+}
+
+// check returns an error unless resp was received over p.
+func (p Protocol) check(resp *http.Response) error {
+	if p == ProtocolAuto || resp.ProtoMajor == int(p) { // the others are numbered by version
+		return nil
+	}
+	err := fmt.Errorf("response over %s, not HTTP/%d", resp.Proto, int(p))
+	if p == ProtocolHTTP3 {
+		return errclass.MarkPermanent(fmt.Errorf("%w; HTTP/3 needs a client with an HTTP/3 transport", err))
+	}
+	return err
+}
+
+// An expectedHeader is a header required by WithExpectHeader.
+type expectedHeader struct {
+	key   string
+	value *regexp.Regexp // anchored at both ends, or nil for any value
+}
+
+// check returns an error unless h has the header with a value that
+// matches.
+func (e expectedHeader) check(h http.Header) error {
+	values := h.Values(e.key)
+	if len(values) == 0 {
+		return fmt.Errorf("response header %s is missing", http.CanonicalHeaderKey(e.key))
+	}
+	if e.value == nil || slices.ContainsFunc(values, e.value.MatchString) {
+		return nil
+	}
+	return fmt.Errorf("response header %s: %q does not match %#q", http.CanonicalHeaderKey(e.key), values[0], e.value)
+}
+
+// An httpChecker checks whether a server responds to requests for url.
+type httpChecker struct {
+	cfg  *config
+	url  string
+	auto bool // fall back from HEAD to GET
+
+	mu     sync.Mutex // for hedged checks
+	method string
+	code   int    // status code of the last response, if any
+	status string // status of the last response, if any
+	last   *ResponseSnapshot
+}
+
+func newHTTPChecker(cfg *config, url string) *httpChecker {
+	p := &httpChecker{cfg: cfg, url: url, method: cfg.method}
+	switch {
+	case cfg.expectBody != nil && (p.method == http.MethodHead || p.method == MethodAuto):
+		p.method = http.MethodGet // only GET responses have a body to match
+	case p.method == MethodAuto:
+		p.method = http.MethodHead
+		p.auto = true
+	}
+	return p
+}
+
+// Check sends one request that is aborted when ctx is done.
+// A response with an unexpected status is reported as a *StatusError.
+// So is a 429 or 503 response with a Retry-After header, and a
+// redirect that is not followed, unless that status is expected
+// explicitly.
+func (p *httpChecker) Check(ctx context.Context) error {
+	p.mu.Lock()
+	p.code, p.status, p.last = 0, "", nil
+	method := p.method
+	p.mu.Unlock()
+	resp, body, err := p.do(ctx, method)
+	if err != nil {
+		return err
+	}
+	if p.auto && method == http.MethodHead &&
+		(resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		p.mu.Lock()
+		p.method = http.MethodGet
+		p.mu.Unlock()
+		if resp, body, err = p.do(ctx, http.MethodGet); err != nil {
+			return err
+		}
+	}
+	var keys []string
+	for _, e := range p.cfg.expectHeaders {
+		keys = append(keys, e.key)
+	}
+	p.mu.Lock()
+	p.code, p.status = resp.StatusCode, resp.Status
+	p.last = newSnapshot(resp, body, keys)
+	p.mu.Unlock()
+	if err := p.cfg.protocol.check(resp); err != nil {
+		return err
+	}
+	wait := parseRetryAfter(resp, time.Now())
+	expected := p.cfg.expectStatus.Contains(resp.StatusCode)
+	if len(p.cfg.expectStatus) == 0 && (wait > 0 || isRedirect(resp)) {
+		expected = false
+	}
+	if !expected {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Wait: wait}
+	}
+	for _, e := range p.cfg.expectHeaders {
+		if err := e.check(resp.Header); err != nil {
+			return err
+		}
+	}
+	if re := p.cfg.expectBody; re != nil && !re.Match(body) {
+		return fmt.Errorf("response body does not match %#q", re)
+	}
+	return nil
+}
+
+func (p *httpChecker) lastStatus() (int, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.code, p.status
+}
+
+func (p *httpChecker) lastResponse() *ResponseSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// do sends a method request as configured by p.cfg and returns the
+// response and the start of its body, as fetched by fetch: the first
+// maxBody bytes if it is to be matched, or else enough for a
+// ResponseSnapshot.
+func (p *httpChecker) do(ctx context.Context, method string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, values := range p.cfg.header {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[key] = values
+	}
+	setRequestID(req)
+	if p.cfg.basicAuth {
+		req.SetBasicAuth(p.cfg.username, p.cfg.password)
+	}
+	req.Close = p.cfg.connections == FreshConnections
+	var limit int64 = maxSnapshot + 1 // to tell if the body goes on
+	if p.cfg.expectBody != nil {
+		limit = maxBody
+	}
+	return fetch(p.cfg.client, req, limit, p.cfg.maxResponseSize)
+}
+
+// limitRedirects returns a CheckRedirect function for http.Client
+// that follows at most n redirects, and returns the response to the
+// last request instead of following any more.
+func limitRedirects(n int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// isRedirect reports whether resp is a redirect that was not followed.
+func isRedirect(resp *http.Response) bool {
+	return resp.StatusCode/100 == 3 && resp.Header.Get("Location") != ""
+}
+
+// fetch sends req with client, and returns the response and the first
+// limit bytes of its body, or an error if the body is larger than max
+// bytes and max is positive. Whatever happens, the body of the response
+// has been drained, up to maxDrain bytes more, and closed by the time
+// fetch returns, so that checks never leak connections and can reuse
+// them for later attempts, and the response is only good for its
+// status and header. All checks of HTTP servers send their requests
+// with fetch.
+func fetch(client *http.Client, req *http.Request, limit, max int64) (*http.Response, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if max > 0 && resp.ContentLength > max && req.Method != http.MethodHead {
+		return nil, nil, errResponseTooLarge(max)
+	}
+	defer io.CopyN(io.Discard, resp.Body, maxDrain) // ignore error; only for connection reuse
+	var body []byte
+	if limit > 0 {
+		if body, err = io.ReadAll(io.LimitReader(resp.Body, limit)); err != nil {
+			return nil, nil, fmt.Errorf("reading response body: %w", err)
+		}
+	}
+	if max > 0 {
+		n := int64(len(body))
+		if n <= max {
+			rest, err := io.CopyN(io.Discard, resp.Body, max+1-n)
+			if err != nil && err != io.EOF {
+				return nil, nil, fmt.Errorf("reading response body: %w", err)
+			}
+			n += rest
+		}
+		if n > max {
+			return nil, nil, errResponseTooLarge(max)
+		}
+	}
+	return resp, body, nil
+}
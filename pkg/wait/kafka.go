@@ -0,0 +1,258 @@
+package wait
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+)
+
+// The Kafka APIs a kafkaChecker uses.
+const (
+	kafkaMetadata    = 3
+	kafkaAPIVersions = 18
+)
+
+// kafkaClientID is the client ID a kafkaChecker sends with requests.
+const kafkaClientID = "wait"
+
+// A kafkaChecker checks whether a Kafka broker answers an ApiVersions
+// request, which it does once it is up, unlike its port, which it
+// opens long before, and, if a topic is given, whether the topic
+// exists and each of its partitions has a leader.
+type kafkaChecker struct {
+	cfg    *config
+	addr   string // host:port
+	secure bool   // use TLS
+	topic  string // or ""
+}
+
+func newKafkaChecker(cfg *config, u *url.URL) *kafkaChecker {
+	return &kafkaChecker{
+		cfg:    cfg,
+		addr:   withDefaultPort(u.Host, "9092"),
+		secure: u.Scheme == "kafkas",
+		topic:  u.Query().Get("topic"),
+	}
+}
+
+func (p *kafkaChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dial(ctx, p.addr, p.secure)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// ApiVersions v0 is answered by brokers of every version.
+	r, err := kafkaRequest(conn, kafkaAPIVersions, 0, 1, nil)
+	if err != nil {
+		return fmt.Errorf("kafka ApiVersions: %w", err)
+	}
+	if code := kafkaError(r.int16()); code != 0 {
+		return fmt.Errorf("kafka ApiVersions: %w", code)
+	}
+	minVersion, maxVersion := -1, -1
+	for range r.array() {
+		key, lo, hi := r.int16(), r.int16(), r.int16()
+		if key == kafkaMetadata {
+			minVersion, maxVersion = int(lo), int(hi)
+		}
+	}
+	if r.err != nil {
+		return fmt.Errorf("kafka ApiVersions: %w", r.err)
+	}
+	if p.topic == "" {
+		return nil
+	}
+
+	// Metadata v4 is the first not to create missing topics on brokers
+	// configured to, and the last before the flexible versions; brokers
+	// too old for it are sent the oldest one they support.
+	version := min(maxVersion, 4)
+	if version < 0 || version < minVersion {
+		return fmt.Errorf("kafka broker supports no Metadata version this program speaks (%d-%d)", minVersion, maxVersion)
+	}
+	body := binary.BigEndian.AppendUint32(nil, 1)
+	body = binary.BigEndian.AppendUint16(body, uint16(len(p.topic)))
+	body = append(body, p.topic...)
+	if version >= 4 {
+		body = append(body, 0) // allow_auto_topic_creation
+	}
+	r, err = kafkaRequest(conn, kafkaMetadata, int16(version), 2, body)
+	if err != nil {
+		return fmt.Errorf("kafka Metadata: %w", err)
+	}
+	return p.checkTopic(r, version)
+}
+
+// checkTopic reads the Metadata response r of the given version and
+// returns whether it describes p.topic as ready.
+func (p *kafkaChecker) checkTopic(r *kafkaReader, version int) error {
+	if version >= 3 {
+		r.int32() // throttle_time_ms
+	}
+	for range r.array() { // brokers
+		r.int32() // node_id
+		r.string()
+		r.int32() // port
+		if version >= 1 {
+			r.string() // rack
+		}
+	}
+	if version >= 2 {
+		r.string() // cluster_id
+	}
+	if version >= 1 {
+		r.int32() // controller_id
+	}
+	var topicErr error
+	found := false
+	for range r.array() {
+		code := kafkaError(r.int16())
+		name := r.string()
+		if version >= 1 {
+			r.skip(1) // is_internal
+		}
+		ours := name == p.topic
+		partitions := r.array()
+		if ours {
+			found = true
+			if code != 0 {
+				topicErr = code
+			} else if partitions == 0 {
+				topicErr = errors.New("no partitions")
+			}
+		}
+		for range partitions {
+			code := kafkaError(r.int16())
+			id := r.int32()
+			leader := r.int32()
+			r.skip(4 * r.array()) // replica_nodes
+			r.skip(4 * r.array()) // isr_nodes
+			if ours && topicErr == nil {
+				if code != 0 {
+					topicErr = fmt.Errorf("partition %d: %w", id, code)
+				} else if leader < 0 {
+					topicErr = fmt.Errorf("partition %d has no leader", id)
+				}
+			}
+		}
+	}
+	switch {
+	case r.err != nil:
+		return fmt.Errorf("kafka Metadata: %w", r.err)
+	case !found:
+		return fmt.Errorf("kafka topic %q: %w", p.topic, kafkaError(3))
+	case topicErr != nil:
+		return fmt.Errorf("kafka topic %q: %w", p.topic, topicErr)
+	}
+	return nil
+}
+
+// kafkaRequest sends a request for version of the API key, with the
+// given correlation ID and body, in the Kafka protocol, and returns
+// a reader of the body of the response.
+func kafkaRequest(conn net.Conn, key, version int16, id int32, body []byte) (*kafkaReader, error) {
+	req := make([]byte, 4, 4+14+len(body))
+	req = binary.BigEndian.AppendUint16(req, uint16(key))
+	req = binary.BigEndian.AppendUint16(req, uint16(version))
+	req = binary.BigEndian.AppendUint32(req, uint32(id))
+	req = binary.BigEndian.AppendUint16(req, uint16(len(kafkaClientID)))
+	req = append(req, kafkaClientID...)
+	req = append(req, body...)
+	binary.BigEndian.PutUint32(req, uint32(len(req)-4))
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 4 || size > 4<<20 {
+		return nil, fmt.Errorf("response of %d bytes", size)
+	}
+	if got := int32(binary.BigEndian.Uint32(header[4:])); got != id {
+		return nil, fmt.Errorf("response to request %d, not %d", got, id)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return &kafkaReader{b: resp}, nil
+}
+
+// A kafkaReader reads the fields of a Kafka response, recording in
+// err whether it was too short.
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *kafkaReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		if r.err == nil {
+			r.err = errors.New("truncated response")
+		}
+		return make([]byte, max(n, 0))
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *kafkaReader) skip(n int) { r.next(n) }
+
+func (r *kafkaReader) int16() int16 { return int16(binary.BigEndian.Uint16(r.next(2))) }
+
+func (r *kafkaReader) int32() int32 { return int32(binary.BigEndian.Uint32(r.next(4))) }
+
+// string reads a string, returning "" for a null one.
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.next(int(n)))
+}
+
+// array reads the length of an array, returning 0 for a null one.
+func (r *kafkaReader) array() int {
+	n := r.int32()
+	if n < 0 || r.err != nil {
+		return 0
+	}
+	if int(n) > len(r.b) {
+		// Every element takes at least a byte.
+		r.err = errors.New("truncated response")
+		return 0
+	}
+	return int(n)
+}
+
+// A kafkaError is an error code of the Kafka protocol.
+type kafkaError int16
+
+// kafkaErrors names the error codes brokers and topics that are not
+// ready yet answer with.
+var kafkaErrors = map[kafkaError]string{
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	14: "COORDINATOR_LOAD_IN_PROGRESS",
+	15: "COORDINATOR_NOT_AVAILABLE",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	35: "UNSUPPORTED_VERSION",
+	58: "SASL_AUTHENTICATION_FAILED",
+}
+
+func (e kafkaError) Error() string {
+	if name, ok := kafkaErrors[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("error code %d", int16(e))
+}
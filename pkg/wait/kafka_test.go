@@ -0,0 +1,111 @@
+package wait
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// A kafkaWire builds the fields of Kafka responses.
+type kafkaWire []byte
+
+func (w kafkaWire) i16(n int16) kafkaWire  { return binary.BigEndian.AppendUint16(w, uint16(n)) }
+func (w kafkaWire) i32(n int32) kafkaWire  { return binary.BigEndian.AppendUint32(w, uint32(n)) }
+func (w kafkaWire) str(s string) kafkaWire { return append(w.i16(int16(len(s))), s...) }
+
+// frame returns the response with the correlation ID id and body.
+func (w kafkaWire) frame(id int32) []byte {
+	return append(kafkaWire(nil).i32(int32(4+len(w))).i32(id), w...)
+}
+
+// serveKafka serves a connection as a Kafka broker that answers the
+// requests it reads with responses in turn, and returns its address
+// and a channel on which it sends the requests it was sent, in hex.
+func serveKafka(t *testing.T, responses ...[]byte) (string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	requests := make(chan string, len(responses))
+	go func() {
+		defer close(requests)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, resp := range responses {
+			var size [4]byte
+			if _, err := io.ReadFull(conn, size[:]); err != nil {
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(size[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			requests <- hex.EncodeToString(append(size[:], req...))
+			conn.Write(resp)
+		}
+	}()
+	return l.Addr().String(), requests
+}
+
+func TestKafkaChecker(t *testing.T) {
+	// ApiVersions v0 with the correlation ID 1 and the client ID "wait",
+	// and Metadata v4 for the topic "orders", without creating it.
+	const apiVersionsReq = "0000000e" + "0012" + "0000" + "00000001" + "0004" + "77616974"
+	const metadataReq = "0000001b" + "0003" + "0004" + "00000002" + "0004" + "77616974" +
+		"00000001" + "0006" + "6f7264657273" + "00"
+
+	versions := kafkaWire(nil).i16(0).i32(2).
+		i16(kafkaMetadata).i16(0).i16(12).
+		i16(kafkaAPIVersions).i16(0).i16(3)
+	topic := func(topicErr int16, leader int32) kafkaWire {
+		w := kafkaWire(nil).i32(0)                          // throttle_time_ms
+		w = w.i32(1).i32(1).str("broker").i32(9092).i16(-1) // a broker without a rack
+		w = w.str("cluster").i32(1)                         // cluster_id, controller_id
+		w = append(w.i32(1).i16(topicErr).str("orders"), 0) // not internal
+		w = w.i32(1).i16(0).i32(0).i32(leader)              // partition 0
+		return w.i32(1).i32(1).i32(1).i32(1)                // replica_nodes, isr_nodes
+	}
+
+	for _, test := range []struct {
+		name      string
+		topic     string
+		responses [][]byte
+		requests  []string
+		err       string // in the error, or "" for none
+	}{
+		{"broker up", "", [][]byte{versions.frame(1)}, []string{apiVersionsReq}, ""},
+		{"topic ready", "orders", [][]byte{versions.frame(1), topic(0, 1).frame(2)}, []string{apiVersionsReq, metadataReq}, ""},
+		{"no leader", "orders", [][]byte{versions.frame(1), topic(0, -1).frame(2)}, nil, `kafka topic "orders": partition 0 has no leader`},
+		{"unknown topic", "orders", [][]byte{versions.frame(1), topic(3, -1).frame(2)}, nil, `kafka topic "orders": UNKNOWN_TOPIC_OR_PARTITION`},
+		{"broker error", "", [][]byte{kafkaWire(nil).i16(35).i32(0).frame(1)}, nil, "kafka ApiVersions: UNSUPPORTED_VERSION"},
+		{"other request", "", [][]byte{versions.frame(7)}, nil, "kafka ApiVersions: response to request 7, not 1"},
+		{"truncated versions", "", [][]byte{versions[:len(versions)-6].frame(1)}, nil, "kafka ApiVersions: truncated response"},
+		{"truncated metadata", "orders", [][]byte{versions.frame(1), topic(0, 1)[:len(topic(0, 1))-4].frame(2)}, nil, "kafka Metadata: truncated response"},
+		{"short frame", "", [][]byte{versions.frame(1)[:12]}, nil, "kafka ApiVersions: unexpected EOF"},
+	} {
+		addr, requests := serveKafka(t, test.responses...)
+		cfg := newConfig(nil)
+		u := &url.URL{Scheme: "kafka", Host: addr, RawQuery: url.Values{"topic": {test.topic}}.Encode()}
+		err := newKafkaChecker(&cfg, u).Check(context.Background())
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got error %v, want %s", test.name, err, test.err)
+		}
+		for i, want := range test.requests {
+			if got := <-requests; got != want {
+				t.Errorf("%s: request %d is %s, want %s", test.name, i+1, got, want)
+			}
+		}
+	}
+}
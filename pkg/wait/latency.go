@@ -0,0 +1,80 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// A LatencyError reports a check that succeeded, but took longer than
+// allowed by WithMaxLatency.
+type LatencyError struct {
+	Latency, Max time.Duration
+}
+
+func (e *LatencyError) Error() string {
+	return fmt.Sprintf("took %s, more than the maximum latency of %s", e.Latency.Round(time.Microsecond), e.Max)
+}
+
+// WithMaxLatency makes a check count as successful only if it takes
+// at most d, so that the wait goes on while a server is responding
+// but still warming up. By default any latency is fine.
+func WithMaxLatency(d time.Duration) Option {
+	return func(c *config) { c.maxLatency = d }
+}
+
+// WithHedging makes each check that hasn't ended after d run a
+// second time concurrently, and succeed as soon as either does, to
+// cut the tail latency of checks of servers that are only sometimes
+// slow to respond. By default checks are not hedged.
+func WithHedging(d time.Duration) Option {
+	return func(c *config) { c.hedgeDelay = d }
+}
+
+// hedge returns a probe that hedges probe as configured by cfg.
+func (cfg *config) hedge(probe func(context.Context) error) func(context.Context) error {
+	if cfg.hedgeDelay <= 0 {
+		return probe
+	}
+	return func(ctx context.Context) error {
+		_, err := retry.Hedge(ctx, cfg.hedgeDelay, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, probe(ctx)
+		})
+		return err
+	}
+}
+
+// limitLatency returns a probe that fails with a LatencyError when
+// probe succeeds, but takes longer than cfg allows.
+func (cfg *config) limitLatency(probe func(context.Context) error) func(context.Context) error {
+	if cfg.maxLatency <= 0 {
+		return probe
+	}
+	return func(ctx context.Context) error {
+		start := cfg.clock.Now()
+		if err := probe(ctx); err != nil {
+			return err
+		}
+		if latency := cfg.since(start); latency > cfg.maxLatency {
+			return &LatencyError{Latency: latency, Max: cfg.maxLatency}
+		}
+		return nil
+	}
+}
+
+// LatencyPercentile returns the latency that the given percentage of
+// the attempts of r took at most, by the nearest-rank method, such as
+// the median for 50 and the longest latency for 100. It returns 0 if
+// r has no attempts.
+func (r Result) LatencyPercentile(percent float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(r.Latencies))
+	rank := int(math.Ceil(percent / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,151 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// A metadataProvider describes the instance metadata service of a
+// cloud.
+type metadataProvider struct {
+	endpoint string            // the default
+	path     string            // of the metadata, before the path of the target
+	item     string            // the default path of the target, of an item every instance has
+	query    string            // added to the URL, if not empty
+	header   map[string]string // to send with requests
+	token    bool              // AWS IMDSv2
+}
+
+var metadataProviders = map[string]metadataProvider{
+	"aws": {
+		endpoint: "http://169.254.169.254",
+		path:     "/latest/meta-data/",
+		item:     "instance-id",
+		token:    true,
+	},
+	"gcp": {
+		endpoint: "http://metadata.google.internal",
+		path:     "/computeMetadata/v1/",
+		item:     "instance/id",
+		header:   map[string]string{"Metadata-Flavor": "Google"},
+	},
+	"azure": {
+		endpoint: "http://169.254.169.254",
+		path:     "/metadata/",
+		item:     "instance/compute/vmId",
+		query:    "api-version=2021-02-01&format=text",
+		header:   map[string]string{"Metadata": "true"},
+	},
+}
+
+// A metadataChecker checks whether the instance metadata service of a
+// cloud serves an item, showing that the instance has its network and
+// identity set up.
+type metadataChecker struct {
+	cfg      *config
+	provider metadataProvider
+	url      string // of the item
+	client   *http.Client
+
+	responseStatus
+}
+
+// metadataClient sends the requests of metadataCheckers to the
+// services, which are on the local network, never through proxies.
+var metadataClient = &http.Client{Transport: &http.Transport{
+	DialContext:       (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+	DisableKeepAlives: true,
+}}
+
+// newMetadataChecker returns a checker for a metadata://cloud[/item]
+// target, where cloud is aws, gcp, or azure, with an optional
+// endpoint=URL setting, connecting only to the addresses that cfg
+// does not block and reading no more of responses than it allows.
+func newMetadataChecker(cfg *config, u *url.URL) (*metadataChecker, error) {
+	provider, ok := metadataProviders[u.Host]
+	if !ok {
+		return nil, fmt.Errorf("unknown cloud %q in %s; want aws, gcp, or azure", u.Host, u)
+	}
+	endpoint := provider.endpoint
+	if e := u.Query().Get("endpoint"); e != "" {
+		endpoint = strings.TrimSuffix(e, "/")
+	}
+	item := strings.TrimPrefix(u.Path, "/")
+	if item == "" {
+		item = provider.item
+	}
+	p := &metadataChecker{cfg: cfg, provider: provider, url: endpoint + provider.path + item, client: metadataClient}
+	if control := cfg.control(); control != nil || cfg.maxResponseSize > 0 {
+		p.client = &http.Client{Transport: &http.Transport{
+			DialContext:            (&net.Dialer{Timeout: 5 * time.Second, Control: control}).DialContext,
+			DisableKeepAlives:      true,
+			MaxResponseHeaderBytes: cfg.maxResponseSize,
+		}}
+	}
+	if provider.query != "" {
+		p.url += "?" + provider.query
+	}
+	if _, err := url.Parse(p.url); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *metadataChecker) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+	for name, value := range p.provider.header {
+		req.Header.Set(name, value)
+	}
+	if p.provider.token {
+		token, err := p.imdsToken(ctx)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			req.Header.Set("X-aws-ec2-metadata-token", token)
+		}
+	}
+	resp, _, err := fetch(p.client, req, 0, p.cfg.maxResponseSize)
+	if err != nil {
+		return err
+	}
+	p.set(resp.StatusCode, resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		// Items show up as the instance is set up.
+		return errclass.MarkRetryable(fmt.Errorf("metadata: %w", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}))
+	}
+	return nil
+}
+
+// imdsToken returns a session token of the instance metadata service
+// of AWS (IMDSv2), or "" if the service only serves IMDSv1.
+func (p *metadataChecker) imdsToken(ctx context.Context) (string, error) {
+	u, _ := url.Parse(p.url)
+	u.Path, u.RawQuery = "/latest/api/token", ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	resp, token, err := fetch(p.client, req, 4<<10, p.cfg.maxResponseSize)
+	if err != nil {
+		return "", err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return strings.TrimSpace(string(token)), nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return "", nil
+	}
+	return "", errclass.MarkRetryable(fmt.Errorf("metadata token: %w", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}))
+}
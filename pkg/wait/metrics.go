@@ -0,0 +1,107 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics counts the attempts of waits, as Prometheus metrics:
+//
+//	wait_attempts_total{target}               attempts made
+//	wait_failures_total{target,reason}        attempts that failed, by reason
+//	wait_attempt_duration_seconds{target}     time taken by each attempt
+//	wait_time_to_ready_seconds{target}        time until the target was ready
+//
+// A reason is one of refused, timeout, dns, tls, status, slow, or other.
+type Metrics struct {
+	attempts    *prometheus.CounterVec
+	failures    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	timeToReady *prometheus.HistogramVec
+}
+
+// NewMetrics returns Metrics registered with reg, unless reg is nil.
+// It panics if the registration fails, as when the metrics are
+// registered twice.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wait_attempts_total",
+			Help: "Number of attempts made to check targets.",
+		}, []string{"target"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wait_failures_total",
+			Help: "Number of attempts that failed, by reason.",
+		}, []string{"target", "reason"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wait_attempt_duration_seconds",
+			Help:    "Time taken by attempts to check targets.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"target"}),
+		timeToReady: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wait_time_to_ready_seconds",
+			Help:    "Time waited until targets were ready.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+		}, []string{"target"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.attempts, m.failures, m.latency, m.timeToReady)
+	}
+	return m
+}
+
+// WithMetrics makes the waits count their attempts in m.
+func WithMetrics(m *Metrics) Option {
+	return func(c *config) { c.metrics = m }
+}
+
+// attempt records an attempt to check target that took latency and
+// failed with err, if not nil.
+func (m *Metrics) attempt(target string, latency time.Duration, err error) {
+	m.attempts.WithLabelValues(target).Inc()
+	m.latency.WithLabelValues(target).Observe(latency.Seconds())
+	if err != nil {
+		m.failures.WithLabelValues(target, failureReason(err)).Inc()
+	}
+}
+
+// ready records that target was ready after elapsed.
+func (m *Metrics) ready(target string, elapsed time.Duration) {
+	m.timeToReady.WithLabelValues(target).Observe(elapsed.Seconds())
+}
+
+// failureReason classifies err for the reason label of Metrics.
+func failureReason(err error) string {
+	var (
+		statusErr  *StatusError
+		latencyErr *LatencyError
+		dnsErr     *net.DNSError
+		certErr    *tls.CertificateVerificationError
+		hostErr    x509.HostnameError
+		netErr     net.Error
+	)
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &certErr), errors.As(err, &hostErr):
+		return "tls"
+	case errors.As(err, &statusErr):
+		return "status"
+	case errors.As(err, &latencyErr):
+		return "slow"
+	}
+	return "other"
+}
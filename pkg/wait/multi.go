@@ -0,0 +1,121 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WaitForAll waits for the servers of all urls concurrently, and
+// returns the Results of the waits in the order of urls. The timeout
+// given by opts applies to the whole wait, not to each server.
+// It reports an error, joining those of the servers that failed to
+// respond, if any of them does; see WithFailFast.
+func WaitForAll(ctx context.Context, urls []string, opts ...Option) ([]Result, error) {
+	ctx, cancel, opts := shareDeadline(ctx, opts)
+	defer cancel()
+	ctx, abort := abortOnPermanent(ctx, opts)
+
+	results := make([]Result, len(urls))
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = WaitForServer(ctx, url, opts...)
+			abort(url, errs[i])
+		}()
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// WaitForAny waits for the servers of urls concurrently until one of
+// them responds, and returns the Result of the wait for it, whose
+// Target is its URL. The timeout given by opts applies to the whole
+// wait. It reports an error, joining those of all servers, if none of
+// them responds, or if there are no urls.
+func WaitForAny(ctx context.Context, urls []string, opts ...Option) (Result, error) {
+	if len(urls) == 0 {
+		err := errors.New("no servers to wait for")
+		return Result{Err: err}, err
+	}
+	ctx, cancel, opts := shareDeadline(ctx, opts)
+	defer cancel()
+
+	type result struct {
+		r   Result
+		err error
+	}
+	results := make(chan result, len(urls))
+	for _, url := range urls {
+		go func() {
+			r, err := WaitForServer(ctx, url, opts...)
+			results <- result{r, err}
+		}()
+	}
+	var errs []error
+	for range urls {
+		r := <-results
+		if r.err == nil {
+			cancel() // stop waiting for the others
+			return r.r, nil
+		}
+		errs = append(errs, r.err)
+	}
+	err := errors.Join(errs...)
+	return Result{Err: err}, err
+}
+
+// WithFailFast makes WaitForAll and Config.Wait stop waiting for all
+// targets as soon as the wait for one of them fails with
+// ErrNonRetryable, such as for a host name that does not exist,
+// rather than until the others are ready or also fail. The waits for
+// the other targets fail with errors matching ErrCanceled, whose
+// cause, as by context.Cause, is an *AbortError naming the target.
+func WithFailFast() Option {
+	return func(c *config) { c.failFast = true }
+}
+
+// An AbortError is the cause of the cancellation of the waits that are
+// stopped, as by WithFailFast, because the wait for another target
+// failed for good. It does not wrap the error of that wait, which is
+// reported with the others, so that the errors of the stopped waits
+// don't match ErrNonRetryable.
+type AbortError struct {
+	Target string // that failed
+	Err    error  // of the wait for Target
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("aborted, as %s failed for good", e.Target)
+}
+
+// abortOnPermanent returns a context derived from ctx and a function
+// to call with the outcome of the wait for each target, which, if
+// opts has WithFailFast, cancels the context with an *AbortError if
+// the wait failed with ErrNonRetryable.
+func abortOnPermanent(ctx context.Context, opts []Option) (context.Context, func(target string, err error)) {
+	if !newConfig(opts).failFast {
+		return ctx, func(string, error) {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, func(target string, err error) {
+		if errors.Is(err, ErrNonRetryable) {
+			cancel(&AbortError{Target: target, Err: err})
+		}
+	}
+}
+
+// shareDeadline applies the timeout given by opts to ctx, and returns
+// opts extended so that the waits using the new context don't apply
+// it once more.
+func shareDeadline(ctx context.Context, opts []Option) (context.Context, context.CancelFunc, []Option) {
+	cfg := newConfig(opts)
+	timeout := cfg.effectiveTimeout(ctx)
+	ctx, cancel := cfg.withTimeout(ctx)
+	opts = append(opts[:len(opts):len(opts)], func(c *config) { c.timeout, c.sharedTimeout = -1, timeout })
+	return ctx, cancel, opts
+}
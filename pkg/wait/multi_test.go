@@ -0,0 +1,132 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveHanging returns the URL of a server that doesn't respond until
+// the request is canceled, and a channel closed once one is.
+func serveHanging(t *testing.T) (string, <-chan struct{}) {
+	canceled := make(chan struct{})
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		once.Do(func() { close(canceled) })
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, canceled
+}
+
+func TestWaitForAll(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	urls := []string{serveOrdered(t, "api", 2, &mu, &order), serveOrdered(t, "web", 0, &mu, &order)}
+	results, err := WaitForAll(context.Background(), urls, WithExpectStatus(Statuses{{200, 200}}),
+		WithInitialDelay(time.Millisecond), WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Attempts != 3 || results[1].Attempts != 1 {
+		t.Errorf("got results %+v, want those of api after 3 attempts and web after 1", results)
+	}
+
+	if results, err := WaitForAll(context.Background(), nil); err != nil || len(results) != 0 {
+		t.Errorf("waiting for all of none: %v, %v", results, err)
+	}
+}
+
+func TestWaitForAllErrors(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	db, cache := "tcp://"+closedAddr(t), "tcp://"+closedAddr(t)
+	urls := []string{db, serveOrdered(t, "api", 0, &mu, &order), cache}
+	results, err := WaitForAll(context.Background(), urls, WithMaxAttempts(2), WithInitialDelay(time.Millisecond),
+		WithLogger(slog.New(slog.DiscardHandler)))
+	if !errors.Is(err, ErrMaxAttempts) {
+		t.Fatalf("got %v, want the errors of db and cache", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("got %d errors joined, want 2: %v", n, err)
+	}
+	for _, url := range []string{db, cache} {
+		if !strings.Contains(err.Error(), strings.TrimPrefix(url, "tcp://")) {
+			t.Errorf("error %q does not name %s", err, url)
+		}
+	}
+	if results[0].Err == nil || results[1].Err != nil || results[2].Err == nil {
+		t.Errorf("got results %+v, want only api ready", results)
+	}
+}
+
+func TestWaitForAny(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	hanging, canceled := serveHanging(t)
+	ready := serveOrdered(t, "api", 1, &mu, &order)
+	r, err := WaitForAny(context.Background(), []string{hanging, ready}, WithExpectStatus(Statuses{{200, 200}}),
+		WithTimeout(time.Minute), WithAttemptTimeout(time.Minute), WithInitialDelay(time.Millisecond),
+		WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Target != ready || r.Attempts != 2 {
+		t.Errorf("got the result for %s after %d attempts, want %s after 2", r.Target, r.Attempts, ready)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(10 * time.Second):
+		t.Error("the wait for the other server was not canceled")
+	}
+}
+
+func TestWaitForAnyErrors(t *testing.T) {
+	db, cache := "tcp://"+closedAddr(t), "tcp://"+closedAddr(t)
+	r, err := WaitForAny(context.Background(), []string{db, cache}, WithMaxAttempts(2),
+		WithInitialDelay(time.Millisecond), WithLogger(slog.New(slog.DiscardHandler)))
+	if !errors.Is(err, ErrMaxAttempts) || r.Err != err {
+		t.Fatalf("got %v, with the result error %v, want the errors of db and cache", err, r.Err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("got %d errors joined, want 2: %v", n, err)
+	}
+
+	r, err = WaitForAny(context.Background(), nil)
+	if err == nil || r.Err != err {
+		t.Errorf("waiting for any of none: got %v, with the result error %v, want an error", err, r.Err)
+	}
+}
+
+func TestWaitForAllFailFast(t *testing.T) {
+	db := "tcp://" + closedAddr(t)
+	hanging, _ := serveHanging(t)
+	results, err := WaitForAll(context.Background(), []string{db, hanging}, WithFailFast(),
+		WithRetryIf(func(error) bool { return false }), WithTimeout(time.Minute), WithAttemptTimeout(time.Minute),
+		WithLogger(slog.New(slog.DiscardHandler)))
+	if !errors.Is(err, ErrNonRetryable) || !errors.Is(err, ErrCanceled) {
+		t.Fatalf("got %v, want the errors of db and of the wait stopped", err)
+	}
+	if errors.Is(results[1].Err, ErrNonRetryable) || !errors.Is(results[1].Err, ErrCanceled) {
+		t.Errorf("the wait stopped failed with %v, want it canceled", results[1].Err)
+	}
+	var ae *AbortError
+	if !errors.As(results[1].Err, &ae) || ae.Target != db || !errors.Is(ae.Err, ErrNonRetryable) {
+		t.Errorf("the wait stopped failed with %v, want it aborted for %s", results[1].Err, db)
+	}
+
+	// Without WithFailFast, the wait for the other server goes on.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	results, _ = WaitForAll(ctx, []string{db, hanging}, WithRetryIf(func(error) bool { return false }),
+		WithLogger(slog.New(slog.DiscardHandler)))
+	if !errors.Is(results[1].Err, ErrTimeout) || errors.As(results[1].Err, &ae) {
+		t.Errorf("the other wait failed with %v, want it to time out", results[1].Err)
+	}
+}
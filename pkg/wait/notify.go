@@ -0,0 +1,150 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// A Notification tells a Notifier that a wait for a target is over,
+// or that a watched target went up or down.
+type Notification struct {
+	Event    string // "ready" or "not ready" for waits, "down" or "still up" with WithUntilDown, "up" or "down" for Watch
+	Target   string
+	Time     time.Time
+	Attempts int           // attempts of the wait, or checks of Watch so far
+	Elapsed  time.Duration // time spent waiting, zero for Watch
+	Flapping bool          // for Watch, as for Change
+	Err      error         // why the target is not ready, or down
+}
+
+// Text returns a line describing n for people, such as
+// "db:5432 ready after 3 attempts over 4.2s".
+func (n Notification) Text() string {
+	var s string
+	switch n.Event {
+	case "ready":
+		s = fmt.Sprintf("%s ready after %d attempt%s over %v", n.Target, n.Attempts, plural(n.Attempts), n.Elapsed.Round(time.Millisecond))
+	case "not ready":
+		s = n.Target + " not ready" // the error says after how long
+	default:
+		s = fmt.Sprintf("%s is %s", n.Target, n.Event)
+		if n.Flapping {
+			s += ", flapping"
+		}
+	}
+	if n.Err != nil {
+		s += ": " + n.Err.Error()
+	}
+	return s
+}
+
+// A Notifier is told of the outcomes of waits and of the changes of
+// watched targets, so that other systems can react to them without
+// polling. Its Notify method may be called concurrently.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// notifyTimeout limits the time that notifying may take.
+const notifyTimeout = 10 * time.Second
+
+// WithNotifier makes WaitForServer and Watch tell n of the outcomes of
+// waits and of the changes of targets, in addition to the notifiers
+// given by other WithNotifier options. Notifications that fail are
+// logged; they don't fail the wait.
+func WithNotifier(n Notifier) Option {
+	return func(c *config) { c.notifiers = append(c.notifiers, n) }
+}
+
+// notify publishes n, for the notifiers of c and the other
+// subscribers.
+func (c *config) notify(ctx context.Context, n Notification) {
+	c.publish(ctx, n)
+}
+
+// sendNotification sends n to the notifiers of c, even if ctx is done.
+func (c *config) sendNotification(ctx context.Context, n Notification) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	for _, notifier := range c.notifiers {
+		if err := notifier.Notify(ctx, n); err != nil {
+			c.logger.WarnContext(ctx, "notification failed", "target", n.Target, "event", n.Event, "err", err)
+		}
+	}
+}
+
+// A Webhook is a Notifier posting notifications to URL as JSON objects
+// such as
+//
+//	{"event": "not ready", "target": "http://db:8080/health", "time": "2026-10-14T09:30:00Z",
+//	 "attempts": 12, "elapsed": 60.002, "error": "...", "text": "http://db:8080/health not ready: failed after ..."}
+//
+// with the elapsed time in seconds, and, as text, that of the
+// Notification, which Slack incoming webhooks and the like show.
+type Webhook struct {
+	URL    string
+	Client *http.Client // or nil for http.DefaultClient
+}
+
+type webhookNotification struct {
+	Event    string    `json:"event"`
+	Target   string    `json:"target"`
+	Time     time.Time `json:"time"`
+	Attempts int       `json:"attempts"`
+	Elapsed  float64   `json:"elapsed,omitempty"`
+	Flapping bool      `json:"flapping,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Text     string    `json:"text"`
+}
+
+// Notify posts n to w.URL, and returns an error if the response has a
+// status other than 2xx. The error does not give w.URL, which may hold
+// a secret, as those of chat services do.
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	body := webhookNotification{
+		Event:    n.Event,
+		Target:   n.Target,
+		Time:     n.Time,
+		Attempts: n.Attempts,
+		Elapsed:  n.Elapsed.Seconds(),
+		Flapping: n.Flapping,
+		Text:     n.Text(),
+	}
+	if n.Err != nil {
+		body.Error = n.Err.Error()
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return errors.New("webhook: invalid URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package wait
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ntpEpoch is the start of era 0 of NTP timestamps, whose seconds
+// wrap around in 2036, at the start of era 1.
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// An ntpChecker checks whether the clock of the system is within a
+// tolerance of that of an NTP server, by asking the server for the
+// time as an SNTP client does (RFC 4330).
+type ntpChecker struct {
+	cfg       *config
+	addr      string // host:port
+	maxOffset time.Duration
+
+	responseStatus
+}
+
+// newNTPChecker returns a checker for an ntp://host[:port] target,
+// with an optional max_offset=D setting, 1s by default.
+func newNTPChecker(cfg *config, u *url.URL) (*ntpChecker, error) {
+	p := &ntpChecker{cfg: cfg, addr: withDefaultPort(u.Host, "123"), maxOffset: time.Second}
+	if s := u.Query().Get("max_offset"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid max_offset %q in %s", s, u.Redacted())
+		}
+		p.maxOffset = d
+	}
+	return p, nil
+}
+
+func (p *ntpChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dialContext(ctx, "udp", p.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	// A client request of version 4, timestamped with when it is sent,
+	// which the server copies into its response.
+	var req [48]byte
+	req[0] = 4<<3 | 3
+	sent := time.Now()
+	binary.BigEndian.PutUint64(req[40:], ntpTimestamp(sent))
+	if _, err := conn.Write(req[:]); err != nil {
+		return err
+	}
+	var resp [48]byte
+	for {
+		n, err := conn.Read(resp[:])
+		if err != nil {
+			return fmt.Errorf("reading NTP response: %w", err)
+		}
+		// Ignore stray datagrams, such as late responses to others.
+		if n == len(resp) && resp[0]&7 == 4 && [8]byte(resp[24:32]) == [8]byte(req[40:]) {
+			break
+		}
+	}
+	received := time.Now()
+
+	leap, stratum := resp[0]>>6, resp[1]
+	switch {
+	case stratum == 0:
+		// A kiss-o'-death packet, with a code such as RATE or DENY.
+		return fmt.Errorf("NTP server sent kiss code %q", resp[12:16])
+	case leap == 3:
+		return fmt.Errorf("NTP server is not synchronized")
+	}
+	offset, delay := ntpOffset(resp[:], received)
+	p.set(0, fmt.Sprintf("offset %s, delay %s, stratum %d", offset.Round(time.Microsecond), delay.Round(time.Microsecond), stratum))
+	if offset.Abs() > p.maxOffset {
+		return fmt.Errorf("system clock is off by %s from NTP, more than %s", offset.Round(time.Microsecond), p.maxOffset)
+	}
+	return nil
+}
+
+// ntpOffset returns how far the clock of the server is ahead of that
+// of the system, and the time the request and the NTP response resp
+// spent on the network, for a response received at received (RFC 4330
+// section 5).
+func ntpOffset(resp []byte, received time.Time) (offset, delay time.Duration) {
+	t1 := ntpTime(binary.BigEndian.Uint64(resp[24:])) // the request was sent
+	t2 := ntpTime(binary.BigEndian.Uint64(resp[32:])) // the server received it
+	t3 := ntpTime(binary.BigEndian.Uint64(resp[40:])) // the server replied
+	return (t2.Sub(t1) + t3.Sub(received)) / 2, received.Sub(t1) - t3.Sub(t2)
+}
+
+// ntpTimestamp returns t as an NTP timestamp, in seconds since the
+// start of its era in fixed point with 32 fractional bits.
+func ntpTimestamp(t time.Time) uint64 {
+	d := t.Sub(ntpEpoch)
+	secs := uint64(d / time.Second)
+	frac := uint64(d%time.Second) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// ntpTime returns the time of the NTP timestamp ts, which is in era 1
+// unless the high bit of its seconds is set, so that it is between 1968
+// and 2104 (RFC 4330 section 3).
+func ntpTime(ts uint64) time.Time {
+	secs := time.Duration(ts>>32) * time.Second
+	frac := time.Duration((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	epoch := ntpEpoch
+	if ts>>63 == 0 {
+		epoch = epoch.Add(1 << 32 * time.Second)
+	}
+	return epoch.Add(secs).Add(frac)
+}
@@ -0,0 +1,65 @@
+package wait
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// ntpResponse returns a response of a server of stratum 2 with the
+// given originate, receive, and transmit timestamps, in hex.
+func ntpResponse(t *testing.T, origin, receive, transmit string) []byte {
+	b, err := hex.DecodeString("24020000" + "00000000" + "00000000" + "47505300" + "0000000000000000" + origin + receive + transmit)
+	if err != nil || len(b) != 48 {
+		t.Fatalf("bad NTP response: %x, %v", b, err)
+	}
+	return b
+}
+
+func TestNTPOffset(t *testing.T) {
+	for _, test := range []struct {
+		name                      string
+		origin, receive, transmit string
+		received                  time.Time
+		offset, delay             time.Duration
+	}{
+		// Sent at midnight, 250ms each way, and 250ms at a server 1s ahead.
+		{"ahead", "e93c7f0000000000", "e93c7f0140000000", "e93c7f0180000000",
+			time.Date(2024, 1, 1, 0, 0, 0, 750e6, time.UTC), time.Second, 500 * time.Millisecond},
+		{"behind", "e93c7f0000000000", "e93c7efe40000000", "e93c7efe80000000",
+			time.Date(2024, 1, 1, 0, 0, 0, 750e6, time.UTC), -2 * time.Second, 500 * time.Millisecond},
+		// From the last second of era 0 to the first of era 1, on a server
+		// in sync.
+		{"era boundary", "ffffffff80000000", "ffffffffc0000000", "0000000000000000",
+			time.Date(2036, 2, 7, 6, 28, 16, 250e6, time.UTC), 0, 500 * time.Millisecond},
+		{"era 1", "0000000100000000", "0000000180000000", "0000000180000000",
+			time.Date(2036, 2, 7, 6, 28, 18, 0, time.UTC), 0, time.Second},
+	} {
+		resp := ntpResponse(t, test.origin, test.receive, test.transmit)
+		offset, delay := ntpOffset(resp, test.received)
+		if offset != test.offset || delay != test.delay {
+			t.Errorf("%s: offset %v and delay %v, want %v and %v", test.name, offset, delay, test.offset, test.delay)
+		}
+	}
+}
+
+func TestNTPTimestamp(t *testing.T) {
+	for _, test := range []struct {
+		t  time.Time
+		ts uint64
+	}{
+		{time.Date(1968, 1, 20, 3, 14, 8, 0, time.UTC), 1 << 63},
+		{time.Date(2024, 1, 1, 0, 0, 0, 500e6, time.UTC), 0xe93c7f0080000000},
+		{time.Date(2036, 2, 7, 6, 28, 15, 0, time.UTC), 0xffffffff00000000},
+		{time.Date(2036, 2, 7, 6, 28, 16, 0, time.UTC), 0}, // era 1
+		{time.Date(2036, 2, 7, 6, 28, 16, 250e6, time.UTC), 0x40000000},
+		{time.Date(2104, 2, 26, 9, 42, 23, 0, time.UTC), 0x7fffffff00000000},
+	} {
+		if got := ntpTimestamp(test.t); got != test.ts {
+			t.Errorf("ntpTimestamp(%v) = %#x, want %#x", test.t, got, test.ts)
+		}
+		if got := ntpTime(test.ts); !got.Equal(test.t) {
+			t.Errorf("ntpTime(%#x) = %v, want %v", test.ts, got, test.t)
+		}
+	}
+}
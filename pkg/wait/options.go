@@ -0,0 +1,525 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/events"
+	"github.com/go-monk/error-handling/pkg/logdedup"
+	"github.com/go-monk/error-handling/pkg/retry"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Defaults used by WaitForServer when no options are given.
+const (
+	DefaultTimeout      = 1 * time.Minute
+	DefaultInitialDelay = 1 * time.Second
+	DefaultMaxDelay     = 1 * time.Minute
+	DefaultMaxRedirects = 10 // as many as http.Client follows
+)
+
+// An Option configures WaitForServer, Wait, and the other functions of
+// this package. Options that don't apply to what is waited for, such
+// as WithMethod for a TCP target, are ignored.
+type Option func(*config)
+
+type config struct {
+	timeout                   time.Duration
+	sharedTimeout             time.Duration // the timeout applied to ctx by shareDeadline
+	initialDelay              time.Duration
+	maxDelay                  time.Duration
+	maxAttempts               int
+	attemptTimeout            time.Duration
+	jitter                    backoff.Jitter
+	backoff                   backoff.Backoff
+	retryIf                   func(error) bool
+	client                    *http.Client
+	proxy                     *url.URL
+	expectStatus              Statuses
+	expectBody                *regexp.Regexp
+	expectHeaders             []expectedHeader
+	method                    string
+	maxRedirects              int
+	header                    http.Header
+	username, password        string
+	basicAuth                 bool
+	successCount              int
+	failureThreshold          int
+	startDelay                time.Duration
+	maxLatency                time.Duration
+	budget                    *retry.Budget
+	hedgeDelay                time.Duration
+	rate                      float64
+	rateBurst                 int
+	connections               Connections
+	protocol                  Protocol
+	onRetry                   func(attempt int, delay time.Duration, err error)
+	onAttempt                 func(Attempt)
+	notifiers                 []Notifier
+	cache                     *Cache
+	untilDown                 bool
+	recorder                  retry.Recorder
+	metrics                   *Metrics
+	progress                  *Progress
+	tracer                    trace.Tracer
+	clock                     clock.Clock
+	flapWindow, flapThreshold int
+	logger                    *slog.Logger
+	logRepeats                time.Duration
+	logDedup                  *logdedup.Handler // made for logRepeats
+	resolver                  *net.Resolver
+	dialer                    Dialer
+	ipVersion                 int
+	allAddresses              bool
+	resolution                Resolution
+	pinned                    *pinnedAddrs
+	tlsConfig                 *tls.Config
+	minCertValidity           time.Duration
+	grpcService               string
+	plugins                   bool
+	state                     *StateFile
+	failFast                  bool
+	schedule                  backoff.Schedule
+	events                    *events.Bus // of WithEvents
+	bus                       *events.Bus // of the waits, made by subscribe
+	maxResponseSize           int64
+	sameHostRedirects         bool
+	blockedNetworks           []netip.Prefix
+	minTLSVersion             uint16
+}
+
+// defaultOptions are the options set by SetDefaults, or nil.
+var defaultOptions atomic.Pointer[[]Option]
+
+// SetDefaults sets options that every call of WaitForServer, Wait,
+// and the other functions of this package applies before its own, so
+// that a program can configure all its waits, such as with
+// WithTimeout or WithLogger, in one place. The options that a call is
+// given still override them. The settings of retry.SetDefault apply
+// as well, to those that no option sets.
+//
+// SetDefaults is meant to be called once, as the program starts,
+// before waiting for anything. It panics if it is called again.
+func SetDefaults(opts ...Option) {
+	opts = slices.Clone(opts)
+	if !defaultOptions.CompareAndSwap(nil, &opts) {
+		panic("wait: SetDefaults called twice")
+	}
+}
+
+// newConfig returns the configuration resulting from applying the
+// options of SetDefaults, and then opts, to the defaults.
+func newConfig(opts []Option) config {
+	cfg := config{
+		initialDelay: DefaultInitialDelay,
+		maxDelay:     DefaultMaxDelay,
+		method:       http.MethodHead,
+		maxRedirects: DefaultMaxRedirects,
+		logger:       slog.Default(),
+		resolver:     net.DefaultResolver,
+		tracer:       noop.NewTracerProvider().Tracer(""),
+		clock:        clock.Real,
+	}
+	if defaults := defaultOptions.Load(); defaults != nil {
+		opts = append(slices.Clip(*defaults), opts...)
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.logRepeats > 0 {
+		cfg.logDedup = logdedup.NewHandler(cfg.logger.Handler(), &logdedup.Options{
+			Interval: cfg.logRepeats,
+			Vary:     varyingLogKeys,
+			Clock:    cfg.clock,
+		})
+		cfg.logger = slog.New(cfg.logDedup)
+	}
+	cfg.subscribe()
+	if cfg.resolution == ResolvePin {
+		cfg.pinned = &pinnedAddrs{addrs: make(map[string][]netip.Addr)}
+	}
+	if cfg.minTLSVersion != 0 {
+		conf := cfg.tlsConfig.Clone()
+		if conf == nil {
+			conf = new(tls.Config)
+		}
+		conf.MinVersion = cfg.minTLSVersion
+		cfg.tlsConfig = conf
+	}
+	if cfg.client == nil {
+		cfg.client = http.DefaultClient
+		custom := cfg.ipVersion != 0 || cfg.allAddresses || cfg.resolution != ResolveDefault || cfg.dialer != nil ||
+			len(cfg.blockedNetworks) > 0
+		forced := cfg.protocol == ProtocolHTTP1 || cfg.protocol == ProtocolHTTP2
+		if cfg.tlsConfig != nil || cfg.proxy != nil || custom || forced || cfg.maxResponseSize > 0 {
+			t := http.DefaultTransport.(*http.Transport).Clone()
+			t.TLSClientConfig = cfg.tlsConfig
+			if custom {
+				t.DialContext = cfg.dialContext
+			}
+			t.DisableKeepAlives = cfg.resolution == ResolveFresh
+			if cfg.proxy != nil {
+				t.Proxy = proxyFunc(cfg.proxy)
+			}
+			if forced {
+				t.Protocols = cfg.protocol.protocols()
+			}
+			t.MaxResponseHeaderBytes = cfg.maxResponseSize
+			cfg.client = &http.Client{Transport: t}
+		}
+	}
+	if check := cfg.checkRedirect(); check != nil {
+		client := *cfg.client
+		client.CheckRedirect = check
+		cfg.client = &client
+	}
+	return cfg
+}
+
+// WithTimeout limits the overall time spent waiting. Without it,
+// WaitForServer gives up after DefaultTimeout unless ctx already
+// carries a deadline. However short d is, one attempt is made.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// WithAttemptTimeout limits the time each attempt may take, so that
+// a hung connection cannot use up the whole timeout. Zero, the
+// default, means no limit other than the overall timeout.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(c *config) { c.attemptTimeout = d }
+}
+
+// WithInitialDelay sets the delay after the first failed attempt.
+// Each following delay is twice the previous one. It has no effect
+// when WithBackoff is used.
+func WithInitialDelay(d time.Duration) Option {
+	return func(c *config) { c.initialDelay = d }
+}
+
+// WithMaxDelay caps the delay between attempts, DefaultMaxDelay by
+// default. Zero means no cap. It has no effect when WithBackoff is
+// used.
+func WithMaxDelay(d time.Duration) Option {
+	return func(c *config) { c.maxDelay = d }
+}
+
+// WithMaxAttempts limits the number of attempts. Zero means no limit.
+func WithMaxAttempts(n int) Option {
+	return func(c *config) { c.maxAttempts = n }
+}
+
+// WithJitter randomizes the delays between attempts using the given
+// strategy. The default is backoff.NoJitter.
+func WithJitter(j backoff.Jitter) Option {
+	return func(c *config) { c.jitter = j }
+}
+
+// WithBackoff replaces the default exponential back-off with b.
+func WithBackoff(b backoff.Backoff) Option {
+	return func(c *config) { c.backoff = b }
+}
+
+// WithSchedule makes the waits retry at the times of s, as told by the
+// clock set by WithClock, instead of backing off, such as only on the
+// hour and at half past for servers restarted on a schedule. It takes
+// precedence over WithBackoff, and the delays are not jittered.
+func WithSchedule(s backoff.Schedule) Option {
+	return func(c *config) { c.schedule = s }
+}
+
+// WithRetryIf makes WaitForServer give up as soon as an attempt
+// fails with an error for which f reports false. Transient is a
+// reasonable choice of f.
+func WithRetryIf(f func(err error) bool) Option {
+	return func(c *config) { c.retryIf = f }
+}
+
+// WithClient makes WaitForServer send its requests using client
+// instead of http.DefaultClient, for example to use a custom proxy or
+// transport. The client's own TLS configuration takes precedence
+// over WithTLSConfig.
+func WithClient(client *http.Client) Option {
+	return func(c *config) { c.client = client }
+}
+
+// WithProxy makes http:// and https:// requests go through the proxy
+// at u, except for the hosts listed by the NO_PROXY environment
+// variable. It has no effect when WithClient is used. By default the
+// proxies given by HTTP_PROXY and HTTPS_PROXY are used, as by
+// http.ProxyFromEnvironment.
+func WithProxy(u *url.URL) Option {
+	return func(c *config) { c.proxy = u }
+}
+
+// proxyFunc returns a function for http.Transport.Proxy that chooses
+// the proxy at u, unless NO_PROXY exempts the request.
+func proxyFunc(u *url.URL) func(*http.Request) (*url.URL, error) {
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	f := (&httpproxy.Config{HTTPProxy: u.String(), HTTPSProxy: u.String(), NoProxy: noProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) { return f(req.URL) }
+}
+
+// WithExpectStatus makes WaitForServer keep retrying until the
+// server responds with a status code in s. By default any response
+// counts as success.
+func WithExpectStatus(s Statuses) Option {
+	return func(c *config) { c.expectStatus = s }
+}
+
+// WithExpectBody makes WaitForServer keep retrying until the body of
+// the response, of which at most the first MiB is read, matches re,
+// for example `"status":\s*"ok"`. HEAD requests are sent as GET
+// requests instead, as their responses have no body.
+func WithExpectBody(re *regexp.Regexp) Option {
+	return func(c *config) { c.expectBody = re }
+}
+
+// WithExpectHeader makes WaitForServer keep retrying until the
+// response has a key header with a value that re matches in full, or
+// with any value if re is nil, for servers behind load balancers
+// that respond 200 OK with maintenance pages, so that only a header
+// tells whether the server is ready. It may be given more than once
+// to require several headers.
+func WithExpectHeader(key string, re *regexp.Regexp) Option {
+	if re != nil {
+		re = regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	}
+	return func(c *config) { c.expectHeaders = append(c.expectHeaders, expectedHeader{key, re}) }
+}
+
+// WithMethod sets the HTTP method of the requests, HEAD by default.
+// MethodAuto tries HEAD and falls back to GET if the server responds
+// with 405 Method Not Allowed or 501 Not Implemented.
+func WithMethod(method string) Option {
+	return func(c *config) { c.method = method }
+}
+
+// WithMaxRedirects makes HTTP requests follow at most n redirects,
+// DefaultMaxRedirects by default, and none if n is zero, so that a
+// redirect to an error page or a login form doesn't pass for a ready
+// server. The redirect response that is not followed counts as a
+// failure, unless its status is expected by WithExpectStatus.
+func WithMaxRedirects(n int) Option {
+	return func(c *config) { c.maxRedirects = max(n, 0) }
+}
+
+// WithHeader adds a header to the requests. A Host header sets the
+// host the requests are addressed to, for probing virtual hosts.
+func WithHeader(key, value string) Option {
+	return func(c *config) {
+		if c.header == nil {
+			c.header = make(http.Header)
+		}
+		c.header.Add(key, value)
+	}
+}
+
+// WithBasicAuth makes the requests use HTTP basic authentication.
+func WithBasicAuth(username, password string) Option {
+	return func(c *config) {
+		c.username, c.password = username, password
+		c.basicAuth = true
+	}
+}
+
+// WithSuccessCount makes WaitForServer succeed only after n
+// consecutive successful probes, spaced by the first back-off delay.
+// The default is 1.
+func WithSuccessCount(n int) Option {
+	return func(c *config) { c.successCount = n }
+}
+
+// WithFailureThreshold makes WaitForServer give up once n probes in a
+// row have failed, like the failureThreshold of a Kubernetes probe.
+// Zero, the default, means no limit other than the timeout.
+func WithFailureThreshold(n int) Option {
+	return func(c *config) { c.failureThreshold = n }
+}
+
+// WithBudget makes the waits retry no faster than b allows, so that
+// waits sharing b, such as those of WaitForAll, together keep to its
+// rate however many targets there are.
+func WithBudget(b *retry.Budget) Option {
+	return func(c *config) { c.budget = b }
+}
+
+// WithRateLimit makes WaitForServer probe each server no more than
+// rate times per second on average, and burst times in quick
+// succession, whatever the delays between attempts, for servers that
+// penalize bursty clients. Combined with
+// WithBackoff(backoff.Constant(0)), it probes at a steady rate
+// instead of backing off. Unlike WithBudget, it limits each wait
+// separately.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *config) { c.rate, c.rateBurst = rate, burst }
+}
+
+// WithStartDelay makes WaitForServer wait for d before the first
+// probe, like the initialDelaySeconds of a Kubernetes probe. The
+// delay counts towards the timeout.
+func WithStartDelay(d time.Duration) Option {
+	return func(c *config) { c.startDelay = d }
+}
+
+// WithOnRetry makes WaitForServer call f after each failed attempt
+// that is going to be retried, instead of logging the failure.
+// Attempts are numbered from 0; delay is how long WaitForServer is
+// about to sleep.
+func WithOnRetry(f func(attempt int, delay time.Duration, err error)) Option {
+	return func(c *config) { c.onRetry = f }
+}
+
+// WithOnAttempt makes WaitForServer call f after each attempt, failed
+// or not, for example to report progress. WaitForAll and the other
+// functions waiting for several things at once may call f
+// concurrently.
+func WithOnAttempt(f func(Attempt)) Option {
+	return func(c *config) { c.onAttempt = f }
+}
+
+// WithRecorder makes WaitForServer record each attempt with r, such
+// as a *retry.History. The events of the targets of WaitForAll and
+// the like are recorded together; use a Recorder per target, or
+// WithOnAttempt, to tell them apart.
+func WithRecorder(r retry.Recorder) Option {
+	return func(c *config) { c.recorder = r }
+}
+
+// WithLogger makes WaitForServer log failed attempts, and other
+// progress, to logger instead of slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) { c.logger = logger }
+}
+
+// WithUntilDown makes WaitForServer wait for the target to go down
+// rather than up, as by checking Not(c) for the Checker c of the
+// target: it waits until the check fails, such as when the server no
+// longer accepts connections. The Cache of WithCache is not used.
+func WithUntilDown() Option {
+	return func(c *config) { c.untilDown = true }
+}
+
+// WithLogRepeats makes WaitForServer log the failed attempts that
+// repeat the error of the one before, and the other records repeating
+// one another, at most once per interval, with the number of attempts
+// they stand for, as by package logdedup. The records suppressed are
+// logged once the wait is over. Zero, the default, logs every one.
+func WithLogRepeats(interval time.Duration) Option {
+	return func(c *config) { c.logRepeats = interval }
+}
+
+// varyingLogKeys are the attributes of the records logged that differ
+// between attempts failing with the same error.
+var varyingLogKeys = []string{"attempt", "delay", "elapsed", "latency"}
+
+// WithResolver makes dns:// targets use r instead of
+// net.DefaultResolver.
+func WithResolver(r *net.Resolver) Option {
+	return func(c *config) { c.resolver = r }
+}
+
+// WithTLSConfig sets the TLS configuration for tls:// targets and, unless
+// WithClient is used, for https:// ones, for example to trust extra
+// certificate authorities or to skip verification.
+func WithTLSConfig(conf *tls.Config) Option {
+	return func(c *config) { c.tlsConfig = conf }
+}
+
+// WithMinCertValidity makes tls:// targets count as ready only once
+// the server's certificate remains valid for at least d.
+func WithMinCertValidity(d time.Duration) Option {
+	return func(c *config) { c.minCertValidity = d }
+}
+
+// WithGRPCService sets the service whose health grpc:// and grpcs://
+// targets check, unless the target names one with ?service=. By
+// default the health of the server as a whole is checked. Servers
+// without the health service, or that do not know the service, fail
+// the checks for good.
+func WithGRPCService(name string) Option {
+	return func(c *config) { c.grpcService = name }
+}
+
+// WithClock makes the waits time their attempts and sleep between
+// them with clk instead of clock.Real, for example to test a
+// configuration without waiting; see retry.Policy.Clock.
+func WithClock(clk clock.Clock) Option {
+	return func(c *config) { c.clock = clk }
+}
+
+// since returns the time elapsed since t by the clock of c.
+func (c *config) since(t time.Time) time.Duration {
+	return c.clock.Now().Sub(t)
+}
+
+// newBackoff returns the Backoff described by c.
+func (c *config) newBackoff() backoff.Backoff {
+	if c.schedule != nil {
+		return backoff.OnSchedule(c.schedule, c.clock.Now)
+	}
+	b := c.backoff
+	if b == nil {
+		b = backoff.Exponential(c.initialDelay, c.maxDelay)
+	}
+	return backoff.WithJitter(b, c.jitter)
+}
+
+// effectiveTimeout returns the timeout for a wait on ctx: the one set
+// by WithTimeout, or DefaultTimeout if ctx has no deadline.
+// Zero or less means no timeout other than ctx's.
+func (c *config) effectiveTimeout(ctx context.Context) time.Duration {
+	if _, ok := ctx.Deadline(); !ok && c.timeout == 0 {
+		return DefaultTimeout
+	}
+	return c.timeout
+}
+
+// minTimeout is the least time that withTimeout allows, so that one
+// attempt can be made however short the timeout, as retry.Do allows
+// the first attempt.
+const minTimeout = 100 * time.Millisecond
+
+// withTimeout returns ctx limited to the effective timeout.
+func (c *config) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := c.effectiveTimeout(ctx); timeout > 0 {
+		return context.WithTimeout(ctx, max(timeout, minTimeout))
+	}
+	return context.WithCancel(ctx)
+}
+
+// policy returns the retry policy described by c for a wait on ctx.
+func (c *config) policy(ctx context.Context) retry.Policy {
+	timeout := c.effectiveTimeout(ctx)
+	return retry.Policy{
+		Backoff:        c.newBackoff(),
+		Timeout:        timeout,
+		AttemptTimeout: c.attemptTimeout,
+		MaxAttempts:    c.maxAttempts,
+		StartDelay:     c.startDelay,
+		RetryIf:        retryNotReady(c.retryIf),
+		OnRetry:        c.onRetry,
+		Recorder:       c.recorder,
+		Budget:         c.budget,
+		Rate:           c.rate,
+		RateBurst:      c.rateBurst,
+		Clock:          c.clock,
+	}
+}
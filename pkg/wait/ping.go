@@ -0,0 +1,119 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// A pingChecker checks whether a host answers ICMP echo requests.
+// It uses a raw ICMP socket if the process is privileged enough, and
+// otherwise an unprivileged ICMP datagram ("UDP ping") socket, which
+// Linux allows for the groups in net.ipv4.ping_group_range and macOS
+// allows for everyone.
+type pingChecker struct {
+	cfg  *config
+	host string
+	seq  atomic.Uint32
+}
+
+// pingData marks our echo requests.
+var pingData = []byte("wait ping")
+
+func (p *pingChecker) Check(ctx context.Context) error {
+	ips, err := p.cfg.lookupIPs(ctx, p.host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err := p.cfg.checkAddr(ip); err != nil {
+			return err
+		}
+		if err := p.ping(ctx, net.IP(ip.AsSlice())); err != nil {
+			return fmt.Errorf("ping %s: %w", ip, err)
+		}
+	}
+	return nil
+}
+
+// ping sends an echo request to ip and waits for the reply.
+func (p *pingChecker) ping(ctx context.Context, ip net.IP) error {
+	conn, udp, err := listenICMP(ip.To4() != nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var typ, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	proto := 1 // ICMP for IPv4
+	if ip.To4() == nil {
+		typ, reply, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, 58
+	}
+	id := os.Getpid() & 0xffff
+	seq := int(p.seq.Add(1) & 0xffff)
+	msg := icmp.Message{Type: typ, Body: &icmp.Echo{ID: id, Seq: seq, Data: pingData}}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if udp {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	if _, err := conn.WriteTo(b, dst); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return ctxErr(ctx, err)
+		}
+		m, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || m.Type != reply {
+			continue
+		}
+		// Datagram sockets get their ID assigned by the kernel,
+		// which also filters the replies by it.
+		if echo, ok := m.Body.(*icmp.Echo); ok && echo.Seq == seq && (udp || echo.ID == id) {
+			return nil
+		}
+	}
+}
+
+// listenICMP opens a raw ICMP socket, or a datagram one if that is
+// not permitted, and reports which kind it opened.
+func listenICMP(v4 bool) (conn *icmp.PacketConn, udp bool, err error) {
+	raw, dgram, addr := "ip4:icmp", "udp4", "0.0.0.0"
+	if !v4 {
+		raw, dgram, addr = "ip6:ipv6-icmp", "udp6", "::"
+	}
+	conn, rawErr := icmp.ListenPacket(raw, addr)
+	if rawErr == nil {
+		return conn, false, nil
+	}
+	conn, dgramErr := icmp.ListenPacket(dgram, addr)
+	if dgramErr == nil {
+		return conn, true, nil
+	}
+	return nil, false, fmt.Errorf("cannot send pings: %w", errors.Join(rawErr, dgramErr))
+}
+
+// ctxErr returns ctx's error if ctx is done, as an I/O error on a
+// connection closed by context.AfterFunc is then just a consequence.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
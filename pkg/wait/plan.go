@@ -0,0 +1,334 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+// A Plan describes a wait for a target as it would be made with some
+// options, without checking the target, so that the options can be
+// reviewed before they are relied on, as by the -dry-run flag of the
+// wait program.
+type Plan struct {
+	Target    string   // the target URL, with its secrets redacted, or the name of a Checker
+	Name      string   // of the target of a Config, or Target
+	DependsOn []string // the targets of a Config waited for first
+	Checker   string   // the scheme of the target URL, or "custom" for a Checker
+
+	// Settings are the options in effect for the target, those that
+	// apply to its scheme, in a fixed order.
+	Settings []Setting
+
+	// Host is the host name that checks of the target resolve, if
+	// any, and Addrs the addresses it resolved to when the Plan was
+	// made, or ResolveErr why it did not.
+	Host       string
+	Addrs      []netip.Addr
+	ResolveErr error
+
+	// Attempts are when the attempts would start, relative to the
+	// start of the wait, if each failed at once, with the delays of
+	// the backoff before jitter. More reports whether there would be
+	// more attempts than those listed, which are at most MaxPlanned.
+	Attempts []time.Duration
+	More     bool
+}
+
+// A Setting is an option in effect for a target, as in a Plan.
+type Setting struct {
+	Name, Value string
+}
+
+// MaxPlanned is the most attempts that a Plan lists.
+const MaxPlanned = 20
+
+// NewPlan returns the Plan of a wait for target with opts, as by
+// WaitForServer, resolving the host name of target, if it has one,
+// but not checking it. It returns a *TargetError if target cannot be
+// waited for, such as for a malformed URL or an unknown scheme.
+func NewPlan(ctx context.Context, target string, opts ...Option) (Plan, error) {
+	cfg := newConfig(opts)
+	c, err := newChecker(&cfg, target)
+	if err != nil {
+		return Plan{}, err
+	}
+	u, _ := url.Parse(target) // parsed by newChecker
+	p := cfg.plan(checkerName(c), u.Scheme)
+	if host := u.Hostname(); host != "" && resolvedSchemes[u.Scheme] {
+		if _, ok := registeredScheme(u.Scheme); !ok {
+			p.Host = host
+			p.Addrs, p.ResolveErr = cfg.resolve(ctx, host)
+		}
+	}
+	return p, nil
+}
+
+// PlanChecker returns the Plan of a wait for c with opts, as by Wait,
+// such as for a CommandChecker.
+func PlanChecker(c Checker, opts ...Option) Plan {
+	cfg := newConfig(opts)
+	return cfg.plan(checkerName(c), "custom")
+}
+
+// Plan returns the Plans of the targets of cfg, in order, as they
+// would be waited for by Wait with opts, resolving their host names
+// as NewPlan does.
+func (cfg *Config) Plan(ctx context.Context, opts ...Option) ([]Plan, error) {
+	if cfg.Timeout != 0 {
+		opts = append(opts[:len(opts):len(opts)], WithTimeout(time.Duration(cfg.Timeout)))
+	}
+	plans := make([]Plan, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		p, err := NewPlan(ctx, t.URL, t.options(opts)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		p.Name, p.DependsOn = t.Name, t.DependsOn
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+// resolvedSchemes are the schemes of the targets whose hosts are
+// resolved and connected to, unlike, say, the names of the processes
+// of process:// targets.
+var resolvedSchemes = map[string]bool{
+	"amqp": true, "amqps": true, "dns": true, "es": true, "ess": true, "grpc": true, "grpcs": true,
+	"http": true, "https": true, "kafka": true, "kafkas": true, "ntp": true, "ping": true,
+	"redis": true, "rediss": true, "ssh": true, "tcp": true, "tls": true,
+}
+
+// httpSchemes are the schemes of the targets checked over HTTP, to
+// which the HTTP options apply.
+var httpSchemes = map[string]bool{"http": true, "https": true, "es": true, "ess": true}
+
+// resolve returns the addresses of host, as the checks of c look them
+// up.
+func (c *config) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{ip}, nil
+	}
+	ips, err := c.resolver.LookupNetIP(ctx, c.network("ip"), host)
+	for i := range ips {
+		ips[i] = ips[i].Unmap()
+	}
+	return ips, err
+}
+
+// plan returns the Plan of a wait with c for the target named name,
+// of scheme.
+func (c *config) plan(name, scheme string) Plan {
+	p := Plan{Target: name, Name: name, Checker: scheme, Settings: c.settings(scheme)}
+	p.Attempts, p.More = c.attemptTimes()
+	return p
+}
+
+// attemptTimes returns when the attempts of a wait with c would start
+// if each failed at once, as retry.Do would make them, and whether
+// there would be more than MaxPlanned.
+func (c *config) attemptTimes() ([]time.Duration, bool) {
+	start := c.clock.Now()
+	var t time.Duration // since start
+	b := c.backoff
+	switch {
+	case c.schedule != nil:
+		b = backoff.OnSchedule(c.schedule, func() time.Time { return start.Add(t) })
+	case b == nil:
+		b = backoff.Exponential(c.initialDelay, c.maxDelay)
+	}
+	limit := c.maxAttempts
+	if c.failureThreshold > 0 && (limit <= 0 || c.failureThreshold < limit) {
+		limit = c.failureThreshold
+	}
+	timeout := c.effectiveTimeout(context.Background())
+	if timeout > 0 {
+		timeout = max(timeout, minTimeout)
+	}
+	t = c.startDelay
+	var attempts []time.Duration
+	for n, final := 0, false; ; n++ {
+		if len(attempts) == MaxPlanned {
+			return attempts, true
+		}
+		attempts = append(attempts, t)
+		if final || limit > 0 && n+1 >= limit {
+			return attempts, false
+		}
+		delay := b.NextDelay(n)
+		if timeout > 0 {
+			// As retry.Do makes a final attempt rather than sleep past
+			// the deadline.
+			if last := timeout - t - minTimeout; delay >= last {
+				delay, final = max(last, 0), true
+			}
+		}
+		if t+delay < t {
+			return attempts, false // never, as after the last time of a schedule
+		}
+		t += delay
+	}
+}
+
+// settings returns the Settings of a wait with c for a target of
+// scheme.
+func (c *config) settings(scheme string) []Setting {
+	var s []Setting
+	add := func(name, format string, args ...any) {
+		s = append(s, Setting{name, fmt.Sprintf(format, args...)})
+	}
+	orNone := func(d time.Duration) string {
+		if d <= 0 {
+			return "none"
+		}
+		return d.String()
+	}
+
+	if c.untilDown {
+		add("wait for", "down")
+	}
+	add("timeout", "%s", orNone(c.effectiveTimeout(context.Background())))
+	add("attempt timeout", "%s", orNone(c.attemptTimeout))
+	if c.startDelay > 0 {
+		add("start delay", "%s", c.startDelay)
+	}
+	// Schedules and backoffs are described by the attempts they make,
+	// unless they describe themselves.
+	switch {
+	case c.schedule != nil:
+		if str, ok := c.schedule.(fmt.Stringer); ok {
+			add("backoff", "on schedule %s", str)
+		} else {
+			add("backoff", "on a schedule")
+		}
+	case c.backoff != nil:
+		if str, ok := c.backoff.(fmt.Stringer); ok {
+			add("backoff", "%s", str)
+		} else {
+			add("backoff", "custom")
+		}
+	default:
+		add("backoff", "exponential from %s to %s", c.initialDelay, c.maxDelay)
+	}
+	add("jitter", "%s", c.jitter)
+	if c.maxAttempts > 0 {
+		add("max attempts", "%d", c.maxAttempts)
+	}
+	if c.successCount > 1 {
+		add("success count", "%d", c.successCount)
+	}
+	if c.failureThreshold > 0 {
+		add("failure threshold", "%d", c.failureThreshold)
+	}
+	if c.maxLatency > 0 {
+		add("max latency", "%s", c.maxLatency)
+	}
+	if c.hedgeDelay > 0 {
+		add("hedge delay", "%s", c.hedgeDelay)
+	}
+	if c.rate > 0 {
+		add("rate", "%s per second, bursts of %d", strconv.FormatFloat(c.rate, 'g', -1, 64), max(c.rateBurst, 1))
+	}
+	if c.budget != nil {
+		add("retry budget", "shared")
+	}
+
+	if httpSchemes[scheme] {
+		add("method", "%s", c.method)
+		if c.expectStatus != nil {
+			add("expect status", "%s", c.expectStatus)
+		} else {
+			add("expect status", "any")
+		}
+		if c.expectBody != nil {
+			add("expect body", "%s", c.expectBody)
+		}
+		for _, h := range c.expectHeaders {
+			if h.value == nil {
+				add("expect header", "%s", h.key)
+			} else {
+				add("expect header", "%s matching %s", h.key, h.value)
+			}
+		}
+		if len(c.header) > 0 {
+			// Only the names, as the values may be credentials.
+			keys := make([]string, 0, len(c.header))
+			for key := range c.header {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			add("headers", "%s", strings.Join(keys, ", "))
+		}
+		if c.basicAuth {
+			add("basic auth", "as %s", c.username)
+		}
+		add("max redirects", "%d", c.maxRedirects)
+		if c.sameHostRedirects {
+			add("redirects", "to the same host only")
+		}
+		if c.maxResponseSize > 0 {
+			add("max response size", "%d bytes", c.maxResponseSize)
+		}
+		if c.protocol != ProtocolAuto {
+			add("protocol", "%s", c.protocol)
+		}
+		if c.connections != ReuseConnections {
+			add("connections", "%s", c.connections)
+		}
+	}
+	if (scheme == "s3" || scheme == "metadata") && c.maxResponseSize > 0 {
+		add("max response size", "%d bytes", c.maxResponseSize)
+	}
+	if scheme == "grpc" || scheme == "grpcs" {
+		if c.grpcService != "" {
+			add("grpc service", "%s", c.grpcService)
+		} else {
+			add("grpc service", "the whole server")
+		}
+	}
+
+	if resolvedSchemes[scheme] {
+		if c.ipVersion != 0 {
+			add("ip version", "%d", c.ipVersion)
+		}
+		if c.allAddresses {
+			add("addresses", "all must be ready")
+		}
+		if c.resolution != ResolveDefault {
+			add("resolve", "%s", c.resolution)
+		}
+		if c.proxy != nil {
+			add("proxy", "%s", c.proxy.Redacted())
+		}
+		if c.dialer != nil {
+			add("dialer", "custom")
+		}
+		if len(c.blockedNetworks) > 0 {
+			networks := make([]string, len(c.blockedNetworks))
+			for i, n := range c.blockedNetworks {
+				networks[i] = n.String()
+			}
+			add("blocked networks", "%s", strings.Join(networks, ", "))
+		}
+		if c.tlsConfig != nil && c.tlsConfig.InsecureSkipVerify {
+			add("tls verify", "off")
+		}
+		if v := c.minTLSVersion; v != 0 {
+			add("tls min version", "%s", tls.VersionName(v))
+		} else if c.tlsConfig != nil && c.tlsConfig.MinVersion != 0 {
+			add("tls min version", "%s", tls.VersionName(c.tlsConfig.MinVersion))
+		}
+		if c.minCertValidity > 0 {
+			add("min cert validity", "%s", c.minCertValidity)
+		}
+	}
+	return s
+}
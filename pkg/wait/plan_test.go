@@ -0,0 +1,44 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestPlanAttempts(t *testing.T) {
+	every10m, err := backoff.ParseCron("*/10 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The deadlines of waits are of the system clock, which the fake
+	// one does not move, so none of the plans end by their timeouts.
+	for _, test := range []struct {
+		name string
+		opts []Option
+	}{
+		{"exponential", []Option{WithInitialDelay(time.Second), WithMaxDelay(4 * time.Second), WithMaxAttempts(6)}},
+		{"start delay", []Option{WithStartDelay(5 * time.Second), WithFailureThreshold(3)}},
+		{"backoff", []Option{WithBackoff(new(recordingBackoff)), WithMaxAttempts(4)}},
+		{"schedule", []Option{WithSchedule(every10m), WithTimeout(time.Hour), WithMaxAttempts(4)}},
+	} {
+		clk := clock.NewInstant(time.Unix(0, 0))
+		opts := append(test.opts, WithClock(clk), WithLogger(slog.New(slog.DiscardHandler)))
+		var got []time.Duration
+		c := CheckerFunc(func(context.Context) error {
+			got = append(got, clk.Now().Sub(time.Unix(0, 0)))
+			return errors.New("not yet")
+		})
+		p := PlanChecker(c, opts...)
+		Wait(context.Background(), c, opts...)
+		if !slices.Equal(p.Attempts, got) || p.More {
+			t.Errorf("%s: planned attempts at %v (more: %t), made at %v", test.name, p.Attempts, p.More, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// PluginPrefix starts the names of the programs that check targets of
+// schemes this package does not know; see WithPlugins.
+const PluginPrefix = "wait-check-"
+
+// Exit statuses of plugins, other than 0 for a ready target.
+const (
+	PluginNotReady  = 1 // the check failed, and is to be retried
+	PluginPermanent = 2 // the check failed, and retrying is pointless
+)
+
+// WithPlugins makes targets of schemes this package does not know be
+// checked by running a program named PluginPrefix followed by the
+// scheme, such as wait-check-ldap for ldap://host, found in the
+// directories named by the PATH environment variable.
+//
+// Each check runs the program once, with the target in the variable
+// WAIT_TARGET and as the "target" of a JSON object on its standard
+// input, which also has the seconds left for the check as "timeout"
+// if it has a deadline, and the ID of the attempt, as by AttemptID,
+// as "attempt_id" and in WAIT_ATTEMPT_ID; the program is killed when
+// the check is aborted. The program exits with status 0 if the target is ready,
+// PluginNotReady if it is not yet, and PluginPermanent if it never
+// will be; other statuses are failures of the program, which are
+// retried too. It may write a JSON object to its standard output with
+// a "status" describing the response it got and an "error" saying why
+// the target is not ready, which is otherwise taken from its standard
+// error.
+func WithPlugins() Option {
+	return func(c *config) { c.plugins = true }
+}
+
+// A pluginRequest is what a plugin is given on its standard input.
+type pluginRequest struct {
+	Target    string  `json:"target"`
+	Timeout   float64 `json:"timeout,omitempty"` // in seconds
+	AttemptID string  `json:"attempt_id,omitempty"`
+}
+
+// A pluginResponse is what a plugin may write to its standard output.
+type pluginResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// A pluginChecker checks a target by running a plugin.
+type pluginChecker struct {
+	path   string // of the program
+	target string
+
+	responseStatus
+}
+
+// newPluginChecker returns a checker running the plugin for scheme, or
+// an error if there is none.
+func newPluginChecker(scheme, target string) (*pluginChecker, error) {
+	path, err := exec.LookPath(PluginPrefix + scheme)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported scheme %q in %s, and no plugin for it: %w", scheme, target, err)
+	}
+	return &pluginChecker{path: path, target: target}, nil
+}
+
+func (p *pluginChecker) Check(ctx context.Context) error {
+	req := pluginRequest{Target: p.target, AttemptID: AttemptID(ctx)}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Timeout = time.Until(deadline).Seconds()
+	}
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Env = append(os.Environ(), "WAIT_TARGET="+p.target, "WAIT_ATTEMPT_ID="+req.AttemptID)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+
+	var resp pluginResponse
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		if err := json.Unmarshal(out, &resp); err != nil && runErr == nil {
+			return fmt.Errorf("parsing the output of %s: %w", p.path, err)
+		}
+	}
+	p.set(0, resp.Status)
+	if runErr == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(runErr, &exitErr) {
+		return fmt.Errorf("running %s: %w", p.path, runErr)
+	}
+	msg := resp.Error
+	if out := bytes.TrimSpace(stderr.Bytes()); msg == "" && len(out) > 0 {
+		if len(out) > maxOutput {
+			out = append([]byte("..."), out[len(out)-maxOutput:]...)
+		}
+		msg = string(out)
+	}
+	err = runErr
+	if msg != "" {
+		err = errors.New(msg)
+	}
+	switch exitErr.ExitCode() {
+	case PluginNotReady:
+		return err
+	case PluginPermanent:
+		return errclass.MarkPermanent(err)
+	}
+	if msg != "" {
+		return fmt.Errorf("%s: %w: %s", p.path, runErr, msg)
+	}
+	return fmt.Errorf("%s: %w", p.path, runErr)
+}
@@ -0,0 +1,127 @@
+package wait
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// testPlugin is a plugin for the scheme "test" acting as the host of
+// its target says, which writes what it was given to the files
+// request and env in its directory.
+const testPlugin = `#!/bin/sh
+dir=$(dirname "$0")
+cat >"$dir/request"
+echo "$WAIT_TARGET $WAIT_ATTEMPT_ID" >"$dir/env"
+case "$WAIT_TARGET" in
+test://ready) echo '{"status": "up 3 days"}' ;;
+test://silent) ;;
+test://starting) echo starting >&2; exit 1 ;;
+test://reported) echo '{"status": "503", "error": "still starting"}'; echo ignored >&2; exit 1 ;;
+test://gone) echo '{"error": "no such database"}'; exit 2 ;;
+test://junk) echo junk ;;
+test://crash) echo 'panic: oops' >&2; exit 3 ;;
+test://hang) exec sleep 60 ;;
+esac
+`
+
+// installTestPlugin puts testPlugin on PATH, and returns its
+// directory.
+func installTestPlugin(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+"test"), []byte(testPlugin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestPlugin(t *testing.T) {
+	installTestPlugin(t)
+
+	if _, err := NewChecker("test://ready"); err == nil {
+		t.Error("checker made without WithPlugins")
+	}
+	if _, err := NewChecker("other://ready", WithPlugins()); err == nil || !strings.Contains(err.Error(), "no plugin") {
+		t.Errorf("checker for a scheme without plugin: %v", err)
+	}
+
+	for _, test := range []struct {
+		target    string
+		status    string
+		err       string // of the check, if any
+		permanent bool
+	}{
+		{"test://ready", "up 3 days", "", false},
+		{"test://silent", "", "", false},
+		{"test://starting", "", "starting", false},
+		{"test://reported", "503", "still starting", false},
+		{"test://gone", "", "no such database", true},
+		{"test://junk", "", "parsing the output", false},
+		{"test://crash", "", "exit status 3: panic: oops", false},
+	} {
+		c, err := NewChecker(test.target, WithPlugins())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Check(context.Background())
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got %v, want %q", test.target, err, test.err)
+		}
+		if err != nil && errclass.Retryable(err) == test.permanent {
+			t.Errorf("%s: retryable %v, want %v", test.target, errclass.Retryable(err), !test.permanent)
+		}
+		if _, status := lastStatus(c); status != test.status {
+			t.Errorf("%s: status %q, want %q", test.target, status, test.status)
+		}
+	}
+}
+
+func TestPluginRequest(t *testing.T) {
+	dir := installTestPlugin(t)
+
+	var attemptID string
+	_, err := WaitForServer(context.Background(), "test://ready", WithPlugins(), WithAttemptTimeout(time.Minute),
+		WithOnAttempt(func(a Attempt) { attemptID = a.ID }), WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "request"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req pluginRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("%v in %s", err, data)
+	}
+	if req.Target != "test://ready" || req.AttemptID != attemptID || req.Timeout <= 50 || req.Timeout > 60 {
+		t.Errorf("got the request %s, want the target, the attempt ID %s and a timeout of about 60", data, attemptID)
+	}
+	if env, _ := os.ReadFile(filepath.Join(dir, "env")); string(env) != "test://ready "+attemptID+"\n" {
+		t.Errorf("got the environment %q", env)
+	}
+
+	// An aborted check kills the plugin.
+	c, err := NewChecker("test://hang", WithPlugins())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.Check(ctx); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 10*time.Second {
+		t.Errorf("got %v after %v, want the check to time out", err, time.Since(start))
+	}
+}
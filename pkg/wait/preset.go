@@ -0,0 +1,87 @@
+package wait
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+)
+
+// A Preset is a named combination of the delays between attempts and
+// their jitter, for use with WithPreset instead of tuning each.
+type Preset int
+
+const (
+	// PresetDefault waits DefaultInitialDelay after the first
+	// failed attempt, twice as long after each following one, up
+	// to DefaultMaxDelay, without jitter. It is what waits do by
+	// default.
+	PresetDefault Preset = iota
+
+	// PresetAggressive retries quickly, for local servers that are
+	// expected to start within seconds: from 100ms up to 5s, with
+	// full jitter.
+	PresetAggressive
+
+	// PresetGentle retries slowly, for remote or shared servers
+	// that should not be loaded by waiting clients: from 5s up to
+	// 5m, with equal jitter.
+	PresetGentle
+
+	// PresetAWSDecorrelated draws each delay between 500ms and
+	// three times the previous delay, up to 30s, as recommended
+	// for many clients retrying the same server at once by
+	// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+	PresetAWSDecorrelated
+)
+
+var presetNames = []string{"default", "aggressive", "gentle", "aws-decorrelated"}
+
+// presets holds the initial and maximum delays and the jitter of each
+// Preset.
+var presets = []struct {
+	initial, max time.Duration
+	jitter       backoff.Jitter
+}{
+	PresetDefault:         {DefaultInitialDelay, DefaultMaxDelay, backoff.NoJitter},
+	PresetAggressive:      {100 * time.Millisecond, 5 * time.Second, backoff.FullJitter},
+	PresetGentle:          {5 * time.Second, 5 * time.Minute, backoff.EqualJitter},
+	PresetAWSDecorrelated: {500 * time.Millisecond, 30 * time.Second, backoff.DecorrelatedJitter},
+}
+
+func (p Preset) String() string {
+	if p < 0 || int(p) >= len(presetNames) {
+		return fmt.Sprintf("Preset(%d)", int(p))
+	}
+	return presetNames[p]
+}
+
+// MarshalText returns the name of p: default, aggressive, gentle, or
+// aws-decorrelated.
+func (p Preset) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText sets p to the Preset named by text.
+func (p *Preset) UnmarshalText(text []byte) error {
+	i := slices.Index(presetNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("unknown backoff preset %q", text)
+	}
+	*p = Preset(i)
+	return nil
+}
+
+// WithPreset sets the delays between attempts and their jitter as p
+// says, as by WithInitialDelay, WithMaxDelay, and WithJitter, which
+// may follow it to adjust them, and undoes WithBackoff.
+func WithPreset(p Preset) Option {
+	return func(c *config) {
+		if p < 0 || int(p) >= len(presets) {
+			return
+		}
+		c.initialDelay, c.maxDelay, c.jitter = presets[p].initial, presets[p].max, presets[p].jitter
+		c.backoff = nil
+	}
+}
@@ -0,0 +1,118 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// A processChecker checks whether a process is running, found by PID
+// file, name, or PID, and optionally has been for some time. It suits
+// supervisors of programs that give no other sign of being ready.
+type processChecker struct {
+	pidFile   string
+	name      string
+	pid       int
+	minUptime time.Duration
+}
+
+// newProcessChecker returns a checker for a process:///path/to/pidfile,
+// process://name, or process://?pid=N target, with an optional
+// min_uptime=D setting.
+func newProcessChecker(u *url.URL) (*processChecker, error) {
+	p := &processChecker{pidFile: u.Path, name: u.Host}
+	q := u.Query()
+	if v := q.Get("pid"); v != "" {
+		var err error
+		if p.pid, err = strconv.Atoi(v); err != nil || p.pid <= 0 {
+			return nil, fmt.Errorf("invalid pid %q", v)
+		}
+	}
+	given := 0
+	for _, ok := range []bool{p.pidFile != "", p.name != "", p.pid != 0} {
+		if ok {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, fmt.Errorf("want one of process:///path/to/pidfile, process://name, or process://?pid=N, not %s", u)
+	}
+	if v := q.Get("min_uptime"); v != "" {
+		var err error
+		if p.minUptime, err = time.ParseDuration(v); err != nil || p.minUptime < 0 {
+			return nil, fmt.Errorf("invalid min_uptime %q", v)
+		}
+	}
+	return p, nil
+}
+
+func (p *processChecker) Check(ctx context.Context) error {
+	pids, err := p.pids()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, pid := range pids {
+		err := p.checkPID(pid)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// pids returns the PIDs of the processes that p may find running.
+func (p *processChecker) pids() ([]int, error) {
+	switch {
+	case p.pidFile != "":
+		data, err := os.ReadFile(p.pidFile)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			return nil, fmt.Errorf("no PID in %s", p.pidFile)
+		}
+		return []int{pid}, nil
+	case p.name != "":
+		pids, err := findProcesses(p.name)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, errclass.MarkPermanent(fmt.Errorf("finding processes by name: %w", err))
+		}
+		if err == nil && len(pids) == 0 {
+			err = fmt.Errorf("no process named %s", p.name)
+		}
+		return pids, err
+	}
+	return []int{p.pid}, nil
+}
+
+// checkPID checks whether the process pid is running, for long
+// enough.
+func (p *processChecker) checkPID(pid int) error {
+	if err := processAlive(pid); err != nil {
+		return fmt.Errorf("process %d: %w", pid, err)
+	}
+	if p.minUptime <= 0 {
+		return nil
+	}
+	start, err := processStart(pid)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return errclass.MarkPermanent(fmt.Errorf("process %d: finding start time: %w", pid, err))
+	}
+	if err != nil {
+		return fmt.Errorf("process %d: %w", pid, err)
+	}
+	if up := time.Since(start); up < p.minUptime {
+		return fmt.Errorf("process %d running for %v, not %v yet", pid, up.Round(time.Millisecond), p.minUptime)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package wait
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the unit of the start times of /proc/PID/stat, in
+// ticks per second, which is 100 on all the architectures Linux
+// supports, whatever its own timer frequency.
+const clockTicks = 100
+
+// errNoProcess is the error of processAlive for processes that are
+// not running.
+var errNoProcess = errors.New("no such process")
+
+// procStat returns the fields of /proc/pid/stat following the name of
+// the process, from its state on.
+func procStat(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errNoProcess
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The name, in parentheses, may hold spaces and parentheses.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	return strings.Fields(string(data[i+1:])), nil
+}
+
+// processAlive returns nil if the process pid is running, and not a
+// zombie.
+func processAlive(pid int) error {
+	fields, err := procStat(pid)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 && (fields[0] == "Z" || fields[0] == "X") {
+		return errors.New("process has exited")
+	}
+	return nil
+}
+
+// processStart returns when the process pid started.
+func processStart(pid int) (time.Time, error) {
+	fields, err := procStat(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	const startField = 22 - 3 // starttime, in fields counted from the state
+	if len(fields) <= startField {
+		return time.Time{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	ticks, err := strconv.ParseUint(fields[startField], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks), nil
+}
+
+// bootTime returns when the system booted, as given by /proc/stat.
+func bootTime() (time.Time, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				break
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("no boot time in /proc/stat")
+}
+
+// findProcesses returns the PIDs of the processes named name, by the
+// name the kernel knows them by, which is cut to 15 bytes, or by that
+// of the program they run.
+func findProcesses(name string) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join("/proc", e.Name())
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			continue // exited meanwhile
+		}
+		match := strings.TrimSuffix(string(comm), "\n") == name
+		if !match {
+			cmdline, _ := os.ReadFile(filepath.Join(dir, "cmdline"))
+			arg0, _, _ := bytes.Cut(cmdline, []byte{0})
+			match = len(arg0) > 0 && filepath.Base(string(arg0)) == name
+		}
+		if match {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
@@ -0,0 +1,66 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+func TestProcessCheckerRunning(t *testing.T) {
+	path, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.Command(path, "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+	check := func(target string) error {
+		t.Helper()
+		c, err := NewChecker(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Check(context.Background())
+	}
+
+	if err := check("process://sleep"); err != nil {
+		t.Errorf("by name: %v", err)
+	}
+	if err := check("process://no-such-program-running"); err == nil || !strings.Contains(err.Error(), "no process named") {
+		t.Errorf("by another name: %v", err)
+	}
+	if err := check(fmt.Sprintf("process://?pid=%d&min_uptime=1ns", pid)); err != nil {
+		t.Errorf("with a min_uptime passed: %v", err)
+	}
+	err = check(fmt.Sprintf("process://?pid=%d&min_uptime=1h", pid))
+	if err == nil || !strings.Contains(err.Error(), "running for") || !errclass.Retryable(err) {
+		t.Errorf("with a min_uptime to come: %v", err)
+	}
+
+	// Killed but not waited for, the process is a zombie.
+	cmd.Process.Kill()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if err = check(fmt.Sprintf("process://?pid=%d", pid)); err != nil {
+			break
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("killed: %v", err)
+	}
+	cmd.Wait()
+	if err := check(fmt.Sprintf("process://?pid=%d", pid)); !errors.Is(err, errNoProcess) {
+		t.Errorf("waited for: %v", err)
+	}
+}
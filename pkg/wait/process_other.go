@@ -0,0 +1,40 @@
+//go:build !linux
+
+package wait
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// processAlive returns nil if the process pid is running.
+func processAlive(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+	if runtime.GOOS == "windows" {
+		return nil // FindProcess fails for processes that are not running
+	}
+	err = p.Signal(syscall.Signal(0))
+	if errors.Is(err, syscall.EPERM) {
+		return nil // running, as another user
+	}
+	return err
+}
+
+// processStart returns when the process pid started, which is only
+// known on Linux.
+func processStart(int) (time.Time, error) {
+	return time.Time{}, errors.ErrUnsupported
+}
+
+// findProcesses returns the PIDs of the processes named name, which
+// can only be found on Linux.
+func findProcesses(string) ([]int, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,56 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessChecker(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	self := os.Getpid()
+	for _, test := range []struct {
+		target string
+		err    string // of the check, if any
+	}{
+		{fmt.Sprintf("process://?pid=%d", self), ""},
+		{"process://" + pidFile("self.pid", fmt.Sprintf("%d\n", self)), ""},
+		{"process://" + filepath.Join(dir, "missing.pid"), "no such file"},
+		{"process://" + pidFile("empty.pid", ""), "no PID in"},
+		{"process://" + pidFile("junk.pid", "junk"), "no PID in"},
+	} {
+		c, err := NewChecker(test.target)
+		if err != nil {
+			t.Errorf("%s: %v", test.target, err)
+			continue
+		}
+		err = c.Check(context.Background())
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got %v, want %q", test.target, err, test.err)
+		}
+	}
+
+	for _, target := range []string{
+		"process://",
+		"process://?pid=0",
+		"process://?pid=x",
+		"process://name?pid=1",
+		"process://name/path",
+		"process://name?min_uptime=x",
+		"process://name?min_uptime=-1s",
+	} {
+		if _, err := NewChecker(target); err == nil {
+			t.Errorf("%s: no error", target)
+		}
+	}
+}
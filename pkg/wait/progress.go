@@ -0,0 +1,104 @@
+package wait
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Progress keeps track of the live state of waits, so that it can
+// be inspected while they go on: it is an expvar.Var, for publishing
+// with expvar.Publish, and an http.Handler serving the same JSON.
+// Its methods may be called concurrently.
+type Progress struct {
+	mu      sync.Mutex
+	targets map[string]*TargetProgress
+}
+
+// A TargetProgress describes the state of the wait for a target.
+type TargetProgress struct {
+	Target      string    `json:"target"`
+	Started     time.Time `json:"started"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+	Done        bool      `json:"done"`  // the wait is over
+	Ready       bool      `json:"ready"` // and succeeded
+}
+
+// NewProgress returns an empty Progress.
+func NewProgress() *Progress {
+	return &Progress{targets: make(map[string]*TargetProgress)}
+}
+
+// WithProgress makes the waits record their state in p.
+func WithProgress(p *Progress) Option {
+	return func(c *config) { c.progress = p }
+}
+
+// Targets returns the state of the waits, ordered by target.
+func (p *Progress) Targets() []TargetProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ts := make([]TargetProgress, 0, len(p.targets))
+	for _, t := range p.targets {
+		ts = append(ts, *t)
+	}
+	slices.SortFunc(ts, func(a, b TargetProgress) int { return strings.Compare(a.Target, b.Target) })
+	return ts
+}
+
+// String returns the state of the waits as a JSON array.
+func (p *Progress) String() string {
+	b, _ := json.Marshal(p.Targets())
+	return string(b)
+}
+
+// ServeHTTP responds with the state of the waits as a JSON array.
+func (p *Progress) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(p.String()))
+}
+
+// update applies f to the state of the wait for target.
+func (p *Progress) update(target string, f func(t *TargetProgress)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t, ok := p.targets[target]
+	if !ok {
+		t = &TargetProgress{Target: target}
+		p.targets[target] = t
+	}
+	f(t)
+}
+
+func (p *Progress) start(target string) {
+	p.update(target, func(t *TargetProgress) {
+		*t = TargetProgress{Target: target, Started: time.Now()}
+	})
+}
+
+func (p *Progress) attempt(target string, err error) {
+	p.update(target, func(t *TargetProgress) {
+		t.Attempts++
+		t.LastError = ""
+		if err != nil {
+			t.LastError = err.Error()
+		}
+		t.NextAttempt = time.Time{}
+	})
+}
+
+func (p *Progress) retry(target string, delay time.Duration) {
+	p.update(target, func(t *TargetProgress) { t.NextAttempt = time.Now().Add(delay) })
+}
+
+func (p *Progress) done(target string, err error) {
+	p.update(target, func(t *TargetProgress) {
+		t.Done, t.Ready = true, err == nil
+		t.NextAttempt = time.Time{}
+	})
+}
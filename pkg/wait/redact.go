@@ -0,0 +1,102 @@
+package wait
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces credentials in logs and errors.
+const redacted = "xxxxx"
+
+// secretParams are substrings of the names of query parameters whose
+// values are taken to be credentials, such as access_token or api_key.
+var secretParams = []string{"token", "secret", "pass", "key", "sig", "auth", "cred", "session"}
+
+// secretHeaders are the headers whose values are credentials.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// RedactURL returns rawURL with its user information, such as
+// user:password, and the values of query parameters that look like
+// credentials, such as token or api_key, replaced by "xxxxx". It is
+// how targets are named in logs, errors, and results.
+func RedactURL(rawURL string) string {
+	return newRedacter(rawURL, nil).Replace(rawURL)
+}
+
+// minSecret is the length of the shortest credentials replaced on
+// their own, rather than only in the forms they take in URLs, so that
+// short ones do not garble messages wherever their characters occur.
+const minSecret = 4
+
+// newRedacter returns a Replacer of the credentials in target and of
+// the values of the secret headers in header, also in the forms that
+// net/http gives them in its errors, and on their own, as servers may
+// echo them back in their responses.
+func newRedacter(target string, header http.Header) *strings.Replacer {
+	var oldnew, values []string
+	if u, err := url.Parse(target); err == nil {
+		if u.User != nil {
+			oldnew = append(oldnew, u.User.String()+"@", redacted+"@")
+			if password, ok := u.User.Password(); ok {
+				oldnew = append(oldnew, url.User(u.User.Username()).String()+":***@", redacted+"@")
+				// As sent by net/http in the Authorization header.
+				basic := u.User.Username() + ":" + password
+				values = append(values, base64.StdEncoding.EncodeToString([]byte(basic)), basic, password)
+			}
+		}
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name, value, ok := strings.Cut(param, "=")
+			if ok && value != "" && isSecretParam(name) {
+				oldnew = append(oldnew, param, name+"="+redacted)
+				if unescaped, err := url.QueryUnescape(value); err == nil && unescaped != value {
+					values = append(values, unescaped)
+				}
+				values = append(values, value)
+			}
+		}
+	}
+	for _, v := range values {
+		if len(v) >= minSecret {
+			oldnew = append(oldnew, v, redacted)
+		}
+	}
+	for _, key := range secretHeaders {
+		for _, value := range header.Values(key) {
+			if value != "" {
+				oldnew = append(oldnew, value, redacted)
+			}
+		}
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretParams {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// A redactedError is an error whose message has its credentials
+// replaced.
+type redactedError struct {
+	err error
+	r   *strings.Replacer
+}
+
+func (e *redactedError) Error() string { return e.r.Replace(e.err.Error()) }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError returns err with its message redacted by r, if any.
+func redactError(err error, r *strings.Replacer) error {
+	if err == nil || r == nil {
+		return err
+	}
+	return &redactedError{err, r}
+}
@@ -0,0 +1,78 @@
+package wait
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// A redisChecker checks whether a Redis server answers PING with PONG,
+// authenticating first if the target URL carries a password.
+type redisChecker struct {
+	cfg    *config
+	addr   string // host:port
+	secure bool   // use TLS
+	user   *url.Userinfo
+}
+
+func newRedisChecker(cfg *config, u *url.URL) *redisChecker {
+	return &redisChecker{
+		cfg:    cfg,
+		addr:   withDefaultPort(u.Host, "6379"),
+		secure: u.Scheme == "rediss",
+		user:   u.User,
+	}
+}
+
+func (p *redisChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dial(ctx, p.addr, p.secure)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	if password, ok := p.user.Password(); ok {
+		// Without a user name, or as the default user, AUTH takes just
+		// the password, as servers before Redis 6 require.
+		args := []string{"AUTH"}
+		if name := p.user.Username(); name != "" && name != "default" {
+			args = append(args, name)
+		}
+		if reply, err := redisCommand(conn, r, append(args, password)...); err != nil {
+			return err
+		} else if reply != "+OK" {
+			return fmt.Errorf("redis AUTH: %s", strings.TrimPrefix(reply, "-"))
+		}
+	}
+	reply, err := redisCommand(conn, r, "PING")
+	if err != nil {
+		return err
+	}
+	if reply != "+PONG" {
+		// For instance "-LOADING Redis is loading the dataset in memory".
+		return fmt.Errorf("redis PING: %s", strings.TrimPrefix(reply, "-"))
+	}
+	return nil
+}
+
+// redisCommand sends a command in the RESP protocol and returns the
+// first line of the reply.
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
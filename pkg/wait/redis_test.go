@@ -0,0 +1,98 @@
+package wait
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveRedis serves connections as a Redis server that accepts any
+// AUTH, and returns its address and a function that returns the
+// commands it was sent since it last returned.
+func serveRedis(t *testing.T) (string, func() []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	var mu sync.Mutex
+	var commands []string
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var n int
+					if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+						return
+					}
+					args := make([]string, n)
+					for i := range args {
+						var size int
+						if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+							return
+						}
+						buf := make([]byte, size+2)
+						if _, err := io.ReadFull(r, buf); err != nil {
+							return
+						}
+						args[i] = string(buf[:size])
+					}
+					mu.Lock()
+					commands = append(commands, strings.Join(args, " "))
+					mu.Unlock()
+					reply := "+OK\r\n"
+					if args[0] == "PING" {
+						reply = "+PONG\r\n"
+					}
+					conn.Write([]byte(reply))
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		c := commands
+		commands = nil
+		return c
+	}
+}
+
+func TestRedisAuth(t *testing.T) {
+	addr, sent := serveRedis(t)
+	cfg := newConfig(nil)
+	for _, test := range []struct {
+		user string // of the URL, with @
+		want []string
+	}{
+		{"", []string{"PING"}},
+		{"alice@", []string{"PING"}}, // no password, no AUTH
+		{":secret@", []string{"AUTH secret", "PING"}},
+		{"default:secret@", []string{"AUTH secret", "PING"}},
+		{"alice:secret@", []string{"AUTH alice secret", "PING"}},
+	} {
+		u, err := url.Parse("redis://" + test.user + addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := newRedisChecker(&cfg, u).Check(context.Background()); err != nil {
+			t.Errorf("%s: %v", u.Redacted(), err)
+		}
+		if got := sent(); !slices.Equal(got, test.want) {
+			t.Errorf("%s: sent %q, want %q", u.Redacted(), got, test.want)
+		}
+	}
+}
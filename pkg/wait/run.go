@@ -0,0 +1,88 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// forwardedSignals are relayed by Run to the command it runs, so that
+// the command can shut down cleanly.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// WaitAndRun waits for all of checkers concurrently, as by Wait with
+// opts, the timeout of which applies to the whole wait, and once they
+// are ready, runs cmd as by Run, so that a Go program can be the
+// entrypoint of a container that starts a service once its
+// dependencies are up. It returns the exit status of cmd, or -1 and
+// an error joining those of the checkers that failed to become ready.
+func WaitAndRun(ctx context.Context, checkers []Checker, cmd *exec.Cmd, opts ...Option) (int, error) {
+	if err := waitAll(ctx, checkers, opts); err != nil {
+		return -1, err
+	}
+	return Run(cmd)
+}
+
+func waitAll(ctx context.Context, checkers []Checker, opts []Option) error {
+	ctx, cancel, opts := shareDeadline(ctx, opts)
+	defer cancel()
+	errs := make([]error, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = Wait(ctx, c, opts...)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Run starts cmd and waits for it to exit, relaying the interrupt,
+// SIGTERM, SIGHUP, and SIGQUIT signals the program receives to it
+// meanwhile rather than letting them end the program, and returns its
+// exit status, or 128 plus the number of the signal that killed it.
+// It also returns an error if cmd cannot be run, and then, as in
+// shells, 127 if the command cannot be found and 126 if it cannot be
+// started otherwise, or 1 if waiting for it failed.
+func Run(cmd *exec.Cmd) (int, error) {
+	// Start relaying before the command starts, so that no signal
+	// sent in between ends the program instead.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, forwardedSignals...)
+	defer signal.Stop(sigs)
+
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			return 127, err
+		}
+		return 126, err
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				cmd.Process.Signal(sig) // ignore error; the command may have exited
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 1, err
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	notExecutable := filepath.Join(dir, "script")
+	if err := os.WriteFile(notExecutable, []byte("exit 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		cmd    *exec.Cmd
+		status int
+		err    bool
+	}{
+		{exec.Command("sh", "-c", "exit 0"), 0, false},
+		{exec.Command("sh", "-c", "exit 3"), 3, false},
+		{exec.Command("sh", "-c", "kill -KILL $$"), 128 + int(syscall.SIGKILL), false},
+		{exec.Command(filepath.Join(dir, "missing")), 127, true},
+		{exec.Command("no-such-command-on-path"), 127, true},
+		{exec.Command(notExecutable), 126, true},
+	} {
+		status, err := Run(test.cmd)
+		if status != test.status || (err != nil) != test.err {
+			t.Errorf("%s: exit status %d, %v, want %d", test.cmd, status, err, test.status)
+		}
+	}
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+	cmd := exec.Command("sh", "-c", `trap 'exit 42' TERM; touch "$0"; while :; do sleep 0.01; done`, ready)
+	go func() {
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if _, err := os.Stat(ready); err == nil {
+				break
+			}
+		}
+		// To the test, which Run keeps from being terminated.
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+	if status, err := Run(cmd); status != 42 || err != nil {
+		t.Errorf("exit status %d, %v, want the command to trap SIGTERM", status, err)
+	}
+}
+
+func TestWaitAndRun(t *testing.T) {
+	ran := filepath.Join(t.TempDir(), "ran")
+	ready, down := &namedChecker{name: "db"}, &namedChecker{name: "cache", err: errors.New("connection refused")}
+	opts := []Option{WithMaxAttempts(2), WithInitialDelay(time.Millisecond), WithLogger(slog.New(slog.DiscardHandler))}
+
+	status, err := WaitAndRun(context.Background(), []Checker{ready, down}, exec.Command("touch", ran), opts...)
+	if status != -1 || !errors.Is(err, ErrMaxAttempts) {
+		t.Errorf("with cache down: exit status %d, %v, want -1 and the error of cache", status, err)
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("the command ran before cache was ready")
+	}
+	if ready.checks.Load() != 1 || down.checks.Load() != 2 {
+		t.Errorf("checked db %d times and cache %d, want 1 and 2", ready.checks.Load(), down.checks.Load())
+	}
+
+	down.err = nil
+	status, err = WaitAndRun(context.Background(), []Checker{ready, down}, exec.Command("sh", "-c", `touch "$0"; exit 7`, ran), opts...)
+	if status != 7 || err != nil {
+		t.Errorf("exit status %d, %v, want that of the command", status, err)
+	}
+	if _, err := os.Stat(ran); err != nil {
+		t.Errorf("the command did not run: %v", err)
+	}
+}
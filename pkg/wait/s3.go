@@ -0,0 +1,199 @@
+package wait
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// An s3Checker checks whether an object exists in an S3-compatible
+// object store, by sending a HEAD request for it, signed with AWS
+// Signature Version 4 if there are credentials.
+type s3Checker struct {
+	cfg    *config
+	bucket string
+	key    string
+	region string
+	url    string // of the object
+
+	accessKey, secretKey, sessionToken string
+
+	responseStatus
+}
+
+// newS3Checker returns a checker for an s3://bucket/key target, with
+// optional region=R and endpoint=URL settings, and credentials given
+// as s3://access-key:secret-key@bucket/key or, by default, by the
+// variables AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and
+// AWS_SESSION_TOKEN. The region defaults to AWS_REGION or
+// AWS_DEFAULT_REGION, or else us-east-1, and the endpoint to
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, or else that of AWS.
+func newS3Checker(cfg *config, u *url.URL) (*s3Checker, error) {
+	p := &s3Checker{cfg: cfg, bucket: u.Host, key: strings.TrimPrefix(u.Path, "/")}
+	if p.bucket == "" || p.key == "" {
+		return nil, fmt.Errorf("want s3://bucket/key, not %s", u.Redacted())
+	}
+	q := u.Query()
+	p.region = firstNonEmpty(q.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	if u.User != nil {
+		p.accessKey = u.User.Username()
+		p.secretKey, _ = u.User.Password()
+	} else {
+		p.accessKey, p.secretKey = os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+		p.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	key := s3Escape(p.key, false)
+	if endpoint := firstNonEmpty(q.Get("endpoint"), os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		e, err := url.Parse(endpoint)
+		if err != nil || e.Scheme != "http" && e.Scheme != "https" || e.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+		}
+		// Other stores, such as MinIO, address buckets by path.
+		p.url = strings.TrimSuffix(e.String(), "/") + "/" + s3Escape(p.bucket, true) + "/" + key
+	} else if strings.Contains(p.bucket, ".") {
+		// The certificates of AWS don't cover such bucket hosts.
+		p.url = fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", p.region, p.bucket, key)
+	} else {
+		p.url = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", p.bucket, p.region, key)
+	}
+	return p, nil
+}
+
+func (p *s3Checker) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, p.url, nil)
+	if err != nil {
+		return err
+	}
+	if p.accessKey != "" {
+		signV4(req, p.accessKey, p.secretKey, p.sessionToken, p.region, "s3", time.Now())
+	}
+	resp, _, err := fetch(p.cfg.client, req, 0, p.cfg.maxResponseSize)
+	if err != nil {
+		return err
+	}
+	p.set(resp.StatusCode, resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusForbidden:
+		// Not there yet; without the permission to list the bucket,
+		// missing objects are forbidden rather than not found.
+		return errclass.MarkRetryable(fmt.Errorf("s3://%s/%s: %w", p.bucket, p.key, CheckResponse(resp)))
+	}
+	if err := CheckResponse(resp); err != nil {
+		return err
+	}
+	return fmt.Errorf("s3://%s/%s: unexpected status %s", p.bucket, p.key, resp.Status)
+}
+
+// emptySHA256 is the SHA-256 hash of no bytes, that of the payload of
+// requests without a body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signV4 signs req, which has no body, with AWS Signature Version 4,
+// at time t, for service in region, as described in
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html.
+func signV4(req *http.Request, accessKey, secretKey, sessionToken, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	canonical, signed := canonicalRequest(req)
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	toSign := stringToSign(amzDate, scope, canonical)
+	key := []byte("AWS4" + secretKey)
+	for _, s := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// canonicalRequest returns the canonical form of req, which has no
+// body, that signV4 signs, and the names of the headers it signs.
+func canonicalRequest(req *http.Request) (canonical, signed string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n%s\n", req.Method, req.URL.EscapedPath(), canonicalQuery(req.URL.Query()))
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signed = strings.Join(names, ";")
+	fmt.Fprintf(&b, "\n%s\n%s", signed, emptySHA256)
+	return b.String(), signed
+}
+
+// stringToSign returns the string that signV4 signs for the canonical
+// request made at amzDate in scope.
+func stringToSign(amzDate, scope, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	return "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the parameters of q sorted and escaped, as
+// signed by signV4.
+func canonicalQuery(q url.Values) string {
+	var params []string
+	for name, values := range q {
+		for _, v := range values {
+			params = append(params, s3Escape(name, true)+"="+s3Escape(v, true))
+		}
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes the bytes of s other than letters, digits,
+// '-', '.', '_', and '~', and the slashes unless escapeSlash is set,
+// as AWS Signature Version 4 requires, so that the paths of requests
+// are sent as they are signed.
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', strings.IndexByte("-._~", c) >= 0,
+			c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// firstNonEmpty returns the first of ss that is not empty, or "".
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package wait
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A SchemeFactory returns the Checker for a target URL of the scheme
+// it is registered for with RegisterScheme.
+type SchemeFactory func(target *url.URL) (Checker, error)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]SchemeFactory{}
+)
+
+// RegisterScheme makes targets of scheme, such as "myproto" for
+// myproto://host, be checked by the Checkers that factory returns,
+// in WaitForServer, NewChecker, and everything else that takes target
+// URLs, including the wait program built with it. Schemes of this
+// package can be registered too, to replace how their targets are
+// checked. Schemes are case-insensitive, and typically registered in
+// init functions. RegisterScheme panics if scheme is already
+// registered, or if factory is nil.
+func RegisterScheme(scheme string, factory SchemeFactory) {
+	if factory == nil {
+		panic("wait: RegisterScheme factory is nil")
+	}
+	scheme = strings.ToLower(scheme) // as url.Parse makes schemes
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	if _, dup := schemes[scheme]; dup {
+		panic("wait: RegisterScheme called twice for scheme " + scheme)
+	}
+	schemes[scheme] = factory
+}
+
+// builtinSchemes are the schemes of the targets this package checks,
+// as newSchemeChecker does.
+var builtinSchemes = []string{
+	"amqp", "amqps", "dns", "es", "ess", "file", "grpc", "grpcs", "http", "https",
+	"kafka", "kafkas", "metadata", "ntp", "ping", "process", "redis", "rediss",
+	"s3", "ssh", "tcp", "tls", "unix", "winsvc",
+}
+
+// Schemes returns the schemes of the targets that can be waited for,
+// those of this package and those registered with RegisterScheme,
+// sorted, such as to complete target URLs with.
+func Schemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	all := append(slices.Clone(builtinSchemes), slices.Collect(maps.Keys(schemes))...)
+	slices.Sort(all)
+	return slices.Compact(all)
+}
+
+// registeredScheme returns the factory registered for scheme, if any.
+func registeredScheme(scheme string) (SchemeFactory, bool) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	f, ok := schemes[scheme]
+	return f, ok
+}
@@ -0,0 +1,58 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// registeredChecks are the hosts of the targets the Checkers of the
+// scheme test-registered checked.
+var registeredChecks []string
+
+func init() {
+	RegisterScheme("Test-Registered", func(u *url.URL) (Checker, error) {
+		if u.Host == "" {
+			return nil, errors.New("missing host")
+		}
+		return CheckerFunc(func(context.Context) error {
+			registeredChecks = append(registeredChecks, u.Host)
+			return nil
+		}), nil
+	})
+}
+
+func TestRegisterScheme(t *testing.T) {
+	registeredChecks = nil
+	r, err := WaitForServer(context.Background(), "TEST-REGISTERED://db", WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil || r.Target != "TEST-REGISTERED://db" || !slices.Equal(registeredChecks, []string{"db"}) {
+		t.Errorf("got %+v, %v, with the hosts %q checked", r, err, registeredChecks)
+	}
+	var te *TargetError
+	if _, err := NewChecker("test-registered:///path"); !errors.As(err, &te) || te.Err.Error() != "missing host" {
+		t.Errorf("got %v, want the error of the factory", err)
+	}
+	if !slices.Contains(Schemes(), "test-registered") || !slices.Contains(Schemes(), "http") || !slices.IsSorted(Schemes()) {
+		t.Errorf("Schemes() = %q", Schemes())
+	}
+
+	for name, register := range map[string]func(){
+		"twice":  func() { RegisterScheme("test-REGISTERED", func(*url.URL) (Checker, error) { return nil, nil }) },
+		"no nil": func() { RegisterScheme("test-nil", nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+	if _, ok := registeredScheme("test-nil"); ok {
+		t.Error("nil factory registered")
+	}
+}
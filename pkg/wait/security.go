@@ -0,0 +1,166 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// WithMaxResponseSize makes HTTP checks, of http, https, es, s3, and
+// metadata targets, fail if the header or the body of a response is
+// larger than n bytes, so that targets cannot make the waits read any
+// more than that. Zero, the default, means no limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *config) { c.maxResponseSize = max(n, 0) }
+}
+
+// WithSameHostRedirects makes HTTP checks fail on redirects to hosts
+// other than that of the target, rather than following them, so that
+// a target cannot send the requests, with their headers and
+// credentials, elsewhere. Redirects to other ports and schemes of the
+// same host, as from http to https, are still followed.
+func WithSameHostRedirects() Option {
+	return func(c *config) { c.sameHostRedirects = true }
+}
+
+// MetadataNetworks are the networks where the metadata services of
+// cloud instances answer, which hand out the credentials of the
+// instances: the link-local networks of IPv4 and IPv6, where the
+// services of AWS, GCP, and Azure are, the IPv6 address of that of
+// AWS, and the address of that of Alibaba Cloud.
+var MetadataNetworks = []netip.Prefix{
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fd00:ec2::254/128"),
+	netip.MustParsePrefix("100.100.100.200/32"),
+}
+
+// WithBlockedNetworks makes checks fail, without retrying, on
+// connecting to addresses of networks, such as MetadataNetworks or
+// those of a private network, so that targets that are not fully
+// trusted, such as those of the configuration files of the users of
+// a CI service, cannot probe the instance the waits run on or its
+// neighbors. Calls add to the networks blocked by earlier ones.
+//
+// The addresses blocked are those actually connected to, once host
+// names are resolved, so that host names cannot resolve to other
+// addresses after being checked. With WithDialer, they are those that
+// the host names of targets resolve to with the resolver of
+// WithResolver, before the Dialer connects to them; with WithProxy,
+// that of the proxy, which connects to the targets itself; and for
+// ping:// targets, those pinged. The connections of the client of
+// WithClient, of SQL drivers, and of the programs of WithPlugins are
+// not blocked.
+func WithBlockedNetworks(networks ...netip.Prefix) Option {
+	return func(c *config) { c.blockedNetworks = append(c.blockedNetworks, networks...) }
+}
+
+// WithMinTLSVersion makes TLS connections, those of https targets and
+// all others but of the client of WithClient, fail with servers that
+// do not speak at least version v of TLS, such as tls.VersionTLS12,
+// whatever the MinVersion of the configuration of WithTLSConfig.
+func WithMinTLSVersion(v uint16) Option {
+	return func(c *config) { c.minTLSVersion = v }
+}
+
+// A BlockedAddrError is the error of a check that would have connected
+// to an address of the networks blocked by WithBlockedNetworks.
+type BlockedAddrError struct {
+	Addr    netip.Addr
+	Network netip.Prefix // blocked, and containing Addr
+}
+
+func (e *BlockedAddrError) Error() string {
+	return fmt.Sprintf("address %s is blocked, being in %s", e.Addr, e.Network)
+}
+
+// checkAddr returns a *BlockedAddrError, marked as permanent, if ip is
+// of a network blocked by WithBlockedNetworks.
+func (c *config) checkAddr(ip netip.Addr) error {
+	ip = ip.Unmap()
+	for _, n := range c.blockedNetworks {
+		if n.Contains(ip) {
+			return errclass.MarkPermanent(&BlockedAddrError{Addr: ip, Network: n})
+		}
+	}
+	return nil
+}
+
+// checkHost returns a *BlockedAddrError if the host of the network
+// address addr, or any address it resolves to, is blocked.
+func (c *config) checkHost(ctx context.Context, network, addr string) error {
+	if len(c.blockedNetworks) == 0 || network == "unix" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return c.checkAddr(ip)
+	}
+	ips, err := c.resolver.LookupNetIP(ctx, c.network("ip"), host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err := c.checkAddr(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// control is the Control function of the net.Dialers of checks,
+// refusing to connect to blocked addresses, or nil if none are.
+func (c *config) control() func(network, address string, _ syscall.RawConn) error {
+	if len(c.blockedNetworks) == 0 {
+		return nil
+	}
+	return func(network, address string, _ syscall.RawConn) error {
+		if strings.HasPrefix(network, "unix") {
+			return nil
+		}
+		addr, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		return c.checkAddr(addr.Addr())
+	}
+}
+
+// checkRedirect returns the CheckRedirect function of the HTTP client
+// of c, or nil for the default of http.Client.
+func (c *config) checkRedirect() func(*http.Request, []*http.Request) error {
+	var check func(*http.Request, []*http.Request) error
+	if c.maxRedirects != DefaultMaxRedirects {
+		check = limitRedirects(c.maxRedirects)
+	}
+	if !c.sameHostRedirects {
+		return check
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if from, to := via[0].URL.Hostname(), req.URL.Hostname(); !strings.EqualFold(from, to) {
+			return fmt.Errorf("redirect from %s to another host, %s", from, to)
+		}
+		if check != nil {
+			return check(req, via)
+		}
+		if len(via) >= DefaultMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", DefaultMaxRedirects) // as by http.Client
+		}
+		return nil
+	}
+}
+
+// errResponseTooLarge is the error of responses larger than
+// WithMaxResponseSize allows.
+func errResponseTooLarge(max int64) error {
+	return fmt.Errorf("response is larger than %d bytes", max)
+}
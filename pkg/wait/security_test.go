@@ -0,0 +1,180 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// quiet are the options of the waits of the tests of this file, which
+// make a single attempt.
+var quiet = []Option{WithMaxAttempts(1), WithLogger(slog.New(slog.DiscardHandler))}
+
+// serveDNS serves DNS over UDP, answering A queries for any name with
+// addr and others with no records, and returns a resolver using it.
+func serveDNS(t *testing.T, addr netip.Addr) *net.Resolver {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			if q.Type == dnsmessage.TypeA {
+				b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: q.Class, TTL: 60}, dnsmessage.AResource{A: addr.As4()})
+			}
+			if resp, err := b.Finish(); err == nil {
+				pc.WriteTo(resp, from)
+			}
+		}
+	}()
+	return &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", pc.LocalAddr().String())
+	}}
+}
+
+func TestBlockedRedirects(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/metadata":
+			http.Redirect(w, req, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, req, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)+"/ok", http.StatusFound)
+		case "/here":
+			http.Redirect(w, req, "/ok", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	for _, test := range []struct {
+		path string
+		opt  Option
+		err  string // in the error, or "" for none
+	}{
+		{"/metadata", WithBlockedNetworks(MetadataNetworks...), "address 169.254.169.254 is blocked, being in 169.254.0.0/16"},
+		{"/elsewhere", WithSameHostRedirects(), "redirect from 127.0.0.1 to another host, localhost"},
+		{"/here", WithSameHostRedirects(), ""},
+	} {
+		_, err := WaitForServer(context.Background(), srv.URL+test.path, append(quiet, test.opt)...)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.path, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got error %v, want %s", test.path, err, test.err)
+		}
+	}
+}
+
+func TestBlockedResolvedAddr(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	resolver := serveDNS(t, netip.MustParseAddr("127.0.0.1"))
+
+	// The name is not blocked, but the address it resolves to is, and
+	// the connection is refused once net.Dialer has resolved it.
+	_, err = WaitForServer(context.Background(), "tcp://inside.test:"+port,
+		append(quiet, WithResolver(resolver), WithBlockedNetworks(netip.MustParsePrefix("127.0.0.0/8")))...)
+	var blocked *BlockedAddrError
+	if !errors.As(err, &blocked) || blocked.Addr != netip.MustParseAddr("127.0.0.1") {
+		t.Fatalf("got %v, want a BlockedAddrError for 127.0.0.1", err)
+	}
+	if !errors.Is(err, ErrNonRetryable) {
+		t.Errorf("got %v, want it not retried", err)
+	}
+	if _, err := WaitForServer(context.Background(), "tcp://inside.test:"+port, append(quiet, WithResolver(resolver))...); err != nil {
+		t.Errorf("without blocked networks: %v", err)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	body := strings.Repeat("x", 10000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/chunked" {
+			w.Write([]byte(body[:5000]))
+			w.(http.Flusher).Flush() // before the length is known
+			w.Write([]byte(body[5000:]))
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/sized", "/chunked"} {
+		for _, test := range []struct {
+			max int64
+			err string
+		}{
+			{9999, "response is larger than 9999 bytes"},
+			{10000, ""},
+		} {
+			_, err := WaitForServer(context.Background(), srv.URL+path,
+				append(quiet, WithMethod(http.MethodGet), WithMaxResponseSize(test.max))...)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("%s of at most %d bytes: %v", path, test.max, err)
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Errorf("%s of at most %d bytes: got error %v, want %s", path, test.max, err, test.err)
+			}
+		}
+	}
+}
+
+func TestMinTLSVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // of the refused handshake
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	conf := &tls.Config{RootCAs: roots}
+
+	if _, err := WaitForServer(context.Background(), srv.URL, append(quiet, WithTLSConfig(conf), WithMinTLSVersion(tls.VersionTLS12))...); err != nil {
+		t.Errorf("TLS 1.2 server with TLS 1.2 allowed: %v", err)
+	}
+	_, err := WaitForServer(context.Background(), srv.URL, append(quiet, WithTLSConfig(conf), WithMinTLSVersion(tls.VersionTLS13))...)
+	if err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("TLS 1.2 server with TLS 1.3 required: got error %v, want a protocol version alert", err)
+	}
+	if conf.MinVersion != 0 {
+		t.Errorf("the configuration of WithTLSConfig was changed to a MinVersion of %#x", conf.MinVersion)
+	}
+}
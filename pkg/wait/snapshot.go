@@ -0,0 +1,102 @@
+package wait
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxSnapshot is how much of the body of a response is kept in its
+// ResponseSnapshot.
+const maxSnapshot = 512
+
+// snapshotHeaders are the headers of responses kept in their
+// ResponseSnapshots, besides those required by WithExpectHeader:
+// those telling what answered and why, and none that may hold
+// secrets, such as Set-Cookie.
+var snapshotHeaders = []string{"Content-Type", "Content-Length", "Location", "Retry-After", "Server", "Via", "X-Request-Id"}
+
+// A ResponseSnapshot describes the last response to the checks of an
+// HTTP server that was not found ready. The error of such a wait has
+// it as its "response" field, as given by errfields.Get, to find out
+// afterwards what the server responded, say in a failed CI job.
+type ResponseSnapshot struct {
+	Status    string      `json:"status"` // the status line, such as "HTTP/1.1 503 Service Unavailable"
+	Header    http.Header `json:"header"` // selected headers
+	Body      string      `json:"body"`   // the start of the body, if any was read
+	Truncated bool        `json:"truncated"`
+}
+
+// redacted returns a copy of s with the credentials replaced by r, as
+// they may come back in a Location or the body.
+func (s *ResponseSnapshot) redacted(r *strings.Replacer) *ResponseSnapshot {
+	c := *s
+	c.Header = make(http.Header, len(s.Header))
+	for key, values := range s.Header {
+		for _, v := range values {
+			c.Header[key] = append(c.Header[key], r.Replace(v))
+		}
+	}
+	c.Body = r.Replace(s.Body)
+	return &c
+}
+
+// newSnapshot returns a snapshot of resp, whose body starts with body,
+// keeping the headers in keys as well as the usual ones.
+func newSnapshot(resp *http.Response, body []byte, keys []string) *ResponseSnapshot {
+	s := &ResponseSnapshot{Status: resp.Proto + " " + resp.Status, Header: make(http.Header)}
+	for _, key := range slices.Concat(snapshotHeaders, keys) {
+		if values := resp.Header.Values(key); len(values) > 0 {
+			s.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	if len(body) > maxSnapshot {
+		body, s.Truncated = body[:maxSnapshot], true
+	}
+	s.Body = string(body)
+	return s
+}
+
+// String returns s formatted like an HTTP response.
+func (s *ResponseSnapshot) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", s.Status)
+	s.Header.Write(&b)
+	if s.Body != "" {
+		fmt.Fprintf(&b, "\n%s", s.Body)
+		if s.Truncated {
+			b.WriteString("...")
+		}
+	}
+	return strings.ReplaceAll(b.String(), "\r\n", "\n")
+}
+
+// LogValue returns a group of the status, headers, and body of s.
+func (s *ResponseSnapshot) LogValue() slog.Value {
+	header := make([]any, 0, len(s.Header))
+	for key := range s.Header {
+		header = append(header, slog.String(key, s.Header.Get(key)))
+	}
+	return slog.GroupValue(
+		slog.String("status", s.Status),
+		slog.Group("header", header...),
+		slog.String("body", s.Body),
+		slog.Bool("truncated", s.Truncated))
+}
+
+// A snapshotter is a Checker that keeps a snapshot of the last
+// response it got.
+type snapshotter interface {
+	lastResponse() *ResponseSnapshot
+}
+
+// lastResponse returns the snapshot of the last response c got, if c
+// keeps one, or nil.
+func lastResponse(c Checker) *ResponseSnapshot {
+	if s, ok := c.(snapshotter); ok {
+		return s.lastResponse()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package wait
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// WaitForSQL waits for the database identified by driverName and
+// dsn, as accepted by sql.Open, to answer pings. The driver must have
+// been registered, usually by importing its package.
+func WaitForSQL(ctx context.Context, driverName, dsn string, opts ...Option) (Result, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return Result{Target: dbName(driverName), Err: err}, err
+	}
+	defer db.Close()
+	return Wait(ctx, dbChecker(db, driverName), opts...)
+}
+
+// WaitForDB waits for db to answer pings, for example before running
+// migrations against a database that is still starting up.
+func WaitForDB(ctx context.Context, db *sql.DB, opts ...Option) (Result, error) {
+	return Wait(ctx, DBChecker(db), opts...)
+}
+
+// DBChecker returns a Checker that pings db. It names db by the name
+// its driver is registered under, as WaitForSQL does.
+func DBChecker(db *sql.DB) Checker {
+	return dbChecker(db, driverName(db.Driver()))
+}
+
+// dbChecker returns a DBChecker for db, opened with the driver of
+// driverName.
+func dbChecker(db *sql.DB, driverName string) Checker {
+	return named{&sqlChecker{db}, dbName(driverName), nil}
+}
+
+// dbName returns the name of the databases of the driver of
+// driverName in logs, errors, events, and metrics, such as "postgres
+// database". The data source name may hold credentials, so only the
+// driver names the database.
+func dbName(driverName string) string {
+	return driverName + " database"
+}
+
+// driverName returns the name that d is registered under with
+// database/sql, or, for drivers that cannot be opened without a data
+// source name, the type of d.
+func driverName(d driver.Driver) string {
+	t := reflect.TypeOf(d)
+	for _, name := range sql.Drivers() {
+		db, err := sql.Open(name, "") // which does not connect
+		if err != nil {
+			continue
+		}
+		same := reflect.TypeOf(db.Driver()) == t
+		db.Close()
+		if same {
+			return name
+		}
+	}
+	return fmt.Sprintf("%T", d)
+}
+
+// A sqlChecker checks whether a database answers pings.
+type sqlChecker struct {
+	db *sql.DB
+}
+
+func (p *sqlChecker) Check(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
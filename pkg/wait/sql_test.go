@@ -0,0 +1,44 @@
+package wait
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// A fakeDriver opens connections to databases that answer pings.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (fakeConn) Ping(context.Context) error          { return nil }
+
+func init() {
+	sql.Register("waitfake", fakeDriver{})
+}
+
+func TestSQLNames(t *testing.T) {
+	r, err := WaitForSQL(context.Background(), "waitfake", "", WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("waitfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if name := checkerName(DBChecker(db)); r.Target != "waitfake database" || name != r.Target {
+		t.Errorf("WaitForSQL waited for %q, and DBChecker is of %q; want both of waitfake database", r.Target, name)
+	}
+	if r, _ := WaitForSQL(context.Background(), "nosuchdriver", ""); r.Target != "nosuchdriver database" {
+		t.Errorf("WaitForSQL with an unknown driver waited for %q, want nosuchdriver database", r.Target)
+	}
+}
@@ -0,0 +1,122 @@
+package wait
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// An sshChecker checks whether an SSH server sends its banner, or, if
+// it has a key, whether the server lets the user in with it.
+type sshChecker struct {
+	cfg  *config
+	addr string // host:port
+	user string
+	auth []ssh.AuthMethod    // if a key is given
+	host ssh.HostKeyCallback // for handshakes
+
+	responseStatus
+}
+
+// newSSHChecker returns a checker for an ssh://[user@]host[:port]
+// target, with optional key=path and known_hosts=path settings; the
+// user defaults to the current one. Host keys are only verified if
+// known_hosts is given.
+func newSSHChecker(cfg *config, u *url.URL) (*sshChecker, error) {
+	q := u.Query()
+	p := &sshChecker{cfg: cfg, addr: withDefaultPort(u.Host, "22"), host: ssh.InsecureIgnoreHostKey()}
+	if path := q.Get("key"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SSH key %s: %w", path, err)
+		}
+		p.auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	}
+	if path := q.Get("known_hosts"); path != "" {
+		callback, err := knownhosts.New(path)
+		if err != nil {
+			return nil, err
+		}
+		p.host = callback
+	}
+	if u.User != nil {
+		p.user = u.User.Username()
+	} else if current, err := user.Current(); err == nil {
+		p.user = current.Username
+	}
+	return p, nil
+}
+
+func (p *sshChecker) Check(ctx context.Context) error {
+	conn, err := p.cfg.dial(ctx, p.addr, false)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if p.auth == nil {
+		banner, err := sshBanner(bufio.NewReader(conn))
+		if err != nil {
+			return err
+		}
+		p.set(0, banner)
+		return nil
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, p.addr, &ssh.ClientConfig{
+		User:            p.user,
+		Auth:            p.auth,
+		HostKeyCallback: p.host,
+	})
+	if err != nil {
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			// known_hosts has another key for the host, or none,
+			// which retrying does not change; the authorized keys
+			// of the user are often installed late, so failures
+			// to authenticate are retried.
+			return errclass.MarkPermanent(fmt.Errorf("ssh handshake: %w", err))
+		}
+		return fmt.Errorf("ssh handshake: %w", err)
+	}
+	defer c.Close()
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		for ch := range chans {
+			ch.Reject(ssh.Prohibited, "")
+		}
+	}()
+	p.set(0, string(c.ServerVersion()))
+	return nil
+}
+
+// sshBanner reads the identification string an SSH server starts
+// with, after any other lines, as described in RFC 4253.
+func sshBanner(r *bufio.Reader) (string, error) {
+	for range 20 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("reading SSH banner: %w", err)
+		}
+		if line = strings.TrimRight(line, "\r\n"); strings.HasPrefix(line, "SSH-") {
+			if !strings.HasPrefix(line, "SSH-2.0-") && !strings.HasPrefix(line, "SSH-1.99-") {
+				return "", fmt.Errorf("unsupported SSH version in %q", line)
+			}
+			return line, nil
+		}
+	}
+	return "", errors.New("no SSH banner")
+}
@@ -0,0 +1,181 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// serveLines returns the address of a server that writes s to each
+// connection and closes it.
+func serveLines(t *testing.T, s string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(s))
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestSSHBanner(t *testing.T) {
+	for _, test := range []struct {
+		sent   string
+		banner string // the status
+		err    string // of the check, if any
+	}{
+		{"SSH-2.0-OpenSSH_9.6\r\n", "SSH-2.0-OpenSSH_9.6", ""},
+		{"SSH-1.99-old\r\n", "SSH-1.99-old", ""},
+		{"Welcome\r\nto the server\r\nSSH-2.0-OpenSSH_9.6\r\n", "SSH-2.0-OpenSSH_9.6", ""},
+		{"SSH-1.5-older\r\n", "", "unsupported SSH version"},
+		{"HTTP/1.1 400 Bad Request\r\n", "", "reading SSH banner"},
+		{strings.Repeat("junk\r\n", 30), "", "no SSH banner"},
+		{"", "", "reading SSH banner"},
+	} {
+		c, err := NewChecker("ssh://" + serveLines(t, test.sent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Check(context.Background())
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%q: got %v, want %q", test.sent, err, test.err)
+		}
+		if _, status := lastStatus(c); status != test.banner {
+			t.Errorf("%q: status %q, want %q", test.sent, status, test.banner)
+		}
+	}
+}
+
+// newSSHKey returns a new key, and the path of a file holding it in
+// dir.
+func newSSHKey(t *testing.T, dir, name string) (ssh.Signer, string) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return signer, path
+}
+
+// serveSSH serves connections as an SSH server with hostKey, letting
+// in user with the key authorized, and returns its address.
+func serveSSH(t *testing.T, hostKey ssh.Signer, user string, authorized ssh.PublicKey) string {
+	conf := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() != user || !bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, os.ErrPermission
+			}
+			return nil, nil
+		},
+	}
+	conf.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := ssh.NewServerConn(conn, conf)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for range chans {
+				}
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestSSHKey(t *testing.T) {
+	dir := t.TempDir()
+	hostKey, _ := newSSHKey(t, dir, "host")
+	otherHostKey, _ := newSSHKey(t, dir, "other_host")
+	userKey, keyPath := newSSHKey(t, dir, "id_ed25519")
+	_, otherKeyPath := newSSHKey(t, dir, "id_other")
+	addr := serveSSH(t, hostKey, "deploy", userKey.PublicKey())
+
+	knownHosts := func(name string, key ssh.PublicKey) string {
+		path := filepath.Join(dir, name)
+		line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key) + "\n"
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	known := knownHosts("known_hosts", hostKey.PublicKey())
+	otherKnown := knownHosts("other_known_hosts", otherHostKey.PublicKey())
+
+	for _, test := range []struct {
+		target    string
+		err       string // of the check, if any
+		permanent bool
+	}{
+		{"ssh://deploy@" + addr + "?key=" + keyPath, "", false},
+		{"ssh://deploy@" + addr + "?key=" + keyPath + "&known_hosts=" + known, "", false},
+		{"ssh://deploy@" + addr + "?key=" + otherKeyPath, "unable to authenticate", false},
+		{"ssh://root@" + addr + "?key=" + keyPath, "unable to authenticate", false},
+		{"ssh://deploy@" + addr + "?key=" + keyPath + "&known_hosts=" + otherKnown, "key mismatch", true},
+	} {
+		c, err := NewChecker(test.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Check(context.Background())
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got %v, want %q", test.target, err, test.err)
+		}
+		if err != nil && errclass.Retryable(err) == test.permanent {
+			t.Errorf("%s: retryable %v, want %v", test.target, errclass.Retryable(err), !test.permanent)
+		}
+	}
+
+	for _, target := range []string{
+		"ssh://" + addr + "?key=" + filepath.Join(dir, "missing"),
+		"ssh://" + addr + "?key=" + known,
+		"ssh://" + addr + "?known_hosts=" + filepath.Join(dir, "missing"),
+	} {
+		if _, err := NewChecker(target); err == nil {
+			t.Errorf("%s: no error", target)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package wait
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/backoff"
+	"github.com/go-monk/error-handling/pkg/circuitbreaker"
+)
+
+// A TargetState is what a StateFile keeps of a target between runs of
+// a program.
+type TargetState struct {
+	// Attempts is the number of attempts made by the waits for the
+	// target that have not succeeded yet, which the next one goes
+	// on from.
+	Attempts int `json:"attempts,omitempty"`
+
+	// The outcome of the last check by Watch, and of how many.
+	Checks   int       `json:"checks,omitempty"`
+	Up       *bool     `json:"up,omitempty"`
+	Since    time.Time `json:"since,omitzero"` // when Up last changed
+	Flapping bool      `json:"flapping,omitempty"`
+	Flaps    []bool    `json:"flaps,omitempty"` // the results of the last checks, oldest first
+
+	// What the last attempt or check got.
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Breaker is the state of a circuit breaker guarding the
+	// target, for programs that use one to save it with the rest.
+	Breaker *circuitbreaker.Snapshot `json:"breaker,omitempty"`
+
+	Updated time.Time `json:"updated"`
+}
+
+// A StateFile keeps the TargetStates of targets in a JSON file, so
+// that the waits and watches given it by WithState go on where those
+// of an earlier run of the program left off, rather than starting
+// over with short delays and no flapping history. The file is read
+// when the StateFile is first used, and written whenever a state is
+// put; a missing file holds no states, and a corrupt one is reported
+// once and then replaced, as if it held none. The zero StateFile is
+// not usable; its methods may be called concurrently.
+type StateFile struct {
+	Path string
+
+	mu     sync.Mutex
+	states map[string]TargetState // once loaded
+}
+
+// Get returns the state of target, which is the zero TargetState if
+// the file holds none.
+func (f *StateFile) Get(target string) (TargetState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(); err != nil {
+		return TargetState{}, err
+	}
+	return f.states[target], nil
+}
+
+// Put sets the state of target and writes the file.
+func (f *StateFile) Put(target string, s TargetState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(); err != nil {
+		return err
+	}
+	f.states[target] = s
+	b, err := json.MarshalIndent(f.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Written next to the file and renamed over it, so that it is
+	// never left half written.
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(b, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+func (f *StateFile) load() error {
+	if f.states != nil {
+		return nil
+	}
+	states := make(map[string]TargetState)
+	b, err := os.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(b, &states); err != nil {
+			f.states = make(map[string]TargetState) // to be written over
+			return fmt.Errorf("reading state %s: %w", f.Path, err)
+		}
+	}
+	f.states = states
+	return nil
+}
+
+// WithState makes waits and watches keep the states of their targets
+// in f: a wait goes on from the attempts of earlier waits that did
+// not succeed, with the delays and the attempts left that they
+// leave, and Watch goes on counting checks and detecting flapping
+// where earlier watches left off, telling notifiers about changes
+// from the state they left. Results and Changes still count only the
+// attempts of the wait at hand. Failures to read or write f are
+// logged, and otherwise ignored.
+func WithState(f *StateFile) Option {
+	return func(c *config) { c.state = f }
+}
+
+// getState returns the state of target in the state file of c, if
+// any.
+func (c *config) getState(target string) TargetState {
+	if c.state == nil {
+		return TargetState{}
+	}
+	s, err := c.state.Get(target)
+	if err != nil {
+		c.logger.Warn("cannot read state", "target", target, "err", err)
+	}
+	return s
+}
+
+// putState sets the state of target in the state file of c, if any.
+func (c *config) putState(target string, s TargetState) {
+	if c.state == nil {
+		return
+	}
+	s.Updated = c.clock.Now()
+	if err := c.state.Put(target, s); err != nil {
+		c.logger.Warn("cannot save state", "target", target, "err", err)
+	}
+}
+
+// resumedBackoff delays the attempts of a wait as b delays those
+// after the ones made earlier.
+type resumedBackoff struct {
+	b       backoff.Backoff
+	earlier int
+}
+
+func (r resumedBackoff) NextDelay(attempt int) time.Duration {
+	return r.b.NextDelay(r.earlier + attempt)
+}
+
+// errString returns the message of err, or "" if it is nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,109 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// failingChecker fails its first fails checks.
+type failingChecker struct {
+	name  string
+	fails int
+	n     int
+}
+
+func (c *failingChecker) Check(context.Context) error {
+	if c.n++; c.n <= c.fails {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (c *failingChecker) String() string { return c.name }
+
+// waitWithState waits for a target whose first fails checks fail,
+// with a StateFile of path, as a run of a program would, and returns
+// the Result and how long the wait slept.
+func waitWithState(t *testing.T, path string, fails int, opts ...Option) (Result, []time.Duration, error) {
+	t.Helper()
+	clk := clock.NewInstant(time.Unix(0, 0))
+	opts = append(opts, WithState(&StateFile{Path: path}), WithClock(clk), WithInitialDelay(time.Second),
+		WithLogger(slog.New(slog.DiscardHandler)))
+	r, err := Wait(context.Background(), &failingChecker{name: "db", fails: fails}, opts...)
+	return r, clk.Timers(), err
+}
+
+func TestStateResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	r, slept, err := waitWithState(t, path, 10, WithMaxAttempts(3))
+	if !errors.Is(err, ErrMaxAttempts) || r.Attempts != 3 {
+		t.Fatalf("first run: %d attempts, %v", r.Attempts, err)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(slept, want) {
+		t.Errorf("first run slept %v, want %v", slept, want)
+	}
+	s, err := (&StateFile{Path: path}).Get("db")
+	if err != nil || s.Attempts != 3 || s.Error != "connection refused" || !s.Updated.Equal(time.Unix(0, 0).Add(3*time.Second)) {
+		t.Errorf("state after the first run: %+v, %v", s, err)
+	}
+
+	// Going on from the 3 attempts made, with 2 of the 5 left and the
+	// delays after them.
+	r, slept, err = waitWithState(t, path, 10, WithMaxAttempts(5))
+	if !errors.Is(err, ErrMaxAttempts) || r.Attempts != 2 {
+		t.Fatalf("second run: %d attempts, %v", r.Attempts, err)
+	}
+	if want := []time.Duration{8 * time.Second}; !slices.Equal(slept, want) {
+		t.Errorf("second run slept %v, want %v", slept, want)
+	}
+
+	// Once the target is ready, the next waits start over.
+	r, slept, err = waitWithState(t, path, 1)
+	if err != nil || r.Attempts != 2 || !slices.Equal(slept, []time.Duration{32 * time.Second}) {
+		t.Errorf("third run: %d attempts after sleeping %v, %v", r.Attempts, slept, err)
+	}
+	if s, err := (&StateFile{Path: path}).Get("db"); err != nil || s.Attempts != 0 {
+		t.Errorf("state after a ready run: %+v, %v", s, err)
+	}
+	if r, slept, err := waitWithState(t, path, 1); err != nil || r.Attempts != 2 || !slices.Equal(slept, []time.Duration{time.Second}) {
+		t.Errorf("run after a ready one: %d attempts after sleeping %v, %v", r.Attempts, slept, err)
+	}
+}
+
+func TestStateFileMissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	missing := &StateFile{Path: filepath.Join(dir, "missing.json")}
+	if s, err := missing.Get("db"); err != nil || s.Attempts != 0 {
+		t.Errorf("Get of a missing file: %+v, %v", s, err)
+	}
+	if err := missing.Put("db", TargetState{Attempts: 2}); err != nil {
+		t.Errorf("Put to a missing file: %v", err)
+	}
+	if s, err := (&StateFile{Path: missing.Path}).Get("db"); err != nil || s.Attempts != 2 {
+		t.Errorf("Get of the file written: %+v, %v", s, err)
+	}
+
+	path := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(path, []byte(`{"db": {"attempts": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&StateFile{Path: path}).Get("db"); err == nil || !strings.HasPrefix(err.Error(), "reading state "+path) {
+		t.Errorf("Get of a corrupt file: %v", err)
+	}
+	// A wait goes on as without a state, and replaces the file.
+	if r, _, err := waitWithState(t, path, 2, WithMaxAttempts(2)); !errors.Is(err, ErrMaxAttempts) || r.Attempts != 2 {
+		t.Errorf("wait with a corrupt file: %d attempts, %v", r.Attempts, err)
+	}
+	if s, err := (&StateFile{Path: path}).Get("db"); err != nil || s.Attempts != 2 {
+		t.Errorf("state after a wait with a corrupt file: %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,186 @@
+package wait
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A StatusError reports a response whose status code was not
+// among the expected ones.
+type StatusError struct {
+	StatusCode int
+	Status     string // e.g. "503 Service Unavailable"
+
+	// Wait is how long the server asked to wait before retrying,
+	// per the Retry-After header of a 429 or 503 response, or zero.
+	Wait time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Wait > 0 {
+		return fmt.Sprintf("unexpected status %s (retry after %s)", e.Status, e.Wait)
+	}
+	return fmt.Sprintf("unexpected status %s", e.Status)
+}
+
+// RetryAfter returns e.Wait. It makes retry.Do honor the server's
+// Retry-After header instead of backing off on its own.
+func (e *StatusError) RetryAfter() time.Duration { return e.Wait }
+
+// Errors matched by the StatusErrors of the responses of each class,
+// so that callers can tell them apart with errors.Is.
+var (
+	ErrClientError     = errors.New("client error")      // 4xx
+	ErrServerError     = errors.New("server error")      // 5xx
+	ErrTooManyRequests = errors.New("too many requests") // 429, also a client error
+)
+
+// Is reports whether target is the error of the class of e's status.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrClientError:
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	case ErrServerError:
+		return e.StatusCode >= 500 && e.StatusCode < 600
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Retryable reports whether the request may succeed if sent again:
+// for server errors (5xx), 408 Request Timeout, and 429 Too Many
+// Requests, but for no other client errors. It makes
+// errclass.Retryable classify e.
+func (e *StatusError) Retryable() bool {
+	code := e.StatusCode
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+	return code < 400 || code >= 500
+}
+
+// CheckResponse returns a *StatusError for a response with a client
+// or server error status (4xx or 5xx), with the delay requested by
+// its Retry-After header, and nil for any other response.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Wait: parseRetryAfter(resp, time.Now())}
+}
+
+// parseRetryAfter returns the delay requested by the Retry-After
+// header of resp, which is either a number of seconds or a date.
+// It returns zero for responses other than 429 and 503.
+func parseRetryAfter(resp *http.Response, now time.Time) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(min(secs, int(maxRetryAfter/time.Second))) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// maxRetryAfter guards against absurd Retry-After values.
+const maxRetryAfter = 24 * time.Hour
+
+// A StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	Min, Max int
+}
+
+// Statuses is a set of HTTP status codes, such as the codes a server
+// is expected to respond with. Its text form is a comma-separated
+// list of codes and ranges like "200-299,301".
+type Statuses []StatusRange
+
+// ParseStatuses parses the text form of a set of status codes.
+func ParseStatuses(s string) (Statuses, error) {
+	var set Statuses
+	if s == "" {
+		return set, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(field), "-")
+		min, err := parseStatus(lo)
+		if err != nil {
+			return nil, err
+		}
+		max := min
+		if isRange {
+			if max, err = parseStatus(hi); err != nil {
+				return nil, err
+			}
+			if max < min {
+				return nil, fmt.Errorf("invalid status range %s", field)
+			}
+		}
+		set = append(set, StatusRange{min, max})
+	}
+	return set, nil
+}
+
+func parseStatus(s string) (int, error) {
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 999 {
+		return 0, fmt.Errorf("invalid status code %q", s)
+	}
+	return code, nil
+}
+
+// Contains reports whether code is in s. An empty set contains every
+// code.
+func (s Statuses) Contains(code int) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if r.Min <= code && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Statuses) String() string {
+	var fields []string
+	for _, r := range s {
+		if r.Min == r.Max {
+			fields = append(fields, strconv.Itoa(r.Min))
+		} else {
+			fields = append(fields, fmt.Sprintf("%d-%d", r.Min, r.Max))
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Statuses) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Statuses) UnmarshalText(text []byte) error {
+	set, err := ParseStatuses(string(text))
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
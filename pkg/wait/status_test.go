@@ -0,0 +1,209 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+func TestStatusesContains(t *testing.T) {
+	set, err := ParseStatuses("200-299, 301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.String(); got != "200-299,301" {
+		t.Errorf("String() = %q, want 200-299,301", got)
+	}
+	for _, test := range []struct {
+		set  Statuses
+		code int
+		want bool
+	}{
+		{set, 200, true},
+		{set, 299, true},
+		{set, 301, true},
+		{set, 302, false},
+		{set, 503, false},
+		{nil, 503, true}, // an empty set contains every code
+	} {
+		if got := test.set.Contains(test.code); got != test.want {
+			t.Errorf("%v.Contains(%d) = %v, want %v", test.set, test.code, got, test.want)
+		}
+	}
+	for _, s := range []string{"20x", "99", "1000", "299-200", "200,"} {
+		if _, err := ParseStatuses(s); err == nil {
+			t.Errorf("ParseStatuses(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestHTTPCheckerStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/ok":
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/busy":
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/moved":
+			http.Redirect(w, req, "/ok", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	for _, test := range []struct {
+		path string
+		opts []Option
+		want string // the error, or "" for none
+	}{
+		{"/ok", nil, ""},
+		// By default any status will do, but for those asking to wait,
+		// and redirects not followed.
+		{"/error", nil, ""},
+		{"/busy", nil, "unexpected status 503 Service Unavailable (retry after 5s)"},
+		{"/moved", nil, ""},
+		{"/moved", []Option{WithMaxRedirects(0)}, "unexpected status 302 Found"},
+		{"/ok", []Option{WithExpectStatus(Statuses{{200, 299}})}, ""},
+		{"/error", []Option{WithExpectStatus(Statuses{{200, 299}})}, "unexpected status 500 Internal Server Error"},
+		{"/moved", []Option{WithMaxRedirects(0), WithExpectStatus(Statuses{{302, 302}})}, ""},
+	} {
+		cfg := newConfig(test.opts)
+		err := newHTTPChecker(&cfg, srv.URL+test.path).Check(context.Background())
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("%s %v: %v, want no error", test.path, test.opts, err)
+		case test.want != "" && (err == nil || err.Error() != test.want):
+			t.Errorf("%s %v: %v, want %s", test.path, test.opts, err, test.want)
+		case test.want != "":
+			var se *StatusError
+			if !errors.As(err, &se) {
+				t.Errorf("%s: %T, want a *StatusError", test.path, err)
+			}
+		}
+	}
+}
+
+func TestWaitForServerStatus(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if requests++; requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	clk := clock.NewInstant(time.Unix(0, 0))
+	r, err := WaitForServer(context.Background(), srv.URL, WithClock(clk), WithLogger(slog.New(slog.DiscardHandler)),
+		WithExpectStatus(Statuses{{200, 299}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Attempts != 3 || r.StatusCode != http.StatusOK {
+		t.Errorf("ready after %d attempts with status %d, want 3 with 200", r.Attempts, r.StatusCode)
+	}
+	if got, want := clk.Timers(), []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v", got, want)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	for _, test := range []struct {
+		code      int
+		class     error
+		retryable bool
+	}{
+		{http.StatusBadRequest, ErrClientError, false},
+		{http.StatusNotFound, ErrClientError, false},
+		{http.StatusRequestTimeout, ErrClientError, true},
+		{http.StatusTooManyRequests, ErrTooManyRequests, true},
+		{http.StatusInternalServerError, ErrServerError, true},
+		{http.StatusServiceUnavailable, ErrServerError, true},
+	} {
+		err := &StatusError{StatusCode: test.code, Status: http.StatusText(test.code)}
+		if !errors.Is(err, test.class) {
+			t.Errorf("status %d is not %v", test.code, test.class)
+		}
+		if test.code != http.StatusTooManyRequests && errors.Is(err, ErrTooManyRequests) {
+			t.Errorf("status %d is %v", test.code, ErrTooManyRequests)
+		}
+		if got := err.Retryable(); got != test.retryable {
+			t.Errorf("status %d: Retryable() = %v, want %v", test.code, got, test.retryable)
+		}
+	}
+	err := &StatusError{StatusCode: 429, Status: "429 Too Many Requests", Wait: 2 * time.Second}
+	if got, want := err.Error(), "unexpected status 429 Too Many Requests (retry after 2s)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err.RetryAfter() != 2*time.Second {
+		t.Errorf("RetryAfter() = %v, want 2s", err.RetryAfter())
+	}
+	if err := CheckResponse(&http.Response{StatusCode: 204, Status: "204 No Content"}); err != nil {
+		t.Errorf("CheckResponse of 204: %v, want nil", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		code  int
+		value string
+		want  time.Duration
+	}{
+		{503, "120", 2 * time.Minute},
+		{429, " 7 ", 7 * time.Second},
+		{503, "0", 0},
+		{503, "-5", 0},
+		{503, "100000", maxRetryAfter}, // capped at 24h
+		{503, now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{429, "Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{503, now.Add(-time.Minute).Format(http.TimeFormat), 0}, // in the past
+		{503, "soon", 0},
+		{503, "", 0},
+		{500, "120", 0}, // only for 429 and 503
+		{200, "120", 0},
+	} {
+		resp := &http.Response{StatusCode: test.code, Header: http.Header{}}
+		if test.value != "" {
+			resp.Header.Set("Retry-After", test.value)
+		}
+		if got := parseRetryAfter(resp, now); got != test.want {
+			t.Errorf("%d with Retry-After %q: waits %v, want %v", test.code, test.value, got, test.want)
+		}
+	}
+}
+
+func TestWaitForServerRetryAfter(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if requests++; requests < 3 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	quiet := WithLogger(slog.New(slog.DiscardHandler))
+
+	clk := clock.NewInstant(time.Unix(0, 0))
+	r, err := WaitForServer(context.Background(), srv.URL, WithClock(clk), quiet)
+	if err != nil || r.Attempts != 3 {
+		t.Fatalf("WaitForServer: %d attempts, %v; want 3, nil", r.Attempts, err)
+	}
+	if got, want := clk.Timers(), []time.Duration{30 * time.Second, 30 * time.Second}; !slices.Equal(got, want) {
+		t.Errorf("slept %v, want %v as asked by Retry-After", got, want)
+	}
+
+	// An expected 503 is ready, however long it asks to wait.
+	requests = 0
+	cfg := newConfig([]Option{WithExpectStatus(Statuses{{503, 503}})})
+	if err := newHTTPChecker(&cfg, srv.URL).Check(context.Background()); err != nil {
+		t.Errorf("Check of an expected 503: %v, want nil", err)
+	}
+}
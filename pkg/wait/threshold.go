@@ -0,0 +1,84 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"github.com/go-monk/error-handling/pkg/retry"
+)
+
+// A notReadyError reports a successful probe that was not yet
+// preceded by enough other consecutive successes.
+type notReadyError struct {
+	successes, want int
+}
+
+func (e *notReadyError) Error() string {
+	return fmt.Sprintf("%d of %d consecutive successes", e.successes, e.want)
+}
+
+// A failuresError reports that a probe failed too many times in a
+// row to go on.
+type failuresError struct {
+	failures int
+	err      error // of the last failure
+}
+
+func (e *failuresError) Error() string {
+	return fmt.Sprintf("%d consecutive failures: %v", e.failures, e.err)
+}
+
+func (e *failuresError) Unwrap() error { return e.err }
+
+// consecutive returns a probe that succeeds only once probe has
+// succeeded n times in a row, and that fails for good once probe has
+// failed m times in a row, if m is positive. Successes short of n are
+// reported as errors that make retry.Do wait the delay returned by
+// interval before probing again, which is only called then, so that
+// it may ask backoffs that keep state without upsetting them.
+func consecutive(probe func(context.Context) error, n, m int, interval func() time.Duration) func(context.Context) error {
+	if n <= 1 && m <= 0 {
+		return probe
+	}
+	successes, failures := 0, 0
+	return func(ctx context.Context) error {
+		if err := probe(ctx); err != nil {
+			successes = 0
+			failures++
+			if m > 0 && failures >= m {
+				return &failuresError{failures, err}
+			}
+			return err
+		}
+		successes, failures = successes+1, 0
+		if successes < n {
+			return retry.After(&notReadyError{successes, n}, interval())
+		}
+		return nil
+	}
+}
+
+// retryNotReady extends a RetryIf predicate to always retry probes
+// that are only waiting for more consecutive successes, and never
+// those that failed too many times in a row. By default, errors are
+// retried unless marked as permanent, as by errclass.MarkPermanent.
+func retryNotReady(retryIf func(error) bool) func(error) bool {
+	return func(err error) bool {
+		var nr *notReadyError
+		var fe *failuresError
+		switch {
+		case errors.As(err, &fe):
+			return false
+		case errors.As(err, &nr):
+			return true
+		}
+		if retryIf != nil {
+			return retryIf(err)
+		}
+		retryable, marked := errclass.Marked(err)
+		return retryable || !marked
+	}
+}
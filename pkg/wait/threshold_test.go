@@ -0,0 +1,64 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// A recordingBackoff waits a second more after each attempt, and
+// records the attempts it was asked about.
+type recordingBackoff struct {
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (b *recordingBackoff) NextDelay(attempt int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts = append(b.attempts, attempt)
+	return time.Duration(attempt+1) * time.Second
+}
+
+func TestConsecutiveBackoff(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		fails     int // before the checks succeed
+		successes int
+		want      []int // the attempts the backoff is asked about
+		slept     []time.Duration
+	}{
+		// Only as retry.Do asks, not once more before the first attempt.
+		{"failure threshold", 2, 1, []int{0, 1}, []time.Duration{time.Second, 2 * time.Second}},
+		// Once more, as the attempt ends, for the delay after each success
+		// short of the count.
+		{"success count", 1, 3, []int{0, 0, 1, 0, 2}, []time.Duration{time.Second, time.Second, time.Second}},
+	} {
+		b := new(recordingBackoff)
+		clk := clock.NewInstant(time.Unix(0, 0))
+		n := 0
+		c := CheckerFunc(func(context.Context) error {
+			if n++; n <= test.fails {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		_, err := Wait(context.Background(), c, WithBackoff(b), WithClock(clk), WithLogger(slog.New(slog.DiscardHandler)),
+			WithFailureThreshold(5), WithSuccessCount(test.successes))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !slices.Equal(b.attempts, test.want) {
+			t.Errorf("%s: backoff asked about attempts %v, want %v", test.name, b.attempts, test.want)
+		}
+		if got := clk.Timers(); !slices.Equal(got, test.slept) {
+			t.Errorf("%s: slept %v, want %v", test.name, got, test.slept)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package wait
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// A tlsChecker checks whether a server completes a TLS handshake with a
+// valid certificate chain for the expected name.
+type tlsChecker struct {
+	cfg        *config
+	addr       string // host:port
+	serverName string // name to send with SNI and verify, if not the host
+}
+
+func (p *tlsChecker) Check(ctx context.Context) error {
+	conf := p.cfg.tlsConfig.Clone()
+	if conf == nil {
+		conf = new(tls.Config)
+	}
+	if p.serverName != "" {
+		conf.ServerName = p.serverName
+	}
+	conn, err := p.cfg.dialTLS(ctx, p.addr, conf)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if p.cfg.minCertValidity <= 0 {
+		return nil
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	left := time.Until(certs[0].NotAfter)
+	if left < p.cfg.minCertValidity {
+		return fmt.Errorf("certificate for %s expires in %s, want at least %s",
+			hostOf(p.addr, conf.ServerName), days(left), days(p.cfg.minCertValidity))
+	}
+	return nil
+}
+
+// days formats d as a number of days, the usual unit of certificate
+// lifetimes.
+func days(d time.Duration) string {
+	return fmt.Sprintf("%.1f days", d.Hours()/24)
+}
+
+// hostOf returns the name the certificate should be valid for.
+func hostOf(addr, serverName string) string {
+	if serverName != "" {
+		return serverName
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
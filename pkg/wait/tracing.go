@@ -0,0 +1,41 @@
+package wait
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer makes each wait a span named "wait", and each of its
+// attempts a child span named "wait.attempt", created by t. The spans
+// have the attribute wait.target; waits also wait.attempts, the
+// number of attempts made, and attempts wait.attempt, the number of
+// the attempt, and wait.backoff, the seconds slept before it. Spans
+// that failed record their error. By default no spans
+// are created.
+func WithTracer(t trace.Tracer) Option {
+	return func(c *config) { c.tracer = t }
+}
+
+// startAttempt starts the span of attempt n to check target, made
+// after sleeping for backoff.
+func (c *config) startAttempt(ctx context.Context, target string, n int, backoff time.Duration) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "wait.attempt", trace.WithAttributes(
+		attribute.String("wait.target", target),
+		attribute.Int("wait.attempt", n),
+		attribute.String("wait.attempt_id", AttemptID(ctx)),
+		attribute.Float64("wait.backoff", backoff.Seconds()),
+	))
+}
+
+// endSpan ends span, which failed with err if it is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
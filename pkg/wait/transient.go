@@ -0,0 +1,28 @@
+package wait
+
+import (
+	"errors"
+	"net"
+	"net/url"
+)
+
+// Transient reports whether err, returned by an attempt to contact a
+// server, may go away by itself. Connection failures, timeouts, and
+// server errors (5xx) are transient; unknown hosts, malformed URLs,
+// and client errors (4xx) such as 401 Unauthorized are not, except for
+// 408 Request Timeout and 429 Too Many Requests.
+func Transient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Op == "parse" {
+		return false
+	}
+	return true
+}
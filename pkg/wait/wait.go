@@ -0,0 +1,293 @@
+// Package wait waits for servers to start responding, and more
+// generally for any Checker to report being ready, retrying failed
+// attempts with exponential back-off.
+//
+// Original: https://github.com/adonovan/gopl.io/blob/master/ch5/wait/wait.go
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"github.com/go-monk/error-handling/pkg/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WaitForServer attempts to contact the server of a URL.
+// It tries until ctx is done, or for one minute using exponential
+// back-off if ctx carries no deadline of its own.
+// It returns a Result describing the attempts made, and an error if
+// all of them fail, which matches ErrTimeout, ErrCanceled,
+// ErrMaxAttempts, or ErrNonRetryable, and carries the target,
+// attempts, and elapsed time as errfields fields.
+//
+// The scheme of the URL selects how the server is contacted:
+//
+//	http, https  an HTTP request; see WithMethod and WithExpectStatus
+//	tcp          a TCP connection to tcp://host:port
+//	tls          a TLS handshake with tls://host:port[?servername=name],
+//	             which must yield a valid certificate for name or host;
+//	             see WithTLSConfig and WithMinCertValidity
+//	unix         a connection to the Unix domain socket unix:///path
+//	grpc, grpcs  a grpc.health.v1 health check of grpc://host:port
+//	             [?service=name], over TLS for grpcs, which succeeds
+//	             once the service reports SERVING; see WithGRPCService
+//	redis,       a PING of redis://[user:password@]host[:port], over TLS
+//	rediss       for rediss, which must be answered with PONG
+//	amqp, amqps  the start of an AMQP 0-9-1 handshake with
+//	             amqp://host[:port], over TLS for amqps
+//	kafka,       an ApiVersions request to the broker of kafka://host
+//	kafkas       [:port][?topic=name], over TLS for kafkas, which must
+//	             be answered without error, and a Metadata request
+//	             for the topic if given, which must exist with a
+//	             leader for each partition; SASL is not supported
+//	es, ess      a request for the health of the Elasticsearch or
+//	             OpenSearch cluster of es://[user:password@]host
+//	             [:port][/prefix][?status=S&nodes=N], over HTTPS for
+//	             ess, which succeeds once the cluster is of status S
+//	             (red, yellow, or green; yellow by default) or better,
+//	             with at least N nodes, 1 by default
+//	ssh          the banner of the SSH server of ssh://[user@]host
+//	             [:port], or with key=path, a handshake in which the
+//	             user logs in with the private key in path, checking
+//	             the host key against known_hosts=path if given
+//	ntp          an SNTP request to the server of ntp://host[:port]
+//	             [?max_offset=D], which succeeds once the system
+//	             clock is within D of that of the server, 1s by
+//	             default
+//	file         a look at file:///path?type=T&nonempty=B&mode=M, which
+//	             succeeds once path exists, is of type T (file, dir,
+//	             or socket) and not empty if B is true, and has all
+//	             the permission bits of the octal mode M
+//	ping         an ICMP echo request to ping://host, which must be
+//	             answered; unprivileged processes need to be allowed
+//	             to use ICMP datagram sockets
+//	dns          a lookup of dns://host?type=T&want=V, which succeeds
+//	             once host has records of type T (A, AAAA, IP, CNAME,
+//	             MX, NS, SRV, or TXT; IP by default), one of them
+//	             with value V if given
+//	process      a look for the process of process:///path/to/pidfile,
+//	             named by process://name, or process://?pid=N, which
+//	             succeeds once it runs, and with min_uptime=D, once it
+//	             has for D; only PID files and PIDs are supported
+//	             outside Linux, without min_uptime
+//	winsvc       a query of the service control manager for the state
+//	             of the Windows service of winsvc://name[?state=S],
+//	             which succeeds once it is S (running, stopped, or
+//	             paused; running by default); only on Windows
+//	s3           a HEAD request for the object of s3://bucket/key
+//	             [?region=R&endpoint=URL], which must exist; requests
+//	             are signed with the credentials of the URL, or of
+//	             AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, and go
+//	             to AWS unless endpoint or AWS_ENDPOINT_URL_S3 names
+//	             another store, such as MinIO; 403 Forbidden is
+//	             retried, as S3 answers it for missing objects
+//	             without the permission to list the bucket, so bad
+//	             credentials fail only by the timeout
+//	metadata     a request for an item of the instance metadata of the
+//	             cloud of metadata://aws, gcp, or azure[/item], by
+//	             default the ID of the instance, which must be served
+//
+// RegisterScheme adds schemes, and with WithPlugins, programs on PATH
+// check the targets of other schemes.
+func WaitForServer(ctx context.Context, url string, opts ...Option) (Result, error) {
+	cfg := newConfig(opts)
+	c, err := newChecker(&cfg, url)
+	if err != nil {
+		return Result{Target: url, Err: err}, err
+	}
+	return wait(ctx, &cfg, c)
+}
+
+// Wait checks c until it reports being ready, retrying as configured
+// by opts in the same way as WaitForServer. Logs, errors, and the
+// Result name c by its String method, if it has one.
+func Wait(ctx context.Context, c Checker, opts ...Option) (Result, error) {
+	cfg := newConfig(opts)
+	return wait(ctx, &cfg, c)
+}
+
+// A Result describes a wait, whether it succeeded or not.
+type Result struct {
+	Target   string        // what was waited for
+	Attempts int           // number of attempts made
+	Elapsed  time.Duration // time spent waiting, delays included
+	Latency  time.Duration // time taken by the last attempt
+
+	// Latencies are the times taken by each attempt, in order, to
+	// see how the response times of a server trended while it was
+	// starting. LatencyPercentile summarizes them.
+	Latencies []time.Duration
+
+	// StatusCode and Status describe the last response, for targets
+	// that get one with a status, such as "200 OK" for HTTP.
+	StatusCode int
+	Status     string
+
+	// Err is the error the wait failed with, or nil.
+	Err error
+
+	// Cached reports whether the wait succeeded because the Cache
+	// given by WithCache remembered the target as ready, or failed
+	// because it remembered the target as failed for good.
+	Cached bool
+}
+
+// LastErr returns the error of the last attempt of the wait, or Err
+// if it made none.
+func (r Result) LastErr() error {
+	var e *retry.Error
+	if errors.As(r.Err, &e) && len(e.Attempts) > 0 {
+		return e.Attempts[len(e.Attempts)-1]
+	}
+	return r.Err
+}
+
+// An Attempt describes one check made while waiting, as passed to the
+// function given to WithOnAttempt.
+type Attempt struct {
+	Target  string        // what is waited for
+	Number  int           // number of the attempt, from 1
+	ID      string        // of the attempt, as by AttemptID
+	Elapsed time.Duration // time spent waiting so far
+	Latency time.Duration // time taken by the attempt
+
+	// StatusCode and Status describe the response, as for Result.
+	StatusCode int
+	Status     string
+
+	Err error // why the attempt failed, or nil
+}
+
+// safe turns the panics of check into a *retry.PanicError, so that
+// a panicking Checker fails the attempt rather than the program.
+func safe(check func(context.Context) error) func(context.Context) error {
+	op := retry.Safe(func(ctx context.Context) (struct{}, error) { return struct{}{}, check(ctx) })
+	return func(ctx context.Context) error {
+		_, err := op(ctx)
+		return err
+	}
+}
+
+// wait checks c until it is ready, as configured by cfg.
+func wait(ctx context.Context, cfg *config, c Checker) (Result, error) {
+	target := checkerName(c)
+	r := Result{Target: target}
+	ready, notReady := "ready", "not ready"
+	if cfg.untilDown {
+		c = Not(c)
+		ready, notReady = "down", "still up"
+	}
+	if cfg.cache != nil && !cfg.untilDown && cfg.cache.Ready(target) {
+		cfg.logger.DebugContext(ctx, "target ready, as cached", "target", target)
+		r.Cached = true
+		return r, nil
+	}
+	if cfg.cache != nil && !cfg.untilDown {
+		if err := cfg.cache.Failed(target); err != nil {
+			cfg.logger.DebugContext(ctx, "target failed for good, as cached", "target", target)
+			r.Cached, r.Err = true, err
+			return r, err
+		}
+	}
+	start := cfg.clock.Now()
+	ctx, span := cfg.tracer.Start(ctx, "wait", trace.WithAttributes(attribute.String("wait.target", target)))
+	p := cfg.policy(ctx)
+	saved := cfg.getState(target)
+	if saved.Attempts > 0 {
+		p.Backoff = resumedBackoff{p.Backoff, saved.Attempts}
+		if p.MaxAttempts > 0 {
+			p.MaxAttempts = max(p.MaxAttempts-saved.Attempts, 1)
+		}
+		cfg.logger.InfoContext(ctx, "resuming wait", "target", target, "earlier_attempts", saved.Attempts)
+	}
+	timeout := p.Timeout // allowed, for the error if it runs out
+	if timeout <= 0 {
+		timeout = cfg.sharedTimeout
+	}
+	if deadline, ok := ctx.Deadline(); ok && timeout <= 0 {
+		timeout = time.Until(deadline).Round(time.Millisecond)
+	}
+	var attemptID string    // of the last attempt
+	var delay time.Duration // before the next attempt
+	p.OnRetry = func(attempt int, d time.Duration, err error) {
+		delay = d
+		cfg.publish(ctx, Retrying{Target: target, Number: attempt + 1, ID: attemptID,
+			Delay: d, Elapsed: cfg.since(start), Err: err})
+	}
+	cfg.publish(ctx, Started{Target: target, Time: start})
+	interval := func() time.Duration { return p.Backoff.NextDelay(0) }
+	check := consecutive(cfg.limitLatency(cfg.hedge(safe(c.Check))), cfg.successCount, cfg.failureThreshold, interval)
+	_, err := retry.Do(ctx, p, func(ctx context.Context) (struct{}, error) {
+		if cfg.cache != nil && !cfg.untilDown && cfg.cache.Ready(target) {
+			r.Cached = true // by another wait
+			return struct{}{}, nil
+		}
+		r.Attempts++
+		attemptID = newAttemptID()
+		cfg.publish(ctx, AttemptStarted{Target: target, Number: r.Attempts, ID: attemptID, Delay: delay})
+		ctx, span := cfg.startAttempt(withAttemptID(ctx, attemptID), target, r.Attempts, delay)
+		t := cfg.clock.Now()
+		err := check(ctx)
+		r.Latency = cfg.since(t)
+		r.Latencies = append(r.Latencies, r.Latency)
+		endSpan(span, err)
+		r.StatusCode, r.Status = lastStatus(c)
+		if cfg.state != nil {
+			saved.Attempts++
+			saved.Status, saved.Error = r.Status, errString(err)
+			cfg.putState(target, saved)
+		}
+		cfg.publish(ctx, Attempt{
+			Target:     target,
+			Number:     r.Attempts,
+			ID:         attemptID,
+			Elapsed:    cfg.since(start),
+			Latency:    r.Latency,
+			StatusCode: r.StatusCode,
+			Status:     r.Status,
+			Err:        err,
+		})
+		return struct{}{}, err
+	})
+	r.Elapsed = cfg.since(start)
+	if cfg.logDedup != nil {
+		cfg.logDedup.Flush(ctx)
+	}
+	span.SetAttributes(attribute.Int("wait.attempts", r.Attempts))
+	endSpan(span, err)
+	if err != nil {
+		reason := stopped(ctx, r, timeout, err)
+		err = fmt.Errorf("%s %s: %w", target, notReady, reason)
+		fields := []any{"target", target, "attempts", r.Attempts, "elapsed", r.Elapsed}
+		if attemptID != "" {
+			fields = append(fields, "attempt_id", attemptID) // of the last attempt
+		}
+		if s := lastResponse(c); s != nil {
+			fields = append(fields, "response", s)
+		}
+		r.Err = errfields.With(err, fields...)
+		if cfg.cache != nil && !cfg.untilDown && errors.Is(r.Err, ErrNonRetryable) {
+			cfg.cache.fail(target, r.Err)
+		}
+		cfg.publish(ctx, Done{Target: target, Attempts: r.Attempts, Elapsed: r.Elapsed, Err: r.Err})
+		cfg.notify(ctx, Notification{Event: notReady, Target: target, Time: cfg.clock.Now(), Attempts: r.Attempts, Elapsed: r.Elapsed, Err: reason})
+		return r, r.Err
+	}
+	if cfg.cache != nil && !cfg.untilDown && !r.Cached {
+		cfg.cache.add(target)
+	}
+	if cfg.state != nil && saved.Attempts > 0 {
+		saved.Attempts = 0
+		cfg.putState(target, saved)
+	}
+	cfg.publish(ctx, Done{Target: target, Attempts: r.Attempts, Elapsed: r.Elapsed})
+	cfg.logger.InfoContext(ctx, "target "+ready, "target", target,
+		"attempts", r.Attempts, "elapsed", r.Elapsed.Round(time.Millisecond))
+	cfg.notify(ctx, Notification{Event: ready, Target: target, Time: cfg.clock.Now(), Attempts: r.Attempts, Elapsed: r.Elapsed})
+	return r, nil
+}
@@ -0,0 +1,29 @@
+package waittest_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/wait"
+	"github.com/go-monk/error-handling/pkg/wait/waittest"
+)
+
+func ExampleServer() {
+	// A server that drops two connections, is unavailable once,
+	// and then responds normally.
+	steps := append(waittest.Times(2, waittest.Drop()), waittest.Status(http.StatusServiceUnavailable), waittest.OK)
+	srv := waittest.NewServer(steps...)
+	defer srv.Close()
+
+	r, err := wait.WaitForServer(context.Background(), srv.URL,
+		wait.WithExpectStatus(wait.Statuses{{Min: 200, Max: 299}}),
+		wait.WithClock(clock.NewInstant(time.Unix(0, 0))), // don't sleep between attempts
+		wait.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	fmt.Println(r.Attempts, r.Status, err)
+	// Output: 4 200 OK <nil>
+}
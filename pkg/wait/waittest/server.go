@@ -0,0 +1,119 @@
+// Package waittest provides a fake HTTP server whose responses are
+// scripted, for testing how programs wait for servers.
+package waittest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// A Step says how the Server handles a request.
+type Step struct {
+	Status int           // status code of the response, 200 if zero
+	Header http.Header   // headers of the response
+	Delay  time.Duration // time to wait before responding
+	Drop   bool          // close the connection instead of responding
+}
+
+// OK responds with 200 OK.
+var OK = Step{Status: http.StatusOK}
+
+// Status returns a Step responding with code.
+func Status(code int) Step { return Step{Status: code} }
+
+// Drop returns a Step closing the connection without a response.
+func Drop() Step { return Step{Drop: true} }
+
+// Times returns a script repeating step n times.
+func Times(n int, step Step) []Step {
+	steps := make([]Step, n)
+	for i := range steps {
+		steps[i] = step
+	}
+	return steps
+}
+
+// A Server is an httptest.Server that handles the requests it gets
+// by following a script of Steps in order, repeating the last one
+// once the script has run out.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	steps    []Step
+	requests int
+}
+
+// NewServer starts and returns a Server following the script steps.
+// An empty script responds to every request with 200 OK. The caller
+// should call Close when done.
+func NewServer(steps ...Step) *Server {
+	s := &Server{steps: steps}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// ReadyAfter starts and returns a Server that drops the connections
+// of its first n requests and responds to the rest with 200 OK, like
+// a server that is still starting up.
+func ReadyAfter(n int) *Server {
+	return NewServer(append(Times(n, Drop()), OK)...)
+}
+
+// Script replaces the script of s by steps, starting from the next
+// request.
+func (s *Server) Script(steps ...Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = steps[:len(steps):len(steps)]
+	s.requests = 0
+}
+
+// Requests returns the number of requests s got since it started or
+// its script was last replaced.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	step := s.next()
+	if step.Delay > 0 {
+		select {
+		case <-time.After(step.Delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if step.Drop {
+		if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+		panic(http.ErrAbortHandler) // HTTP/2: abort the stream instead
+	}
+	for key, values := range step.Header {
+		w.Header()[key] = values
+	}
+	if step.Status != 0 {
+		w.WriteHeader(step.Status)
+	}
+}
+
+// next returns the step for the next request.
+func (s *Server) next() Step {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.requests
+	s.requests++
+	switch {
+	case len(s.steps) == 0:
+		return OK
+	case i >= len(s.steps):
+		return s.steps[len(s.steps)-1]
+	}
+	return s.steps[i]
+}
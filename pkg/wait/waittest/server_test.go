@@ -0,0 +1,112 @@
+package waittest_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/wait"
+	"github.com/go-monk/error-handling/pkg/wait/waittest"
+)
+
+// get requests the root of srv, and returns the status code of the
+// response or the error.
+func get(t *testing.T, srv *waittest.Server) (int, error) {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestServerScript(t *testing.T) {
+	srv := waittest.NewServer(waittest.Drop(), waittest.Status(http.StatusServiceUnavailable),
+		waittest.Step{Status: http.StatusTeapot, Header: http.Header{"X-Step": {"3"}}})
+	defer srv.Close()
+
+	if _, err := get(t, srv); err == nil {
+		t.Error("step 1: got a response, want the connection dropped")
+	}
+	if code, err := get(t, srv); code != http.StatusServiceUnavailable {
+		t.Errorf("step 2: got %d, %v, want 503", code, err)
+	}
+	// The last step is repeated.
+	for range 2 {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTeapot || resp.Header.Get("X-Step") != "3" {
+			t.Errorf("step 3: got %d with X-Step %q", resp.StatusCode, resp.Header.Get("X-Step"))
+		}
+	}
+	if n := srv.Requests(); n != 4 {
+		t.Errorf("Requests() = %d, want 4", n)
+	}
+
+	srv.Script(waittest.OK)
+	if n := srv.Requests(); n != 0 {
+		t.Errorf("Requests() = %d after the script was replaced, want 0", n)
+	}
+	if code, err := get(t, srv); code != http.StatusOK {
+		t.Errorf("replaced: got %d, %v, want 200", code, err)
+	}
+
+	empty := waittest.NewServer()
+	defer empty.Close()
+	if code, err := get(t, empty); code != http.StatusOK {
+		t.Errorf("empty script: got %d, %v, want 200", code, err)
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	srv := waittest.NewServer(waittest.Step{Delay: time.Hour}, waittest.Step{Delay: 10 * time.Millisecond})
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := http.DefaultClient.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("delayed an hour: got %v, want a timeout", err)
+	}
+	start := time.Now()
+	if code, err := get(t, srv); code != http.StatusOK || time.Since(start) < 10*time.Millisecond {
+		t.Errorf("delayed briefly: got %d, %v after %v", code, err, time.Since(start))
+	}
+}
+
+var quiet = []wait.Option{
+	wait.WithExpectStatus(wait.Statuses{{Min: 200, Max: 299}}),
+	wait.WithClock(clock.NewInstant(time.Unix(0, 0))),
+	wait.WithLogger(slog.New(slog.DiscardHandler)),
+}
+
+func TestReadyAfter(t *testing.T) {
+	srv := waittest.ReadyAfter(3)
+	defer srv.Close()
+	r, err := wait.WaitForServer(context.Background(), srv.URL, quiet...)
+	if err != nil || r.Attempts != 4 || r.StatusCode != http.StatusOK {
+		t.Errorf("got %d attempts ending with %d, %v, want 4 ending with 200", r.Attempts, r.StatusCode, err)
+	}
+}
+
+func TestServerNeverReady(t *testing.T) {
+	srv := waittest.NewServer(waittest.Drop(), waittest.Status(http.StatusServiceUnavailable))
+	defer srv.Close()
+	r, err := wait.WaitForServer(context.Background(), srv.URL, append(quiet, wait.WithMaxAttempts(3))...)
+	var se *wait.StatusError
+	if !errors.Is(err, wait.ErrMaxAttempts) || !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want the 503 of the last attempt", err)
+	}
+	if r.Attempts != 3 || srv.Requests() != 3 {
+		t.Errorf("%d attempts and %d requests, want 3", r.Attempts, srv.Requests())
+	}
+}
@@ -0,0 +1,144 @@
+package wait
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// A Change reports that a watched target went up or down, or started
+// or stopped flapping.
+type Change struct {
+	Target string
+	Up     bool      // the target is now ready
+	Time   time.Time // when the check that noticed the change ended
+	Err    error     // why the target is down, if it is
+	Checks int       // number of checks made so far
+
+	// Flapping reports whether the target keeps going up and down,
+	// as detected by WithFlapDetection.
+	Flapping bool
+}
+
+// notification returns the Notification of ch.
+func (ch Change) notification() Notification {
+	event := "down"
+	if ch.Up {
+		event = "up"
+	}
+	return Notification{Event: event, Target: ch.Target, Time: ch.Time, Attempts: ch.Checks, Flapping: ch.Flapping, Err: ch.Err}
+}
+
+// WithFlapDetection makes Watch consider a target to be flapping when
+// it went up or down more than threshold times during the last window
+// checks, and to report when it starts and stops flapping as well.
+// By default flapping is not detected.
+func WithFlapDetection(window, threshold int) Option {
+	return func(c *config) { c.flapWindow, c.flapThreshold = window, threshold }
+}
+
+// flapDetector keeps the results of the last checks of a target.
+type flapDetector struct {
+	window, threshold int
+	results           []bool // true for up, oldest first
+}
+
+// add records the result of a check and reports whether the target
+// is flapping.
+func (d *flapDetector) add(up bool) bool {
+	if d.window <= 0 {
+		return false
+	}
+	d.results = append(d.results, up)
+	if len(d.results) > d.window {
+		d.results = d.results[1:]
+	}
+	changes := 0
+	for i := 1; i < len(d.results); i++ {
+		if d.results[i] != d.results[i-1] {
+			changes++
+		}
+	}
+	return changes > d.threshold
+}
+
+// Watch checks c every interval until ctx is done, and calls f with
+// the result of the first check, and after that whenever the target
+// goes up or down, or starts or stops flapping, as it tells the
+// notifiers given by WithNotifier. With WithState, the notifiers are
+// not told of a first state that is the one an earlier watch left.
+// Each check is limited by the attempt timeout given by opts; the
+// other retry options don't apply. Watch returns the error of ctx.
+func Watch(ctx context.Context, c Checker, interval time.Duration, f func(Change), opts ...Option) error {
+	cfg := newConfig(opts)
+	target := checkerName(c)
+	flaps := flapDetector{window: cfg.flapWindow, threshold: cfg.flapThreshold}
+	checks := 0
+	first, up, flapping := true, false, false
+	saved := cfg.getState(target)
+	resumed := saved.Up != nil
+	if resumed {
+		checks, up, flapping = saved.Checks, *saved.Up, saved.Flapping
+		flaps.results = slices.Clone(saved.Flaps[max(len(saved.Flaps)-flaps.window, 0):])
+	}
+	for {
+		checks++
+		err := cfg.checkOnce(ctx, c)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fl := flaps.add(err == nil)
+		now := cfg.clock.Now()
+		changed := first && !resumed || up != (err == nil) || flapping != fl
+		if first || changed {
+			first, up, flapping = false, err == nil, fl
+			ch := Change{Target: target, Up: up, Time: now, Err: err, Checks: checks, Flapping: flapping}
+			f(ch)
+			if changed {
+				// Not if the state is the one an earlier watch left.
+				cfg.notify(ctx, ch.notification())
+			}
+		}
+		if cfg.state != nil {
+			if saved.Up == nil || *saved.Up != up {
+				saved.Since = now
+			}
+			// Copies, since other watches marshal the states too.
+			saved.Checks, saved.Up, saved.Flapping = checks, new(bool), flapping
+			*saved.Up = up
+			saved.Flaps = slices.Clone(flaps.results)
+			_, saved.Status = lastStatus(c)
+			saved.Error = errString(err)
+			cfg.putState(target, saved)
+		}
+		t := cfg.clock.NewTimer(interval)
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// WatchServer watches the server of url, as by Watch with the
+// Checker that WaitForServer would use.
+func WatchServer(ctx context.Context, url string, interval time.Duration, f func(Change), opts ...Option) error {
+	c, err := NewChecker(url, opts...)
+	if err != nil {
+		return err
+	}
+	return Watch(ctx, c, interval, f, opts...)
+}
+
+// checkOnce checks c once, limited to the attempt timeout, as an
+// attempt with an ID of its own.
+func (cfg *config) checkOnce(ctx context.Context, c Checker) error {
+	ctx = withAttemptID(ctx, newAttemptID())
+	if cfg.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.attemptTimeout)
+		defer cancel()
+	}
+	return safe(c.Check)(ctx)
+}
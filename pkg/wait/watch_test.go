@@ -0,0 +1,114 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+)
+
+// A scriptedChecker is up or down as its script says for each check,
+// and cancels the watch once the script has run out.
+type scriptedChecker struct {
+	script string // of u for up and d for down
+	cancel context.CancelFunc
+	n      int
+}
+
+func (c *scriptedChecker) Check(context.Context) error {
+	if c.n == len(c.script) {
+		c.cancel()
+		return nil
+	}
+	c.n++
+	if c.script[c.n-1] == 'd' {
+		return errors.New("down")
+	}
+	return nil
+}
+
+func (c *scriptedChecker) String() string { return "scripted" }
+
+// watchScript watches a scriptedChecker of script every 10s, on a
+// fake clock, with opts, and returns the changes it reported.
+func watchScript(t *testing.T, script string, opts ...Option) []Change {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := clock.NewInstant(time.Unix(0, 0))
+	var changes []Change
+	err := Watch(ctx, &scriptedChecker{script: script, cancel: cancel}, 10*time.Second, func(ch Change) {
+		changes = append(changes, ch)
+	}, append(opts, WithClock(clk))...)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("%s: Watch returned %v, want %v", script, err, context.Canceled)
+	}
+	return changes
+}
+
+func TestWatch(t *testing.T) {
+	for script, want := range map[string][]int{ // the checks reporting changes
+		"uuu":   {1},
+		"ddd":   {1},
+		"uuddu": {1, 3, 5},
+		"dudud": {1, 2, 3, 4, 5},
+	} {
+		changes := watchScript(t, script)
+		var got []int
+		for _, ch := range changes {
+			got = append(got, ch.Checks)
+			if up := script[ch.Checks-1] == 'u'; ch.Up != up || (ch.Err == nil) != up {
+				t.Errorf("%s: check %d reported up: %t, err: %v", script, ch.Checks, ch.Up, ch.Err)
+			}
+			// Every 10s from the start on the fake clock.
+			if want := time.Unix(int64(10*(ch.Checks-1)), 0); !ch.Time.Equal(want) {
+				t.Errorf("%s: check %d at %v, want %v", script, ch.Checks, ch.Time, want)
+			}
+			if ch.Target != "scripted" || ch.Flapping {
+				t.Errorf("%s: change %+v", script, ch)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: changes at checks %v, want %v", script, got, want)
+		}
+	}
+}
+
+func TestFlapDetection(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		want   string // for each change, u or d, and F if flapping
+	}{
+		// At most 2 changes in the last 4 checks are not flapping.
+		{"uduuuu", "u d u"},
+		{"uddduddd", "u d u d"},
+		// 3 are, until there are fewer again.
+		{"ududdd", "u d u dF d"},
+		{"ududuuuu", "u d u dF uF u"},
+		{"dudududd", "d u d uF dF uF dF d"},
+	} {
+		var got []string
+		for _, ch := range watchScript(t, test.script, WithFlapDetection(4, 2)) {
+			s := "d"
+			if ch.Up {
+				s = "u"
+			}
+			if ch.Flapping {
+				s += "F"
+			}
+			got = append(got, s)
+		}
+		if s := strings.Join(got, " "); s != test.want {
+			t.Errorf("%s: changes %s, want %s", test.script, s, test.want)
+		}
+	}
+	// Without flap detection, the same targets are never flapping.
+	for _, ch := range watchScript(t, "dudududd") {
+		if ch.Flapping {
+			t.Errorf("flapping without flap detection at check %d", ch.Checks)
+		}
+	}
+}
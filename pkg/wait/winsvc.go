@@ -0,0 +1,92 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// A serviceState is the state of a Windows service, with the values
+// of the CurrentState of SERVICE_STATUS.
+type serviceState uint32
+
+const (
+	serviceStopped serviceState = 1 + iota
+	serviceStartPending
+	serviceStopPending
+	serviceRunning
+	serviceContinuePending
+	servicePausePending
+	servicePaused
+)
+
+// serviceStateNames are the names of service states, as sc query
+// writes them.
+var serviceStateNames = map[serviceState]string{
+	serviceStopped:         "STOPPED",
+	serviceStartPending:    "START_PENDING",
+	serviceStopPending:     "STOP_PENDING",
+	serviceRunning:         "RUNNING",
+	serviceContinuePending: "CONTINUE_PENDING",
+	servicePausePending:    "PAUSE_PENDING",
+	servicePaused:          "PAUSED",
+}
+
+func (s serviceState) String() string {
+	if name, ok := serviceStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("state %d", uint32(s))
+}
+
+// A serviceChecker checks whether a Windows service is in a state,
+// such as RUNNING, as told by the service control manager.
+type serviceChecker struct {
+	name string
+	want serviceState
+
+	responseStatus
+}
+
+// newServiceChecker returns a checker for a winsvc://name target, or
+// winsvc:///name for names that are not valid host names, with an
+// optional state=S setting, running by default.
+func newServiceChecker(u *url.URL) (*serviceChecker, error) {
+	p := &serviceChecker{name: u.Host, want: serviceRunning}
+	if p.name == "" {
+		p.name = strings.TrimPrefix(u.Path, "/")
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("missing service name in %s", u.Redacted())
+	}
+	if s := u.Query().Get("state"); s != "" {
+		switch strings.ToLower(s) {
+		case "running":
+		case "stopped":
+			p.want = serviceStopped
+		case "paused":
+			p.want = servicePaused
+		default:
+			return nil, fmt.Errorf("invalid state %q in %s, want running, stopped, or paused", s, u.Redacted())
+		}
+	}
+	return p, nil
+}
+
+func (p *serviceChecker) Check(ctx context.Context) error {
+	state, exitCode, err := queryService(p.name)
+	if err != nil {
+		return fmt.Errorf("service %s: %w", p.name, err)
+	}
+	p.set(0, state.String())
+	switch {
+	case state == p.want:
+		return nil
+	case state == serviceStopped && exitCode != 0:
+		// It failed, and may yet be restarted by the service
+		// control manager.
+		return fmt.Errorf("service %s is %v, with exit code %d", p.name, state, exitCode)
+	}
+	return fmt.Errorf("service %s is %v, not %v", p.name, state, p.want)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package wait
+
+import (
+	"errors"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+)
+
+// queryService fails, as there are Windows services only on Windows.
+func queryService(string) (serviceState, uint32, error) {
+	return 0, 0, errclass.MarkPermanent(errors.New("services can only be checked on Windows"))
+}
@@ -0,0 +1,42 @@
+package wait
+
+import (
+	"errors"
+
+	"github.com/go-monk/error-handling/pkg/errclass"
+	"golang.org/x/sys/windows"
+)
+
+// queryService returns the state of the Windows service name, and the
+// exit code it last stopped with. Services that do not exist may yet
+// be installed, but the errors of being denied access are permanent.
+func queryService(name string) (state serviceState, exitCode uint32, err error) {
+	defer func() {
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+			err = errclass.MarkPermanent(err)
+		}
+	}()
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, 0, errclass.MarkPermanent(err)
+	}
+	m, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer windows.CloseServiceHandle(m)
+	s, err := windows.OpenService(m, p, windows.SERVICE_QUERY_STATUS)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer windows.CloseServiceHandle(s)
+	var st windows.SERVICE_STATUS
+	if err := windows.QueryServiceStatus(s, &st); err != nil {
+		return 0, 0, err
+	}
+	exitCode = st.Win32ExitCode
+	if exitCode == uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR) {
+		exitCode = st.ServiceSpecificExitCode
+	}
+	return serviceState(st.CurrentState), exitCode, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/go-monk/error-handling/pkg/errcode"
+)
+
+// exitCodes are the error codes of failed waits, by exit status, which
+// key the templates of -messages.
+var exitCodes = map[int]*errcode.Entry{
+	ExitNotReady: errcode.Register(errcode.Entry{
+		Name: "wait.not_ready", Category: errcode.Unavailable, Severity: errcode.Error, ExitCode: ExitNotReady,
+	}),
+	ExitTimeout: errcode.Register(errcode.Entry{
+		Name: "wait.timeout", Category: errcode.Timeout, Severity: errcode.Error, ExitCode: ExitTimeout,
+	}),
+	ExitCanceled: errcode.Register(errcode.Entry{
+		Name: "wait.canceled", Category: errcode.Canceled, Severity: errcode.Warning, ExitCode: ExitCanceled,
+	}),
+	ExitInvalidTarget: errcode.Register(errcode.Entry{
+		Name: "wait.invalid_target", Category: errcode.Invalid, Severity: errcode.Error, ExitCode: ExitInvalidTarget,
+	}),
+}
+
+// withCodes returns err, or each of the errors of the targets that it
+// joins, with the code of its exit status.
+func withCodes(err error) error {
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := j.Unwrap()
+		coded := make([]error, len(errs))
+		for i, err := range errs {
+			coded[i] = withCodes(err)
+		}
+		return errors.Join(coded...)
+	}
+	return errcode.With(err, exitCodes[exitStatus(err)])
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// serveDebug starts serving the live state of the waits on addr, at
+// /debug/wait and as the "wait" variable of /debug/vars, and returns
+// the Progress to record it in.
+func serveDebug(addr string) (*wait.Progress, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p := wait.NewProgress()
+	expvar.Publish("wait", p)
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/debug/wait", p)
+	go http.Serve(ln, mux) // until the program exits
+	return p, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+	"golang.org/x/net/proxy"
+)
+
+// newDialer returns a Dialer connecting from the address source, if
+// not nil, and through the SOCKS5 proxy at proxyURL, if not nil.
+func newDialer(proxyURL *url.URL, source net.IP) (wait.Dialer, error) {
+	d := new(net.Dialer)
+	if source != nil {
+		d.LocalAddr = &net.TCPAddr{IP: source}
+	}
+	if proxyURL == nil {
+		return d, nil
+	}
+	pd, err := proxy.FromURL(proxyURL, d)
+	if err != nil {
+		return nil, err
+	}
+	cd, ok := pd.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("dial proxy %s cannot be canceled", wait.RedactURL(proxyURL.String()))
+	}
+	return cd, nil
+}
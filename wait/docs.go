@@ -0,0 +1,49 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+
+	"github.com/go-monk/error-handling/pkg/clidoc"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// source is that of this file's neighbor main.go, whose doc comment
+// is the description of the manual page.
+//
+//go:embed main.go
+var source []byte
+
+// command describes wait for its completions and manual page, once
+// its flags are defined.
+func command() *clidoc.Command {
+	var schemes []string
+	for _, s := range wait.Schemes() {
+		schemes = append(schemes, s+"://")
+	}
+	return &clidoc.Command{
+		Name:    "wait",
+		Summary: "wait for servers and other targets to be ready",
+		Usage: []string{
+			"[flags] url ... [-- command [arg ...]]",
+			"completion bash|zsh|fish",
+			"docs",
+			"selftest [-timeout d] [-delay d] [-v]",
+		},
+		Doc:   clidoc.PackageDoc(source),
+		Flags: flag.CommandLine,
+		Values: map[string][]string{
+			"backoff-preset":  {"default", "aggressive", "gentle", "aws-decorrelated"},
+			"connections":     {"reuse", "fresh"},
+			"jitter":          {"none", "full", "equal", "decorrelated"},
+			"log-format":      {"text", "json"},
+			"log-level":       {"debug", "info", "warn", "error"},
+			"method":          {"HEAD", "GET", "auto"},
+			"output":          {"text", "json", "ndjson"},
+			"protocol":        {"auto", "http1", "http2", "http3"},
+			"resolve":         {"default", "fresh", "pin"},
+			"tls-min-version": {"1.0", "1.1", "1.2", "1.3"},
+		},
+		Args: schemes,
+	}
+}
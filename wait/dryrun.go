@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// dryRun writes the plans of the waits that main would make, for
+// -dry-run, to w: for the database of driver, if any, then for the
+// shell commands cmds, the targets of conf, and urls, followed by the
+// command that would be run once they are ready. It resolves the host
+// names of the targets, but does not check them, and returns an error
+// if a wait could not be made.
+func dryRun(ctx context.Context, w io.Writer, driver, dsn string, cmds []string, conf *wait.Config, urls, command []string, opts []wait.Option) error {
+	var plans []wait.Plan
+	if driver != "" {
+		db, err := sql.Open(driver, dsn) // which does not connect
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		p := wait.PlanChecker(wait.DBChecker(db), opts...)
+		p.Checker = "sql"
+		plans = append(plans, p)
+	}
+	for _, c := range cmds {
+		p := wait.PlanChecker(wait.CommandChecker(shellCommand(c)...), opts...)
+		p.Checker = "command"
+		plans = append(plans, p)
+	}
+	if conf != nil {
+		ps, err := conf.Plan(ctx, opts...)
+		if err != nil {
+			return err
+		}
+		plans = append(plans, ps...)
+	}
+	for _, u := range urls {
+		p, err := wait.NewPlan(ctx, u, opts...)
+		if err != nil {
+			return err
+		}
+		plans = append(plans, p)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, p := range plans {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		if p.Name != p.Target {
+			fmt.Fprintf(tw, "%s: %s (%s)\n", p.Name, p.Target, p.Checker)
+		} else {
+			fmt.Fprintf(tw, "%s (%s)\n", p.Target, p.Checker)
+		}
+		if len(p.DependsOn) > 0 {
+			fmt.Fprintf(tw, "  depends on\t%s\n", strings.Join(p.DependsOn, ", "))
+		}
+		switch {
+		case p.ResolveErr != nil:
+			fmt.Fprintf(tw, "  addresses\tnone yet: %v\n", p.ResolveErr)
+		case p.Host != "":
+			addrs := make([]string, len(p.Addrs))
+			for i, a := range p.Addrs {
+				addrs[i] = a.String()
+			}
+			fmt.Fprintf(tw, "  addresses\t%s\n", strings.Join(addrs, ", "))
+		}
+		for _, s := range p.Settings {
+			fmt.Fprintf(tw, "  %s\t%s\n", s.Name, s.Value)
+		}
+		fmt.Fprintf(tw, "  attempts at\t%s\n", attemptTimes(p))
+	}
+	if len(command) > 0 {
+		fmt.Fprintf(tw, "\nthen runs\t%s\n", strings.Join(command, " "))
+	}
+	return tw.Flush()
+}
+
+// attemptTimes returns the times of the attempts of p, relative to
+// the start of the wait, such as "0s, 1s, 3s, and so on".
+func attemptTimes(p wait.Plan) string {
+	times := make([]string, len(p.Attempts))
+	for i, t := range p.Attempts {
+		times[i] = t.Round(time.Millisecond).String()
+	}
+	s := strings.Join(times, ", ")
+	if p.More {
+		s += ", and so on"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix starts the names of the environment variables that stand
+// in for flags: WAIT_TIMEOUT for -timeout, WAIT_LOG_LEVEL for
+// -log-level, and so on.
+const envPrefix = "WAIT_"
+
+// envTargets is the environment variable listing the targets to wait
+// for, separated by spaces or commas, when none are given as
+// arguments.
+const envTargets = envPrefix + "TARGETS"
+
+// flagAliases maps the names of flags to those of the flags they are
+// the same as.
+var flagAliases = map[string]string{"quiet": "q", "verbose": "v", "success-threshold": "success-count"}
+
+// envName returns the environment variable that stands in for the
+// flag name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// setFromEnv sets each flag of fs that was not given on the command
+// line, under any of its names, from its environment variable, if
+// that is set.
+func setFromEnv(fs *flag.FlagSet) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[canonicalFlag(f.Name)] = true })
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if err != nil || !ok || given[canonicalFlag(f.Name)] {
+			return
+		}
+		if e := fs.Set(f.Name, value); e != nil {
+			err = fmt.Errorf("%s: %v", envName(f.Name), e)
+		}
+	})
+	return err
+}
+
+// canonicalFlag returns the name of the flag that the flag name is
+// the same as, if any, and name otherwise.
+func canonicalFlag(name string) string {
+	if alias, ok := flagAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// targetsFromEnv returns the targets listed by envTargets.
+func targetsFromEnv() []string {
+	return strings.FieldsFunc(os.Getenv(envTargets), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+
+	"github.com/go-monk/error-handling/pkg/errreport"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// cancelOnSignal returns a copy of ctx that is canceled, with a cause
+// naming the signal, when one of sigs arrives, until stop is called.
+func cancelOnSignal(ctx context.Context, sigs ...os.Signal) (_ context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-c:
+			cancel(fmt.Errorf("canceled by signal: %v", sig))
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// shellCommand returns the arguments to run command with the shell.
+func shellCommand(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"/bin/sh", "-c", command}
+}
+
+// splitCommand splits args at the first "--" into the targets to wait
+// for and the command to run afterwards, if any.
+func splitCommand(args []string) (targets, command []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// run runs the command described by args with the standard streams
+// of the wait program, as by wait.Run, and returns the exit status to
+// exit with, reporting any error running it to rep.
+func run(rep *errreport.Reporter, args []string) int {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	status, err := wait.Run(cmd)
+	if err != nil {
+		rep.Error(err)
+	}
+	return status
+}
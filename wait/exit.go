@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-monk/error-handling/pkg/errreport"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// Exit statuses of the wait program, unless it runs a command, in
+// which case it exits with the command's status once the wait
+// succeeds.
+const (
+	ExitReady         = 0 // everything waited for is ready
+	ExitNotReady      = 1 // something is not ready, for another reason than below
+	ExitUsage         = 2 // invalid flags, environment variables, or files
+	ExitTimeout       = 3 // the timeout expired before everything was ready
+	ExitCanceled      = 4 // the wait was interrupted by a signal
+	ExitInvalidTarget = 5 // a target cannot be waited for, such as a malformed URL
+	ExitFlapping      = 6 // with -watch, a target kept going up and down
+)
+
+// exitRules map the errors of failed waits to exit statuses, in
+// order.
+var exitRules = []errreport.Rule{
+	errreport.As[*wait.AbortError](ExitNotReady), // with -fail-fast, not a signal
+	errreport.Is(wait.ErrCanceled, ExitCanceled),
+	errreport.Is(wait.ErrTimeout, ExitTimeout),
+	errreport.Is(wait.ErrMaxAttempts, ExitNotReady),
+	errreport.Is(wait.ErrNonRetryable, ExitNotReady),
+	errreport.Is(context.Canceled, ExitCanceled),
+	errreport.Is(context.DeadlineExceeded, ExitTimeout),
+	errreport.As[*wait.TargetError](ExitInvalidTarget),
+}
+
+// exitStatus returns the status to exit with after a wait failed
+// with err.
+func exitStatus(err error) int {
+	return errreport.ExitCode(err, ExitNotReady, exitRules...)
+}
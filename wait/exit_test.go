@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+func TestExitStatusFailFast(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer srv.Close()
+
+	// The waits stopped are canceled, but not by a signal.
+	urls := []string{"tcp://" + l.Addr().String(), srv.URL}
+	_, err = wait.WaitForAll(context.Background(), urls, wait.WithFailFast(),
+		wait.WithRetryIf(func(error) bool { return false }), wait.WithTimeout(time.Minute),
+		wait.WithLogger(slog.New(slog.DiscardHandler)))
+	if got := exitStatus(err); got != ExitNotReady {
+		t.Errorf("with fail fast: exit status %d for %v, want %d", got, err, ExitNotReady)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = wait.WaitForAll(ctx, urls[1:], wait.WithLogger(slog.New(slog.DiscardHandler)))
+	if got := exitStatus(err); got != ExitCanceled {
+		t.Errorf("canceled: exit status %d for %v, want %d", got, err, ExitCanceled)
+	}
+}
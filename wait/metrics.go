@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serveMetrics starts serving the metrics of the waits at /metrics
+// on addr, and returns the Metrics to record them in.
+func serveMetrics(addr string) (*wait.Metrics, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	m := wait.NewMetrics(reg)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	go http.Serve(ln, mux) // until the program exits
+	return m, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// A notifier sends state notifications to systemd, over the socket
+// named by NOTIFY_SOCKET, for services of Type=notify. See
+// sd_notify(3).
+type notifier struct {
+	conn *net.UnixConn
+}
+
+// newNotifier returns a notifier for the socket named by
+// NOTIFY_SOCKET, or nil if that is not set.
+func newNotifier() (*notifier, error) {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(name, "@") {
+		name = "\x00" + name[1:] // abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("NOTIFY_SOCKET: %v", err)
+	}
+	return &notifier{conn}, nil
+}
+
+// notify sends the newline-separated variable assignments state.
+// Failures are ignored, as the service manager may have gone away.
+func (n *notifier) notify(state string) {
+	if n != nil {
+		n.conn.Write([]byte(state))
+	}
+}
+
+// attempt reports the failure of an attempt as the status of the
+// service.
+func (n *notifier) attempt(a wait.Attempt) {
+	if a.Err != nil {
+		n.notify(fmt.Sprintf("STATUS=waiting for %s (attempt %d): %v", a.Target, a.Number, a.Err))
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errreport"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// A jsonOutput writes the progress and outcome of a wait as the
+// events and results of rep, with durations in seconds.
+type jsonOutput struct {
+	rep  *errreport.Reporter
+	down bool // for -down
+}
+
+type jsonAttempt struct {
+	Event      string  `json:"event"` // "attempt"
+	Target     string  `json:"target"`
+	Attempt    int     `json:"attempt"`
+	AttemptID  string  `json:"attempt_id"`
+	Elapsed    float64 `json:"elapsed"`
+	Latency    float64 `json:"latency"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Status     string  `json:"status,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type jsonChange struct {
+	Event    string    `json:"event"` // "up" or "down"
+	Target   string    `json:"target"`
+	Time     time.Time `json:"time"`
+	Checks   int       `json:"checks"`
+	Flapping bool      `json:"flapping"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type jsonResult struct {
+	Target     string  `json:"target"`
+	State      string  `json:"state"` // "ready", "not ready", "down", "still up", or "not tried"
+	Attempts   int     `json:"attempts"`
+	Elapsed    float64 `json:"elapsed"`
+	Latency    float64 `json:"latency"`
+	LatencyP50 float64 `json:"latency_p50"`
+	LatencyP95 float64 `json:"latency_p95"`
+	LatencyMax float64 `json:"latency_max"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Status     string  `json:"status,omitempty"`
+	LastError  string  `json:"last_error,omitempty"`
+
+	Response *wait.ResponseSnapshot `json:"response,omitempty"` // the last one, if not ready
+}
+
+type jsonDone struct {
+	Event      string       `json:"event"` // "done"
+	Ready      bool         `json:"ready"`
+	ExitStatus int          `json:"exit_status"`
+	Elapsed    float64      `json:"elapsed"`
+	Results    []jsonResult `json:"results"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// attempt writes an event describing a.
+func (o *jsonOutput) attempt(a wait.Attempt) {
+	o.rep.Event(jsonAttempt{
+		Event:      "attempt",
+		Target:     a.Target,
+		Attempt:    a.Number,
+		AttemptID:  a.ID,
+		Elapsed:    a.Elapsed.Seconds(),
+		Latency:    a.Latency.Seconds(),
+		StatusCode: a.StatusCode,
+		Status:     a.Status,
+		Error:      errorString(a.Err),
+	})
+}
+
+// change writes an event describing ch.
+func (o *jsonOutput) change(ch wait.Change) {
+	event := "down"
+	if ch.Up {
+		event = "up"
+	}
+	// Changes are the outcomes of -watch, so they are written in
+	// either format.
+	o.rep.Result(jsonChange{
+		Event:    event,
+		Target:   ch.Target,
+		Time:     ch.Time,
+		Checks:   ch.Checks,
+		Flapping: ch.Flapping,
+		Error:    errorString(ch.Err),
+	})
+}
+
+// done writes the outcome of a wait that took elapsed and made the
+// program exit with status.
+func (o *jsonOutput) done(results []wait.Result, elapsed time.Duration, err error, status int) {
+	d := jsonDone{
+		Event:      "done",
+		Ready:      err == nil,
+		ExitStatus: status,
+		Elapsed:    elapsed.Seconds(),
+		Results:    []jsonResult{},
+		Error:      errorString(err),
+	}
+	for _, r := range results {
+		d.Results = append(d.Results, jsonResult{
+			Target:     r.Target,
+			State:      state(r, o.down),
+			Attempts:   r.Attempts,
+			Elapsed:    r.Elapsed.Seconds(),
+			Latency:    r.Latency.Seconds(),
+			LatencyP50: r.LatencyPercentile(50).Seconds(),
+			LatencyP95: r.LatencyPercentile(95).Seconds(),
+			LatencyMax: r.LatencyPercentile(100).Seconds(),
+			StatusCode: r.StatusCode,
+			Status:     r.Status,
+			LastError:  errorString(r.LastErr()),
+			Response:   lastResponse(r),
+		})
+	}
+	o.rep.Result(d)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// isTerminal reports whether f is a terminal, or at least a character
+// device, without taking the trouble of asking the terminal driver.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// A liveProgress redraws the state of the waits recorded in a Progress
+// on a terminal, one line per target, in place of the logs.
+type liveProgress struct {
+	w        io.Writer
+	p        *wait.Progress
+	deadline time.Time // of all waits, or zero
+	width    int       // of the terminal
+	lines    int       // drawn last time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// showProgress starts redrawing the state of the waits recorded in p
+// on w until the returned function is called, which draws it a last
+// time.
+func showProgress(w io.Writer, p *wait.Progress, deadline time.Time) (stop func()) {
+	lp := &liveProgress{w: w, p: p, deadline: deadline, width: 80,
+		stop: make(chan struct{}), done: make(chan struct{})}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		lp.width = n
+	}
+	go func() {
+		defer close(lp.done)
+		tick := time.NewTicker(100 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			lp.draw(time.Now())
+			select {
+			case <-tick.C:
+			case <-lp.stop:
+				lp.draw(time.Now())
+				return
+			}
+		}
+	}()
+	return func() {
+		close(lp.stop)
+		<-lp.done
+	}
+}
+
+// draw replaces the lines drawn last time by the state of the waits
+// at now.
+func (lp *liveProgress) draw(now time.Time) {
+	var b strings.Builder
+	if lp.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", lp.lines) // back to the first line
+	}
+	targets := lp.p.Targets()
+	for _, t := range targets {
+		line := progressLine(t, now, lp.deadline)
+		if r := []rune(line); len(r) > lp.width-1 {
+			line = string(r[:lp.width-1]) // lines that wrap would not be redrawn
+		}
+		fmt.Fprintf(&b, "\x1b[2K%s\n", line)
+	}
+	lp.lines = len(targets)
+	io.WriteString(lp.w, b.String())
+}
+
+// progressLine describes the state t of a wait at now, such as
+//
+//	waiting    db:5432  attempt 3, retrying in 1.8s, 4.2s elapsed, 55.8s left: connection refused
+func progressLine(t wait.TargetProgress, now, deadline time.Time) string {
+	var b strings.Builder
+	state := "waiting"
+	switch {
+	case t.Ready:
+		state = "ready"
+	case t.Done:
+		state = "not ready"
+	}
+	fmt.Fprintf(&b, "%-9s  %s  attempt %d", state, t.Target, t.Attempts)
+	if !t.NextAttempt.IsZero() && t.NextAttempt.After(now) {
+		fmt.Fprintf(&b, ", retrying in %s", t.NextAttempt.Sub(now).Round(100*time.Millisecond))
+	}
+	if !t.Done {
+		fmt.Fprintf(&b, ", %s elapsed", now.Sub(t.Started).Round(100*time.Millisecond))
+		if !deadline.IsZero() {
+			fmt.Fprintf(&b, ", %s left", max(deadline.Sub(now), 0).Round(100*time.Millisecond))
+		}
+	}
+	if t.LastError != "" && !t.Ready {
+		msg, _, _ := strings.Cut(t.LastError, "\n")
+		fmt.Fprintf(&b, ": %s", msg)
+	}
+	return b.String()
+}
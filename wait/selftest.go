@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+	"github.com/go-monk/error-handling/pkg/wait/waittest"
+)
+
+// untested are the schemes that selftest has no local stand-ins for.
+var untested = []string{"kafka", "metadata", "ntp", "s3", "winsvc"}
+
+// A selfTest is a target of selftest, served by a local stand-in.
+type selfTest struct {
+	checker string // the scheme of the target
+	target  string
+	opts    []wait.Option
+}
+
+// selftest runs the selftest subcommand with args, the arguments
+// after its name, and returns the status to exit with: it starts
+// local stand-ins for the servers of most schemes, which become ready
+// after a delay, waits for each, and writes a table of which checkers
+// work in the environment, such as a CI runner that forbids unix
+// sockets or ICMP.
+func selftest(args []string) int {
+	fs := flag.NewFlagSet("wait selftest", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "give up on each checker after this long")
+	delay := fs.Duration("delay", 300*time.Millisecond, "how long the stand-in servers take to become ready")
+	verbose := fs.Bool("v", false, "log the attempts of the checks to standard error")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: wait selftest [-timeout d] [-delay d] [-v]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitReady
+		}
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	dir, err := os.MkdirTemp("", "wait-selftest")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "wait: selftest: %v\n", err)
+		return ExitNotReady
+	}
+	defer os.RemoveAll(dir)
+
+	var s standIns
+	defer s.close()
+	tests, roots := s.start(dir, time.Now().Add(*delay))
+
+	logger := slog.New(slog.DiscardHandler)
+	if *verbose {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	results := make([]wait.Result, len(tests))
+	var wg sync.WaitGroup
+	for i, t := range tests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := append([]wait.Option{
+				wait.WithTimeout(*timeout),
+				wait.WithInitialDelay(50 * time.Millisecond),
+				wait.WithMaxDelay(250 * time.Millisecond),
+				wait.WithTLSConfig(&tls.Config{RootCAs: roots}),
+				wait.WithLogger(logger.With("checker", t.checker)),
+			}, t.opts...)
+			results[i], _ = wait.WaitForServer(context.Background(), t.target, opts...)
+		}()
+	}
+	wg.Wait()
+
+	status := ExitReady
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECKER\tTARGET\tRESULT\tATTEMPTS\tELAPSED\tERROR")
+	for i, r := range results {
+		result, lastErr := "ok", "-"
+		if r.Err != nil {
+			result, status = "FAIL", ExitNotReady
+			if err := r.LastErr(); err != nil {
+				lastErr, _, _ = strings.Cut(err.Error(), "\n")
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", tests[i].checker, r.Target, result,
+			r.Attempts, r.Elapsed.Round(time.Millisecond), lastErr)
+	}
+	tw.Flush()
+	fmt.Printf("not tested, for want of local stand-ins: %s\n", strings.Join(untested, ", "))
+	return status
+}
+
+// standIns are the local servers of selftest, stopped together.
+type standIns struct {
+	closers []func()
+}
+
+// close stops them.
+func (s *standIns) close() {
+	for _, c := range s.closers {
+		c()
+	}
+}
+
+// start starts the stand-ins, to become ready at ready, with any
+// files they need in dir, and returns the targets served by them and
+// the pool of the certificates they serve TLS with.
+func (s *standIns) start(dir string, ready time.Time) ([]selfTest, *x509.CertPool) {
+	notReady := func() bool { return time.Now().Before(ready) }
+	var tests []selfTest
+	add := func(checker, target string, opts ...wait.Option) {
+		tests = append(tests, selfTest{checker, target, opts})
+	}
+
+	// The HTTP server drops the connections of its first requests;
+	// the other HTTP servers answer that they are not ready until
+	// ready.
+	hs := waittest.ReadyAfter(2)
+	s.closers = append(s.closers, hs.Close)
+	add("http", hs.URL)
+
+	https := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if notReady() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	s.closers = append(s.closers, https.Close)
+	add("https", https.URL, wait.WithExpectStatus(wait.Statuses{{Min: 200, Max: 299}}))
+	roots := x509.NewCertPool()
+	roots.AddCert(https.Certificate())
+
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := "green"
+		if notReady() {
+			status = "red"
+		}
+		fmt.Fprintf(w, `{"status":%q,"number_of_nodes":1}`, status)
+	}))
+	s.closers = append(s.closers, es.Close)
+	add("es", "es://"+es.Listener.Addr().String())
+
+	gs := grpc.NewServer()
+	h := health.NewServer()
+	h.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(gs, h)
+	if ln, err := net.Listen("tcp", "127.0.0.1:0"); err == nil {
+		go gs.Serve(ln)
+		s.closers = append(s.closers, gs.Stop)
+		t := time.AfterFunc(time.Until(ready), func() {
+			h.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		})
+		s.closers = append(s.closers, func() { t.Stop() })
+		add("grpc", "grpc://"+ln.Addr().String())
+	} else {
+		add("grpc", "grpc://127.0.0.1:0") // fails, as it should
+	}
+
+	// The others listen only once ready.
+	tlsConf := https.TLS.Clone()
+	tlsConf.NextProtos = nil
+	for _, l := range []struct {
+		checker, network string
+		serve            func(net.Conn)
+	}{
+		{"tcp", "tcp", func(net.Conn) {}},
+		{"unix", "unix", func(net.Conn) {}},
+		{"tls", "tcp", func(c net.Conn) { tls.Server(c, tlsConf).Handshake() }},
+		{"redis", "tcp", serveRedis},
+		{"ssh", "tcp", func(c net.Conn) { io.WriteString(c, "SSH-2.0-selftest\r\n") }},
+		{"amqp", "tcp", serveAMQP},
+	} {
+		addr := filepath.Join(dir, l.checker+".sock")
+		target := "unix://" + filepath.ToSlash(addr)
+		if l.network == "tcp" {
+			addr = freeAddr()
+			target = l.checker + "://" + addr
+		}
+		s.closers = append(s.closers, listenAt(ready, l.network, addr, l.serve))
+		add(l.checker, target)
+	}
+
+	file := filepath.Join(dir, "ready")
+	t := time.AfterFunc(time.Until(ready), func() { os.WriteFile(file, nil, 0o666) })
+	s.closers = append(s.closers, func() { t.Stop() })
+	add("file", (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String())
+
+	// These need no stand-ins, only the host itself.
+	add("dns", "dns://localhost")
+	add("ping", "ping://127.0.0.1")
+	add("process", fmt.Sprintf("process://?pid=%d", os.Getpid()))
+	return tests, roots
+}
+
+// freeAddr returns the address of a TCP port of the loopback
+// interface that was free, to listen on later, or one that cannot be
+// listened on if none was.
+func freeAddr() string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "127.0.0.1:0"
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// listenAt listens on addr of network once ready, serving each
+// connection with serve before closing it, and returns a function that
+// stops listening.
+func listenAt(ready time.Time, network, addr string, serve func(net.Conn)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-time.After(time.Until(ready)):
+		case <-done:
+			return
+		}
+		ln, err := net.Listen(network, addr)
+		if err != nil {
+			return // and the check fails
+		}
+		go func() {
+			<-done
+			ln.Close()
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				serve(conn)
+			}()
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// serveRedis answers PING with PONG, as a Redis server does.
+func serveRedis(c net.Conn) {
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if strings.EqualFold(strings.TrimSpace(line), "PING") {
+			io.WriteString(c, "+PONG\r\n")
+		}
+	}
+}
+
+// serveAMQP answers the protocol header of a client with the frame of
+// the connection.start method, as an AMQP 0-9-1 broker does.
+func serveAMQP(c net.Conn) {
+	var header [8]byte
+	if _, err := io.ReadFull(c, header[:]); err != nil {
+		return
+	}
+	c.Write([]byte{1, 0, 0, 0, 0, 0, 4, 0, 10, 0, 10})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/errfields"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// summary describes in one line the successful waits of results,
+// which took elapsed in all, for the targets to be ready, or, with
+// -down, down.
+func summary(results []wait.Result, elapsed time.Duration, down bool) string {
+	var b strings.Builder
+	ready := "ready"
+	if down {
+		ready = "down"
+	}
+	fmt.Fprintf(&b, "%s after %s:", ready, elapsed.Round(time.Millisecond))
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, " %s (%d attempt%s", r.Target, r.Attempts, plural(r.Attempts))
+		if r.Status != "" {
+			fmt.Fprintf(&b, ", %s", r.Status)
+		}
+		fmt.Fprintf(&b, ", latency %s)", r.Latency.Round(time.Microsecond))
+	}
+	return b.String()
+}
+
+// lastResponse returns the snapshot of the last response of a failed
+// wait, if it has one.
+func lastResponse(r wait.Result) *wait.ResponseSnapshot {
+	s, _ := errfields.Get(r.Err, "response")
+	snap, _ := s.(*wait.ResponseSnapshot)
+	return snap
+}
+
+// latencies describes how long the attempts of r took.
+func latencies(r wait.Result) string {
+	return fmt.Sprintf("%s: latency p50 %s, p95 %s, max %s over %d attempt%s", r.Target,
+		r.LatencyPercentile(50).Round(time.Microsecond),
+		r.LatencyPercentile(95).Round(time.Microsecond),
+		r.LatencyPercentile(100).Round(time.Microsecond),
+		len(r.Latencies), plural(len(r.Latencies)))
+}
+
+// printTable writes a table of results to w, with a row for each
+// target giving its state, the status of its last response, how many
+// attempts the wait for it made and how long it took, and the error
+// of its last attempt.
+func printTable(w io.Writer, results []wait.Result, down bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tSTATE\tSTATUS\tATTEMPTS\tELAPSED\tLAST ERROR")
+	for _, r := range results {
+		status := r.Status
+		if status == "" {
+			status = "-"
+		}
+		lastErr := "-"
+		if err := r.LastErr(); err != nil {
+			lastErr, _, _ = strings.Cut(err.Error(), "\n")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", r.Target, state(r, down), status,
+			r.Attempts, r.Elapsed.Round(time.Millisecond), lastErr)
+	}
+	tw.Flush()
+}
+
+// state describes the outcome of the wait r describes in a word or
+// two, that of a wait for the target to go down with -down.
+func state(r wait.Result, down bool) string {
+	switch {
+	case r.Attempts == 0 && r.Err != nil:
+		return "not tried"
+	case down && r.Err == nil:
+		return "down"
+	case down:
+		return "still up"
+	case r.Err == nil:
+		return "ready"
+	}
+	return "not ready"
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// watch watches checkers every interval until ctx is done, logging
+// their changes, and returns the status to exit with: ExitFlapping if
+// any of them was flapping in the end, ExitReady if all of them were
+// up, and ExitNotReady otherwise.
+func watch(ctx context.Context, checkers []wait.Checker, interval time.Duration, logger *slog.Logger, out *jsonOutput, opts []wait.Option) int {
+	var mu sync.Mutex
+	down := make(map[string]bool)
+	flapping := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Watch(ctx, c, interval, func(ch wait.Change) {
+				mu.Lock()
+				wasDown, wasFlapping := down[ch.Target], flapping[ch.Target]
+				down[ch.Target], flapping[ch.Target] = !ch.Up, ch.Flapping
+				mu.Unlock()
+				switch {
+				case ch.Flapping && !wasFlapping:
+					logger.Warn("target flapping", "target", ch.Target, "checks", ch.Checks, "up", ch.Up)
+				case !ch.Flapping && wasFlapping:
+					logger.Info("target stable", "target", ch.Target, "checks", ch.Checks, "up", ch.Up)
+				case ch.Up && wasDown:
+					logger.Info("target up", "target", ch.Target, "checks", ch.Checks)
+				case !ch.Up && !wasDown:
+					logger.Warn("target down", "target", ch.Target, "checks", ch.Checks, "err", ch.Err)
+				}
+				if out != nil {
+					out.change(ch)
+				}
+			}, opts...)
+		}()
+	}
+	wg.Wait()
+	for _, f := range flapping {
+		if f {
+			return ExitFlapping
+		}
+	}
+	for _, d := range down {
+		if d {
+			return ExitNotReady
+		}
+	}
+	return ExitReady
+}
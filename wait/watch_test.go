@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/go-monk/error-handling/pkg/clock"
+	"github.com/go-monk/error-handling/pkg/wait"
+)
+
+// scripted returns a Checker that is up or down as script says for
+// each check, of u for up and d for down, and then cancels the watch.
+func scripted(script string, cancel context.CancelFunc) wait.Checker {
+	n := 0
+	return wait.CheckerFunc(func(context.Context) error {
+		if n == len(script) {
+			cancel()
+			return nil
+		}
+		n++
+		if script[n-1] == 'd' {
+			return errors.New("down")
+		}
+		return nil
+	})
+}
+
+func TestWatchExitStatus(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		want   int
+	}{
+		{"dduu", ExitReady},
+		{"uudd", ExitNotReady},
+		{"udud", ExitFlapping},  // in the end, with 3 changes in the last 4 checks
+		{"ududd", ExitNotReady}, // no longer
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		opts := []wait.Option{wait.WithClock(clock.NewInstant(time.Unix(0, 0))), wait.WithFlapDetection(4, 2)}
+		got := watch(ctx, []wait.Checker{scripted(test.script, cancel)}, time.Second, slog.New(slog.DiscardHandler), nil, opts)
+		cancel()
+		if got != test.want {
+			t.Errorf("%s: exit status %d, want %d", test.script, got, test.want)
+		}
+	}
+}